@@ -2,28 +2,52 @@ package main
 
 import (
 	"fmt"
+	"github.com/atakurt/messagingApp/internal/features/campaigns"
+	"github.com/atakurt/messagingApp/internal/features/conversations"
+	"github.com/atakurt/messagingApp/internal/features/enqueue"
+	"github.com/atakurt/messagingApp/internal/features/exports"
+	"github.com/atakurt/messagingApp/internal/features/imports"
+	"github.com/atakurt/messagingApp/internal/features/inbound"
+	"github.com/atakurt/messagingApp/internal/features/messagecontrol"
 	"github.com/atakurt/messagingApp/internal/features/messagecontrol/commandlistener"
+	"github.com/atakurt/messagingApp/internal/features/messagecontrol/drain"
+	"github.com/atakurt/messagingApp/internal/features/messagecontrol/instances"
 	"github.com/atakurt/messagingApp/internal/features/messagecontrol/list_sent"
 	"github.com/atakurt/messagingApp/internal/features/messagecontrol/start"
 	"github.com/atakurt/messagingApp/internal/features/messagecontrol/stop"
 	"github.com/atakurt/messagingApp/internal/infrastructure/scheduler/retry"
 	"go.uber.org/zap"
+	"io"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	_ "github.com/atakurt/messagingApp/docs"
 	"github.com/atakurt/messagingApp/internal/features/messageretry"
+	"github.com/atakurt/messagingApp/internal/features/reaper"
 	"github.com/atakurt/messagingApp/internal/features/sendmessages"
+	"github.com/atakurt/messagingApp/internal/features/spend"
+	"github.com/atakurt/messagingApp/internal/features/stats"
+	"github.com/atakurt/messagingApp/internal/features/suppression"
+	"github.com/atakurt/messagingApp/internal/features/templates"
+	"github.com/atakurt/messagingApp/internal/features/tenants"
+	"github.com/atakurt/messagingApp/internal/infrastructure/adaptive"
 	"github.com/atakurt/messagingApp/internal/infrastructure/config"
 	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	drainCoordinator "github.com/atakurt/messagingApp/internal/infrastructure/drain"
 	httpClient "github.com/atakurt/messagingApp/internal/infrastructure/http"
+	"github.com/atakurt/messagingApp/internal/infrastructure/instance"
+	"github.com/atakurt/messagingApp/internal/infrastructure/leader"
 	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/infrastructure/metrics"
 	"github.com/atakurt/messagingApp/internal/infrastructure/monitoring"
 	"github.com/atakurt/messagingApp/internal/infrastructure/redis"
 	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
 	"github.com/atakurt/messagingApp/internal/infrastructure/scheduler"
+	campaignScheduler "github.com/atakurt/messagingApp/internal/infrastructure/scheduler/campaign"
+	reaperScheduler "github.com/atakurt/messagingApp/internal/infrastructure/scheduler/reaper"
 	goRedis "github.com/go-redis/redis/v8"
 	"github.com/gofiber/fiber/v2"
 	fiberSwagger "github.com/swaggo/fiber-swagger"
@@ -35,6 +59,9 @@ import (
 // @description Auto message scheduler
 // @BasePath /
 // @contact.name  Dev Team
+// @securityDefinitions.apikey  ApiKeyAuth
+// @in                          header
+// @name                        X-API-Key
 func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -50,34 +77,119 @@ func main() {
 
 	// Create the message service
 	messageRepository := repository.NewMessageRepository(gormDB)
+	templateRepository := repository.NewTemplateRepository(gormDB)
+	inboundRepository := repository.NewInboundRepository(gormDB)
+	conversationRepository := repository.NewConversationRepository(gormDB)
+	campaignRepository := repository.NewCampaignRepository(gormDB)
+	importRepository := repository.NewImportRepository(gormDB)
+	exportRepository := repository.NewExportRepository(gormDB)
+	statsRepository := repository.NewStatsRepository(gormDB)
+	spendRepository := repository.NewSpendRepository(gormDB)
 	client := httpClient.NewHttpClient()
 
 	redisClient := redis.NewClient(ctx, goRedis.NewClient(&goRedis.Options{
 		Addr: config.Cfg.Redis.Addr,
 	}))
 
-	messageService := sendmessages.NewService(messageRepository, client, redisClient)
-	messageRetryService := messageretry.NewService(messageRepository, client)
+	suppressionService := suppression.NewService(repository.NewSuppressionRepository(gormDB), redisClient)
+	tenantService := tenants.NewService(repository.NewTenantRepository(gormDB), redisClient)
+	if config.Cfg.Tenants.AdminKey != "" {
+		if err := tenantService.BootstrapAdminKey(config.Cfg.Tenants.AdminKey); err != nil {
+			logger.Log.Fatal("Failed to store the admin API key", zap.Error(err))
+		}
+	} else if config.Cfg.Tenants.RequireAPIKey {
+		logger.Log.Warn("tenants.requireAPIKey is set without tenants.adminKey, admin routes only accept admin keys created before")
+	}
+
+	budget := spend.NewBudget(spendRepository)
+	spendService := spend.NewService(spendRepository, budget)
+
+	serviceOptions := []sendmessages.Option{sendmessages.WithSuppressionChecker(suppressionService), sendmessages.WithBudget(budget), sendmessages.WithTenants(tenantService)}
+	var adaptiveController *adaptive.Controller
+	if config.Cfg.Adaptive.Enabled {
+		adaptiveController = adaptive.New(config.Cfg)
+		serviceOptions = append(serviceOptions, sendmessages.WithTuner(adaptiveController))
+	}
+
+	messageService := sendmessages.NewService(messageRepository, client, redisClient, serviceOptions...)
+	messageRetryService := messageretry.NewService(messageRepository, client, redisClient, messageretry.WithSuppressionChecker(suppressionService), messageretry.WithBudget(budget), messageretry.WithTenants(tenantService))
+
+	registry := instance.NewRegistry(redisClient, config.Cfg)
+
+	schedulerOptions := []scheduler.Option{scheduler.WithHeartbeat(registry)}
+	if adaptiveController != nil {
+		schedulerOptions = append(schedulerOptions, scheduler.WithLimits(adaptiveController))
+	}
+	var reaperOptions []reaperScheduler.Option
+	monitoringOptions := []monitoring.Option{monitoring.WithInstanceID(registry.ID())}
+	var elector leader.Elector
+	if config.Cfg.Leader.Enabled {
+		sqlDB, err := db.DB.GetSQLDB()
+		if err != nil {
+			logger.Log.Fatal("Failed to get DB for leader election", zap.Error(err))
+		}
+		elector = leader.New(config.Cfg, redisClient, sqlDB)
+		schedulerOptions = append(schedulerOptions, scheduler.WithLeaderElection(elector))
+		reaperOptions = append(reaperOptions, reaperScheduler.WithLeaderElection(elector))
+		monitoringOptions = append(monitoringOptions, monitoring.WithLeader(elector))
+		go elector.Run(ctx)
+	}
+
+	if config.Cfg.Scheduler.Mode == scheduler.ModeStream {
+		listener := db.NewNotificationListener(config.Cfg.Database.DSN, db.MessagesInsertedChannel)
+		schedulerOptions = append(schedulerOptions, scheduler.WithWakeSource(listener))
+		go listener.Run(ctx)
+	}
+
+	mainScheduler := scheduler.NewScheduler(messageService, redisClient, schedulerOptions...)
+	retryScheduler := retry.NewRetryScheduler(messageRetryService, redisClient, config.Cfg, retry.WithHeartbeat(registry))
+	commandListenr := commandlistener.NewCommandListener(redisClient, registry.ID(), map[string]commandlistener.Controllable{
+		messagecontrol.ComponentMain:  mainScheduler,
+		messagecontrol.ComponentRetry: retryScheduler,
+	})
+
+	registry.Register(messagecontrol.ComponentMain, mainScheduler)
+	registry.Register(messagecontrol.ComponentRetry, retryScheduler)
 
-	mainScheduler := scheduler.NewScheduler(messageService, redisClient)
-	retryScheduler := retry.NewRetryScheduler(messageRetryService, redisClient, config.Cfg)
-	commandListenr := commandlistener.NewCommandListener(redisClient, mainScheduler)
+	coordinator := drainCoordinator.NewCoordinator(config.Cfg.Drain.Timeout)
+	coordinator.Register(messagecontrol.ComponentMain, mainScheduler)
+	coordinator.Register(messagecontrol.ComponentRetry, retryScheduler)
 
+	stuckMessageReaper := reaperScheduler.NewReaperScheduler(reaper.NewService(messageRepository, redisClient, config.Cfg, reaper.WithTenants(tenantService)), config.Cfg, reaperOptions...)
+	campaignExpansion := campaignScheduler.NewCampaignScheduler(campaigns.NewExpander(campaignRepository, templateRepository, config.Cfg), config.Cfg)
+
+	go registry.Run(ctx)
 	go commandListenr.Listen(ctx)
+	go stuckMessageReaper.Run(ctx)
+	go campaignExpansion.Run(ctx)
 
 	// Start the schedulers
 	mainScheduler.Start(ctx)
 	retryScheduler.Start(ctx)
 
-	app := fiber.New()
+	if config.Cfg.Exports.SigningKey == "" {
+		logger.Log.Warn("exports.signingKey is not set, download links only work on this instance until it restarts")
+	}
+	signer, err := exports.NewSigner(config.Cfg.Exports.SigningKey, config.Cfg.Exports.LinkTTL)
+	if err != nil {
+		logger.Log.Fatal("Failed to create export link signer", zap.Error(err))
+	}
+	exportService := exports.NewService(exportRepository, signer)
+	go exportService.RunRetention(ctx)
+	importService := imports.NewService(importRepository, templateRepository, imports.WithQuota(tenantService))
+	go importService.RunRecovery(ctx)
 
-	setupRoutes(app, redisClient, messageRepository)
+	// bodies over the default limit are streamed to the route, limitBody applies the limit of each route
+	app := fiber.New(fiber.Config{StreamRequestBody: true, DisablePreParseMultipartForm: true})
+	app.Use(limitBody)
+
+	setupRoutes(app, redisClient, messageRepository, templateRepository, inboundRepository, conversationRepository, campaignRepository, importService, exportService, statsRepository, suppressionService, spendService, tenantService, registry, coordinator, monitoringOptions)
 
 	listen(app)
 
 	<-ctx.Done()
 
-	shutdown(app, redisClient, mainScheduler, retryScheduler)
+	shutdown(app, redisClient, registry, elector, coordinator, importService, exportService)
 }
 
 func listenShutdownSignal(cancel context.CancelFunc) {
@@ -98,12 +210,15 @@ func listen(app *fiber.App) {
 	}()
 }
 
-func setupRoutes(app *fiber.App, redisClient *redis.RedisClient, messageRepository *repository.MessageRepository) {
-	app.Post("/start", func(ctx *fiber.Ctx) error {
-		return start.StartHandler(ctx, redisClient)
+func setupRoutes(app *fiber.App, redisClient *redis.RedisClient, messageRepository *repository.MessageRepository, templateRepository *repository.TemplateRepository, inboundRepository *repository.InboundRepository, conversationRepository *repository.ConversationRepository, campaignRepository *repository.CampaignRepository, importService *imports.ImportService, exportService *exports.ExportService, statsRepository *repository.StatsRepository, suppressionService *suppression.SuppressionService, spendService *spend.SpendService, tenantService *tenants.TenantService, registry *instance.Registry, coordinator *drainCoordinator.Coordinator, monitoringOptions []monitoring.Option) {
+	app.Use(tenantService.Middleware(publicRoute))
+
+	dispatcher := messagecontrol.NewDispatcher(redisClient, registry, config.Cfg.Commands.AckTimeout)
+	app.Post("/start", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return start.StartHandler(ctx, dispatcher)
 	})
-	app.Post("/stop", func(ctx *fiber.Ctx) error {
-		return stop.StopHandler(ctx, redisClient)
+	app.Post("/stop", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return stop.StopHandler(ctx, dispatcher)
 	})
 
 	messagecontrolService := list_sent.NewService(messageRepository)
@@ -111,13 +226,168 @@ func setupRoutes(app *fiber.App, redisClient *redis.RedisClient, messageReposito
 		return messagecontrolService.ListSentMessages(ctx)
 	})
 
+	enqueueService := enqueue.NewService(messageRepository, templateRepository, enqueue.WithQuota(tenantService))
+	app.Post("/messages", func(ctx *fiber.Ctx) error {
+		return enqueueService.Enqueue(ctx)
+	})
+
+	templateService := templates.NewService(templateRepository)
+	app.Post("/templates", func(ctx *fiber.Ctx) error {
+		return templateService.CreateTemplate(ctx)
+	})
+	app.Get("/templates", func(ctx *fiber.Ctx) error {
+		return templateService.ListTemplates(ctx)
+	})
+	app.Get("/templates/:id", func(ctx *fiber.Ctx) error {
+		return templateService.GetTemplate(ctx)
+	})
+	app.Get("/templates/:id/versions", func(ctx *fiber.Ctx) error {
+		return templateService.ListTemplateVersions(ctx)
+	})
+	app.Put("/templates/:id", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return templateService.UpdateTemplate(ctx)
+	})
+	app.Delete("/templates/:id", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return templateService.DeleteTemplate(ctx)
+	})
+
+	app.Post("/suppressions", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return suppressionService.AddSuppression(ctx)
+	})
+	app.Get("/suppressions", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return suppressionService.ListSuppressions(ctx)
+	})
+	app.Delete("/suppressions/:phone", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return suppressionService.RemoveSuppression(ctx)
+	})
+
+	inboundService := inbound.NewService(inboundRepository, inbound.DefaultRouter(suppressionService, enqueueService))
+	if config.Cfg.Inbound.WebhookSecret == "" {
+		logger.Log.Warn("inbound.webhookSecret is not set, provider callbacks are refused")
+	}
+	verifySignature := inbound.VerifySignature(config.Cfg.Inbound.WebhookSecret)
+	app.Post("/inbound", verifySignature, func(ctx *fiber.Ctx) error {
+		return inboundService.Receive(ctx)
+	})
+	// kept for providers configured before /inbound stored messages
+	app.Post("/inbound/sms", verifySignature, func(ctx *fiber.Ctx) error {
+		return inboundService.Receive(ctx)
+	})
+	app.Get("/inbound", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return inboundService.ListInbound(ctx)
+	})
+
+	conversationService := conversations.NewService(conversationRepository)
+	app.Get("/conversations", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return conversationService.ListConversations(ctx)
+	})
+	app.Get("/conversations/:phone", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return conversationService.GetConversation(ctx)
+	})
+	app.Post("/conversations/:phone/read", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return conversationService.MarkRead(ctx)
+	})
+
+	campaignService := campaigns.NewService(campaignRepository, templateRepository, campaigns.WithQuota(tenantService))
+	app.Post("/campaigns", func(ctx *fiber.Ctx) error {
+		return campaignService.CreateCampaign(ctx)
+	})
+	app.Get("/campaigns", func(ctx *fiber.Ctx) error {
+		return campaignService.ListCampaigns(ctx)
+	})
+	app.Get("/campaigns/:id", func(ctx *fiber.Ctx) error {
+		return campaignService.GetCampaign(ctx)
+	})
+	app.Post("/campaigns/:id/pause", func(ctx *fiber.Ctx) error {
+		return campaignService.PauseCampaign(ctx)
+	})
+	app.Post("/campaigns/:id/resume", func(ctx *fiber.Ctx) error {
+		return campaignService.ResumeCampaign(ctx)
+	})
+	app.Post("/campaigns/:id/cancel", func(ctx *fiber.Ctx) error {
+		return campaignService.CancelCampaign(ctx)
+	})
+
+	app.Post("/imports", func(ctx *fiber.Ctx) error {
+		return importService.CreateImport(ctx)
+	})
+	app.Get("/imports/:id", func(ctx *fiber.Ctx) error {
+		return importService.GetImport(ctx)
+	})
+	app.Get("/imports/:id/errors", func(ctx *fiber.Ctx) error {
+		return importService.ListImportErrors(ctx)
+	})
+
+	app.Post("/exports", func(ctx *fiber.Ctx) error {
+		return exportService.CreateExport(ctx)
+	})
+	app.Get("/exports", func(ctx *fiber.Ctx) error {
+		return exportService.ListExports(ctx)
+	})
+	app.Get("/exports/:id", func(ctx *fiber.Ctx) error {
+		return exportService.GetExport(ctx)
+	})
+	app.Get("/exports/:id/download", func(ctx *fiber.Ctx) error {
+		return exportService.DownloadExport(ctx)
+	})
+
+	statsService := stats.NewService(statsRepository, redisClient)
+	app.Get("/stats", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return statsService.GetStats(ctx)
+	})
+
+	app.Get("/spend", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return spendService.GetSpend(ctx)
+	})
+	app.Get("/budget", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return spendService.GetBudget(ctx)
+	})
+
+	app.Post("/tenants", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return tenantService.CreateTenant(ctx)
+	})
+	app.Get("/tenants", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return tenantService.ListTenants(ctx)
+	})
+	app.Get("/tenants/:id", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return tenantService.GetTenant(ctx)
+	})
+	app.Put("/tenants/:id", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return tenantService.UpdateTenant(ctx)
+	})
+	app.Post("/tenants/:id/api-keys", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return tenantService.CreateAPIKey(ctx)
+	})
+	app.Get("/tenants/:id/api-keys", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return tenantService.ListAPIKeys(ctx)
+	})
+	app.Delete("/tenants/:id/api-keys/:keyId", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return tenantService.RevokeAPIKey(ctx)
+	})
+
+	instancesService := instances.NewService(registry)
+	app.Get("/instances", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return instancesService.ListInstances(ctx)
+	})
+
+	drainService := drain.NewService(coordinator)
+	app.Post("/drain", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return drainService.StartDrain(ctx)
+	})
+	app.Get("/drain", tenants.AdminOnly, func(ctx *fiber.Ctx) error {
+		return drainService.GetDrain(ctx)
+	})
+	app.Get("/drain/prestop", func(ctx *fiber.Ctx) error {
+		return drainService.PreStop(ctx)
+	})
+
 	app.Get("/swagger/*", fiberSwagger.WrapHandler)
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.Redirect("/swagger/index.html", fiber.StatusFound)
 	})
 
 	// monitoring
-	monitoringService := monitoring.NewMonitoringService(db.DB, redisClient)
+	monitoringService := monitoring.NewMonitoringService(db.DB, redisClient, monitoringOptions...)
 	app.Get("/ready", func(c *fiber.Ctx) error {
 		return monitoringService.Readiness(c)
 	})
@@ -125,9 +395,75 @@ func setupRoutes(app *fiber.App, redisClient *redis.RedisClient, messageReposito
 	app.Get("/live", func(c *fiber.Ctx) error {
 		return monitoringService.Liveness(c)
 	})
+
+	app.Get("/health", func(c *fiber.Ctx) error {
+		return monitoringService.Health(c)
+	})
+
+	app.Get("/metrics", metrics.Handler())
 }
 
-func shutdown(app *fiber.App, redisClient *redis.RedisClient, scheduler *scheduler.Scheduler, retryScheduler *retry.RetryScheduler) {
+// limitBody rejects bodies over the limit of their route: imports.maxFileSize for uploads to
+// /imports and fiber.DefaultBodyLimit for every other route. Chunked bodies have no length up front,
+// uploads are streamed to /imports which stops reading at its limit and other bodies are read up to
+// the default limit.
+func limitBody(c *fiber.Ctx) error {
+	if c.Method() == fiber.MethodPost && c.Path() == "/imports" {
+		if length := c.Request().Header.ContentLength(); length > config.Cfg.Imports.MaxFileSize {
+			return bodyTooLarge(c, config.Cfg.Imports.MaxFileSize)
+		}
+		return c.Next()
+	}
+
+	limit := fiber.DefaultBodyLimit
+	req := c.Request()
+	if length := req.Header.ContentLength(); length > limit {
+		return bodyTooLarge(c, limit)
+	} else if length == -1 && req.IsBodyStream() {
+		body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(limit)+1))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid request body",
+			})
+		}
+		if len(body) > limit {
+			return bodyTooLarge(c, limit)
+		}
+		req.SetBody(body)
+	}
+	return c.Next()
+}
+
+// bodyTooLarge answers before the body is read, the connection is closed rather than reading the rest
+func bodyTooLarge(c *fiber.Ctx, limit int) error {
+	c.Context().SetConnectionClose()
+	return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+		"error": fmt.Sprintf("request body is larger than %d bytes", limit),
+	})
+}
+
+// publicRoute reports whether a request is served without an API key: probes, metrics, docs,
+// provider callbacks and signed export downloads
+func publicRoute(c *fiber.Ctx) bool {
+	path := c.Path()
+	switch {
+	case path == "/" || path == "/ready" || path == "/live" || path == "/health" || path == "/metrics" || path == "/drain/prestop":
+		return true
+	case strings.HasPrefix(path, "/swagger/"):
+		return true
+	case c.Method() == fiber.MethodPost && (path == "/inbound" || path == "/inbound/sms"):
+		return true
+	case c.Method() == fiber.MethodGet && strings.HasPrefix(path, "/exports/") && strings.HasSuffix(path, "/download"):
+		return true
+	}
+	return false
+}
+
+func shutdown(app *fiber.App, redisClient *redis.RedisClient, registry *instance.Registry, elector leader.Elector, coordinator *drainCoordinator.Coordinator, importService *imports.ImportService, exportService *exports.ExportService) {
+	// let in-flight sends finish first, returns immediately if the preStop hook already drained
+	progress := coordinator.Drain(context.Background())
+	logger.Log.Info("Schedulers drained", zap.String("state", string(progress.State)), zap.Int("inFlight", progress.InFlight))
+
 	logger.Log.Info("Shutting down Fiber app")
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
@@ -135,14 +471,26 @@ func shutdown(app *fiber.App, redisClient *redis.RedisClient, scheduler *schedul
 	shutdownErr := make(chan error, 1)
 
 	go func() {
-		scheduler.Stop(shutdownCtx)
-		retryScheduler.Stop(shutdownCtx)
+		if err := registry.Deregister(shutdownCtx); err != nil {
+			logger.Log.Warn("Failed to deregister instance", zap.Error(err))
+		}
+
+		if elector != nil {
+			if err := elector.Resign(shutdownCtx); err != nil {
+				logger.Log.Warn("Failed to resign leadership", zap.Error(err))
+			}
+		}
 
 		if err := app.ShutdownWithContext(shutdownCtx); err != nil {
 			shutdownErr <- fmt.Errorf("fiber shutdown error: %w", err)
 			return
 		}
 
+		// background imports and exports still write to the database, running imports are failed
+		// rather than cut off with the instance
+		importService.Stop()
+		exportService.Wait()
+
 		if err := redisClient.Close(shutdownCtx); err != nil {
 			shutdownErr <- fmt.Errorf("redis close error: %w", err)
 			return
@@ -1,17 +1,170 @@
+CREATE TABLE IF NOT EXISTS tenants (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    webhook_url TEXT,
+    provider VARCHAR(50),
+    rate_limit INT NOT NULL DEFAULT 0,
+    daily_quota INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- requests without an API key and rows stored before tenants existed belong to the default tenant
+INSERT INTO tenants (id, name) VALUES (1, 'default') ON CONFLICT DO NOTHING;
+SELECT setval('tenants_id_seq', (SELECT MAX(id) FROM tenants));
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(255),
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    admin BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant ON api_keys (tenant_id, id);
+
+CREATE TABLE IF NOT EXISTS templates (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    version INT NOT NULL DEFAULT 1,
+    body TEXT NOT NULL,
+    locales JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_templates_name ON templates (name) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS template_versions (
+    template_id INT NOT NULL REFERENCES templates(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    body TEXT NOT NULL,
+    locales JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (template_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS campaigns (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL DEFAULT 1 REFERENCES tenants(id),
+    name VARCHAR(255) NOT NULL,
+    template_id INT NOT NULL REFERENCES templates(id),
+    template_version INT NOT NULL,
+    locale VARCHAR(35),
+    priority SMALLINT NOT NULL DEFAULT 1,
+    category VARCHAR(50),
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    recipients INT NOT NULL DEFAULT 0,
+    expanded INT NOT NULL DEFAULT 0,
+    invalid INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    expanded_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- campaigns with recipients left to expand
+CREATE INDEX IF NOT EXISTS idx_campaigns_expandable ON campaigns (id) WHERE status = 'running' AND expanded < recipients;
+
+CREATE TABLE IF NOT EXISTS campaign_recipients (
+    campaign_id INT NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    phone_number VARCHAR(50) NOT NULL,
+    locale VARCHAR(35),
+    variables JSONB,
+    error TEXT,
+    PRIMARY KEY (campaign_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS imports (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL DEFAULT 1 REFERENCES tenants(id),
+    file_name VARCHAR(255),
+    format VARCHAR(10) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'processing',
+    rows INT NOT NULL DEFAULT 0,
+    imported INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS import_errors (
+    import_id INT NOT NULL REFERENCES imports(id) ON DELETE CASCADE,
+    line INT NOT NULL,
+    error TEXT NOT NULL,
+    PRIMARY KEY (import_id, line)
+);
+
 CREATE TABLE IF NOT EXISTS messages (
     id SERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL DEFAULT 1 REFERENCES tenants(id),
     phone_number VARCHAR(20) NOT NULL,
-    content VARCHAR(160) NOT NULL,
+    country_code VARCHAR(3),
+    number_type VARCHAR(20),
+    content TEXT NOT NULL,
     status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    priority SMALLINT NOT NULL DEFAULT 0,
     message_id VARCHAR(255),
     last_error TEXT,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     processed_at TIMESTAMP,
     sent_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    category VARCHAR(50),
+    time_zone VARCHAR(64),
+    deliver_after TIMESTAMP,
+    duplicate_of INT REFERENCES messages(id),
+    campaign_id INT REFERENCES campaigns(id),
+    import_id INT REFERENCES imports(id),
+    template_id INT REFERENCES templates(id),
+    template_version INT,
+    locale VARCHAR(35),
+    encoding VARCHAR(10),
+    segments SMALLINT,
+    provider VARCHAR(50),
+    cost NUMERIC(14, 6),
+    recovery_attempts INT NOT NULL DEFAULT 0,
+    lease_owner VARCHAR(255),
+    lease_expires_at TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
 CREATE INDEX IF NOT EXISTS idx_messages_status ON messages (status);
+CREATE INDEX IF NOT EXISTS idx_messages_processing ON messages (lease_expires_at) WHERE status = 'processing';
+-- claim order per tenant: priority (-2 critical, -1 high, 0 normal, 1 bulk) then age
+CREATE INDEX IF NOT EXISTS idx_messages_pending_priority ON messages (tenant_id, priority, id) WHERE status = 'pending';
+-- conversation threads and the correlation of inbound replies with the last message sent to the number
+CREATE INDEX IF NOT EXISTS idx_messages_phone_number ON messages (phone_number);
+-- statistics over a time window
+CREATE INDEX IF NOT EXISTS idx_messages_created_at ON messages (created_at);
+-- the cost lets the spend of budget periods be summed from the index alone
+CREATE INDEX IF NOT EXISTS idx_messages_sent_at ON messages (sent_at) INCLUDE (cost) WHERE status = 'done';
+-- campaign progress and pause, resume and cancel
+CREATE INDEX IF NOT EXISTS idx_messages_campaign ON messages (campaign_id, status) WHERE campaign_id IS NOT NULL;
+-- finds normal and bulk messages old enough for the starvation boost
+CREATE INDEX IF NOT EXISTS idx_messages_pending_created ON messages (tenant_id, created_at, id) WHERE status = 'pending' AND priority >= 0;
+-- sent messages of a tenant
+CREATE INDEX IF NOT EXISTS idx_messages_tenant_sent ON messages (tenant_id, id) WHERE status = 'done';
+
+-- wakes stream mode workers on new messages, one notification per insert statement
+CREATE OR REPLACE FUNCTION notify_messages_inserted() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('messages_inserted', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER messages_inserted
+    AFTER INSERT ON messages
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION notify_messages_inserted();
 
 INSERT INTO messages (phone_number, content, status)
 VALUES
@@ -30,11 +183,16 @@ FROM generate_series(1, 62) AS s(i);
 CREATE TABLE message_retries (
                                  id SERIAL PRIMARY KEY,
                                  original_message_id BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+                                 tenant_id INT NOT NULL DEFAULT 1 REFERENCES tenants(id),
                                  phone_number TEXT NOT NULL,
                                  content TEXT NOT NULL,
                                  retry_count INT NOT NULL DEFAULT 0,
                                  last_error TEXT,
-                                 created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+                                 expires_at TIMESTAMP,
+                                 created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                 deliver_after TIMESTAMP,
+                                 lease_owner VARCHAR(255),
+                                 lease_expires_at TIMESTAMP
 );
 
 CREATE INDEX idx_message_retries_original_message_id ON message_retries(original_message_id);
@@ -43,6 +201,7 @@ CREATE INDEX idx_message_retries_original_message_id ON message_retries(original
 CREATE TABLE message_dead_letters (
                                       id SERIAL PRIMARY KEY,
                                       original_message_id BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+                                      tenant_id INT NOT NULL DEFAULT 1 REFERENCES tenants(id),
                                       phone_number TEXT NOT NULL,
                                       content TEXT NOT NULL,
                                       last_error TEXT,
@@ -53,3 +212,56 @@ CREATE INDEX idx_message_dead_letters_original_message_id ON message_dead_letter
 CREATE INDEX idx_message_dead_letters_failed_at ON message_dead_letters(failed_at);
 
 
+CREATE TABLE suppressions (
+                              phone_number VARCHAR(20) PRIMARY KEY,
+                              reason TEXT,
+                              source VARCHAR(20) NOT NULL,
+                              created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+
+CREATE TABLE inbound_messages (
+                                  id SERIAL PRIMARY KEY,
+                                  from_number VARCHAR(64) NOT NULL,
+                                  to_number VARCHAR(64),
+                                  body TEXT NOT NULL,
+                                  keyword VARCHAR(50),
+                                  provider_id VARCHAR(255),
+                                  reply_to BIGINT REFERENCES messages(id) ON DELETE SET NULL,
+                                  received_at TIMESTAMP NOT NULL,
+                                  read_at TIMESTAMP,
+                                  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- providers deliver a message again when our response is lost
+CREATE UNIQUE INDEX idx_inbound_messages_provider_id ON inbound_messages(provider_id) WHERE provider_id <> '';
+CREATE INDEX idx_inbound_messages_from_number ON inbound_messages(from_number, id);
+
+
+CREATE TABLE exports (
+                         id SERIAL PRIMARY KEY,
+                         tenant_id INT NOT NULL DEFAULT 1 REFERENCES tenants(id),
+                         format VARCHAR(10) NOT NULL,
+                         filter JSONB,
+                         status VARCHAR(20) NOT NULL DEFAULT 'processing',
+                         rows INT NOT NULL DEFAULT 0,
+                         size BIGINT NOT NULL DEFAULT 0,
+                         path TEXT,
+                         last_error TEXT,
+                         created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                         completed_at TIMESTAMP
+);
+
+
+CREATE TABLE message_audit (
+                               id SERIAL PRIMARY KEY,
+                               message_id BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+                               action TEXT NOT NULL,
+                               from_status VARCHAR(20),
+                               to_status VARCHAR(20),
+                               reason TEXT,
+                               actor TEXT NOT NULL,
+                               created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_message_audit_message_id ON message_audit(message_id);
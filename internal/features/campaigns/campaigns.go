@@ -0,0 +1,366 @@
+package campaigns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/atakurt/messagingApp/internal/features/tenants"
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+type CampaignRepositoryInterface interface {
+	CreateCampaign(campaign *db.Campaign, recipients []db.CampaignRecipient) error
+	GetCampaign(id uint) (db.Campaign, error)
+	ListCampaigns(tenantID uint, lastID, limit int) ([]db.Campaign, error)
+	CountCampaignMessages(id uint) (map[db.MessageStatus]int, error)
+	PauseCampaign(id uint) error
+	ResumeCampaign(id uint) error
+	CancelCampaign(id uint) error
+}
+
+type TemplateRepositoryInterface interface {
+	GetTemplate(id uint) (db.Template, error)
+}
+
+// Quota counts the messages of a tenant against its daily quota, messages that were reserved but
+// not stored are released
+type Quota interface {
+	Reserve(ctx context.Context, tenant db.Tenant, messages int) error
+	Release(ctx context.Context, tenant db.Tenant, messages int)
+}
+
+type CampaignService struct {
+	repository CampaignRepositoryInterface
+	templates  TemplateRepositoryInterface
+	quota      Quota
+}
+
+type Option func(*CampaignService)
+
+// WithQuota rejects campaigns with more recipients than the daily quota of their tenant has left
+func WithQuota(quota Quota) Option {
+	return func(s *CampaignService) {
+		s.quota = quota
+	}
+}
+
+func NewService(repository CampaignRepositoryInterface, templates TemplateRepositoryInterface, opts ...Option) *CampaignService {
+	s := &CampaignService{
+		repository: repository,
+		templates:  templates,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// CampaignRequest is the body of a campaign create
+// @Description Campaign sending a template to a list of recipients, priority defaults to bulk
+type CampaignRequest struct {
+	Name       string             `json:"name" example:"spring-sale"`
+	TemplateID uint               `json:"template_id"`
+	Locale     string             `json:"locale,omitempty" example:"tr-TR"`
+	Priority   *db.Priority       `json:"priority,omitempty" swaggertype:"string" enums:"critical,high,normal,bulk"`
+	Category   string             `json:"category,omitempty" example:"marketing"`
+	Recipients []RecipientRequest `json:"recipients"`
+}
+
+// RecipientRequest is a member of the audience, locale overrides the locale of the campaign
+type RecipientRequest struct {
+	PhoneNumber string            `json:"phone_number" example:"+905321234567"`
+	Locale      string            `json:"locale,omitempty"`
+	Variables   map[string]string `json:"variables,omitempty"`
+}
+
+// Progress counts the recipients of a campaign by outcome
+// @Description Recipients by outcome, unexpanded recipients are not messages yet
+type Progress struct {
+	Unexpanded int `json:"unexpanded"`
+	// Queued messages wait to be sent, including the held messages of a paused campaign
+	Queued     int `json:"queued"`
+	Sent       int `json:"sent"`
+	Failed     int `json:"failed"`
+	Suppressed int `json:"suppressed"`
+	Expired    int `json:"expired"`
+	Duplicate  int `json:"duplicate"`
+	Cancelled  int `json:"cancelled"`
+}
+
+// CampaignResponse is a campaign with its progress
+type CampaignResponse struct {
+	db.Campaign
+	Progress Progress `json:"progress"`
+}
+
+// CampaignListResponse is a page of campaigns
+// @Description Paginated list of campaigns
+type CampaignListResponse struct {
+	LastID int           `json:"last_id"`
+	Limit  int           `json:"limit"`
+	Data   []db.Campaign `json:"data"`
+}
+
+func (r CampaignRequest) validate() error {
+	switch {
+	case strings.TrimSpace(r.Name) == "":
+		return errors.New("name is required")
+	case len(r.Name) > 255:
+		return errors.New("name is longer than 255 characters")
+	case r.TemplateID == 0:
+		return errors.New("template_id is required")
+	case len(r.Recipients) == 0:
+		return errors.New("recipients are required")
+	case len(r.Recipients) > config.Cfg.Campaigns.MaxRecipients:
+		return fmt.Errorf("too many recipients, the limit is %d", config.Cfg.Campaigns.MaxRecipients)
+	case len(strings.TrimSpace(r.Category)) > 50:
+		return errors.New("category is longer than 50 characters")
+	}
+	return nil
+}
+
+// CreateCampaign godoc
+// @Summary      Create a campaign
+// @Description  Stores a campaign and its recipients and returns at once, the recipients are turned into messages in chunks of campaigns.chunkSize in the background. The current version of the template is pinned, later template updates do not change the campaign. Recipients with an invalid number or missing variables are skipped and counted as failed. Every recipient counts against the daily quota of the tenant when the campaign is created. Only the admin may create critical campaigns.
+// @Tags         Campaigns
+// @Accept       json
+// @Produce      json
+// @Param        campaign  body      CampaignRequest  true  "Campaign"
+// @Success      201       {object}  db.Campaign
+// @Failure      400       {object}  map[string]string
+// @Failure      403       {object}  map[string]string
+// @Failure      429       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /campaigns [post]
+func (s *CampaignService) CreateCampaign(c *fiber.Ctx) error {
+	var req CampaignRequest
+	if err := c.BodyParser(&req); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+	if err := req.validate(); err != nil {
+		return badRequest(c, err.Error())
+	}
+
+	template, err := s.templates.GetTemplate(req.TemplateID)
+	if errors.Is(err, repository.ErrTemplateNotFound) {
+		return badRequest(c, err.Error())
+	}
+	if err != nil {
+		return campaignError(c, err)
+	}
+
+	tenant := tenants.From(c)
+	campaign := db.Campaign{
+		TenantID:        tenant.ID,
+		Name:            strings.TrimSpace(req.Name),
+		TemplateID:      template.ID,
+		TemplateVersion: template.Version,
+		Locale:          req.Locale,
+		Priority:        db.PriorityBulk,
+		Category:        strings.ToLower(strings.TrimSpace(req.Category)),
+	}
+	if req.Priority != nil {
+		campaign.Priority = *req.Priority
+	}
+	// critical messages skip delivery windows and the budget, only the admin sends them in bulk
+	if campaign.Priority == db.PriorityCritical && !tenants.IsAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "only the admin can create critical campaigns",
+		})
+	}
+
+	recipients := make([]db.CampaignRecipient, len(req.Recipients))
+	for i, recipient := range req.Recipients {
+		recipients[i] = db.CampaignRecipient{
+			PhoneNumber: recipient.PhoneNumber,
+			Locale:      recipient.Locale,
+			Variables:   recipient.Variables,
+		}
+	}
+
+	if s.quota != nil {
+		if err := s.quota.Reserve(c.UserContext(), tenant, len(recipients)); err != nil {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+	if err := s.repository.CreateCampaign(&campaign, recipients); err != nil {
+		if s.quota != nil {
+			s.quota.Release(c.UserContext(), tenant, len(recipients))
+		}
+		return campaignError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(campaign)
+}
+
+// ListCampaigns godoc
+// @Summary      List campaigns
+// @Description  Lists the campaigns of the tenant using keyset pagination, get a campaign for its progress
+// @Tags         Campaigns
+// @Produce      json
+// @Param        last_id  query     int  false  "Only return campaigns with ID > last_id"
+// @Param        limit    query     int  false  "Maximum number of campaigns to return (max 100)"
+// @Success      200      {object}  CampaignListResponse
+// @Failure      500      {object}  map[string]string
+// @Router       /campaigns [get]
+func (s *CampaignService) ListCampaigns(c *fiber.Ctx) error {
+	lastID := c.QueryInt("last_id", 0)
+	limit := c.QueryInt("limit", 0)
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	campaigns, err := s.repository.ListCampaigns(tenants.From(c).ID, lastID, limit)
+	if err != nil {
+		return campaignError(c, err)
+	}
+	return c.JSON(CampaignListResponse{LastID: lastID, Limit: limit, Data: campaigns})
+}
+
+// GetCampaign godoc
+// @Summary      Get a campaign
+// @Description  Returns a campaign with the number of its recipients queued, sent, failed, suppressed, expired, duplicate and cancelled
+// @Tags         Campaigns
+// @Produce      json
+// @Param        id   path      int  true  "Campaign ID"
+// @Success      200  {object}  CampaignResponse
+// @Failure      404  {object}  map[string]string
+// @Router       /campaigns/{id} [get]
+func (s *CampaignService) GetCampaign(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return badRequest(c, "invalid campaign id")
+	}
+	return s.respond(c, uint(id))
+}
+
+// PauseCampaign godoc
+// @Summary      Pause a campaign
+// @Description  Stops expanding a running campaign and holds its queued messages until it is resumed. Messages already being sent complete, the global scheduler keeps running.
+// @Tags         Campaigns
+// @Produce      json
+// @Param        id   path      int  true  "Campaign ID"
+// @Success      200  {object}  CampaignResponse
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /campaigns/{id}/pause [post]
+func (s *CampaignService) PauseCampaign(c *fiber.Ctx) error {
+	return s.transition(c, s.repository.PauseCampaign)
+}
+
+// ResumeCampaign godoc
+// @Summary      Resume a campaign
+// @Description  Releases the held messages of a paused campaign and continues expanding it
+// @Tags         Campaigns
+// @Produce      json
+// @Param        id   path      int  true  "Campaign ID"
+// @Success      200  {object}  CampaignResponse
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /campaigns/{id}/resume [post]
+func (s *CampaignService) ResumeCampaign(c *fiber.Ctx) error {
+	return s.transition(c, s.repository.ResumeCampaign)
+}
+
+// CancelCampaign godoc
+// @Summary      Cancel a campaign
+// @Description  Stops a running or paused campaign for good, its queued messages and unexpanded recipients are never sent
+// @Tags         Campaigns
+// @Produce      json
+// @Param        id   path      int  true  "Campaign ID"
+// @Success      200  {object}  CampaignResponse
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /campaigns/{id}/cancel [post]
+func (s *CampaignService) CancelCampaign(c *fiber.Ctx) error {
+	return s.transition(c, s.repository.CancelCampaign)
+}
+
+func (s *CampaignService) transition(c *fiber.Ctx, action func(id uint) error) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return badRequest(c, "invalid campaign id")
+	}
+	if _, err := s.campaign(c, uint(id)); err != nil {
+		return campaignError(c, err)
+	}
+	if err := action(uint(id)); err != nil {
+		return campaignError(c, err)
+	}
+	return s.respond(c, uint(id))
+}
+
+func (s *CampaignService) respond(c *fiber.Ctx, id uint) error {
+	campaign, err := s.campaign(c, id)
+	if err != nil {
+		return campaignError(c, err)
+	}
+	counts, err := s.repository.CountCampaignMessages(id)
+	if err != nil {
+		return campaignError(c, err)
+	}
+	return c.JSON(CampaignResponse{Campaign: campaign, Progress: NewProgress(campaign, counts)})
+}
+
+// campaign returns a campaign of the tenant of the request, campaigns of other tenants are not found
+func (s *CampaignService) campaign(c *fiber.Ctx, id uint) (db.Campaign, error) {
+	campaign, err := s.repository.GetCampaign(id)
+	if err == nil && campaign.TenantID != tenants.From(c).ID {
+		return db.Campaign{}, repository.ErrCampaignNotFound
+	}
+	return campaign, err
+}
+
+// NewProgress sums the message counts of a campaign into outcomes. Recipients of a cancelled or
+// failed campaign that were never expanded count as cancelled or failed.
+func NewProgress(campaign db.Campaign, counts map[db.MessageStatus]int) Progress {
+	progress := Progress{
+		Unexpanded: campaign.Recipients - campaign.Expanded,
+		Queued:     counts[db.StatusPending] + counts[db.StatusProcessing] + counts[db.StatusPaused],
+		Sent:       counts[db.StatusDone],
+		Failed:     counts[db.StatusError] + counts[db.StatusReview] + campaign.Invalid,
+		Suppressed: counts[db.StatusSuppressed],
+		Expired:    counts[db.StatusExpired],
+		Duplicate:  counts[db.StatusDuplicate],
+		Cancelled:  counts[db.StatusCancelled],
+	}
+
+	switch campaign.Status {
+	case db.CampaignCancelled:
+		progress.Cancelled += progress.Unexpanded
+		progress.Unexpanded = 0
+	case db.CampaignFailed:
+		progress.Failed += progress.Unexpanded
+		progress.Unexpanded = 0
+	}
+	return progress
+}
+
+func badRequest(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": message,
+	})
+}
+
+func campaignError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	message := "Failed to access campaigns"
+	switch {
+	case errors.Is(err, repository.ErrCampaignNotFound):
+		status, message = fiber.StatusNotFound, err.Error()
+	case errors.Is(err, repository.ErrCampaignState):
+		status, message = fiber.StatusConflict, err.Error()
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": message,
+	})
+}
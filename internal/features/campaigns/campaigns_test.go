@@ -0,0 +1,275 @@
+package campaigns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/atakurt/messagingApp/internal/features/tenants"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCampaignHandlers(t *testing.T) {
+	setTestConfig()
+	otp := db.Template{ID: 3, Name: "otp", Version: 2, Body: "Hi {{name}}"}
+	running := db.Campaign{ID: 5, TenantID: db.DefaultTenantID, Name: "spring", TemplateID: 3, TemplateVersion: 2, Status: db.CampaignRunning, Recipients: 10, Expanded: 8, Invalid: 1}
+	counts := map[db.MessageStatus]int{db.StatusPending: 2, db.StatusPaused: 1, db.StatusDone: 3, db.StatusError: 1}
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		setupMock      func(*mocks.MockCampaignRepositoryInterface, *mocks.MockTemplateRepositoryInterface)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "Create campaign pins the template version",
+			method: fiber.MethodPost,
+			url:    "/campaigns",
+			body:   `{"name":" spring ","template_id":3,"category":"Marketing","recipients":[{"phone_number":"05321234567","variables":{"name":"Ali"}},{"phone_number":"+14155550100","locale":"en"}]}`,
+			setupMock: func(m *mocks.MockCampaignRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {
+				templates.EXPECT().GetTemplate(uint(3)).Return(otp, nil)
+				m.EXPECT().CreateCampaign(gomock.Any(), gomock.Any()).DoAndReturn(func(campaign *db.Campaign, recipients []db.CampaignRecipient) error {
+					assert.Equal(t, "spring", campaign.Name)
+					assert.Equal(t, db.DefaultTenantID, campaign.TenantID)
+					assert.Equal(t, 2, campaign.TemplateVersion)
+					assert.Equal(t, db.PriorityBulk, campaign.Priority)
+					assert.Equal(t, "marketing", campaign.Category)
+					assert.Len(t, recipients, 2)
+					assert.Equal(t, map[string]string{"name": "Ali"}, recipients[0].Variables)
+					assert.Equal(t, "en", recipients[1].Locale)
+					campaign.ID, campaign.Status = 5, db.CampaignRunning
+					return nil
+				})
+			},
+			expectedStatus: fiber.StatusCreated,
+			expectedBody:   `"status":"running"`,
+		},
+		{
+			name:   "Create keeps an explicit priority",
+			method: fiber.MethodPost,
+			url:    "/campaigns",
+			body:   `{"name":"spring","template_id":3,"priority":"normal","recipients":[{"phone_number":"05321234567"}]}`,
+			setupMock: func(m *mocks.MockCampaignRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {
+				templates.EXPECT().GetTemplate(uint(3)).Return(otp, nil)
+				m.EXPECT().CreateCampaign(gomock.Any(), gomock.Any()).DoAndReturn(func(campaign *db.Campaign, recipients []db.CampaignRecipient) error {
+					assert.Equal(t, db.PriorityNormal, campaign.Priority)
+					return nil
+				})
+			},
+			expectedStatus: fiber.StatusCreated,
+		},
+		{
+			name:           "Create requires recipients",
+			method:         fiber.MethodPost,
+			url:            "/campaigns",
+			body:           `{"name":"spring","template_id":3}`,
+			setupMock:      func(m *mocks.MockCampaignRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "recipients are required",
+		},
+		{
+			name:           "Create limits recipients",
+			method:         fiber.MethodPost,
+			url:            "/campaigns",
+			body:           `{"name":"spring","template_id":3,"recipients":[{"phone_number":"1"},{"phone_number":"2"},{"phone_number":"3"}]}`,
+			setupMock:      func(m *mocks.MockCampaignRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "too many recipients, the limit is 2",
+		},
+		{
+			name:   "Create rejects an unknown template",
+			method: fiber.MethodPost,
+			url:    "/campaigns",
+			body:   `{"name":"spring","template_id":4,"recipients":[{"phone_number":"05321234567"}]}`,
+			setupMock: func(m *mocks.MockCampaignRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {
+				templates.EXPECT().GetTemplate(uint(4)).Return(db.Template{}, repository.ErrTemplateNotFound)
+			},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   repository.ErrTemplateNotFound.Error(),
+		},
+		{
+			name:   "List campaigns",
+			method: fiber.MethodGet,
+			url:    "/campaigns?last_id=2&limit=500",
+			setupMock: func(m *mocks.MockCampaignRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {
+				m.EXPECT().ListCampaigns(db.DefaultTenantID, 2, 100).Return([]db.Campaign{running}, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"limit":100`,
+		},
+		{
+			name:   "Get campaign with progress",
+			method: fiber.MethodGet,
+			url:    "/campaigns/5",
+			setupMock: func(m *mocks.MockCampaignRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {
+				m.EXPECT().GetCampaign(uint(5)).Return(running, nil)
+				m.EXPECT().CountCampaignMessages(uint(5)).Return(counts, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"progress":{"unexpanded":2,"queued":3,"sent":3,"failed":2,"suppressed":0,"expired":0,"duplicate":0,"cancelled":0}`,
+		},
+		{
+			name:   "Get unknown campaign",
+			method: fiber.MethodGet,
+			url:    "/campaigns/6",
+			setupMock: func(m *mocks.MockCampaignRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {
+				m.EXPECT().GetCampaign(uint(6)).Return(db.Campaign{}, repository.ErrCampaignNotFound)
+			},
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody:   repository.ErrCampaignNotFound.Error(),
+		},
+		{
+			name:   "Campaign of another tenant is not found",
+			method: fiber.MethodPost,
+			url:    "/campaigns/5/cancel",
+			setupMock: func(m *mocks.MockCampaignRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {
+				other := running
+				other.TenantID = 2
+				m.EXPECT().GetCampaign(uint(5)).Return(other, nil)
+			},
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody:   repository.ErrCampaignNotFound.Error(),
+		},
+		{
+			name:   "Pause campaign",
+			method: fiber.MethodPost,
+			url:    "/campaigns/5/pause",
+			setupMock: func(m *mocks.MockCampaignRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {
+				paused := running
+				paused.Status = db.CampaignPaused
+				m.EXPECT().GetCampaign(uint(5)).Return(running, nil)
+				m.EXPECT().PauseCampaign(uint(5)).Return(nil)
+				m.EXPECT().GetCampaign(uint(5)).Return(paused, nil)
+				m.EXPECT().CountCampaignMessages(uint(5)).Return(counts, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"status":"paused"`,
+		},
+		{
+			name:   "Resume a running campaign conflicts",
+			method: fiber.MethodPost,
+			url:    "/campaigns/5/resume",
+			setupMock: func(m *mocks.MockCampaignRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {
+				m.EXPECT().GetCampaign(uint(5)).Return(running, nil)
+				m.EXPECT().ResumeCampaign(uint(5)).Return(repository.ErrCampaignState)
+			},
+			expectedStatus: fiber.StatusConflict,
+			expectedBody:   repository.ErrCampaignState.Error(),
+		},
+		{
+			name:   "Cancel counts unexpanded recipients as cancelled",
+			method: fiber.MethodPost,
+			url:    "/campaigns/5/cancel",
+			setupMock: func(m *mocks.MockCampaignRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {
+				cancelled := running
+				cancelled.Status = db.CampaignCancelled
+				m.EXPECT().GetCampaign(uint(5)).Return(running, nil)
+				m.EXPECT().CancelCampaign(uint(5)).Return(nil)
+				m.EXPECT().GetCampaign(uint(5)).Return(cancelled, nil)
+				m.EXPECT().CountCampaignMessages(uint(5)).Return(map[db.MessageStatus]int{db.StatusCancelled: 3}, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"unexpanded":0,"queued":0,"sent":0,"failed":1,"suppressed":0,"expired":0,"duplicate":0,"cancelled":5`,
+		},
+		{
+			name:           "Invalid id",
+			method:         fiber.MethodPost,
+			url:            "/campaigns/abc/pause",
+			setupMock:      func(m *mocks.MockCampaignRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "invalid campaign id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockCampaignRepositoryInterface(ctrl)
+			mockTemplates := mocks.NewMockTemplateRepositoryInterface(ctrl)
+			tt.setupMock(mockRepo, mockTemplates)
+			service := NewService(mockRepo, mockTemplates)
+
+			app := fiber.New()
+			app.Post("/campaigns", service.CreateCampaign)
+			app.Get("/campaigns", service.ListCampaigns)
+			app.Get("/campaigns/:id", service.GetCampaign)
+			app.Post("/campaigns/:id/pause", service.PauseCampaign)
+			app.Post("/campaigns/:id/resume", service.ResumeCampaign)
+			app.Post("/campaigns/:id/cancel", service.CancelCampaign)
+
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			body, _ := io.ReadAll(resp.Body)
+			if tt.expectedBody != "" {
+				assert.Contains(t, string(body), tt.expectedBody)
+			}
+			if resp.StatusCode == fiber.StatusOK {
+				assert.True(t, json.Valid(body))
+			}
+		})
+	}
+}
+
+type quotaStub struct {
+	reserved int
+}
+
+func (q *quotaStub) Reserve(_ context.Context, _ db.Tenant, messages int) error {
+	q.reserved += messages
+	return nil
+}
+
+func (q *quotaStub) Release(_ context.Context, _ db.Tenant, messages int) {
+	q.reserved -= messages
+}
+
+func TestCreateCampaign_Tenant(t *testing.T) {
+	setTestConfig()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockCampaignRepositoryInterface(ctrl)
+	mockTemplates := mocks.NewMockTemplateRepositoryInterface(ctrl)
+	mockTemplates.EXPECT().GetTemplate(uint(3)).Return(db.Template{ID: 3, Version: 2}, nil).Times(2)
+	mockRepo.EXPECT().CreateCampaign(gomock.Any(), gomock.Any()).Return(errors.New("db down"))
+	quota := &quotaStub{}
+	service := NewService(mockRepo, mockTemplates, WithQuota(quota))
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		tenants.Set(c, db.Tenant{ID: 2, DailyQuota: 100})
+		return c.Next()
+	})
+	app.Post("/campaigns", service.CreateCampaign)
+
+	post := func(body string) int {
+		req := httptest.NewRequest(fiber.MethodPost, "/campaigns", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusForbidden, post(`{"name":"alert","template_id":3,"priority":"critical","recipients":[{"phone_number":"05321234567"}]}`))
+	// recipients of a campaign that was not stored do not count against the quota
+	assert.Equal(t, fiber.StatusInternalServerError, post(`{"name":"spring","template_id":3,"recipients":[{"phone_number":"05321234567"}]}`))
+	assert.Equal(t, 0, quota.reserved)
+}
@@ -0,0 +1,166 @@
+package campaigns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atakurt/messagingApp/internal/features/enqueue"
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/infrastructure/metrics"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"go.uber.org/zap"
+)
+
+//go:generate mockgen -destination=../../mocks/mock_campaign_expander.go -package=mocks github.com/atakurt/messagingApp/internal/features/campaigns CampaignExpanderInterface
+type CampaignExpanderInterface interface {
+	// ExpandNextChunk turns the next chunk of recipients of a running campaign into messages and returns the number expanded
+	ExpandNextChunk(ctx context.Context) int
+}
+
+type TemplateVersionRepositoryInterface interface {
+	ListTemplateVersions(id uint) ([]db.TemplateVersion, error)
+}
+
+type CampaignExpander struct {
+	repository repository.CampaignRepositoryInterface
+	templates  TemplateVersionRepositoryInterface
+	chunkSize  int
+}
+
+func NewExpander(repository repository.CampaignRepositoryInterface, templates TemplateVersionRepositoryInterface, cfg config.Config) *CampaignExpander {
+	return &CampaignExpander{
+		repository: repository,
+		templates:  templates,
+		chunkSize:  cfg.Campaigns.ChunkSize,
+	}
+}
+
+// pinnedTemplate serves the version of a template a campaign was created with
+type pinnedTemplate struct {
+	template db.Template
+}
+
+func (p pinnedTemplate) GetTemplate(uint) (db.Template, error) {
+	return p.template, nil
+}
+
+func (s *CampaignExpander) ExpandNextChunk(ctx context.Context) int {
+	tx := s.repository.GetDB().Begin()
+	if tx.Error != nil {
+		logger.Log.Error("Failed to begin transaction", zap.Error(tx.Error))
+		return 0
+	}
+	defer tx.Rollback()
+
+	campaign, err := s.repository.LockExpandableCampaign(tx)
+	if errors.Is(err, repository.ErrCampaignNotFound) {
+		return 0
+	}
+	if err != nil {
+		logger.Log.Error("Failed to select a campaign to expand", zap.Error(err))
+		return 0
+	}
+
+	template, err := s.pinnedTemplate(campaign)
+	if errors.Is(err, repository.ErrTemplateNotFound) {
+		logger.Log.Warn("Campaign template is gone, failing the campaign", zap.Uint("campaign_id", campaign.ID), zap.Error(err))
+		if err := s.repository.FailCampaign(tx, campaign.ID, err.Error()); err != nil {
+			logger.Log.Error("Failed to fail campaign", zap.Uint("campaign_id", campaign.ID), zap.Error(err))
+			return 0
+		}
+		if err := tx.Commit().Error; err != nil {
+			logger.Log.Error("Failed to commit transaction", zap.Error(err))
+		}
+		return 0
+	}
+	if err != nil {
+		logger.Log.Error("Failed to load campaign template", zap.Uint("campaign_id", campaign.ID), zap.Error(err))
+		return 0
+	}
+
+	recipients, err := s.repository.GetRecipients(tx, campaign.ID, campaign.Expanded, s.chunkSize)
+	if err != nil {
+		logger.Log.Error("Failed to load campaign recipients", zap.Uint("campaign_id", campaign.ID), zap.Error(err))
+		return 0
+	}
+
+	messages, invalid, err := s.render(campaign, template, recipients)
+	if err != nil {
+		logger.Log.Error("Failed to render campaign messages", zap.Uint("campaign_id", campaign.ID), zap.Error(err))
+		return 0
+	}
+
+	if err := s.repository.RecordExpansion(tx, campaign, messages, invalid, len(recipients)); err != nil {
+		logger.Log.Error("Failed to record campaign expansion", zap.Uint("campaign_id", campaign.ID), zap.Error(err))
+		return 0
+	}
+	if err := tx.Commit().Error; err != nil {
+		logger.Log.Error("Failed to commit transaction", zap.Error(err))
+		return 0
+	}
+
+	metrics.CampaignRecipientsExpanded.WithLabelValues("queued").Add(float64(len(messages)))
+	metrics.CampaignRecipientsExpanded.WithLabelValues("invalid").Add(float64(len(invalid)))
+	return len(recipients)
+}
+
+// pinnedTemplate returns the template as it was when the campaign was created
+func (s *CampaignExpander) pinnedTemplate(campaign db.Campaign) (pinnedTemplate, error) {
+	versions, err := s.templates.ListTemplateVersions(campaign.TemplateID)
+	if err != nil {
+		return pinnedTemplate{}, err
+	}
+	for _, version := range versions {
+		if version.Version == campaign.TemplateVersion {
+			return pinnedTemplate{template: db.Template{
+				ID:      version.TemplateID,
+				Version: version.Version,
+				Body:    version.Body,
+				Locales: version.Locales,
+			}}, nil
+		}
+	}
+	return pinnedTemplate{}, fmt.Errorf("%w: version %d", repository.ErrTemplateNotFound, campaign.TemplateVersion)
+}
+
+// render turns recipients into messages of the campaign, recipients that cannot become a message
+// are returned as invalid with the reason
+func (s *CampaignExpander) render(campaign db.Campaign, template pinnedTemplate, recipients []db.CampaignRecipient) ([]db.Message, []db.CampaignRecipient, error) {
+	renderer := enqueue.NewService(nil, template)
+	messages := make([]db.Message, 0, len(recipients))
+	var invalid []db.CampaignRecipient
+	for _, recipient := range recipients {
+		msg, err := renderer.NewMessage(s.request(campaign, recipient))
+		var validationErr *enqueue.ValidationError
+		if errors.As(err, &validationErr) {
+			recipient.Error = validationErr.Reason
+			invalid = append(invalid, recipient)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		msg.CampaignID = &campaign.ID
+		msg.TenantID = campaign.TenantID
+		messages = append(messages, msg)
+	}
+	return messages, invalid, nil
+}
+
+func (s *CampaignExpander) request(campaign db.Campaign, recipient db.CampaignRecipient) enqueue.Request {
+	locale := recipient.Locale
+	if locale == "" {
+		locale = campaign.Locale
+	}
+	return enqueue.Request{
+		PhoneNumber: recipient.PhoneNumber,
+		TemplateID:  &campaign.TemplateID,
+		Variables:   recipient.Variables,
+		Locale:      locale,
+		Priority:    campaign.Priority,
+		Category:    campaign.Category,
+	}
+}
@@ -0,0 +1,82 @@
+package campaigns
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setTestConfig() {
+	config.Cfg.Phone.DefaultRegion = "TR"
+	config.Cfg.SMS.MaxSegments = 3
+	config.Cfg.Templates.FallbackLocales = []string{"en"}
+	config.Cfg.Campaigns.MaxRecipients = 2
+}
+
+func TestPinnedTemplate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	templates := mocks.NewMockTemplateRepositoryInterface(ctrl)
+	expander := NewExpander(mocks.NewMockCampaignRepositoryInterface(ctrl), templates, config.Config{})
+	campaign := db.Campaign{TemplateID: 3, TemplateVersion: 1}
+
+	templates.EXPECT().ListTemplateVersions(uint(3)).Return([]db.TemplateVersion{
+		{TemplateID: 3, Version: 1, Body: "Hi {{name}}"},
+		{TemplateID: 3, Version: 2, Body: "Hello {{name}}"},
+	}, nil)
+	pinned, err := expander.pinnedTemplate(campaign)
+	require.NoError(t, err)
+	assert.Equal(t, db.Template{ID: 3, Version: 1, Body: "Hi {{name}}"}, pinned.template)
+
+	templates.EXPECT().ListTemplateVersions(uint(3)).Return([]db.TemplateVersion{{TemplateID: 3, Version: 2}}, nil)
+	_, err = expander.pinnedTemplate(campaign)
+	assert.True(t, errors.Is(err, repository.ErrTemplateNotFound))
+
+	templates.EXPECT().ListTemplateVersions(uint(3)).Return(nil, repository.ErrTemplateNotFound)
+	_, err = expander.pinnedTemplate(campaign)
+	assert.True(t, errors.Is(err, repository.ErrTemplateNotFound))
+}
+
+func TestRender(t *testing.T) {
+	setTestConfig()
+	expander := &CampaignExpander{}
+	campaign := db.Campaign{ID: 9, TemplateID: 3, TemplateVersion: 2, Locale: "tr", Priority: db.PriorityBulk, Category: "marketing"}
+	template := pinnedTemplate{template: db.Template{
+		ID:      3,
+		Version: 2,
+		Body:    "Hi {{name}}",
+		Locales: map[string]string{"tr": "Merhaba {{name}}"},
+	}}
+
+	messages, invalid, err := expander.render(campaign, template, []db.CampaignRecipient{
+		{CampaignID: 9, Position: 0, PhoneNumber: "05321234567", Variables: map[string]string{"name": "Ali"}},
+		{CampaignID: 9, Position: 1, PhoneNumber: "+14155550100", Locale: "en", Variables: map[string]string{"name": "Ann"}},
+		{CampaignID: 9, Position: 2, PhoneNumber: "12", Variables: map[string]string{"name": "Bob"}},
+		{CampaignID: 9, Position: 3, PhoneNumber: "05321234568"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, messages, 2)
+	assert.Equal(t, "+905321234567", messages[0].PhoneNumber)
+	assert.Equal(t, "Merhaba Ali", messages[0].Content)
+	assert.Equal(t, 2, messages[0].TemplateVersion)
+	assert.Equal(t, db.PriorityBulk, messages[0].Priority)
+	assert.Equal(t, "marketing", messages[0].Category)
+	assert.Equal(t, uint(9), *messages[0].CampaignID)
+	assert.Equal(t, "Hi Ann", messages[1].Content)
+	assert.Equal(t, "tr", messages[0].Locale)
+
+	require.Len(t, invalid, 2)
+	assert.Equal(t, 2, invalid[0].Position)
+	assert.NotEmpty(t, invalid[0].Error)
+	assert.Equal(t, 3, invalid[1].Position)
+	assert.Contains(t, invalid[1].Error, "missing template variables: name")
+}
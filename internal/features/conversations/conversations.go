@@ -0,0 +1,221 @@
+package conversations
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/phone"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ConversationRepositoryInterface interface {
+	GetThread(phoneNumber string, before *repository.ThreadPosition, limit int) ([]db.ConversationEntry, error)
+	ListConversations(before *repository.ConversationPosition, limit int) ([]db.Conversation, error)
+	MarkRead(phoneNumber string, readAt time.Time) (int64, error)
+}
+
+type ConversationService struct {
+	repository ConversationRepositoryInterface
+}
+
+func NewService(repository ConversationRepositoryInterface) *ConversationService {
+	return &ConversationService{
+		repository: repository,
+	}
+}
+
+// ThreadResponse is a page of a conversation
+// @Description Messages exchanged with a number in chronological order, pass before to get older messages
+type ThreadResponse struct {
+	PhoneNumber string                 `json:"phone_number"`
+	Limit       int                    `json:"limit"`
+	Data        []db.ConversationEntry `json:"data"`
+	// Before is the cursor of the next, older page, empty on the first message of the conversation
+	Before string `json:"before,omitempty"`
+}
+
+// ConversationListResponse is a page of conversations
+// @Description Conversations ordered by last activity, newest first, pass before to get the next page
+type ConversationListResponse struct {
+	Limit  int               `json:"limit"`
+	Data   []db.Conversation `json:"data"`
+	Before string            `json:"before,omitempty"`
+}
+
+// MarkReadResponse tells how many replies were marked as read
+// @Description Number of replies marked as read
+type MarkReadResponse struct {
+	Read int64 `json:"read"`
+}
+
+// GetConversation godoc
+// @Summary      Get a conversation
+// @Description  Returns the messages sent to a number and the replies received from it in chronological order, the latest page first. The number is normalized to E.164, a leading + must be URL encoded as %2B.
+// @Tags         Conversations
+// @Produce      json
+// @Param        phone   path      string  true   "Phone number"
+// @Param        before  query     string  false  "Cursor of the page, from the before field of the previous response"
+// @Param        limit   query     int     false  "Maximum number of messages to return (max 100)"
+// @Success      200     {object}  ThreadResponse
+// @Failure      400     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /conversations/{phone} [get]
+func (s *ConversationService) GetConversation(c *fiber.Ctx) error {
+	number, err := phoneParam(c)
+	if err != nil {
+		return badRequest(c, "invalid phone number")
+	}
+	limit := limitQuery(c)
+
+	var before *repository.ThreadPosition
+	if cursor := c.Query("before"); cursor != "" {
+		position, err := parseThreadCursor(cursor)
+		if err != nil {
+			return badRequest(c, err.Error())
+		}
+		before = &position
+	}
+
+	entries, err := s.repository.GetThread(number, before, limit)
+	if err != nil {
+		return internalError(c)
+	}
+
+	response := ThreadResponse{PhoneNumber: number, Limit: limit, Data: entries}
+	if len(entries) == limit {
+		response.Before = threadCursor(entries[0])
+	}
+	return c.JSON(response)
+}
+
+// ListConversations godoc
+// @Summary      List conversations
+// @Description  Lists the numbers that replied at least once with their last activity in either direction and the number of unread replies, most recent first
+// @Tags         Conversations
+// @Produce      json
+// @Param        before  query     string  false  "Cursor of the page, from the before field of the previous response"
+// @Param        limit   query     int     false  "Maximum number of conversations to return (max 100)"
+// @Success      200     {object}  ConversationListResponse
+// @Failure      400     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /conversations [get]
+func (s *ConversationService) ListConversations(c *fiber.Ctx) error {
+	limit := limitQuery(c)
+
+	var before *repository.ConversationPosition
+	if cursor := c.Query("before"); cursor != "" {
+		position, err := parseConversationCursor(cursor)
+		if err != nil {
+			return badRequest(c, err.Error())
+		}
+		before = &position
+	}
+
+	conversations, err := s.repository.ListConversations(before, limit)
+	if err != nil {
+		return internalError(c)
+	}
+
+	response := ConversationListResponse{Limit: limit, Data: conversations}
+	if len(conversations) == limit {
+		last := conversations[len(conversations)-1]
+		response.Before = fmt.Sprintf("%d.%s", last.LastActivityAt.UnixMicro(), last.PhoneNumber)
+	}
+	return c.JSON(response)
+}
+
+// MarkRead godoc
+// @Summary      Mark a conversation as read
+// @Description  Marks every unread reply from the number as read
+// @Tags         Conversations
+// @Produce      json
+// @Param        phone  path      string  true  "Phone number"
+// @Success      200    {object}  MarkReadResponse
+// @Failure      400    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /conversations/{phone}/read [post]
+func (s *ConversationService) MarkRead(c *fiber.Ctx) error {
+	number, err := phoneParam(c)
+	if err != nil {
+		return badRequest(c, "invalid phone number")
+	}
+
+	read, err := s.repository.MarkRead(number, time.Now())
+	if err != nil {
+		return internalError(c)
+	}
+	return c.JSON(MarkReadResponse{Read: read})
+}
+
+// phoneParam returns the phone path parameter in E.164, numbers that do not parse, e.g. short
+// codes, are used as they are like inbound messages store them
+func phoneParam(c *fiber.Ctx) (string, error) {
+	raw, err := url.PathUnescape(c.Params("phone"))
+	if err != nil || strings.TrimSpace(raw) == "" {
+		return "", fmt.Errorf("invalid phone number")
+	}
+	if number, err := phone.Normalize(raw); err == nil {
+		return number, nil
+	}
+	return strings.TrimSpace(raw), nil
+}
+
+func limitQuery(c *fiber.Ctx) int {
+	limit := c.QueryInt("limit", 0)
+	if limit <= 0 {
+		limit = 20
+	}
+	return min(limit, 100)
+}
+
+// threadCursor encodes the position of entry as <unix microseconds>.<direction>.<id>
+func threadCursor(entry db.ConversationEntry) string {
+	return fmt.Sprintf("%d.%s.%d", entry.At.UnixMicro(), entry.Direction, entry.ID)
+}
+
+func parseThreadCursor(cursor string) (repository.ThreadPosition, error) {
+	parts := strings.Split(cursor, ".")
+	if len(parts) != 3 {
+		return repository.ThreadPosition{}, fmt.Errorf("invalid cursor")
+	}
+	at, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return repository.ThreadPosition{}, fmt.Errorf("invalid cursor")
+	}
+	direction := db.Direction(parts[1])
+	if direction != db.DirectionInbound && direction != db.DirectionOutbound {
+		return repository.ThreadPosition{}, fmt.Errorf("invalid cursor")
+	}
+	id, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return repository.ThreadPosition{}, fmt.Errorf("invalid cursor")
+	}
+	return repository.ThreadPosition{At: time.UnixMicro(at).UTC(), Direction: direction, ID: uint(id)}, nil
+}
+
+// parseConversationCursor reads <unix microseconds>.<phone number>
+func parseConversationCursor(cursor string) (repository.ConversationPosition, error) {
+	at, number, found := strings.Cut(cursor, ".")
+	micros, err := strconv.ParseInt(at, 10, 64)
+	if !found || err != nil || number == "" {
+		return repository.ConversationPosition{}, fmt.Errorf("invalid cursor")
+	}
+	return repository.ConversationPosition{LastActivityAt: time.UnixMicro(micros).UTC(), PhoneNumber: number}, nil
+}
+
+func badRequest(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": message,
+	})
+}
+
+func internalError(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to access conversations",
+	})
+}
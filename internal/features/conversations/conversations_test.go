@@ -0,0 +1,117 @@
+package conversations
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConversationHandlers(t *testing.T) {
+	config.Cfg.Phone.DefaultRegion = "TR"
+	at := time.Date(2026, time.March, 10, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		setupMock      func(*mocks.MockConversationRepositoryInterface)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "Latest page of a thread",
+			method: fiber.MethodGet,
+			url:    "/conversations/0532%20123%2045%2067?limit=2",
+			setupMock: func(m *mocks.MockConversationRepositoryInterface) {
+				m.EXPECT().GetThread("+905321234567", nil, 2).Return([]db.ConversationEntry{
+					{Direction: db.DirectionOutbound, ID: 41, Body: "Your order has shipped", Status: db.StatusDone, At: at},
+					{Direction: db.DirectionInbound, ID: 5, Body: "Thanks", At: at.Add(time.Minute)},
+				}, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"before":"1773133200000000.outbound.41"`,
+		},
+		{
+			name:   "Older page",
+			method: fiber.MethodGet,
+			url:    "/conversations/%2B905321234567?before=1773133200000000.outbound.41",
+			setupMock: func(m *mocks.MockConversationRepositoryInterface) {
+				m.EXPECT().GetThread("+905321234567", &repository.ThreadPosition{At: at, Direction: db.DirectionOutbound, ID: 41}, 20).Return(nil, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"data":null`,
+		},
+		{
+			name:           "Invalid cursor",
+			method:         fiber.MethodGet,
+			url:            "/conversations/%2B905321234567?before=1773133200000000.sideways.41",
+			setupMock:      func(*mocks.MockConversationRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "invalid cursor",
+		},
+		{
+			name:   "List conversations",
+			method: fiber.MethodGet,
+			url:    "/conversations?limit=1",
+			setupMock: func(m *mocks.MockConversationRepositoryInterface) {
+				m.EXPECT().ListConversations(nil, 1).Return([]db.Conversation{{PhoneNumber: "+905321234567", LastActivityAt: at, UnreadCount: 2}}, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"before":"1773133200000000.+905321234567"`,
+		},
+		{
+			name:   "Next page of conversations",
+			method: fiber.MethodGet,
+			url:    "/conversations?before=1773133200000000.%2B905321234567",
+			setupMock: func(m *mocks.MockConversationRepositoryInterface) {
+				m.EXPECT().ListConversations(&repository.ConversationPosition{LastActivityAt: at, PhoneNumber: "+905321234567"}, 20).Return(nil, errors.New("db down"))
+			},
+			expectedStatus: fiber.StatusInternalServerError,
+		},
+		{
+			name:   "Mark read",
+			method: fiber.MethodPost,
+			url:    "/conversations/%2B905321234567/read",
+			setupMock: func(m *mocks.MockConversationRepositoryInterface) {
+				m.EXPECT().MarkRead("+905321234567", gomock.Any()).Return(int64(2), nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"read":2`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockConversationRepositoryInterface(ctrl)
+			tt.setupMock(mockRepo)
+			service := NewService(mockRepo)
+
+			app := fiber.New()
+			app.Get("/conversations", service.ListConversations)
+			app.Get("/conversations/:phone", service.GetConversation)
+			app.Post("/conversations/:phone/read", service.MarkRead)
+
+			resp, err := app.Test(httptest.NewRequest(tt.method, tt.url, nil))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			body, _ := io.ReadAll(resp.Body)
+			if tt.expectedBody != "" {
+				assert.Contains(t, string(body), tt.expectedBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package enqueue
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/features/templates"
+	"github.com/atakurt/messagingApp/internal/features/tenants"
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/phone"
+	"github.com/atakurt/messagingApp/internal/infrastructure/quiethours"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/atakurt/messagingApp/internal/infrastructure/sms"
+	"github.com/gofiber/fiber/v2"
+)
+
+type MessageRepositoryInterface interface {
+	CreateMessage(msg *db.Message) error
+}
+
+type TemplateRepositoryInterface interface {
+	GetTemplate(id uint) (db.Template, error)
+}
+
+// ErrCriticalNotAllowed is returned for critical messages of requests without admin rights, critical
+// messages skip delivery windows and the budget
+var ErrCriticalNotAllowed = errors.New("only the admin can send critical messages")
+
+// ValidationError is returned when a request cannot become a message
+type ValidationError struct {
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Reason
+}
+
+// Quota counts the messages of a tenant against its daily quota, messages that were reserved but
+// not stored are released
+type Quota interface {
+	Reserve(ctx context.Context, tenant db.Tenant, messages int) error
+	Release(ctx context.Context, tenant db.Tenant, messages int)
+}
+
+type EnqueueService struct {
+	messages  MessageRepositoryInterface
+	templates TemplateRepositoryInterface
+	quota     Quota
+}
+
+type Option func(*EnqueueService)
+
+// WithQuota rejects messages over the daily quota of their tenant
+func WithQuota(quota Quota) Option {
+	return func(s *EnqueueService) {
+		s.quota = quota
+	}
+}
+
+func NewService(messages MessageRepositoryInterface, templates TemplateRepositoryInterface, opts ...Option) *EnqueueService {
+	s := &EnqueueService{
+		messages:  messages,
+		templates: templates,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Request describes a message to send, either with its content or with a template and variables
+// @Description Message to enqueue, set content or template_id
+type Request struct {
+	PhoneNumber string            `json:"phone_number" example:"+905321234567"`
+	Content     string            `json:"content,omitempty" example:"Hey whats up ?"`
+	TemplateID  *uint             `json:"template_id,omitempty"`
+	Variables   map[string]string `json:"variables,omitempty"`
+	Locale      string            `json:"locale,omitempty" example:"tr-TR"`
+	Priority    db.Priority       `json:"priority" swaggertype:"string" enums:"critical,high,normal,bulk"`
+	ExpiresAt   *time.Time        `json:"expires_at,omitempty"`
+	Category    string            `json:"category,omitempty" example:"marketing"`
+	TimeZone    string            `json:"time_zone,omitempty" example:"Europe/Istanbul"`
+}
+
+// NewMessage validates req and renders its template, the returned message is ready to be stored
+func (s *EnqueueService) NewMessage(req Request) (db.Message, error) {
+	msg := db.Message{
+		Content:   req.Content,
+		Priority:  req.Priority,
+		ExpiresAt: req.ExpiresAt,
+		Category:  strings.ToLower(strings.TrimSpace(req.Category)),
+		TimeZone:  req.TimeZone,
+	}
+
+	number, err := phone.Parse(req.PhoneNumber, config.Cfg.Phone.DefaultRegion)
+	if err != nil {
+		return msg, &ValidationError{Reason: err.Error()}
+	}
+	msg.PhoneNumber = number.E164
+	msg.CountryCode = number.CountryCode
+	msg.NumberType = string(number.Type)
+
+	if len(msg.Category) > 50 {
+		return msg, &ValidationError{Reason: "category is longer than 50 characters"}
+	}
+	if _, err := quiethours.Location(msg.TimeZone, msg.CountryCode); err != nil {
+		return msg, &ValidationError{Reason: err.Error()}
+	}
+
+	switch {
+	case req.TemplateID != nil && req.Content != "":
+		return msg, &ValidationError{Reason: "set either content or template_id, not both"}
+	case req.TemplateID != nil:
+		template, err := s.templates.GetTemplate(*req.TemplateID)
+		if errors.Is(err, repository.ErrTemplateNotFound) {
+			return msg, &ValidationError{Reason: err.Error()}
+		}
+		if err != nil {
+			return msg, err
+		}
+
+		body, locale := templates.Resolve(template, req.Locale)
+		content, err := templates.Render(body, req.Variables)
+		if err != nil {
+			return msg, &ValidationError{Reason: err.Error()}
+		}
+		msg.Content = content
+		msg.TemplateID = &template.ID
+		msg.TemplateVersion = template.Version
+		msg.Locale = locale
+	case req.Content == "":
+		return msg, &ValidationError{Reason: "content or template_id is required"}
+	}
+
+	segmentation, err := sms.Check(msg.Content, config.Cfg.SMS.MaxSegments)
+	if err != nil {
+		return msg, &ValidationError{Reason: err.Error()}
+	}
+	msg.Encoding = string(segmentation.Encoding)
+	msg.Segments = segmentation.Segments
+	return msg, nil
+}
+
+// Submit validates req and stores it as a pending message of the default tenant
+func (s *EnqueueService) Submit(req Request) (db.Message, error) {
+	msg, err := s.NewMessage(req)
+	if err != nil {
+		return msg, err
+	}
+	err = s.messages.CreateMessage(&msg)
+	return msg, err
+}
+
+// Enqueue godoc
+// @Summary      Enqueue a message
+// @Description  Stores a pending message. The phone number is normalized to E.164, national numbers are read in phone.defaultRegion. The content may take up to sms.maxSegments GSM-7 or UCS-2 segments. With template_id the template is rendered now with the given variables in the first locale of the fallback chain it has, e.g. tr-TR, tr, en. The message keeps the content and the template version and locale it was rendered from. Messages of a category with a delivery window are only sent inside it in the recipient's time zone, time_zone or the zone of the country calling code, critical messages are sent at any time. Only admin keys send critical messages.
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param        message  body      Request  true  "Message"
+// @Success      201      {object}  db.Message
+// @Failure      400      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Router       /messages [post]
+func (s *EnqueueService) Enqueue(c *fiber.Ctx) error {
+	var req Request
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	if req.Priority == db.PriorityCritical && !tenants.IsAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": ErrCriticalNotAllowed.Error(),
+		})
+	}
+
+	msg, err := s.NewMessage(req)
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": validationErr.Reason,
+		})
+	}
+	if err == nil {
+		tenant := tenants.From(c)
+		msg.TenantID = tenant.ID
+		if s.quota != nil {
+			if err := s.quota.Reserve(c.UserContext(), tenant, 1); err != nil {
+				return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+		}
+		if err = s.messages.CreateMessage(&msg); err != nil && s.quota != nil {
+			s.quota.Release(c.UserContext(), tenant, 1)
+		}
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to enqueue message",
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(msg)
+}
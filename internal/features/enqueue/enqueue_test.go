@@ -0,0 +1,252 @@
+package enqueue
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/atakurt/messagingApp/internal/features/tenants"
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func setTestConfig() {
+	config.Cfg.Phone.DefaultRegion = "TR"
+	config.Cfg.SMS.MaxSegments = 3
+	config.Cfg.Templates.FallbackLocales = []string{"en"}
+}
+
+func TestEnqueue(t *testing.T) {
+	setTestConfig()
+	otp := db.Template{ID: 3, Name: "otp", Version: 2, Body: "Your code is {{code}}"}
+
+	tests := []struct {
+		name           string
+		body           string
+		admin          bool
+		setupMock      func(*mocks.MockMessageRepositoryInterface, *mocks.MockTemplateRepositoryInterface)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Message with content",
+			body: `{"phone_number":"0532 123 45 67","content":"hello","priority":"bulk","category":"Marketing"}`,
+			setupMock: func(messages *mocks.MockMessageRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {
+				messages.EXPECT().CreateMessage(gomock.Any()).DoAndReturn(func(msg *db.Message) error {
+					assert.Equal(t, "+905321234567", msg.PhoneNumber)
+					assert.Equal(t, db.DefaultTenantID, msg.TenantID)
+					assert.Equal(t, "90", msg.CountryCode)
+					assert.Equal(t, "mobile", msg.NumberType)
+					assert.Equal(t, "hello", msg.Content)
+					assert.Equal(t, db.PriorityBulk, msg.Priority)
+					assert.Equal(t, "marketing", msg.Category)
+					assert.Nil(t, msg.TemplateID)
+					assert.Equal(t, "GSM-7", msg.Encoding)
+					assert.Equal(t, 1, msg.Segments)
+					return nil
+				})
+			},
+			expectedStatus: fiber.StatusCreated,
+			expectedBody:   `"priority":"bulk"`,
+		},
+		{
+			name:  "Message rendered from a template",
+			body:  `{"phone_number":"+905321234567","template_id":3,"variables":{"code":"1234"},"priority":"critical"}`,
+			admin: true,
+			setupMock: func(messages *mocks.MockMessageRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {
+				templates.EXPECT().GetTemplate(uint(3)).Return(otp, nil)
+				messages.EXPECT().CreateMessage(gomock.Any()).DoAndReturn(func(msg *db.Message) error {
+					assert.Equal(t, "Your code is 1234", msg.Content)
+					assert.Equal(t, 1, msg.Segments)
+					assert.Equal(t, uint(3), *msg.TemplateID)
+					assert.Equal(t, 2, msg.TemplateVersion)
+					return nil
+				})
+			},
+			expectedStatus: fiber.StatusCreated,
+			expectedBody:   `"template_version":2`,
+		},
+		{
+			name:           "Critical message without admin rights",
+			body:           `{"phone_number":"+905321234567","content":"hello","priority":"critical"}`,
+			setupMock:      func(*mocks.MockMessageRepositoryInterface, *mocks.MockTemplateRepositoryInterface) {},
+			expectedStatus: fiber.StatusForbidden,
+			expectedBody:   ErrCriticalNotAllowed.Error(),
+		},
+		{
+			name: "Missing template variable",
+			body: `{"phone_number":"+905321234567","template_id":3}`,
+			setupMock: func(messages *mocks.MockMessageRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {
+				templates.EXPECT().GetTemplate(uint(3)).Return(otp, nil)
+			},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "missing template variables: code",
+		},
+		{
+			name: "Unknown template",
+			body: `{"phone_number":"+905321234567","template_id":9}`,
+			setupMock: func(messages *mocks.MockMessageRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {
+				templates.EXPECT().GetTemplate(uint(9)).Return(db.Template{}, repository.ErrTemplateNotFound)
+			},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   repository.ErrTemplateNotFound.Error(),
+		},
+		{
+			name:           "Invalid phone number",
+			body:           `{"phone_number":"0532 123","content":"hello"}`,
+			setupMock:      func(*mocks.MockMessageRepositoryInterface, *mocks.MockTemplateRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   `not a valid TR number`,
+		},
+		{
+			name:           "Unknown time zone",
+			body:           `{"phone_number":"+905321234567","content":"hello","time_zone":"Mars/Olympus"}`,
+			setupMock:      func(*mocks.MockMessageRepositoryInterface, *mocks.MockTemplateRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   `unknown time zone`,
+		},
+		{
+			name:           "Content and template together",
+			body:           `{"phone_number":"+905321234567","content":"hello","template_id":3}`,
+			setupMock:      func(*mocks.MockMessageRepositoryInterface, *mocks.MockTemplateRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "not both",
+		},
+		{
+			name:           "Content too long",
+			body:           `{"phone_number":"+905321234567","content":"` + strings.Repeat("ş", 202) + `"}`,
+			setupMock:      func(*mocks.MockMessageRepositoryInterface, *mocks.MockTemplateRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "content needs 4 UCS-2 segments, at most 3 are allowed",
+		},
+		{
+			name:           "Unknown priority",
+			body:           `{"phone_number":"+905321234567","content":"hello","priority":"urgent"}`,
+			setupMock:      func(*mocks.MockMessageRepositoryInterface, *mocks.MockTemplateRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+		},
+		{
+			name: "Database error",
+			body: `{"phone_number":"+905321234567","content":"hello"}`,
+			setupMock: func(messages *mocks.MockMessageRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {
+				messages.EXPECT().CreateMessage(gomock.Any()).Return(errors.New("connection refused"))
+			},
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody:   "Failed to enqueue message",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockMessages := mocks.NewMockMessageRepositoryInterface(ctrl)
+			mockTemplates := mocks.NewMockTemplateRepositoryInterface(ctrl)
+			tt.setupMock(mockMessages, mockTemplates)
+
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				tenants.SetAdmin(c, tt.admin)
+				return c.Next()
+			})
+			app.Post("/messages", NewService(mockMessages, mockTemplates).Enqueue)
+
+			req := httptest.NewRequest(fiber.MethodPost, "/messages", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			body, _ := io.ReadAll(resp.Body)
+			assert.Contains(t, string(body), tt.expectedBody)
+		})
+	}
+}
+
+func TestNewMessage_RendersLocale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	setTestConfig()
+
+	mockTemplates := mocks.NewMockTemplateRepositoryInterface(ctrl)
+	mockTemplates.EXPECT().GetTemplate(uint(3)).Return(db.Template{
+		ID:      3,
+		Version: 1,
+		Body:    "Code {{code}}",
+		Locales: map[string]string{"tr": "Kodunuz {{code}}"},
+	}, nil)
+
+	templateID := uint(3)
+	msg, err := NewService(nil, mockTemplates).NewMessage(Request{
+		PhoneNumber: "+905321234567",
+		TemplateID:  &templateID,
+		Variables:   map[string]string{"code": "1234"},
+		Locale:      "tr-TR",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Kodunuz 1234", msg.Content)
+	assert.Equal(t, "tr", msg.Locale)
+}
+
+type fakeQuota struct {
+	left     int
+	reserved map[uint]int
+}
+
+func (q *fakeQuota) Reserve(_ context.Context, tenant db.Tenant, messages int) error {
+	if messages > q.left {
+		return tenants.ErrQuotaExceeded
+	}
+	q.left -= messages
+	q.reserved[tenant.ID] += messages
+	return nil
+}
+
+func (q *fakeQuota) Release(_ context.Context, tenant db.Tenant, messages int) {
+	q.left += messages
+	q.reserved[tenant.ID] -= messages
+}
+
+func TestEnqueue_TenantQuota(t *testing.T) {
+	setTestConfig()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMessages := mocks.NewMockMessageRepositoryInterface(ctrl)
+	gomock.InOrder(
+		// a message that is not stored gives its reservation back
+		mockMessages.EXPECT().CreateMessage(gomock.Any()).Return(errors.New("db down")),
+		mockMessages.EXPECT().CreateMessage(gomock.Any()).DoAndReturn(func(msg *db.Message) error {
+			assert.Equal(t, uint(7), msg.TenantID)
+			return nil
+		}),
+	)
+	quota := &fakeQuota{left: 1, reserved: map[uint]int{}}
+	service := NewService(mockMessages, mocks.NewMockTemplateRepositoryInterface(ctrl), WithQuota(quota))
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		tenants.Set(c, db.Tenant{ID: 7, DailyQuota: 1})
+		return c.Next()
+	})
+	app.Post("/messages", service.Enqueue)
+
+	for _, expected := range []int{fiber.StatusInternalServerError, fiber.StatusCreated, fiber.StatusTooManyRequests} {
+		req := httptest.NewRequest(fiber.MethodPost, "/messages", strings.NewReader(`{"phone_number":"+905321234567","content":"hello"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, resp.StatusCode)
+	}
+	assert.Equal(t, map[uint]int{7: 1}, quota.reserved)
+}
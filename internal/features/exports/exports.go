@@ -0,0 +1,401 @@
+package exports
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/features/tenants"
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/infrastructure/metrics"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type ExportRepositoryInterface interface {
+	CreateExport(export *db.Export) error
+	GetExport(id uint) (db.Export, error)
+	ListExports(tenantID uint, lastID, limit int) ([]db.Export, error)
+	CompleteExport(export db.Export) error
+	ExportMessages(filter db.MessageFilter, lastID uint, limit int) ([]db.Message, error)
+}
+
+var messageStatuses = map[db.MessageStatus]bool{
+	db.StatusPending:    true,
+	db.StatusProcessing: true,
+	db.StatusDone:       true,
+	db.StatusError:      true,
+	db.StatusReview:     true,
+	db.StatusExpired:    true,
+	db.StatusSuppressed: true,
+	db.StatusDuplicate:  true,
+	db.StatusPaused:     true,
+	db.StatusCancelled:  true,
+}
+
+// ExportService writes reports of messages to files in the background of the instance that received the request
+type ExportService struct {
+	repository ExportRepositoryInterface
+	signer     *Signer
+	dir        string
+	pageSize   int
+	retention  time.Duration
+	interval   time.Duration
+	running    sync.WaitGroup
+}
+
+func NewService(repository ExportRepositoryInterface, signer *Signer) *ExportService {
+	return &ExportService{
+		repository: repository,
+		signer:     signer,
+		dir:        config.Cfg.Exports.Dir,
+		pageSize:   config.Cfg.Exports.PageSize,
+		retention:  config.Cfg.Exports.Retention,
+		interval:   config.Cfg.Exports.SweepInterval,
+	}
+}
+
+// ExportRequest selects the messages of an export
+// @Description Export format and filter, every filter is optional
+type ExportRequest struct {
+	Format     string           `json:"format" enums:"csv,jsonl" example:"csv"`
+	Status     db.MessageStatus `json:"status,omitempty" example:"done"`
+	From       *time.Time       `json:"from,omitempty" example:"2026-09-01T00:00:00Z"`
+	To         *time.Time       `json:"to,omitempty" example:"2026-10-01T00:00:00Z"`
+	CampaignID *uint            `json:"campaign_id,omitempty"`
+	Provider   string           `json:"provider,omitempty" example:"webhook"`
+}
+
+// ExportResponse is an export with a download link once its file is written
+type ExportResponse struct {
+	db.Export
+	// DownloadURL is a link to the file valid for exports.linkTTL
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+// ExportListResponse is a page of exports
+// @Description Paginated list of exports
+type ExportListResponse struct {
+	LastID int              `json:"last_id"`
+	Limit  int              `json:"limit"`
+	Data   []ExportResponse `json:"data"`
+}
+
+func (r ExportRequest) validate() error {
+	switch {
+	case r.Format != FormatCSV && r.Format != FormatJSONL:
+		return errors.New("format must be csv or jsonl")
+	case r.Status != "" && !messageStatuses[r.Status]:
+		return fmt.Errorf("unknown status %q", r.Status)
+	case r.From != nil && r.To != nil && !r.From.Before(*r.To):
+		return errors.New("from must be before to")
+	}
+	return nil
+}
+
+// CreateExport godoc
+// @Summary      Export messages
+// @Description  Writes the messages matching the filter to a CSV or JSONL file in the background, poll the returned export for its download link. from and to bound the creation time, to is exclusive, provider selects the messages sent through one provider. The file is kept on the disk of the instance that wrote it.
+// @Tags         Exports
+// @Accept       json
+// @Produce      json
+// @Param        export  body      ExportRequest  true  "Export"
+// @Success      202     {object}  ExportResponse
+// @Failure      400     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /exports [post]
+func (s *ExportService) CreateExport(c *fiber.Ctx) error {
+	var req ExportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+	req.Format = strings.ToLower(req.Format)
+	if req.Format == "" {
+		req.Format = FormatCSV
+	}
+	if err := req.validate(); err != nil {
+		return badRequest(c, err.Error())
+	}
+
+	export := db.Export{
+		TenantID: tenants.From(c).ID,
+		Format:   req.Format,
+		Filter: db.MessageFilter{
+			Status:     req.Status,
+			From:       req.From,
+			To:         req.To,
+			CampaignID: req.CampaignID,
+			Provider:   strings.TrimSpace(req.Provider),
+		},
+	}
+	if err := s.repository.CreateExport(&export); err != nil {
+		return exportError(c, err)
+	}
+
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		s.run(export)
+	}()
+	return c.Status(fiber.StatusAccepted).JSON(ExportResponse{Export: export})
+}
+
+// ListExports godoc
+// @Summary      List exports
+// @Description  Lists exports using keyset pagination, finished exports carry a fresh download link
+// @Tags         Exports
+// @Produce      json
+// @Param        last_id  query     int  false  "Only return exports with ID > last_id"
+// @Param        limit    query     int  false  "Maximum number of exports to return (max 100)"
+// @Success      200      {object}  ExportListResponse
+// @Failure      500      {object}  map[string]string
+// @Router       /exports [get]
+func (s *ExportService) ListExports(c *fiber.Ctx) error {
+	lastID := c.QueryInt("last_id", 0)
+	limit := c.QueryInt("limit", 0)
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	exports, err := s.repository.ListExports(tenants.From(c).ID, lastID, limit)
+	if err != nil {
+		return exportError(c, err)
+	}
+
+	now := time.Now()
+	data := make([]ExportResponse, len(exports))
+	for i, export := range exports {
+		data[i] = s.response(export, now)
+	}
+	return c.JSON(ExportListResponse{LastID: lastID, Limit: limit, Data: data})
+}
+
+// GetExport godoc
+// @Summary      Get an export
+// @Description  Returns the status of an export, once it is done with a download link valid for exports.linkTTL
+// @Tags         Exports
+// @Produce      json
+// @Param        id   path      int  true  "Export ID"
+// @Success      200  {object}  ExportResponse
+// @Failure      404  {object}  map[string]string
+// @Router       /exports/{id} [get]
+func (s *ExportService) GetExport(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return badRequest(c, "invalid export id")
+	}
+
+	export, err := s.repository.GetExport(uint(id))
+	if err == nil && export.TenantID != tenants.From(c).ID {
+		err = repository.ErrExportNotFound
+	}
+	if err != nil {
+		return exportError(c, err)
+	}
+	return c.JSON(s.response(export, time.Now()))
+}
+
+// DownloadExport godoc
+// @Summary      Download an export
+// @Description  Sends the file of an export, the link is taken from the download_url of the export and expires
+// @Tags         Exports
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Param        id         path   int     true  "Export ID"
+// @Param        expires    query  int     true  "Expiry of the link as a Unix time"
+// @Param        signature  query  string  true  "Signature of the link"
+// @Success      200
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /exports/{id}/download [get]
+func (s *ExportService) DownloadExport(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return badRequest(c, "invalid export id")
+	}
+	if !s.signer.Verify(uint(id), c.Query("expires"), c.Query("signature"), time.Now()) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "download link is invalid or expired",
+		})
+	}
+
+	export, err := s.repository.GetExport(uint(id))
+	if err != nil {
+		return exportError(c, err)
+	}
+	if export.Status != db.ExportDone {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "export is " + string(export.Status),
+		})
+	}
+	if _, err := os.Stat(export.Path); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "export file is not on this instance or was removed after exports.retention",
+		})
+	}
+	return c.Download(export.Path, filepath.Base(export.Path))
+}
+
+func (s *ExportService) response(export db.Export, now time.Time) ExportResponse {
+	response := ExportResponse{Export: export}
+	if export.Status == db.ExportDone {
+		response.DownloadURL = s.signer.Link(export.ID, now)
+	}
+	return response
+}
+
+// run writes the messages of export page by page to a temporary file, which is renamed once complete
+func (s *ExportService) run(export db.Export) {
+	path := filepath.Join(s.dir, fmt.Sprintf("export-%d.%s", export.ID, export.Format))
+	rows, size, err := s.write(export, path)
+	if err != nil {
+		logger.Log.Error("Export failed", zap.Uint("export_id", export.ID), zap.Error(err))
+		os.Remove(path + ".tmp")
+		export.Status, export.LastError = db.ExportFailed, err.Error()
+	} else {
+		export.Status, export.Path = db.ExportDone, path
+	}
+	export.Rows, export.Size = rows, size
+	metrics.ExportRows.Add(float64(rows))
+
+	if err := s.repository.CompleteExport(export); err != nil {
+		logger.Log.Error("Failed to complete export", zap.Uint("export_id", export.ID), zap.Error(err))
+	}
+}
+
+func (s *ExportService) write(export db.Export, path string) (rows int, size int64, err error) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return 0, 0, err
+	}
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	buffered := bufio.NewWriter(file)
+	writer := newMessageWriter(export.Format, buffered)
+	filter := export.Filter
+	filter.TenantID = export.TenantID
+	var lastID uint
+	for {
+		page, err := s.repository.ExportMessages(filter, lastID, s.pageSize)
+		if err != nil {
+			return rows, 0, err
+		}
+		for _, msg := range page {
+			if err := writer.write(msg); err != nil {
+				return rows, 0, err
+			}
+			rows++
+		}
+		if len(page) < s.pageSize {
+			break
+		}
+		lastID = page[len(page)-1].ID
+	}
+
+	if err := writer.flush(); err != nil {
+		return rows, 0, err
+	}
+	if err := buffered.Flush(); err != nil {
+		return rows, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return rows, 0, err
+	}
+	if err := file.Close(); err != nil {
+		return rows, 0, err
+	}
+	return rows, info.Size(), os.Rename(path+".tmp", path)
+}
+
+// Wait blocks until the exports started by this service finish
+func (s *ExportService) Wait() {
+	s.running.Wait()
+}
+
+// RunRetention removes the export files of this instance older than exports.retention every
+// exports.sweepInterval, it blocks until ctx is cancelled
+func (s *ExportService) RunRetention(ctx context.Context) {
+	if s.retention <= 0 {
+		logger.Log.Info("Export retention is disabled by config")
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if removed := s.Sweep(time.Now()); removed > 0 {
+				logger.Log.Info("Removed expired export files", zap.Int("count", removed))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Sweep removes the export files last written before now minus exports.retention, including
+// temporary files left by an instance that stopped mid export, and returns how many it removed
+func (s *ExportService) Sweep(now time.Time) int {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logger.Log.Error("Failed to read the export directory", zap.String("dir", s.dir), zap.Error(err))
+		}
+		return 0
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), "export-") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(now.Add(-s.retention)) {
+			continue
+		}
+		// instances sharing dir sweep the same files, a file already removed is not an error
+		switch err := os.Remove(filepath.Join(s.dir, entry.Name())); {
+		case err == nil:
+			removed++
+		case !errors.Is(err, os.ErrNotExist):
+			logger.Log.Error("Failed to remove export file", zap.String("file", entry.Name()), zap.Error(err))
+		}
+	}
+	return removed
+}
+
+func badRequest(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": message,
+	})
+}
+
+func exportError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, repository.ErrExportNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to access exports",
+	})
+}
@@ -0,0 +1,291 @@
+package exports
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setTestConfig(t *testing.T) {
+	logger.Log = zap.NewNop()
+	config.Cfg.Exports.Dir = t.TempDir()
+	config.Cfg.Exports.PageSize = 2
+}
+
+func TestCreateExport(t *testing.T) {
+	setTestConfig(t)
+	campaignID := uint(9)
+	sentAt := time.Date(2026, 9, 3, 10, 0, 0, 0, time.UTC)
+	cost := 0.02
+	page := []db.Message{
+		{ID: 1, PhoneNumber: "+905321234567", Content: "Hi, Ali", Status: db.StatusDone, Priority: db.PriorityBulk, CampaignID: &campaignID, Segments: 1, Provider: "webhook", Cost: &cost, MessageID: "hook-1", CreatedAt: sentAt.Add(-time.Minute), SentAt: sentAt},
+		{ID: 2, PhoneNumber: "+905321234568", Content: "Hi", Status: db.StatusError, LastError: "timeout", Segments: 1, CreatedAt: sentAt},
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func(*mocks.MockExportRepositoryInterface)
+		expectedStatus int
+		expectedBody   string
+		expectedFile   string
+	}{
+		{
+			name: "CSV export pages through the messages",
+			body: `{"format":"csv","campaign_id":9,"provider":"webhook","from":"2026-09-01T00:00:00Z","to":"2026-10-01T00:00:00Z"}`,
+			setupMock: func(m *mocks.MockExportRepositoryInterface) {
+				m.EXPECT().CreateExport(gomock.Any()).DoAndReturn(func(export *db.Export) error {
+					assert.Equal(t, uint(9), *export.Filter.CampaignID)
+					assert.Equal(t, "webhook", export.Filter.Provider)
+					assert.Equal(t, time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), *export.Filter.From)
+					export.ID, export.Status = 4, db.ExportProcessing
+					return nil
+				})
+				gomock.InOrder(
+					m.EXPECT().ExportMessages(gomock.Any(), uint(0), 2).Return(page, nil),
+					m.EXPECT().ExportMessages(gomock.Any(), uint(2), 2).Return(nil, nil),
+					m.EXPECT().CompleteExport(gomock.Any()).DoAndReturn(func(export db.Export) error {
+						assert.Equal(t, db.ExportDone, export.Status)
+						assert.Equal(t, 2, export.Rows)
+						assert.Equal(t, filepath.Join(config.Cfg.Exports.Dir, "export-4.csv"), export.Path)
+						info, err := os.Stat(export.Path)
+						require.NoError(t, err)
+						assert.Equal(t, info.Size(), export.Size)
+						return nil
+					}),
+				)
+			},
+			expectedStatus: fiber.StatusAccepted,
+			expectedBody:   `"status":"processing"`,
+			expectedFile: "id,phone_number,status,priority,category,campaign_id,template_id,template_version,content,encoding,segments,provider,cost,message_id,last_error,created_at,sent_at\n" +
+				"1,+905321234567,done,bulk,,9,,,\"Hi, Ali\",,1,webhook,0.02,hook-1,,2026-09-03T09:59:00Z,2026-09-03T10:00:00Z\n" +
+				"2,+905321234568,error,normal,,,,,Hi,,1,,,,timeout,2026-09-03T10:00:00Z,\n",
+		},
+		{
+			name: "Storage errors fail the export",
+			body: `{"format":"jsonl","status":"done"}`,
+			setupMock: func(m *mocks.MockExportRepositoryInterface) {
+				m.EXPECT().CreateExport(gomock.Any()).DoAndReturn(func(export *db.Export) error {
+					export.ID = 5
+					return nil
+				})
+				m.EXPECT().ExportMessages(db.MessageFilter{Status: db.StatusDone, TenantID: db.DefaultTenantID}, uint(0), 2).Return(nil, errors.New("db down"))
+				m.EXPECT().CompleteExport(gomock.Any()).DoAndReturn(func(export db.Export) error {
+					assert.Equal(t, db.ExportFailed, export.Status)
+					assert.Equal(t, "db down", export.LastError)
+					assert.NoFileExists(t, filepath.Join(config.Cfg.Exports.Dir, "export-5.jsonl.tmp"))
+					return nil
+				})
+			},
+			expectedStatus: fiber.StatusAccepted,
+		},
+		{
+			name:           "Unknown format",
+			body:           `{"format":"xlsx"}`,
+			setupMock:      func(m *mocks.MockExportRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "format must be csv or jsonl",
+		},
+		{
+			name:           "Unknown status",
+			body:           `{"status":"sent"}`,
+			setupMock:      func(m *mocks.MockExportRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   `unknown status \"sent\"`,
+		},
+		{
+			name:           "Empty date range",
+			body:           `{"from":"2026-10-01T00:00:00Z","to":"2026-09-01T00:00:00Z"}`,
+			setupMock:      func(m *mocks.MockExportRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "from must be before to",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockExportRepositoryInterface(ctrl)
+			tt.setupMock(mockRepo)
+			signer, _ := NewSigner("secret", time.Hour)
+			service := NewService(mockRepo, signer)
+
+			app := fiber.New()
+			app.Post("/exports", service.CreateExport)
+
+			req := httptest.NewRequest(fiber.MethodPost, "/exports", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			body, _ := io.ReadAll(resp.Body)
+			if tt.expectedBody != "" {
+				assert.Contains(t, string(body), tt.expectedBody)
+			}
+			service.Wait()
+
+			if tt.expectedFile != "" {
+				file, err := os.ReadFile(filepath.Join(config.Cfg.Exports.Dir, "export-4.csv"))
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedFile, string(file))
+			}
+		})
+	}
+}
+
+func TestExportDownloads(t *testing.T) {
+	setTestConfig(t)
+	path := filepath.Join(config.Cfg.Exports.Dir, "export-4.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"id":1}`+"\n"), 0o644))
+	done := db.Export{ID: 4, TenantID: db.DefaultTenantID, Format: FormatJSONL, Status: db.ExportDone, Rows: 1, Path: path}
+	signer, _ := NewSigner("secret", time.Hour)
+	link := signer.Link(4, time.Now())
+
+	tests := []struct {
+		name           string
+		url            string
+		setupMock      func(*mocks.MockExportRepositoryInterface)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Get export with a download link",
+			url:  "/exports/4",
+			setupMock: func(m *mocks.MockExportRepositoryInterface) {
+				m.EXPECT().GetExport(uint(4)).Return(done, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"download_url":"/exports/4/download?expires=`,
+		},
+		{
+			name: "Get unknown export",
+			url:  "/exports/5",
+			setupMock: func(m *mocks.MockExportRepositoryInterface) {
+				m.EXPECT().GetExport(uint(5)).Return(db.Export{}, repository.ErrExportNotFound)
+			},
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody:   repository.ErrExportNotFound.Error(),
+		},
+		{
+			name: "Export of another tenant is not found",
+			url:  "/exports/4",
+			setupMock: func(m *mocks.MockExportRepositoryInterface) {
+				other := done
+				other.TenantID = 2
+				m.EXPECT().GetExport(uint(4)).Return(other, nil)
+			},
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody:   repository.ErrExportNotFound.Error(),
+		},
+		{
+			name: "List exports",
+			url:  "/exports?last_id=2&limit=500",
+			setupMock: func(m *mocks.MockExportRepositoryInterface) {
+				m.EXPECT().ListExports(db.DefaultTenantID, 2, 100).Return([]db.Export{done, {ID: 5, Status: db.ExportProcessing}}, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"limit":100`,
+		},
+		{
+			name: "Download",
+			url:  link,
+			setupMock: func(m *mocks.MockExportRepositoryInterface) {
+				m.EXPECT().GetExport(uint(4)).Return(done, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `{"id":1}`,
+		},
+		{
+			name: "Download an unfinished export",
+			url:  link,
+			setupMock: func(m *mocks.MockExportRepositoryInterface) {
+				m.EXPECT().GetExport(uint(4)).Return(db.Export{ID: 4, Status: db.ExportProcessing}, nil)
+			},
+			expectedStatus: fiber.StatusConflict,
+			expectedBody:   "export is processing",
+		},
+		{
+			name:           "Download with an expired link",
+			url:            signer.Link(4, time.Now().Add(-2*time.Hour)),
+			setupMock:      func(m *mocks.MockExportRepositoryInterface) {},
+			expectedStatus: fiber.StatusForbidden,
+			expectedBody:   "download link is invalid or expired",
+		},
+		{
+			name:           "Download without a signature",
+			url:            "/exports/4/download",
+			setupMock:      func(m *mocks.MockExportRepositoryInterface) {},
+			expectedStatus: fiber.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockExportRepositoryInterface(ctrl)
+			tt.setupMock(mockRepo)
+			service := NewService(mockRepo, signer)
+
+			app := fiber.New()
+			app.Get("/exports", service.ListExports)
+			app.Get("/exports/:id", service.GetExport)
+			app.Get("/exports/:id/download", service.DownloadExport)
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, tt.url, nil))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			body, _ := io.ReadAll(resp.Body)
+			assert.Contains(t, string(body), tt.expectedBody)
+			if strings.HasPrefix(resp.Header.Get("Content-Type"), fiber.MIMEApplicationJSON) && resp.StatusCode == fiber.StatusOK {
+				assert.True(t, json.Valid(body))
+			}
+		})
+	}
+}
+
+func TestSweep(t *testing.T) {
+	setTestConfig(t)
+	config.Cfg.Exports.Retention = 24 * time.Hour
+	now := time.Now()
+	files := map[string]time.Time{
+		"export-1.csv":       now.Add(-48 * time.Hour),
+		"export-2.jsonl.tmp": now.Add(-48 * time.Hour),
+		"export-3.csv":       now.Add(-time.Hour),
+		"notes.txt":          now.Add(-48 * time.Hour),
+	}
+	for name, modTime := range files {
+		path := filepath.Join(config.Cfg.Exports.Dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("id\n"), 0o644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	service := NewService(nil, nil)
+	assert.Equal(t, 2, service.Sweep(now))
+	assert.NoFileExists(t, filepath.Join(config.Cfg.Exports.Dir, "export-1.csv"))
+	assert.NoFileExists(t, filepath.Join(config.Cfg.Exports.Dir, "export-2.jsonl.tmp"))
+	assert.FileExists(t, filepath.Join(config.Cfg.Exports.Dir, "export-3.csv"))
+	assert.FileExists(t, filepath.Join(config.Cfg.Exports.Dir, "notes.txt"))
+}
@@ -0,0 +1,56 @@
+package exports
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Signer makes download links that expire, a link is valid on every instance sharing the key
+type Signer struct {
+	key []byte
+	ttl time.Duration
+}
+
+// NewSigner signs with key, an empty key is replaced by a random one so links only work until a restart
+func NewSigner(key string, ttl time.Duration) (*Signer, error) {
+	if key != "" {
+		return &Signer{key: []byte(key), ttl: ttl}, nil
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	return &Signer{key: random, ttl: ttl}, nil
+}
+
+// Link returns the download path of an export valid until now plus the link TTL
+func (s *Signer) Link(id uint, now time.Time) string {
+	expires := now.Add(s.ttl).Unix()
+	return fmt.Sprintf("/exports/%d/download?expires=%d&signature=%s", id, expires, s.signature(id, expires))
+}
+
+// Verify reports whether signature was made for the export and expiry and the expiry is after now
+func (s *Signer) Verify(id uint, expires, signature string, now time.Time) bool {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !now.Before(time.Unix(expiresAt, 0)) {
+		return false
+	}
+	given, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	expected, _ := hex.DecodeString(s.signature(id, expiresAt))
+	return hmac.Equal(given, expected)
+}
+
+func (s *Signer) signature(id uint, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%d.%d", id, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
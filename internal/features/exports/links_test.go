@@ -0,0 +1,34 @@
+package exports
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner(t *testing.T) {
+	now := time.Unix(1_790_000_000, 0)
+	signer, err := NewSigner("secret", time.Hour)
+	require.NoError(t, err)
+
+	link, err := url.Parse(signer.Link(4, now))
+	require.NoError(t, err)
+	assert.Equal(t, "/exports/4/download", link.Path)
+	expires, signature := link.Query().Get("expires"), link.Query().Get("signature")
+	assert.Equal(t, "1790003600", expires)
+
+	assert.True(t, signer.Verify(4, expires, signature, now.Add(59*time.Minute)))
+	assert.False(t, signer.Verify(4, expires, signature, now.Add(time.Hour)), "expired")
+	assert.False(t, signer.Verify(5, expires, signature, now), "another export")
+	assert.False(t, signer.Verify(4, "1790007200", signature, now), "extended expiry")
+	assert.False(t, signer.Verify(4, expires, strings.Repeat("0", len(signature)), now))
+	assert.False(t, signer.Verify(4, "soon", signature, now))
+
+	other, err := NewSigner("", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, other.Verify(4, expires, signature, now), "links are bound to the key")
+}
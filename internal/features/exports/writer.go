@@ -0,0 +1,111 @@
+package exports
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// messageWriter writes exported messages in a file format
+type messageWriter interface {
+	write(msg db.Message) error
+	flush() error
+}
+
+func newMessageWriter(format string, w io.Writer) messageWriter {
+	if format == FormatJSONL {
+		return &jsonlWriter{encoder: json.NewEncoder(w)}
+	}
+	return &csvWriter{writer: csv.NewWriter(w)}
+}
+
+var csvHeader = []string{
+	"id", "phone_number", "status", "priority", "category", "campaign_id", "template_id", "template_version",
+	"content", "encoding", "segments", "provider", "cost", "message_id", "last_error", "created_at", "sent_at",
+}
+
+type csvWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func (w *csvWriter) write(msg db.Message) error {
+	if !w.headerWritten {
+		if err := w.writer.Write(csvHeader); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+
+	var sentAt string
+	if msg.Status == db.StatusDone && !msg.SentAt.IsZero() {
+		sentAt = msg.SentAt.UTC().Format(time.RFC3339)
+	}
+	var cost string
+	if msg.Cost != nil {
+		cost = strconv.FormatFloat(*msg.Cost, 'f', -1, 64)
+	}
+	var templateVersion string
+	if msg.TemplateID != nil {
+		templateVersion = strconv.Itoa(msg.TemplateVersion)
+	}
+	return w.writer.Write([]string{
+		strconv.FormatUint(uint64(msg.ID), 10),
+		msg.PhoneNumber,
+		string(msg.Status),
+		msg.Priority.String(),
+		msg.Category,
+		optionalID(msg.CampaignID),
+		optionalID(msg.TemplateID),
+		templateVersion,
+		msg.Content,
+		msg.Encoding,
+		strconv.Itoa(msg.Segments),
+		msg.Provider,
+		cost,
+		msg.MessageID,
+		msg.LastError,
+		msg.CreatedAt.UTC().Format(time.RFC3339),
+		sentAt,
+	})
+}
+
+func (w *csvWriter) flush() error {
+	// an export without messages still has a header
+	if !w.headerWritten {
+		if err := w.writer.Write(csvHeader); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *jsonlWriter) write(msg db.Message) error {
+	return w.encoder.Encode(msg)
+}
+
+func (w *jsonlWriter) flush() error {
+	return nil
+}
+
+func optionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
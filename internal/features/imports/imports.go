@@ -0,0 +1,490 @@
+package imports
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/features/enqueue"
+	"github.com/atakurt/messagingApp/internal/features/tenants"
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/infrastructure/metrics"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type ImportRepositoryInterface interface {
+	CreateImport(job *db.Import) error
+	GetImport(id uint) (db.Import, error)
+	RecordImportBatch(id uint, messages []db.Message, rowErrors []db.ImportError) error
+	CompleteImport(id uint, status db.ImportStatus, lastError string) error
+	FailStaleImports(before time.Time, lastError string) (int64, error)
+	ListImportErrors(id uint, lastLine, limit int) ([]db.ImportError, error)
+}
+
+// errInterrupted fails the imports running when their instance shuts down
+var errInterrupted = errors.New("import interrupted by shutdown, rows stored before stay enqueued")
+
+// errStale fails the imports of instances that stopped without failing them
+var errStale = errors.New("import stopped making progress, rows stored before stay enqueued")
+
+// ImportService loads uploaded files into messages in the background of the instance that received them
+type ImportService struct {
+	repository ImportRepositoryInterface
+	templates  enqueue.TemplateRepositoryInterface
+	quota      enqueue.Quota
+	batchSize  int
+	staleAfter time.Duration
+	running    sync.WaitGroup
+	// ctx is cancelled by Stop to interrupt the running imports
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+type Option func(*ImportService)
+
+// WithQuota counts every batch against the daily quota of the tenant, the import fails once it is used up
+func WithQuota(quota enqueue.Quota) Option {
+	return func(s *ImportService) {
+		s.quota = quota
+	}
+}
+
+func NewService(repository ImportRepositoryInterface, templates enqueue.TemplateRepositoryInterface, opts ...Option) *ImportService {
+	s := &ImportService{
+		repository: repository,
+		templates:  templates,
+		batchSize:  config.Cfg.Imports.BatchSize,
+		staleAfter: config.Cfg.Imports.StaleAfter,
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ImportErrorListResponse is a page of the error report of an import
+// @Description Rows that were not enqueued in file order, pass the last line to get the next page
+type ImportErrorListResponse struct {
+	LastLine int              `json:"last_line"`
+	Limit    int              `json:"limit"`
+	Data     []db.ImportError `json:"data"`
+}
+
+// CreateImport godoc
+// @Summary      Import messages from a file
+// @Description  Uploads a CSV or JSONL file and enqueues a message per row in the background, poll the returned import for progress. A CSV file starts with a header: phone_number, content, template_id, locale, priority, category, time_zone and expires_at fill the message, every other column is a template variable. A JSONL file has a message object per line, the same body as POST /messages. Rows are validated and normalized like POST /messages, rejected rows are listed in the error report. priority and category apply to rows that do not set them, priority defaults to bulk. Only admin keys import critical messages, critical rows of other keys are rejected. The messages count against the daily quota of the tenant, the import fails at the first batch that does not fit.
+// @Tags         Imports
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file      formData  file    true   "CSV or JSONL file"
+// @Param        format    formData  string  false  "csv or jsonl, by default from the file extension"
+// @Param        priority  formData  string  false  "Priority of rows without one"
+// @Param        category  formData  string  false  "Category of rows without one"
+// @Success      202       {object}  db.Import
+// @Failure      400       {object}  map[string]string
+// @Failure      403       {object}  map[string]string
+// @Failure      413       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /imports [post]
+func (s *ImportService) CreateImport(c *fiber.Ctx) error {
+	// the upload is gone once the request ends, receive keeps a copy for the background import
+	file, err := receive(c, int64(config.Cfg.Imports.MaxFileSize))
+	var invalid *multipartError
+	switch {
+	case errors.Is(err, errNoFile):
+		return badRequest(c, "file is required")
+	case errors.Is(err, errTooLarge):
+		// the rest of the body is not read, the connection is closed rather than reused
+		c.Context().SetConnectionClose()
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": "file is larger than " + strconv.Itoa(config.Cfg.Imports.MaxFileSize) + " bytes",
+		})
+	case errors.As(err, &invalid):
+		return badRequest(c, invalid.Error())
+	case err != nil:
+		logger.Log.Error("Failed to save import file", zap.Error(err))
+		return internalError(c)
+	}
+	path := file.path
+
+	format := strings.ToLower(file.fields["format"])
+	if format == "" {
+		format = formatOf(file.fileName)
+	}
+	defaults := enqueue.Request{Priority: db.PriorityBulk, Category: file.fields["category"]}
+	if priority := file.fields["priority"]; priority != "" {
+		if defaults.Priority, err = db.ParsePriority(priority); err != nil {
+			os.Remove(path)
+			return badRequest(c, err.Error())
+		}
+	}
+	admin := tenants.IsAdmin(c)
+	if defaults.Priority == db.PriorityCritical && !admin {
+		os.Remove(path)
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": enqueue.ErrCriticalNotAllowed.Error(),
+		})
+	}
+
+	reader, closeFile, err := openRows(path, format, defaults)
+	if err != nil {
+		os.Remove(path)
+		return badRequest(c, err.Error())
+	}
+
+	tenant := tenants.From(c)
+	job := db.Import{TenantID: tenant.ID, FileName: filepath.Base(file.fileName), Format: format}
+	if err := s.repository.CreateImport(&job); err != nil {
+		closeFile()
+		logger.Log.Error("Failed to create import", zap.Error(err))
+		return internalError(c)
+	}
+
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		defer closeFile()
+		s.run(s.ctx, job, tenant, admin, reader)
+	}()
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+// GetImport godoc
+// @Summary      Get an import
+// @Description  Returns the status of an import with the number of rows read, imported and failed so far
+// @Tags         Imports
+// @Produce      json
+// @Param        id   path      int  true  "Import ID"
+// @Success      200  {object}  db.Import
+// @Failure      404  {object}  map[string]string
+// @Router       /imports/{id} [get]
+func (s *ImportService) GetImport(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return badRequest(c, "invalid import id")
+	}
+
+	job, err := s.job(c, uint(id))
+	if err != nil {
+		return importError(c, err)
+	}
+	return c.JSON(job)
+}
+
+// ListImportErrors godoc
+// @Summary      Get the error report of an import
+// @Description  Lists the rows that were not enqueued with the reason. With format=csv the whole report is downloaded as a CSV file with line and error columns.
+// @Tags         Imports
+// @Produce      json
+// @Produce      text/csv
+// @Param        id         path      int     true   "Import ID"
+// @Param        last_line  query     int     false  "Only return rows after this line"
+// @Param        limit      query     int     false  "Maximum number of rows to return (max 100)"
+// @Param        format     query     string  false  "csv to download the whole report"
+// @Success      200        {object}  ImportErrorListResponse
+// @Failure      404        {object}  map[string]string
+// @Router       /imports/{id}/errors [get]
+func (s *ImportService) ListImportErrors(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return badRequest(c, "invalid import id")
+	}
+	if _, err := s.job(c, uint(id)); err != nil {
+		return importError(c, err)
+	}
+
+	if c.Query("format") == FormatCSV {
+		return s.downloadErrors(c, uint(id))
+	}
+
+	lastLine := c.QueryInt("last_line", 0)
+	limit := c.QueryInt("limit", 0)
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	rowErrors, err := s.repository.ListImportErrors(uint(id), lastLine, limit)
+	if err != nil {
+		return importError(c, err)
+	}
+	return c.JSON(ImportErrorListResponse{LastLine: lastLine, Limit: limit, Data: rowErrors})
+}
+
+// job returns an import of the tenant of the request, imports of other tenants are not found
+func (s *ImportService) job(c *fiber.Ctx, id uint) (db.Import, error) {
+	job, err := s.repository.GetImport(id)
+	if err == nil && job.TenantID != tenants.From(c).ID {
+		return db.Import{}, repository.ErrImportNotFound
+	}
+	return job, err
+}
+
+// downloadErrors writes the whole error report as CSV, reading it page by page
+func (s *ImportService) downloadErrors(c *fiber.Ctx, id uint) error {
+	const pageSize = 1000
+
+	first, err := s.repository.ListImportErrors(id, 0, pageSize)
+	if err != nil {
+		return importError(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv")
+	c.Attachment("import-" + strconv.Itoa(int(id)) + "-errors.csv")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		writer := csv.NewWriter(w)
+		defer writer.Flush()
+		writer.Write([]string{"line", "error"})
+
+		page := first
+		for len(page) > 0 {
+			for _, rowError := range page {
+				writer.Write([]string{strconv.Itoa(rowError.Line), rowError.Error})
+			}
+			if len(page) < pageSize {
+				return
+			}
+			writer.Flush()
+
+			var err error
+			if page, err = s.repository.ListImportErrors(id, page[len(page)-1].Line, pageSize); err != nil {
+				logger.Log.Error("Failed to read import errors", zap.Uint("import_id", id), zap.Error(err))
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// run reads every row of job and enqueues the valid ones as messages of tenant in batches, progress
+// is stored after each batch. Critical rows are rejected unless admin is set. Cancelling ctx fails
+// the import before its next row.
+func (s *ImportService) run(ctx context.Context, job db.Import, tenant db.Tenant, admin bool, reader rowReader) {
+	renderer := enqueue.NewService(nil, newTemplateCache(s.templates))
+	messages := make([]db.Message, 0, s.batchSize)
+	var rowErrors []db.ImportError
+
+	flush := func() error {
+		if len(messages) == 0 && len(rowErrors) == 0 {
+			return nil
+		}
+		if s.quota != nil {
+			if err := s.quota.Reserve(ctx, tenant, len(messages)); err != nil {
+				return err
+			}
+		}
+		if err := s.repository.RecordImportBatch(job.ID, messages, rowErrors); err != nil {
+			if s.quota != nil {
+				s.quota.Release(context.Background(), tenant, len(messages))
+			}
+			return err
+		}
+		metrics.ImportRows.WithLabelValues("imported").Add(float64(len(messages)))
+		metrics.ImportRows.WithLabelValues("failed").Add(float64(len(rowErrors)))
+		messages, rowErrors = messages[:0], rowErrors[:0]
+		return nil
+	}
+
+	for {
+		if ctx.Err() != nil {
+			s.fail(job, errInterrupted)
+			return
+		}
+		next, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err == nil && next.err == nil && next.req.Priority == db.PriorityCritical && !admin {
+			next.err = enqueue.ErrCriticalNotAllowed
+		}
+		if err == nil {
+			err = s.add(renderer, job, next, &messages, &rowErrors)
+		}
+		if err == nil && len(messages)+len(rowErrors) >= s.batchSize {
+			err = flush()
+		}
+		if err != nil {
+			s.fail(job, err)
+			return
+		}
+	}
+
+	if err := flush(); err != nil {
+		s.fail(job, err)
+		return
+	}
+	if err := s.repository.CompleteImport(job.ID, db.ImportDone, ""); err != nil {
+		logger.Log.Error("Failed to complete import", zap.Uint("import_id", job.ID), zap.Error(err))
+	}
+}
+
+// add turns a row into a message or a row error, errors other than invalid rows stop the import
+func (s *ImportService) add(renderer *enqueue.EnqueueService, job db.Import, next row, messages *[]db.Message, rowErrors *[]db.ImportError) error {
+	importID := job.ID
+	if next.err != nil {
+		*rowErrors = append(*rowErrors, db.ImportError{ImportID: importID, Line: next.line, Error: next.err.Error()})
+		return nil
+	}
+
+	msg, err := renderer.NewMessage(next.req)
+	var validationErr *enqueue.ValidationError
+	if errors.As(err, &validationErr) {
+		*rowErrors = append(*rowErrors, db.ImportError{ImportID: importID, Line: next.line, Error: validationErr.Reason})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	msg.ImportID = &importID
+	msg.TenantID = job.TenantID
+	*messages = append(*messages, msg)
+	return nil
+}
+
+func (s *ImportService) fail(job db.Import, err error) {
+	logger.Log.Error("Import failed", zap.Uint("import_id", job.ID), zap.Error(err))
+	if err := s.repository.CompleteImport(job.ID, db.ImportFailed, err.Error()); err != nil {
+		logger.Log.Error("Failed to complete import", zap.Uint("import_id", job.ID), zap.Error(err))
+	}
+}
+
+// Wait blocks until the imports started by this service finish
+func (s *ImportService) Wait() {
+	s.running.Wait()
+}
+
+// Stop interrupts the imports started by this service and waits until they are marked failed, the
+// batch being stored when Stop is called is kept
+func (s *ImportService) Stop() {
+	s.cancel()
+	s.running.Wait()
+}
+
+// RunRecovery fails the imports that made no progress for imports.staleAfter every imports.staleAfter,
+// starting right away. Their instance stopped before finishing them and their uploads are gone with it.
+// It blocks until ctx is cancelled.
+func (s *ImportService) RunRecovery(ctx context.Context) {
+	if s.staleAfter <= 0 {
+		logger.Log.Info("Stale import recovery is disabled by config")
+		return
+	}
+
+	ticker := time.NewTicker(s.staleAfter)
+	defer ticker.Stop()
+
+	for {
+		s.FailStale(time.Now())
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// FailStale fails the processing imports last updated before now minus imports.staleAfter and returns how many it failed
+func (s *ImportService) FailStale(now time.Time) int64 {
+	failed, err := s.repository.FailStaleImports(now.Add(-s.staleAfter), errStale.Error())
+	if err != nil {
+		logger.Log.Error("Failed to fail stale imports", zap.Error(err))
+		return 0
+	}
+	if failed > 0 {
+		logger.Log.Warn("Failed stale imports", zap.Int64("count", failed))
+	}
+	return failed
+}
+
+// openRows opens the saved upload and reads its header, the returned func closes and removes the file
+func openRows(path, format string, defaults enqueue.Request) (rowReader, func(), error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	closeFile := func() {
+		file.Close()
+		os.Remove(path)
+	}
+
+	reader, err := newRowReader(format, bufio.NewReader(file), defaults)
+	if err != nil {
+		closeFile()
+		return nil, nil, err
+	}
+	return reader, closeFile, nil
+}
+
+func formatOf(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		return FormatCSV
+	case ".jsonl", ".ndjson":
+		return FormatJSONL
+	}
+	return ""
+}
+
+// templateCache remembers the templates used by an import so each is read once
+type templateCache struct {
+	templates enqueue.TemplateRepositoryInterface
+	cached    map[uint]cachedTemplate
+}
+
+type cachedTemplate struct {
+	template db.Template
+	err      error
+}
+
+func newTemplateCache(templates enqueue.TemplateRepositoryInterface) *templateCache {
+	return &templateCache{templates: templates, cached: make(map[uint]cachedTemplate)}
+}
+
+func (c *templateCache) GetTemplate(id uint) (db.Template, error) {
+	if cached, ok := c.cached[id]; ok {
+		return cached.template, cached.err
+	}
+	template, err := c.templates.GetTemplate(id)
+	if err != nil && !errors.Is(err, repository.ErrTemplateNotFound) {
+		return template, err
+	}
+	c.cached[id] = cachedTemplate{template: template, err: err}
+	return template, err
+}
+
+func badRequest(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": message,
+	})
+}
+
+func internalError(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to import messages",
+	})
+}
+
+func importError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, repository.ErrImportNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to access imports",
+	})
+}
@@ -0,0 +1,408 @@
+package imports
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/features/enqueue"
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setTestConfig() {
+	logger.Log = zap.NewNop()
+	config.Cfg.Phone.DefaultRegion = "TR"
+	config.Cfg.SMS.MaxSegments = 3
+	config.Cfg.Templates.FallbackLocales = []string{"en"}
+	config.Cfg.Imports.MaxFileSize = 1 << 20
+	config.Cfg.Imports.BatchSize = 2
+}
+
+func upload(t *testing.T, fileName, content string, fields map[string]string) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, value := range fields {
+		require.NoError(t, writer.WriteField(name, value))
+	}
+	if fileName != "" {
+		part, err := writer.CreateFormFile("file", fileName)
+		require.NoError(t, err)
+		_, err = part.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return body, writer.FormDataContentType()
+}
+
+func TestCreateImport(t *testing.T) {
+	setTestConfig()
+	otp := db.Template{ID: 3, Name: "otp", Version: 2, Body: "Hi {{name}}"}
+
+	tests := []struct {
+		name           string
+		fileName       string
+		content        string
+		fields         map[string]string
+		setupMock      func(*mocks.MockImportRepositoryInterface, *mocks.MockTemplateRepositoryInterface)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:     "CSV rows are imported in batches",
+			fileName: "audience.csv",
+			content:  "phone_number,template_id,name\n05321234567,3,Ali\n12,3,Bob\n05321234568,3,Ayse\n",
+			fields:   map[string]string{"category": "marketing"},
+			setupMock: func(m *mocks.MockImportRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {
+				templates.EXPECT().GetTemplate(uint(3)).Return(otp, nil).Times(1)
+				m.EXPECT().CreateImport(gomock.Any()).DoAndReturn(func(job *db.Import) error {
+					assert.Equal(t, "audience.csv", job.FileName)
+					assert.Equal(t, db.DefaultTenantID, job.TenantID)
+					assert.Equal(t, FormatCSV, job.Format)
+					job.ID, job.Status = 4, db.ImportProcessing
+					return nil
+				})
+				gomock.InOrder(
+					m.EXPECT().RecordImportBatch(uint(4), gomock.Any(), gomock.Any()).DoAndReturn(func(id uint, messages []db.Message, rowErrors []db.ImportError) error {
+						require.Len(t, messages, 1)
+						assert.Equal(t, "+905321234567", messages[0].PhoneNumber)
+						assert.Equal(t, "Hi Ali", messages[0].Content)
+						assert.Equal(t, db.PriorityBulk, messages[0].Priority)
+						assert.Equal(t, "marketing", messages[0].Category)
+						assert.Equal(t, uint(4), *messages[0].ImportID)
+						assert.Equal(t, db.DefaultTenantID, messages[0].TenantID)
+						require.Len(t, rowErrors, 1)
+						assert.Equal(t, 3, rowErrors[0].Line)
+						return nil
+					}),
+					m.EXPECT().RecordImportBatch(uint(4), gomock.Len(1), gomock.Len(0)).Return(nil),
+					m.EXPECT().CompleteImport(uint(4), db.ImportDone, "").Return(nil),
+				)
+			},
+			expectedStatus: fiber.StatusAccepted,
+			expectedBody:   `"status":"processing"`,
+		},
+		{
+			name:     "Storage errors fail the import",
+			fileName: "audience.jsonl",
+			content:  `{"phone_number":"05321234567","content":"Hi"}`,
+			setupMock: func(m *mocks.MockImportRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {
+				m.EXPECT().CreateImport(gomock.Any()).DoAndReturn(func(job *db.Import) error {
+					job.ID = 4
+					return nil
+				})
+				m.EXPECT().RecordImportBatch(uint(4), gomock.Len(1), gomock.Len(0)).Return(errors.New("db down"))
+				m.EXPECT().CompleteImport(uint(4), db.ImportFailed, "db down").Return(nil)
+			},
+			expectedStatus: fiber.StatusAccepted,
+		},
+		{
+			name:           "File is required",
+			setupMock:      func(m *mocks.MockImportRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "file is required",
+		},
+		{
+			name:           "Unknown format",
+			fileName:       "audience.txt",
+			content:        "05321234567",
+			setupMock:      func(m *mocks.MockImportRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   `unknown format \"\", use csv or jsonl`,
+		},
+		{
+			name:           "CSV without a phone number column",
+			fileName:       "audience.txt",
+			content:        "number\n05321234567\n",
+			fields:         map[string]string{"format": "csv"},
+			setupMock:      func(m *mocks.MockImportRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "the header has no phone_number column",
+		},
+		{
+			name:           "Invalid default priority",
+			fileName:       "audience.csv",
+			content:        "phone_number\n05321234567\n",
+			fields:         map[string]string{"priority": "urgent"},
+			setupMock:      func(m *mocks.MockImportRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   `unknown priority \"urgent\"`,
+		},
+		{
+			name:           "Critical default priority without admin rights",
+			fileName:       "audience.csv",
+			content:        "phone_number\n05321234567\n",
+			fields:         map[string]string{"priority": "critical"},
+			setupMock:      func(m *mocks.MockImportRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {},
+			expectedStatus: fiber.StatusForbidden,
+			expectedBody:   enqueue.ErrCriticalNotAllowed.Error(),
+		},
+		{
+			name:     "Critical rows without admin rights are rejected",
+			fileName: "audience.csv",
+			content:  "phone_number,content,priority\n05321234567,Hi,critical\n05321234568,Hi,high\n",
+			setupMock: func(m *mocks.MockImportRepositoryInterface, templates *mocks.MockTemplateRepositoryInterface) {
+				m.EXPECT().CreateImport(gomock.Any()).DoAndReturn(func(job *db.Import) error {
+					job.ID = 4
+					return nil
+				})
+				m.EXPECT().RecordImportBatch(uint(4), gomock.Any(), gomock.Any()).DoAndReturn(func(id uint, messages []db.Message, rowErrors []db.ImportError) error {
+					require.Len(t, messages, 1)
+					assert.Equal(t, db.PriorityHigh, messages[0].Priority)
+					require.Len(t, rowErrors, 1)
+					assert.Equal(t, 2, rowErrors[0].Line)
+					assert.Equal(t, enqueue.ErrCriticalNotAllowed.Error(), rowErrors[0].Error)
+					return nil
+				})
+				m.EXPECT().CompleteImport(uint(4), db.ImportDone, "").Return(nil)
+			},
+			expectedStatus: fiber.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockImportRepositoryInterface(ctrl)
+			mockTemplates := mocks.NewMockTemplateRepositoryInterface(ctrl)
+			tt.setupMock(mockRepo, mockTemplates)
+			service := NewService(mockRepo, mockTemplates)
+
+			app := fiber.New()
+			app.Post("/imports", service.CreateImport)
+
+			body, contentType := upload(t, tt.fileName, tt.content, tt.fields)
+			req := httptest.NewRequest(fiber.MethodPost, "/imports", body)
+			req.Header.Set("Content-Type", contentType)
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			respBody, _ := io.ReadAll(resp.Body)
+			if tt.expectedBody != "" {
+				assert.Contains(t, string(respBody), tt.expectedBody)
+			}
+			service.Wait()
+		})
+	}
+}
+
+func TestCreateImport_Streamed(t *testing.T) {
+	setTestConfig()
+	config.Cfg.Imports.MaxFileSize = 1024
+
+	tests := []struct {
+		name           string
+		content        string
+		setupMock      func(*mocks.MockImportRepositoryInterface)
+		expectedStatus int
+	}{
+		{
+			name:    "Chunked upload within the limit",
+			content: `{"phone_number":"05321234567","content":"Hi"}`,
+			setupMock: func(m *mocks.MockImportRepositoryInterface) {
+				m.EXPECT().CreateImport(gomock.Any()).DoAndReturn(func(job *db.Import) error {
+					job.ID = 4
+					return nil
+				})
+				m.EXPECT().RecordImportBatch(uint(4), gomock.Len(1), gomock.Len(0)).Return(nil)
+				m.EXPECT().CompleteImport(uint(4), db.ImportDone, "").Return(nil)
+			},
+			expectedStatus: fiber.StatusAccepted,
+		},
+		{
+			name:           "Chunked upload over the limit",
+			content:        strings.Repeat(`{"phone_number":"05321234567","content":"Hi"}`+"\n", 50),
+			setupMock:      func(m *mocks.MockImportRepositoryInterface) {},
+			expectedStatus: fiber.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockImportRepositoryInterface(ctrl)
+			tt.setupMock(mockRepo)
+			service := NewService(mockRepo, mocks.NewMockTemplateRepositoryInterface(ctrl))
+
+			app := fiber.New(fiber.Config{StreamRequestBody: true, DisablePreParseMultipartForm: true})
+			app.Post("/imports", service.CreateImport)
+
+			body, contentType := upload(t, "audience.jsonl", tt.content, nil)
+			req := httptest.NewRequest(fiber.MethodPost, "/imports", body)
+			req.Header.Set("Content-Type", contentType)
+			req.ContentLength, req.TransferEncoding = -1, []string{"chunked"}
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			service.Wait()
+		})
+	}
+}
+
+func TestStop(t *testing.T) {
+	setTestConfig()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockImportRepositoryInterface(ctrl)
+	mockRepo.EXPECT().CompleteImport(uint(4), db.ImportFailed, errInterrupted.Error()).Return(nil)
+	service := NewService(mockRepo, mocks.NewMockTemplateRepositoryInterface(ctrl))
+
+	reader, err := newRowReader(FormatJSONL, strings.NewReader(`{"phone_number":"05321234567","content":"Hi"}`), enqueue.Request{})
+	require.NoError(t, err)
+	service.Stop()
+	// the rows of an import interrupted before its first batch are not stored
+	service.run(service.ctx, db.Import{ID: 4}, db.Tenant{ID: db.DefaultTenantID}, true, reader)
+}
+
+func TestFailStale(t *testing.T) {
+	setTestConfig()
+	config.Cfg.Imports.StaleAfter = 15 * time.Minute
+	defer func() { config.Cfg.Imports.StaleAfter = 0 }()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	mockRepo := mocks.NewMockImportRepositoryInterface(ctrl)
+	mockRepo.EXPECT().FailStaleImports(now.Add(-15*time.Minute), errStale.Error()).Return(int64(2), nil)
+	mockRepo.EXPECT().FailStaleImports(gomock.Any(), gomock.Any()).Return(int64(0), errors.New("db down"))
+	service := NewService(mockRepo, mocks.NewMockTemplateRepositoryInterface(ctrl))
+
+	assert.Equal(t, int64(2), service.FailStale(now))
+	assert.Equal(t, int64(0), service.FailStale(now))
+}
+
+func TestImportReports(t *testing.T) {
+	setTestConfig()
+	job := db.Import{ID: 4, TenantID: db.DefaultTenantID, FileName: "audience.csv", Format: FormatCSV, Status: db.ImportDone, Rows: 3, Imported: 2, Failed: 1}
+	rowErrors := []db.ImportError{{ImportID: 4, Line: 3, Error: "invalid phone number"}, {ImportID: 4, Line: 7, Error: "missing template variables: name"}}
+
+	tests := []struct {
+		name           string
+		url            string
+		setupMock      func(*mocks.MockImportRepositoryInterface)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Get import",
+			url:  "/imports/4",
+			setupMock: func(m *mocks.MockImportRepositoryInterface) {
+				m.EXPECT().GetImport(uint(4)).Return(job, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"imported":2,"failed":1`,
+		},
+		{
+			name: "Get unknown import",
+			url:  "/imports/5",
+			setupMock: func(m *mocks.MockImportRepositoryInterface) {
+				m.EXPECT().GetImport(uint(5)).Return(db.Import{}, repository.ErrImportNotFound)
+			},
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody:   repository.ErrImportNotFound.Error(),
+		},
+		{
+			name: "Import of another tenant is not found",
+			url:  "/imports/4/errors",
+			setupMock: func(m *mocks.MockImportRepositoryInterface) {
+				other := job
+				other.TenantID = 2
+				m.EXPECT().GetImport(uint(4)).Return(other, nil)
+			},
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody:   repository.ErrImportNotFound.Error(),
+		},
+		{
+			name: "List errors",
+			url:  "/imports/4/errors?last_line=2&limit=500",
+			setupMock: func(m *mocks.MockImportRepositoryInterface) {
+				m.EXPECT().GetImport(uint(4)).Return(job, nil)
+				m.EXPECT().ListImportErrors(uint(4), 2, 100).Return(rowErrors, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"data":[{"line":3,"error":"invalid phone number"}`,
+		},
+		{
+			name: "Download errors",
+			url:  "/imports/4/errors?format=csv",
+			setupMock: func(m *mocks.MockImportRepositoryInterface) {
+				m.EXPECT().GetImport(uint(4)).Return(job, nil)
+				m.EXPECT().ListImportErrors(uint(4), 0, 1000).Return(rowErrors, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   "line,error\n3,invalid phone number\n7,missing template variables: name\n",
+		},
+		{
+			name:           "Invalid id",
+			url:            "/imports/abc/errors",
+			setupMock:      func(m *mocks.MockImportRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "invalid import id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockImportRepositoryInterface(ctrl)
+			tt.setupMock(mockRepo)
+			service := NewService(mockRepo, mocks.NewMockTemplateRepositoryInterface(ctrl))
+
+			app := fiber.New()
+			app.Get("/imports/:id", service.GetImport)
+			app.Get("/imports/:id/errors", service.ListImportErrors)
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, tt.url, nil))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			body, _ := io.ReadAll(resp.Body)
+			assert.Contains(t, string(body), tt.expectedBody)
+			if strings.HasPrefix(resp.Header.Get("Content-Type"), fiber.MIMEApplicationJSON) && resp.StatusCode == fiber.StatusOK {
+				assert.True(t, json.Valid(body))
+			}
+		})
+	}
+}
+
+func TestTemplateCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	templates := mocks.NewMockTemplateRepositoryInterface(ctrl)
+	templates.EXPECT().GetTemplate(uint(3)).Return(db.Template{ID: 3}, nil).Times(1)
+	templates.EXPECT().GetTemplate(uint(4)).Return(db.Template{}, repository.ErrTemplateNotFound).Times(1)
+	templates.EXPECT().GetTemplate(uint(5)).Return(db.Template{}, errors.New("db down")).Times(2)
+
+	var cache enqueue.TemplateRepositoryInterface = newTemplateCache(templates)
+	for i := 0; i < 2; i++ {
+		_, err := cache.GetTemplate(3)
+		assert.NoError(t, err)
+		_, err = cache.GetTemplate(4)
+		assert.ErrorIs(t, err, repository.ErrTemplateNotFound)
+		_, err = cache.GetTemplate(5)
+		assert.EqualError(t, err, "db down", "other errors are not cached")
+	}
+}
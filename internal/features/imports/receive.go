@@ -0,0 +1,171 @@
+package imports
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxFieldSize caps the form fields sent next to the file, they hold a format, a priority or a category
+const maxFieldSize = 1024
+
+var (
+	errNoFile = errors.New("file is required")
+	// errTooLarge is returned once the request body is longer than imports.maxFileSize
+	errTooLarge = errors.New("upload is too large")
+)
+
+// receivedFile is a file received by CreateImport with the form fields sent next to it
+type receivedFile struct {
+	// path is the temporary copy of the file, it outlives the request for the background import
+	path     string
+	fileName string
+	fields   map[string]string
+}
+
+// receive reads the multipart body of c part by part and copies the file to a temporary file, the
+// body is never held in memory. Bodies longer than limit fail with errTooLarge and malformed bodies
+// with a *multipartError, other errors come from the temporary file.
+func receive(c *fiber.Ctx, limit int64) (receivedFile, error) {
+	received := receivedFile{fields: make(map[string]string)}
+	boundary := string(c.Request().Header.MultipartFormBoundary())
+	if boundary == "" {
+		return received, errNoFile
+	}
+
+	stream := &limitReader{r: body(c), remaining: limit}
+	if err := received.read(multipart.NewReader(stream, boundary)); err != nil {
+		return receivedFile{}, err
+	}
+	// the end of a chunked body follows the closing boundary, left unread it would be parsed as the
+	// next request on the connection
+	if _, err := io.Copy(io.Discard, stream); err != nil {
+		received.remove()
+		return receivedFile{}, malformed(err)
+	}
+
+	if received.path == "" {
+		return received, errNoFile
+	}
+	return received, nil
+}
+
+// read adds every part of parts, the copy of the file is removed when a part fails
+func (u *receivedFile) read(parts *multipart.Reader) error {
+	for {
+		part, err := parts.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			err = malformed(err)
+		} else {
+			err = u.add(part)
+		}
+		if err != nil {
+			u.remove()
+			return err
+		}
+	}
+}
+
+func (u *receivedFile) remove() {
+	if u.path != "" {
+		os.Remove(u.path)
+	}
+}
+
+// multipartError is a malformed multipart body
+type multipartError struct {
+	err error
+}
+
+func (e *multipartError) Error() string {
+	return "invalid multipart body: " + e.err.Error()
+}
+
+func (e *multipartError) Unwrap() error {
+	return e.err
+}
+
+// malformed wraps the errors of reading the body in a *multipartError, errTooLarge is kept as is
+func malformed(err error) error {
+	if err == nil || errors.Is(err, io.EOF) || errors.Is(err, errTooLarge) {
+		return err
+	}
+	return &multipartError{err: err}
+}
+
+// partReader tells the read errors of a part from the write errors of its copy
+type partReader struct {
+	part *multipart.Part
+}
+
+func (r partReader) Read(p []byte) (int, error) {
+	n, err := r.part.Read(p)
+	return n, malformed(err)
+}
+
+// add stores the first file part and the form fields, other files are skipped
+func (u *receivedFile) add(part *multipart.Part) error {
+	defer part.Close()
+
+	reader := partReader{part: part}
+	if part.FileName() == "" {
+		value, err := io.ReadAll(io.LimitReader(reader, maxFieldSize))
+		if err != nil {
+			return err
+		}
+		u.fields[part.FormName()] = string(value)
+		return nil
+	}
+	if part.FormName() != "file" || u.path != "" {
+		_, err := io.Copy(io.Discard, reader)
+		return err
+	}
+
+	file, err := os.CreateTemp("", "import-*")
+	if err != nil {
+		return err
+	}
+	u.path, u.fileName = file.Name(), part.FileName()
+	_, err = io.Copy(file, reader)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// body returns the request body as a stream, bodies that are not streamed are read from memory
+func body(c *fiber.Ctx) io.Reader {
+	if stream := c.Request().BodyStream(); stream != nil {
+		return stream
+	}
+	return bytes.NewReader(c.Request().Body())
+}
+
+// limitReader reads from r and fails with errTooLarge once more than remaining bytes were read
+type limitReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, errTooLarge
+	}
+	// read one byte past the limit to tell a body of exactly the limit from a longer one
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, errTooLarge
+	}
+	return n, err
+}
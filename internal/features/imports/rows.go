@@ -0,0 +1,172 @@
+package imports
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/features/enqueue"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// row is a parsed line of an import, err tells why the line cannot become a request
+type row struct {
+	line int
+	req  enqueue.Request
+	err  error
+}
+
+// rowReader reads an import file one row at a time. next returns io.EOF after the last row,
+// other errors mean the rest of the file cannot be read.
+type rowReader interface {
+	next() (row, error)
+}
+
+func newRowReader(format string, r io.Reader, defaults enqueue.Request) (rowReader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r, defaults)
+	case FormatJSONL:
+		return &jsonlReader{reader: bufio.NewReader(r), defaults: defaults}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q, use csv or jsonl", format)
+}
+
+// csvReader reads rows of a CSV file with a header. Columns named after request fields fill the
+// request, every other column is a template variable.
+type csvReader struct {
+	reader   *csv.Reader
+	header   []string
+	defaults enqueue.Request
+}
+
+func newCSVReader(r io.Reader, defaults enqueue.Request) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the file is empty, the first line must be a header")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+
+	columns := make([]string, len(header))
+	hasPhone := false
+	for i, column := range header {
+		// spreadsheets save UTF-8 files with a byte order mark
+		columns[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		hasPhone = hasPhone || columns[i] == "phone_number"
+	}
+	if !hasPhone {
+		return nil, errors.New("the header has no phone_number column")
+	}
+	return &csvReader{reader: reader, header: columns, defaults: defaults}, nil
+}
+
+func (r *csvReader) next() (row, error) {
+	record, err := r.reader.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return row{line: parseErr.StartLine, err: parseErr.Err}, nil
+	}
+	if err != nil {
+		return row{}, err
+	}
+
+	line, _ := r.reader.FieldPos(0)
+	result := row{line: line, req: r.defaults}
+	result.err = r.fill(&result.req, record)
+	return result, nil
+}
+
+func (r *csvReader) fill(req *enqueue.Request, record []string) error {
+	for i, value := range record {
+		value = strings.TrimSpace(value)
+		column := r.header[i]
+		if value == "" && column != "phone_number" {
+			continue
+		}
+
+		switch column {
+		case "phone_number":
+			req.PhoneNumber = value
+		case "content":
+			req.Content = value
+		case "template_id":
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return fmt.Errorf("invalid template_id %q", value)
+			}
+			templateID := uint(id)
+			req.TemplateID = &templateID
+		case "locale":
+			req.Locale = value
+		case "priority":
+			priority, err := db.ParsePriority(value)
+			if err != nil {
+				return err
+			}
+			req.Priority = priority
+		case "category":
+			req.Category = value
+		case "time_zone":
+			req.TimeZone = value
+		case "expires_at":
+			expiresAt, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return fmt.Errorf("invalid expires_at %q, use RFC 3339", value)
+			}
+			req.ExpiresAt = &expiresAt
+		default:
+			if req.Variables == nil {
+				req.Variables = make(map[string]string)
+			}
+			req.Variables[column] = value
+		}
+	}
+	return nil
+}
+
+// jsonlReader reads one request object per line, blank lines are skipped
+type jsonlReader struct {
+	reader   *bufio.Reader
+	line     int
+	defaults enqueue.Request
+}
+
+func (r *jsonlReader) next() (row, error) {
+	for {
+		data, err := r.reader.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			return row{}, err
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return row{}, err
+		}
+		r.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		result := row{line: r.line, req: r.defaults}
+		if err := json.Unmarshal(data, &result.req); err != nil {
+			result.err = fmt.Errorf("invalid JSON: %w", err)
+		}
+		return result, nil
+	}
+}
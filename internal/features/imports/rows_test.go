@@ -0,0 +1,92 @@
+package imports
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/atakurt/messagingApp/internal/features/enqueue"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAll(t *testing.T, reader rowReader) []row {
+	var rows []row
+	for {
+		next, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		require.NoError(t, err)
+		rows = append(rows, next)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	file := "\ufeffPhone_Number,template_id,priority,name\n" +
+		"05321234567,3,,Ali\n" +
+		"\"+14155550100\",3,high,\"Ann, Jr\"\n" +
+		"05321234568,x,,Bob\n" +
+		"05321234569,3\n"
+	reader, err := newRowReader(FormatCSV, strings.NewReader(file), enqueue.Request{Priority: db.PriorityBulk})
+	require.NoError(t, err)
+
+	rows := readAll(t, reader)
+	require.Len(t, rows, 4)
+
+	assert.Equal(t, 2, rows[0].line)
+	assert.NoError(t, rows[0].err)
+	assert.Equal(t, "05321234567", rows[0].req.PhoneNumber)
+	assert.Equal(t, uint(3), *rows[0].req.TemplateID)
+	assert.Equal(t, db.PriorityBulk, rows[0].req.Priority)
+	assert.Equal(t, map[string]string{"name": "Ali"}, rows[0].req.Variables)
+
+	assert.Equal(t, db.PriorityHigh, rows[1].req.Priority)
+	assert.Equal(t, map[string]string{"name": "Ann, Jr"}, rows[1].req.Variables)
+
+	assert.Equal(t, 4, rows[2].line)
+	assert.EqualError(t, rows[2].err, `invalid template_id "x"`)
+
+	assert.Equal(t, 5, rows[3].line)
+	assert.ErrorContains(t, rows[3].err, "wrong number of fields")
+}
+
+func TestCSVReaderHeader(t *testing.T) {
+	_, err := newRowReader(FormatCSV, strings.NewReader(""), enqueue.Request{})
+	assert.EqualError(t, err, "the file is empty, the first line must be a header")
+
+	_, err = newRowReader(FormatCSV, strings.NewReader("number,content\n05321234567,hi\n"), enqueue.Request{})
+	assert.EqualError(t, err, "the header has no phone_number column")
+
+	_, err = newRowReader("xlsx", strings.NewReader(""), enqueue.Request{})
+	assert.EqualError(t, err, `unknown format "xlsx", use csv or jsonl`)
+}
+
+func TestJSONLReader(t *testing.T) {
+	file := `{"phone_number":"05321234567","content":"Hi"}` + "\n" +
+		"\n" +
+		`{"phone_number":"05321234568","content":"Hi","priority":"normal","category":"news"}` + "\n" +
+		`{"phone_number":` + "\n" +
+		`{"phone_number":"05321234569","template_id":3,"variables":{"name":"Ali"}}`
+	reader, err := newRowReader(FormatJSONL, strings.NewReader(file), enqueue.Request{Priority: db.PriorityBulk, Category: "marketing"})
+	require.NoError(t, err)
+
+	rows := readAll(t, reader)
+	require.Len(t, rows, 4)
+
+	assert.Equal(t, 1, rows[0].line)
+	assert.Equal(t, db.PriorityBulk, rows[0].req.Priority)
+	assert.Equal(t, "marketing", rows[0].req.Category)
+
+	assert.Equal(t, 3, rows[1].line)
+	assert.Equal(t, db.PriorityNormal, rows[1].req.Priority)
+	assert.Equal(t, "news", rows[1].req.Category)
+
+	assert.Equal(t, 4, rows[2].line)
+	assert.ErrorContains(t, rows[2].err, "invalid JSON")
+
+	assert.Equal(t, 5, rows[3].line)
+	assert.Equal(t, map[string]string{"name": "Ali"}, rows[3].req.Variables)
+}
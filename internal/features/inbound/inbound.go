@@ -0,0 +1,184 @@
+package inbound
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/infrastructure/phone"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type InboundRepositoryInterface interface {
+	CreateInboundMessage(msg *db.InboundMessage) error
+	ListInboundMessages(phoneNumber string, lastID, limit int) ([]db.InboundMessage, error)
+	LastSentMessageID(phoneNumber string) (*uint, error)
+}
+
+type InboundService struct {
+	repository InboundRepositoryInterface
+	router     *Router
+}
+
+func NewService(repository InboundRepositoryInterface, router *Router) *InboundService {
+	return &InboundService{
+		repository: repository,
+		router:     router,
+	}
+}
+
+// maxNumberLength is the size of the from and to columns, alphanumeric sender ids are longer than numbers
+const maxNumberLength = 64
+
+// maxMessageIDLength is the size of the provider_id column
+const maxMessageIDLength = 255
+
+// Request is a message the provider received from a recipient
+// @Description Mobile originated message delivered by the provider
+type Request struct {
+	From       string     `json:"from" example:"+905321234567"`
+	To         string     `json:"to,omitempty" example:"4545"`
+	Text       string     `json:"text" example:"STOP"`
+	MessageID  string     `json:"message_id,omitempty" example:"mo-67f2f8a8"`
+	ReceivedAt *time.Time `json:"received_at,omitempty"`
+}
+
+// InboundListResponse is a page of inbound messages
+// @Description Paginated list of inbound messages
+type InboundListResponse struct {
+	LastID int                 `json:"last_id"`
+	Limit  int                 `json:"limit"`
+	Data   []db.InboundMessage `json:"data"`
+}
+
+// Receive godoc
+// @Summary      Receive an inbound message
+// @Description  Called by the provider for every message a recipient sends. from and to take up to 64 characters, enough for alphanumeric sender ids. The message is stored with the last message delivered to the sender as reply_to, a message_id delivered again is stored once. A message that is a routed keyword, surrounding punctuation and case ignored, runs its action: suppression.stopKeywords opt the sender out, suppression.startKeywords opt it back in and inbound.autoReplies keywords are answered.
+// @Tags         Inbound
+// @Accept       json
+// @Produce      json
+// @Param        message      body      Request  true   "Inbound message"
+// @Param        X-Signature  header    string   true   "HMAC-SHA256 of the body keyed with inbound.webhookSecret"
+// @Success      200          {object}  db.InboundMessage
+// @Failure      400          {object}  map[string]string
+// @Failure      401          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Failure      503          {object}  map[string]string
+// @Router       /inbound [post]
+func (s *InboundService) Receive(c *fiber.Ctx) error {
+	var req Request
+	if err := c.BodyParser(&req); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+	if strings.TrimSpace(req.From) == "" {
+		return badRequest(c, "from is required")
+	}
+	// rejected rather than failing to store, the provider would deliver the message again forever
+	if len(req.From) > maxNumberLength || len(req.To) > maxNumberLength {
+		return badRequest(c, "from and to must not exceed "+strconv.Itoa(maxNumberLength)+" characters")
+	}
+	if len(req.MessageID) > maxMessageIDLength {
+		return badRequest(c, "message_id must not exceed "+strconv.Itoa(maxMessageIDLength)+" characters")
+	}
+
+	msg := db.InboundMessage{
+		From:       normalize(req.From),
+		To:         strings.TrimSpace(req.To),
+		Body:       strings.TrimSpace(req.Text),
+		ProviderID: req.MessageID,
+		ReceivedAt: time.Now(),
+	}
+	if req.ReceivedAt != nil {
+		msg.ReceivedAt = *req.ReceivedAt
+	}
+
+	keyword, handler, routed := s.router.Match(msg.Body)
+	if routed {
+		msg.Keyword = keyword
+	}
+
+	replyTo, err := s.repository.LastSentMessageID(msg.From)
+	if err != nil {
+		logger.Log.Error("Failed to correlate inbound message", zap.String("from", msg.From), zap.Error(err))
+		return internalError(c)
+	}
+	msg.ReplyTo = replyTo
+
+	if err := s.repository.CreateInboundMessage(&msg); err != nil {
+		logger.Log.Error("Failed to store inbound message", zap.String("from", msg.From), zap.Error(err))
+		return internalError(c)
+	}
+
+	// a failed action answers 500 so the provider delivers the message again and the action is retried
+	if routed {
+		if err := handler(c.UserContext(), msg); err != nil {
+			logger.Log.Error("Failed to run keyword action",
+				zap.Uint("inboundID", msg.ID),
+				zap.String("keyword", keyword),
+				zap.Error(err))
+			return internalError(c)
+		}
+	}
+
+	logger.Log.Info("Inbound message received",
+		zap.Uint("inboundID", msg.ID),
+		zap.String("from", msg.From),
+		zap.String("keyword", msg.Keyword))
+	return c.JSON(msg)
+}
+
+// ListInbound godoc
+// @Summary      List inbound messages
+// @Description  Lists inbound messages in arrival order using keyset pagination
+// @Tags         Inbound
+// @Produce      json
+// @Param        phone_number  query     string  false  "Only return messages from this number"
+// @Param        last_id       query     int     false  "Only return messages with ID > last_id"
+// @Param        limit         query     int     false  "Maximum number of messages to return (max 100)"
+// @Success      200           {object}  InboundListResponse
+// @Failure      500           {object}  map[string]string
+// @Router       /inbound [get]
+func (s *InboundService) ListInbound(c *fiber.Ctx) error {
+	lastID := c.QueryInt("last_id", 0)
+	limit := c.QueryInt("limit", 0)
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	var from string
+	if number := c.Query("phone_number"); number != "" {
+		from = normalize(number)
+	}
+
+	messages, err := s.repository.ListInboundMessages(from, lastID, limit)
+	if err != nil {
+		return internalError(c)
+	}
+	return c.JSON(InboundListResponse{LastID: lastID, Limit: limit, Data: messages})
+}
+
+// normalize returns number in E.164, short codes and alphanumeric senders are kept as they are
+func normalize(number string) string {
+	if e164, err := phone.Normalize(number); err == nil {
+		return e164
+	}
+	return strings.TrimSpace(number)
+}
+
+func badRequest(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": message,
+	})
+}
+
+func internalError(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to process inbound message",
+	})
+}
@@ -0,0 +1,196 @@
+package inbound
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestInboundHandlers(t *testing.T) {
+	setTestConfig()
+	logger.Log = zap.NewNop()
+	lastSent := uint(41)
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		keywordErr     error
+		setupMock      func(*mocks.MockInboundRepositoryInterface)
+		expectedStatus int
+		expectedBody   string
+		expectedRuns   int
+	}{
+		{
+			name:   "Reply is stored and correlated",
+			method: fiber.MethodPost,
+			url:    "/inbound",
+			body:   `{"from":"0532 123 45 67","to":"4545","text":" thanks! ","message_id":"mo-1"}`,
+			setupMock: func(m *mocks.MockInboundRepositoryInterface) {
+				m.EXPECT().LastSentMessageID("+905321234567").Return(&lastSent, nil)
+				m.EXPECT().CreateInboundMessage(gomock.Any()).DoAndReturn(func(msg *db.InboundMessage) error {
+					assert.Equal(t, "thanks!", msg.Body)
+					assert.Equal(t, "4545", msg.To)
+					assert.Equal(t, "mo-1", msg.ProviderID)
+					assert.Empty(t, msg.Keyword)
+					assert.False(t, msg.ReceivedAt.IsZero())
+					msg.ID = 5
+					return nil
+				})
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"reply_to":41`,
+		},
+		{
+			name:   "Keyword runs its action",
+			method: fiber.MethodPost,
+			url:    "/inbound",
+			body:   `{"from":"+905321234567","text":"Stop","received_at":"2026-03-10T09:00:00Z"}`,
+			setupMock: func(m *mocks.MockInboundRepositoryInterface) {
+				m.EXPECT().LastSentMessageID("+905321234567").Return(nil, nil)
+				m.EXPECT().CreateInboundMessage(gomock.Any()).Return(nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"keyword":"STOP"`,
+			expectedRuns:   1,
+		},
+		{
+			name:   "Message starting with a keyword is not routed",
+			method: fiber.MethodPost,
+			url:    "/inbound/sms",
+			body:   `{"from":"+905321234567","text":"Stop by tomorrow?"}`,
+			setupMock: func(m *mocks.MockInboundRepositoryInterface) {
+				m.EXPECT().LastSentMessageID("+905321234567").Return(nil, nil)
+				m.EXPECT().CreateInboundMessage(gomock.Any()).DoAndReturn(func(msg *db.InboundMessage) error {
+					assert.Empty(t, msg.Keyword)
+					return nil
+				})
+			},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:       "Failed action asks the provider to deliver again",
+			method:     fiber.MethodPost,
+			url:        "/inbound/sms",
+			body:       `{"from":"+905321234567","text":"STOP"}`,
+			keywordErr: errors.New("db down"),
+			setupMock: func(m *mocks.MockInboundRepositoryInterface) {
+				m.EXPECT().LastSentMessageID("+905321234567").Return(nil, nil)
+				m.EXPECT().CreateInboundMessage(gomock.Any()).Return(nil)
+			},
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedRuns:   1,
+		},
+		{
+			name:           "Missing sender",
+			method:         fiber.MethodPost,
+			url:            "/inbound",
+			body:           `{"text":"STOP"}`,
+			setupMock:      func(*mocks.MockInboundRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "from is required",
+		},
+		{
+			name:           "Oversized sender",
+			method:         fiber.MethodPost,
+			url:            "/inbound",
+			body:           `{"from":"` + strings.Repeat("A", 65) + `","text":"STOP"}`,
+			setupMock:      func(*mocks.MockInboundRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "from and to must not exceed 64 characters",
+		},
+		{
+			name:   "List messages from a number",
+			method: fiber.MethodGet,
+			url:    "/inbound?phone_number=05321234567&last_id=3&limit=500",
+			setupMock: func(m *mocks.MockInboundRepositoryInterface) {
+				m.EXPECT().ListInboundMessages("+905321234567", 3, 100).Return([]db.InboundMessage{{ID: 4, From: "+905321234567"}}, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"limit":100`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockInboundRepositoryInterface(ctrl)
+			tt.setupMock(mockRepo)
+
+			runs := 0
+			router := NewRouter()
+			router.Handle("STOP", func(context.Context, db.InboundMessage) error {
+				runs++
+				return tt.keywordErr
+			})
+			service := NewService(mockRepo, router)
+
+			app := fiber.New()
+			app.Post("/inbound", service.Receive)
+			app.Post("/inbound/sms", service.Receive)
+			app.Get("/inbound", service.ListInbound)
+
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			assert.Equal(t, tt.expectedRuns, runs)
+
+			body, _ := io.ReadAll(resp.Body)
+			if tt.expectedBody != "" {
+				assert.Contains(t, string(body), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := `{"from":"+905321234567","text":"STOP"}`
+
+	tests := []struct {
+		name           string
+		secret         string
+		signature      string
+		expectedStatus int
+	}{
+		{"Signed body", "secret", hex.EncodeToString(Sign("secret", []byte(body))), fiber.StatusOK},
+		{"Prefixed signature", "secret", "sha256=" + hex.EncodeToString(Sign("secret", []byte(body))), fiber.StatusOK},
+		{"Signed with another secret", "secret", hex.EncodeToString(Sign("other", []byte(body))), fiber.StatusUnauthorized},
+		{"Missing signature", "secret", "", fiber.StatusUnauthorized},
+		{"No secret configured", "", hex.EncodeToString(Sign("", []byte(body))), fiber.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Post("/inbound", VerifySignature(tt.secret), func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			req := httptest.NewRequest(fiber.MethodPost, "/inbound", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.signature != "" {
+				req.Header.Set(SignatureHeader, tt.signature)
+			}
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package inbound
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/atakurt/messagingApp/internal/features/enqueue"
+	"github.com/atakurt/messagingApp/internal/features/suppression"
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+)
+
+// KeywordHandler acts on an inbound message that matched a keyword. Providers deliver messages
+// again when a response is lost, handlers must be safe to run twice for one message.
+type KeywordHandler func(ctx context.Context, msg db.InboundMessage) error
+
+// Router maps keywords to handlers, keywords are matched case insensitively and the Turkish
+// dotted and dotless i match I, so İptal, iptal and IPTAL are one keyword. A keyword matches
+// when it is the whole message, keywords registered with HandlePrefix also match the first word.
+type Router struct {
+	handlers map[string]KeywordHandler
+	prefixes map[string]KeywordHandler
+}
+
+func NewRouter() *Router {
+	return &Router{
+		handlers: make(map[string]KeywordHandler),
+		prefixes: make(map[string]KeywordHandler),
+	}
+}
+
+// Handle routes messages that are only keyword to handler, replacing an earlier handler
+func (r *Router) Handle(keyword string, handler KeywordHandler) {
+	r.handlers[fold(keyword)] = handler
+}
+
+// HandlePrefix routes messages starting with keyword to handler, replacing an earlier handler.
+// Opt-out keywords should use Handle, "Stop by tomorrow?" is not an opt-out.
+func (r *Router) HandlePrefix(keyword string, handler KeywordHandler) {
+	r.prefixes[fold(keyword)] = handler
+}
+
+// Match returns the keyword body matches and its handler, surrounding punctuation is ignored
+// and a whole message match wins over a first word match. ok is false when no keyword matches.
+func (r *Router) Match(body string) (keyword string, handler KeywordHandler, ok bool) {
+	fields := strings.Fields(body)
+	if len(fields) == 0 {
+		return "", nil, false
+	}
+	keyword = fold(strings.TrimFunc(strings.Join(fields, " "), unicode.IsPunct))
+	if handler, ok = r.handlers[keyword]; ok {
+		return keyword, handler, true
+	}
+	keyword = fold(strings.TrimFunc(fields[0], unicode.IsPunct))
+	if handler, ok = r.prefixes[keyword]; ok {
+		return keyword, handler, true
+	}
+	return "", nil, false
+}
+
+func fold(keyword string) string {
+	return strings.NewReplacer("İ", "I", "ı", "I").Replace(strings.ToUpper(keyword))
+}
+
+type Suppressor interface {
+	Add(ctx context.Context, phoneNumber, reason, source string) (db.Suppression, error)
+	Remove(ctx context.Context, phoneNumber string) error
+}
+
+type Submitter interface {
+	Submit(req enqueue.Request) (db.Message, error)
+}
+
+// DefaultRouter routes suppression.stopKeywords to opt out, suppression.startKeywords to opt in
+// and every inbound.autoReplies keyword to its reply
+func DefaultRouter(suppressions Suppressor, messages Submitter) *Router {
+	router := NewRouter()
+	for _, keyword := range config.Cfg.Suppression.StopKeywords {
+		router.Handle(keyword, OptOut(suppressions))
+	}
+	for _, keyword := range config.Cfg.Suppression.StartKeywords {
+		router.Handle(keyword, OptIn(suppressions))
+	}
+	for keyword, reply := range config.Cfg.Inbound.AutoReplies {
+		router.Handle(keyword, AutoReply(messages, reply))
+	}
+	return router
+}
+
+// OptOut adds the sender to the suppression list
+func OptOut(suppressions Suppressor) KeywordHandler {
+	return func(ctx context.Context, msg db.InboundMessage) error {
+		_, err := suppressions.Add(ctx, msg.From, "replied "+msg.Body, suppression.SourceInbound)
+		return err
+	}
+}
+
+// OptIn lifts the suppression of the sender, a sender that was never suppressed is left alone
+func OptIn(suppressions Suppressor) KeywordHandler {
+	return func(ctx context.Context, msg db.InboundMessage) error {
+		err := suppressions.Remove(ctx, msg.From)
+		if errors.Is(err, repository.ErrSuppressionNotFound) {
+			return nil
+		}
+		return err
+	}
+}
+
+// AutoReply enqueues reply to the sender as a high priority message, the dedupe window keeps a
+// redelivered message from being answered twice
+func AutoReply(messages Submitter, reply string) KeywordHandler {
+	return func(ctx context.Context, msg db.InboundMessage) error {
+		_, err := messages.Submit(enqueue.Request{
+			PhoneNumber: msg.From,
+			Content:     reply,
+			Priority:    db.PriorityHigh,
+		})
+		return err
+	}
+}
@@ -0,0 +1,102 @@
+package inbound
+
+import (
+	"context"
+	"testing"
+
+	"github.com/atakurt/messagingApp/internal/features/enqueue"
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func setTestConfig() {
+	config.Cfg.Phone.DefaultRegion = "TR"
+	config.Cfg.Suppression.StopKeywords = []string{"STOP", "iptal"}
+	config.Cfg.Suppression.StartKeywords = []string{"START"}
+	config.Cfg.Inbound.AutoReplies = map[string]string{"help": "Reply STOP to unsubscribe."}
+}
+
+type suppressor struct {
+	added, removed []string
+	removeErr      error
+}
+
+func (s *suppressor) Add(_ context.Context, phoneNumber, reason, source string) (db.Suppression, error) {
+	s.added = append(s.added, phoneNumber+" "+reason+" "+source)
+	return db.Suppression{PhoneNumber: phoneNumber}, nil
+}
+
+func (s *suppressor) Remove(_ context.Context, phoneNumber string) error {
+	s.removed = append(s.removed, phoneNumber)
+	return s.removeErr
+}
+
+type submitter struct {
+	requests []enqueue.Request
+}
+
+func (s *submitter) Submit(req enqueue.Request) (db.Message, error) {
+	s.requests = append(s.requests, req)
+	return db.Message{}, nil
+}
+
+func TestRouterMatch(t *testing.T) {
+	router := NewRouter()
+	router.Handle("stop", func(context.Context, db.InboundMessage) error { return nil })
+	router.Handle("İptal", func(context.Context, db.InboundMessage) error { return nil })
+	router.HandlePrefix("info", func(context.Context, db.InboundMessage) error { return nil })
+
+	tests := []struct {
+		body    string
+		keyword string
+		ok      bool
+	}{
+		{"STOP", "STOP", true},
+		{"  stop! ", "STOP", true},
+		{"iptal", "IPTAL", true},
+		{"Stop by tomorrow?", "", false},
+		{"stopping", "", false},
+		{"please stop", "", false},
+		{"INFO", "INFO", true},
+		{"info, opening hours?", "INFO", true},
+		{"more info", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		keyword, _, ok := router.Match(tt.body)
+		assert.Equal(t, tt.keyword, keyword, tt.body)
+		assert.Equal(t, tt.ok, ok, tt.body)
+	}
+}
+
+func TestDefaultRouter(t *testing.T) {
+	setTestConfig()
+	ctx := context.Background()
+	suppressions := &suppressor{removeErr: repository.ErrSuppressionNotFound}
+	messages := &submitter{}
+	router := DefaultRouter(suppressions, messages)
+
+	run := func(body string) error {
+		_, handler, ok := router.Match(body)
+		assert.True(t, ok, body)
+		return handler(ctx, db.InboundMessage{From: "+905321234567", Body: body})
+	}
+
+	assert.NoError(t, run("İptal"))
+	assert.Equal(t, []string{"+905321234567 replied İptal inbound"}, suppressions.added)
+
+	// a START from a number that was never suppressed is not an error
+	assert.NoError(t, run("start"))
+	assert.Equal(t, []string{"+905321234567"}, suppressions.removed)
+
+	// only a message that is the keyword opts in or out
+	for _, body := range []string{"Stop by tomorrow?", "Start my order"} {
+		_, _, ok := router.Match(body)
+		assert.False(t, ok, body)
+	}
+
+	assert.NoError(t, run("HELP"))
+	assert.Equal(t, []enqueue.Request{{PhoneNumber: "+905321234567", Content: "Reply STOP to unsubscribe.", Priority: db.PriorityHigh}}, messages.requests)
+}
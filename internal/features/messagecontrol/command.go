@@ -0,0 +1,131 @@
+package messagecontrol
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	CommandsChannel = "scheduler:commands"
+	RepliesChannel  = "scheduler:replies"
+
+	CommandStart = "start"
+	CommandStop  = "stop"
+
+	// TargetAll addresses every instance listening on the commands channel
+	TargetAll = "all"
+
+	ComponentMain  = "main"
+	ComponentRetry = "retry"
+
+	AckStatusOK    = "ok"
+	AckStatusError = "error"
+)
+
+// Command is the envelope published on the commands channel
+type Command struct {
+	ID        string    `json:"command_id"`
+	Command   string    `json:"command"`
+	Target    string    `json:"target"`
+	Component string    `json:"component,omitempty"`
+	Issuer    string    `json:"issuer,omitempty"`
+	IssuedAt  time.Time `json:"issued_at"`
+}
+
+// Ack is published on the replies channel by every instance that executed a command
+type Ack struct {
+	CommandID  string   `json:"command_id"`
+	InstanceID string   `json:"instance_id"`
+	Command    string   `json:"command"`
+	Components []string `json:"components"`
+	Status     string   `json:"status"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// NewCommand builds a command with a fresh ID, defaulting the target to all instances
+func NewCommand(command, target, component, issuer string) Command {
+	if target == "" {
+		target = TargetAll
+	}
+	return Command{
+		ID:        newCommandID(),
+		Command:   command,
+		Target:    target,
+		Component: component,
+		Issuer:    issuer,
+		IssuedAt:  time.Now(),
+	}
+}
+
+func (c Command) Validate() error {
+	switch c.Command {
+	case CommandStart, CommandStop:
+	default:
+		return fmt.Errorf("unknown command %q", c.Command)
+	}
+
+	switch c.Component {
+	case "", ComponentMain, ComponentRetry:
+	default:
+		return fmt.Errorf("unknown component %q", c.Component)
+	}
+
+	return nil
+}
+
+// AppliesTo reports whether the instance with the given ID should execute the command
+func (c Command) AppliesTo(instanceID string) bool {
+	return c.Target == TargetAll || c.Target == instanceID
+}
+
+func newCommandID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+// CommandRequest selects the instances and components a control command applies to
+// @Description Target instance ID or "all" and component "main" or "retry" (empty for both)
+type CommandRequest struct {
+	Target    string `json:"target" example:"all"`
+	Component string `json:"component" example:"retry"`
+	Issuer    string `json:"issuer" example:"ops@example.com"`
+}
+
+// CommandResponse reports the published command and the acknowledgements received in time
+// @Description Published command and instance acknowledgements
+type CommandResponse struct {
+	Message   string `json:"message"`
+	CommandID string `json:"command_id"`
+	Target    string `json:"target"`
+	Component string `json:"component,omitempty"`
+	Acks      []Ack  `json:"acks"`
+}
+
+// ParseCommandRequest builds a command from the optional JSON body of a control request;
+// the issuer falls back to the X-Issuer header and then the client IP
+func ParseCommandRequest(c *fiber.Ctx, command string) (Command, error) {
+	var req CommandRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return Command{}, fmt.Errorf("invalid request body: %w", err)
+		}
+	}
+
+	issuer := req.Issuer
+	if issuer == "" {
+		issuer = c.Get("X-Issuer", c.IP())
+	}
+
+	cmd := NewCommand(command, req.Target, req.Component, issuer)
+	if err := cmd.Validate(); err != nil {
+		return Command{}, err
+	}
+	return cmd, nil
+}
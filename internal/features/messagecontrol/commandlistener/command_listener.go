@@ -2,11 +2,16 @@ package commandlistener
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/features/messagecontrol"
 	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
 	"github.com/atakurt/messagingApp/internal/infrastructure/redis"
 	"go.uber.org/zap"
-	"time"
 )
 
 //go:generate mockgen -destination=../../../mocks/mock_controllable.go -package=mocks github.com/atakurt/messagingApp/internal/features/messagecontrol/commandlistener Controllable
@@ -16,19 +21,23 @@ type Controllable interface {
 }
 
 type CommandListener struct {
-	redisClient *redis.RedisClient
-	targets     []Controllable
+	redisClient redis.Client
+	instanceID  string
+	targets     map[string]Controllable
 }
 
-func NewCommandListener(redisClient *redis.RedisClient, targets ...Controllable) *CommandListener {
+// NewCommandListener creates a listener that executes commands addressed to instanceID
+// on the named components in targets
+func NewCommandListener(redisClient redis.Client, instanceID string, targets map[string]Controllable) *CommandListener {
 	return &CommandListener{
 		redisClient: redisClient,
+		instanceID:  instanceID,
 		targets:     targets,
 	}
 }
 
 func (d *CommandListener) Listen(ctx context.Context) {
-	pubsub := d.redisClient.Subscribe(ctx, "scheduler:commands")
+	pubsub := d.redisClient.Subscribe(ctx, messagecontrol.CommandsChannel)
 	defer pubsub.Close()
 
 	for {
@@ -49,19 +58,91 @@ func (d *CommandListener) Listen(ctx context.Context) {
 			}
 
 			logger.Log.Info("Received scheduler command", zap.String("payload", msg.Payload))
+			d.handle(ctx, msg.Payload)
+		}
+	}
+}
 
-			switch msg.Payload {
-			case "start":
-				for _, t := range d.targets {
-					t.Start(ctx)
-				}
-			case "stop":
-				for _, t := range d.targets {
-					t.Stop(ctx)
-				}
-			default:
-				logger.Log.Warn("Unknown command", zap.String("command", msg.Payload))
-			}
+func (d *CommandListener) handle(ctx context.Context, payload string) {
+	var cmd messagecontrol.Command
+	if err := json.Unmarshal([]byte(payload), &cmd); err != nil {
+		logger.Log.Warn("Malformed command", zap.String("payload", payload), zap.Error(err))
+		return
+	}
+
+	if !cmd.AppliesTo(d.instanceID) {
+		return
+	}
+
+	ack := d.execute(ctx, cmd)
+	if err := d.reply(ctx, ack); err != nil {
+		logger.Log.Error("Failed to publish command ack", zap.String("commandID", cmd.ID), zap.Error(err))
+	}
+}
+
+func (d *CommandListener) execute(ctx context.Context, cmd messagecontrol.Command) messagecontrol.Ack {
+	ack := messagecontrol.Ack{
+		CommandID:  cmd.ID,
+		InstanceID: d.instanceID,
+		Command:    cmd.Command,
+		Components: []string{},
+		Status:     messagecontrol.AckStatusOK,
+	}
+
+	if err := cmd.Validate(); err != nil {
+		logger.Log.Warn("Unknown command", zap.String("command", cmd.Command), zap.String("component", cmd.Component))
+		ack.Status = messagecontrol.AckStatusError
+		ack.Error = err.Error()
+		return ack
+	}
+
+	components, err := d.resolve(cmd.Component)
+	if err != nil {
+		ack.Status = messagecontrol.AckStatusError
+		ack.Error = err.Error()
+		return ack
+	}
+
+	for _, name := range components {
+		switch cmd.Command {
+		case messagecontrol.CommandStart:
+			d.targets[name].Start(ctx)
+		case messagecontrol.CommandStop:
+			d.targets[name].Stop(ctx)
+		}
+	}
+
+	logger.Log.Info("Executed scheduler command",
+		zap.String("commandID", cmd.ID),
+		zap.String("command", cmd.Command),
+		zap.Strings("components", components),
+		zap.String("issuer", cmd.Issuer))
+
+	ack.Components = components
+	return ack
+}
+
+// resolve maps the command component to target names, an empty component addresses every target
+func (d *CommandListener) resolve(component string) ([]string, error) {
+	if component != "" {
+		if _, ok := d.targets[component]; !ok {
+			return nil, fmt.Errorf("component %q is not registered on this instance", component)
 		}
+		return []string{component}, nil
+	}
+
+	names := make([]string, 0, len(d.targets))
+	for name := range d.targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (d *CommandListener) reply(ctx context.Context, ack messagecontrol.Ack) error {
+	payload, err := json.Marshal(ack)
+	if err != nil {
+		return err
 	}
+	return d.redisClient.Publish(ctx, messagecontrol.RepliesChannel, string(payload))
 }
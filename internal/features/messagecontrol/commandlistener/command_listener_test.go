@@ -0,0 +1,112 @@
+package commandlistener
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/atakurt/messagingApp/internal/features/messagecontrol"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func encode(t *testing.T, v interface{}) string {
+	payload, err := json.Marshal(v)
+	assert.NoError(t, err)
+	return string(payload)
+}
+
+func expectAck(t *testing.T, mockRedis *mocks.MockRedisClient, check func(messagecontrol.Ack)) {
+	mockRedis.EXPECT().Publish(gomock.Any(), messagecontrol.RepliesChannel, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, payload interface{}) error {
+			var ack messagecontrol.Ack
+			assert.NoError(t, json.Unmarshal([]byte(payload.(string)), &ack))
+			check(ack)
+			return nil
+		})
+}
+
+func TestCommandListener_Handle(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctx := context.Background()
+
+	t.Run("Stop all components", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		mainTarget := mocks.NewMockControllable(ctrl)
+		retryTarget := mocks.NewMockControllable(ctrl)
+		listener := NewCommandListener(mockRedis, "pod-a", map[string]Controllable{"main": mainTarget, "retry": retryTarget})
+
+		cmd := messagecontrol.NewCommand(messagecontrol.CommandStop, messagecontrol.TargetAll, "", "ops")
+		mainTarget.EXPECT().Stop(gomock.Any())
+		retryTarget.EXPECT().Stop(gomock.Any())
+		expectAck(t, mockRedis, func(ack messagecontrol.Ack) {
+			assert.Equal(t, cmd.ID, ack.CommandID)
+			assert.Equal(t, "pod-a", ack.InstanceID)
+			assert.Equal(t, messagecontrol.AckStatusOK, ack.Status)
+			assert.Equal(t, []string{"main", "retry"}, ack.Components)
+		})
+
+		listener.handle(ctx, encode(t, cmd))
+	})
+
+	t.Run("Start only retry component", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		mainTarget := mocks.NewMockControllable(ctrl)
+		retryTarget := mocks.NewMockControllable(ctrl)
+		listener := NewCommandListener(mockRedis, "pod-a", map[string]Controllable{"main": mainTarget, "retry": retryTarget})
+
+		cmd := messagecontrol.NewCommand(messagecontrol.CommandStart, "pod-a", messagecontrol.ComponentRetry, "ops")
+		retryTarget.EXPECT().Start(gomock.Any())
+		expectAck(t, mockRedis, func(ack messagecontrol.Ack) {
+			assert.Equal(t, []string{"retry"}, ack.Components)
+		})
+
+		listener.handle(ctx, encode(t, cmd))
+	})
+
+	t.Run("Command for another instance is ignored", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		mainTarget := mocks.NewMockControllable(ctrl)
+		listener := NewCommandListener(mockRedis, "pod-a", map[string]Controllable{"main": mainTarget})
+
+		listener.handle(ctx, encode(t, messagecontrol.NewCommand(messagecontrol.CommandStop, "pod-b", "", "ops")))
+	})
+
+	t.Run("Unknown command is acknowledged with error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		mainTarget := mocks.NewMockControllable(ctrl)
+		listener := NewCommandListener(mockRedis, "pod-a", map[string]Controllable{"main": mainTarget})
+
+		expectAck(t, mockRedis, func(ack messagecontrol.Ack) {
+			assert.Equal(t, messagecontrol.AckStatusError, ack.Status)
+			assert.Equal(t, `unknown command "restart"`, ack.Error)
+		})
+
+		listener.handle(ctx, encode(t, messagecontrol.NewCommand("restart", "", "", "ops")))
+	})
+
+	t.Run("Malformed payload is dropped", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		listener := NewCommandListener(mockRedis, "pod-a", map[string]Controllable{})
+
+		listener.handle(ctx, "start")
+	})
+}
@@ -0,0 +1,107 @@
+package messagecontrol
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/instance"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
+	"go.uber.org/zap"
+)
+
+//go:generate mockgen -destination=../../mocks/mock_command_sender.go -package=mocks github.com/atakurt/messagingApp/internal/features/messagecontrol CommandSender
+type CommandSender interface {
+	Send(ctx context.Context, cmd Command) ([]Ack, error)
+}
+
+// InstanceLister is used to know how many acknowledgements to wait for when a command targets all instances
+type InstanceLister interface {
+	List(ctx context.Context) ([]instance.Info, error)
+}
+
+type Dispatcher struct {
+	redisClient redisClient.Client
+	instances   InstanceLister
+	ackTimeout  time.Duration
+}
+
+func NewDispatcher(redisClient redisClient.Client, instances InstanceLister, ackTimeout time.Duration) *Dispatcher {
+	return &Dispatcher{
+		redisClient: redisClient,
+		instances:   instances,
+		ackTimeout:  ackTimeout,
+	}
+}
+
+// Send publishes the command and collects acknowledgements until every expected
+// instance has answered or the ack timeout elapses
+func (d *Dispatcher) Send(ctx context.Context, cmd Command) ([]Ack, error) {
+	payload, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	// subscribe before publishing so fast replies are not lost, Subscribe returns before Redis
+	// confirmed the subscription so wait for the confirmation
+	replies := d.redisClient.Subscribe(ctx, RepliesChannel)
+	defer replies.Close()
+	if _, err := replies.Receive(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := d.redisClient.Publish(ctx, CommandsChannel, string(payload)); err != nil {
+		return nil, err
+	}
+
+	return d.collectAcks(ctx, replies, cmd.ID, d.expectedAcks(ctx, cmd)), nil
+}
+
+func (d *Dispatcher) expectedAcks(ctx context.Context, cmd Command) int {
+	if cmd.Target != TargetAll {
+		return 1
+	}
+	if d.instances == nil {
+		return 0
+	}
+
+	infos, err := d.instances.List(ctx)
+	if err != nil {
+		logger.Log.Warn("Failed to list instances, waiting for full ack timeout", zap.Error(err))
+		return 0
+	}
+
+	live := 0
+	for _, info := range infos {
+		if !info.Stale {
+			live++
+		}
+	}
+	return live
+}
+
+func (d *Dispatcher) collectAcks(ctx context.Context, replies redisClient.Subscription, commandID string, expected int) []Ack {
+	ackCtx, cancel := context.WithTimeout(ctx, d.ackTimeout)
+	defer cancel()
+
+	acks := make([]Ack, 0)
+	for expected == 0 || len(acks) < expected {
+		msg, err := replies.ReceiveMessage(ackCtx)
+		if err != nil {
+			break
+		}
+
+		var ack Ack
+		if err := json.Unmarshal([]byte(msg.Payload), &ack); err != nil {
+			logger.Log.Warn("Ignoring malformed command ack", zap.String("payload", msg.Payload), zap.Error(err))
+			continue
+		}
+		if ack.CommandID != commandID {
+			continue
+		}
+		acks = append(acks, ack)
+	}
+
+	return acks
+}
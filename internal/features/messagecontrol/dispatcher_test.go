@@ -0,0 +1,145 @@
+package messagecontrol_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/features/messagecontrol"
+	"github.com/atakurt/messagingApp/internal/infrastructure/instance"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	goRedis "github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func ackMessage(t *testing.T, ack messagecontrol.Ack) *goRedis.Message {
+	payload, err := json.Marshal(ack)
+	assert.NoError(t, err)
+	return &goRedis.Message{Channel: messagecontrol.RepliesChannel, Payload: string(payload)}
+}
+
+func TestCommand_Validate(t *testing.T) {
+	assert.NoError(t, messagecontrol.NewCommand(messagecontrol.CommandStart, "", "", "ops").Validate())
+	assert.NoError(t, messagecontrol.NewCommand(messagecontrol.CommandStop, "pod-a", messagecontrol.ComponentRetry, "ops").Validate())
+	assert.EqualError(t, messagecontrol.NewCommand("restart", "", "", "ops").Validate(), `unknown command "restart"`)
+	assert.EqualError(t, messagecontrol.NewCommand(messagecontrol.CommandStop, "", "reaper", "ops").Validate(), `unknown component "reaper"`)
+}
+
+func TestCommand_AppliesTo(t *testing.T) {
+	assert.True(t, messagecontrol.NewCommand(messagecontrol.CommandStart, "", "", "").AppliesTo("pod-a"))
+	assert.True(t, messagecontrol.NewCommand(messagecontrol.CommandStart, "pod-a", "", "").AppliesTo("pod-a"))
+	assert.False(t, messagecontrol.NewCommand(messagecontrol.CommandStart, "pod-b", "", "").AppliesTo("pod-a"))
+}
+
+func TestDispatcher_Send_SingleTarget(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	mockReplies := mocks.NewMockSubscription(ctrl)
+	cmd := messagecontrol.NewCommand(messagecontrol.CommandStop, "pod-a", messagecontrol.ComponentRetry, "ops")
+
+	mockRedis.EXPECT().Subscribe(gomock.Any(), messagecontrol.RepliesChannel).Return(mockReplies)
+	confirmed := mockReplies.EXPECT().Receive(gomock.Any()).Return(&goRedis.Subscription{Kind: "subscribe"}, nil)
+	mockRedis.EXPECT().Publish(gomock.Any(), messagecontrol.CommandsChannel, gomock.Any()).After(confirmed).
+		DoAndReturn(func(_ context.Context, _ string, payload interface{}) error {
+			var published messagecontrol.Command
+			assert.NoError(t, json.Unmarshal([]byte(payload.(string)), &published))
+			assert.Equal(t, cmd.ID, published.ID)
+			assert.Equal(t, messagecontrol.ComponentRetry, published.Component)
+			return nil
+		})
+	gomock.InOrder(
+		mockReplies.EXPECT().ReceiveMessage(gomock.Any()).Return(ackMessage(t, messagecontrol.Ack{CommandID: "other"}), nil),
+		mockReplies.EXPECT().ReceiveMessage(gomock.Any()).Return(ackMessage(t, messagecontrol.Ack{CommandID: cmd.ID, InstanceID: "pod-a", Status: messagecontrol.AckStatusOK}), nil),
+	)
+	mockReplies.EXPECT().Close().Return(nil)
+
+	dispatcher := messagecontrol.NewDispatcher(mockRedis, nil, time.Second)
+	acks, err := dispatcher.Send(context.Background(), cmd)
+
+	assert.NoError(t, err)
+	assert.Len(t, acks, 1)
+	assert.Equal(t, "pod-a", acks[0].InstanceID)
+}
+
+func TestDispatcher_Send_AllWaitsForLiveInstances(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	mockReplies := mocks.NewMockSubscription(ctrl)
+	mockInstances := mocks.NewMockInstanceLister(ctrl)
+	cmd := messagecontrol.NewCommand(messagecontrol.CommandStart, "", "", "ops")
+
+	mockRedis.EXPECT().Subscribe(gomock.Any(), messagecontrol.RepliesChannel).Return(mockReplies)
+	mockReplies.EXPECT().Receive(gomock.Any()).Return(&goRedis.Subscription{Kind: "subscribe"}, nil)
+	mockRedis.EXPECT().Publish(gomock.Any(), messagecontrol.CommandsChannel, gomock.Any()).Return(nil)
+	mockInstances.EXPECT().List(gomock.Any()).Return([]instance.Info{
+		{ID: "pod-a"}, {ID: "pod-b"}, {ID: "pod-c", Stale: true},
+	}, nil)
+	gomock.InOrder(
+		mockReplies.EXPECT().ReceiveMessage(gomock.Any()).Return(ackMessage(t, messagecontrol.Ack{CommandID: cmd.ID, InstanceID: "pod-a"}), nil),
+		mockReplies.EXPECT().ReceiveMessage(gomock.Any()).Return(ackMessage(t, messagecontrol.Ack{CommandID: cmd.ID, InstanceID: "pod-b"}), nil),
+	)
+	mockReplies.EXPECT().Close().Return(nil)
+
+	dispatcher := messagecontrol.NewDispatcher(mockRedis, mockInstances, time.Second)
+	acks, err := dispatcher.Send(context.Background(), cmd)
+
+	assert.NoError(t, err)
+	assert.Len(t, acks, 2)
+}
+
+func TestDispatcher_Send_TimeoutReturnsPartialAcks(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	mockReplies := mocks.NewMockSubscription(ctrl)
+	cmd := messagecontrol.NewCommand(messagecontrol.CommandStart, "pod-a", "", "ops")
+
+	mockRedis.EXPECT().Subscribe(gomock.Any(), messagecontrol.RepliesChannel).Return(mockReplies)
+	mockReplies.EXPECT().Receive(gomock.Any()).Return(&goRedis.Subscription{Kind: "subscribe"}, nil)
+	mockRedis.EXPECT().Publish(gomock.Any(), messagecontrol.CommandsChannel, gomock.Any()).Return(nil)
+	mockReplies.EXPECT().ReceiveMessage(gomock.Any()).
+		DoAndReturn(func(ctx context.Context) (*goRedis.Message, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+	mockReplies.EXPECT().Close().Return(nil)
+
+	dispatcher := messagecontrol.NewDispatcher(mockRedis, nil, 50*time.Millisecond)
+	acks, err := dispatcher.Send(context.Background(), cmd)
+
+	assert.NoError(t, err)
+	assert.Empty(t, acks)
+}
+
+func TestDispatcher_Send_FailsWithoutSubscription(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	mockReplies := mocks.NewMockSubscription(ctrl)
+	cmd := messagecontrol.NewCommand(messagecontrol.CommandStart, "pod-a", "", "ops")
+
+	mockRedis.EXPECT().Subscribe(gomock.Any(), messagecontrol.RepliesChannel).Return(mockReplies)
+	mockReplies.EXPECT().Receive(gomock.Any()).Return(nil, errors.New("connection refused"))
+	mockReplies.EXPECT().Close().Return(nil)
+
+	dispatcher := messagecontrol.NewDispatcher(mockRedis, nil, time.Second)
+	acks, err := dispatcher.Send(context.Background(), cmd)
+
+	assert.Error(t, err, "the command is not published without a subscription for its acks")
+	assert.Nil(t, acks)
+}
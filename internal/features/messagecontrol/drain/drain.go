@@ -0,0 +1,66 @@
+package drain
+
+import (
+	"context"
+
+	drainCoordinator "github.com/atakurt/messagingApp/internal/infrastructure/drain"
+	"github.com/gofiber/fiber/v2"
+)
+
+//go:generate mockgen -destination=../../../mocks/mock_drain_controller.go -package=mocks github.com/atakurt/messagingApp/internal/features/messagecontrol/drain DrainController
+type DrainController interface {
+	Start() bool
+	Wait(ctx context.Context) drainCoordinator.Progress
+	Progress() drainCoordinator.Progress
+}
+
+type DrainService struct {
+	coordinator DrainController
+}
+
+func NewService(coordinator DrainController) *DrainService {
+	return &DrainService{
+		coordinator: coordinator,
+	}
+}
+
+// StartDrain godoc
+// @Summary      Drain this instance
+// @Description  Stops the schedulers on this instance from starting new messages and lets in-flight sends finish before shutdown. Draining is one-way, use stop/start to pause and resume. With wait=true the request blocks until the drain finishes or times out.
+// @Tags         Scheduler
+// @Produce      json
+// @Param        wait  query     bool  false  "Block until the drain finishes"
+// @Success      200   {object}  drainCoordinator.Progress
+// @Success      202   {object}  drainCoordinator.Progress
+// @Router       /drain [post]
+func (s *DrainService) StartDrain(c *fiber.Ctx) error {
+	s.coordinator.Start()
+
+	if c.QueryBool("wait") {
+		return c.JSON(s.coordinator.Wait(c.Context()))
+	}
+	return c.Status(fiber.StatusAccepted).JSON(s.coordinator.Progress())
+}
+
+// GetDrain godoc
+// @Summary      Drain progress
+// @Description  Reports whether this instance is draining and how many messages are still in flight per scheduler
+// @Tags         Scheduler
+// @Produce      json
+// @Success      200  {object}  drainCoordinator.Progress
+// @Router       /drain [get]
+func (s *DrainService) GetDrain(c *fiber.Ctx) error {
+	return c.JSON(s.coordinator.Progress())
+}
+
+// PreStop godoc
+// @Summary      Kubernetes preStop hook
+// @Description  Starts the drain and blocks until it finishes or times out; intended for a preStop httpGet hook, which cannot send a POST
+// @Tags         Scheduler
+// @Produce      json
+// @Success      200  {object}  drainCoordinator.Progress
+// @Router       /drain/prestop [get]
+func (s *DrainService) PreStop(c *fiber.Ctx) error {
+	s.coordinator.Start()
+	return c.JSON(s.coordinator.Wait(c.Context()))
+}
@@ -0,0 +1,97 @@
+package drain
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	drainCoordinator "github.com/atakurt/messagingApp/internal/infrastructure/drain"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainHandlers(t *testing.T) {
+	draining := drainCoordinator.Progress{State: drainCoordinator.StateDraining, InFlight: 2}
+	drained := drainCoordinator.Progress{State: drainCoordinator.StateDrained}
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		setupMock      func(*mocks.MockDrainController)
+		expectedStatus int
+		expectedState  drainCoordinator.State
+	}{
+		{
+			name:   "Start drain without waiting",
+			method: fiber.MethodPost,
+			url:    "/drain",
+			setupMock: func(m *mocks.MockDrainController) {
+				m.EXPECT().Start().Return(true)
+				m.EXPECT().Progress().Return(draining)
+			},
+			expectedStatus: fiber.StatusAccepted,
+			expectedState:  drainCoordinator.StateDraining,
+		},
+		{
+			name:   "Start drain and wait",
+			method: fiber.MethodPost,
+			url:    "/drain?wait=true",
+			setupMock: func(m *mocks.MockDrainController) {
+				m.EXPECT().Start().Return(false)
+				m.EXPECT().Wait(gomock.Any()).Return(drained)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedState:  drainCoordinator.StateDrained,
+		},
+		{
+			name:   "Get progress",
+			method: fiber.MethodGet,
+			url:    "/drain",
+			setupMock: func(m *mocks.MockDrainController) {
+				m.EXPECT().Progress().Return(draining)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedState:  drainCoordinator.StateDraining,
+		},
+		{
+			name:   "PreStop drains and waits",
+			method: fiber.MethodGet,
+			url:    "/drain/prestop",
+			setupMock: func(m *mocks.MockDrainController) {
+				m.EXPECT().Start().Return(true)
+				m.EXPECT().Wait(gomock.Any()).Return(drained)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedState:  drainCoordinator.StateDrained,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockController := mocks.NewMockDrainController(ctrl)
+			tt.setupMock(mockController)
+			service := NewService(mockController)
+
+			app := fiber.New()
+			app.Post("/drain", service.StartDrain)
+			app.Get("/drain", service.GetDrain)
+			app.Get("/drain/prestop", service.PreStop)
+
+			resp, err := app.Test(httptest.NewRequest(tt.method, tt.url, nil))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			body, _ := io.ReadAll(resp.Body)
+			var progress drainCoordinator.Progress
+			assert.NoError(t, json.Unmarshal(body, &progress))
+			assert.Equal(t, tt.expectedState, progress.State)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package instances
+
+import (
+	"context"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/instance"
+	"github.com/gofiber/fiber/v2"
+)
+
+//go:generate mockgen -destination=../../../mocks/mock_instance_lister.go -package=mocks github.com/atakurt/messagingApp/internal/features/messagecontrol/instances InstanceLister
+type InstanceLister interface {
+	List(ctx context.Context) ([]instance.Info, error)
+}
+
+type ListInstancesService struct {
+	registry InstanceLister
+}
+
+func NewService(registry InstanceLister) *ListInstancesService {
+	return &ListInstancesService{
+		registry: registry,
+	}
+}
+
+// ListResponse represents the registered instances
+// @Description Live and stale application instances
+type ListResponse struct {
+	Live  int             `json:"live"`
+	Stale int             `json:"stale"`
+	Data  []instance.Info `json:"data"`
+}
+
+// ListInstances godoc
+// @Summary      List application instances
+// @Description  Lists instances that have a heartbeat in Redis with their scheduler state; instances that missed recent heartbeats are flagged as stale
+// @Tags         Scheduler
+// @Produce      json
+// @Success      200  {object}  ListResponse
+// @Failure      500  {object}  map[string]string
+// @Router       /instances [get]
+func (s *ListInstancesService) ListInstances(c *fiber.Ctx) error {
+	infos, err := s.registry.List(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve instances",
+		})
+	}
+
+	response := ListResponse{Data: infos}
+	for _, info := range infos {
+		if info.Stale {
+			response.Stale++
+		} else {
+			response.Live++
+		}
+	}
+
+	return c.JSON(response)
+}
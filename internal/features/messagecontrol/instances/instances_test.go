@@ -0,0 +1,76 @@
+package instances
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/instance"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListInstances(t *testing.T) {
+	tests := []struct {
+		name           string
+		setupMock      func(*gomock.Controller) *mocks.MockInstanceLister
+		expectedStatus int
+		expectedLive   int
+		expectedStale  int
+	}{
+		{
+			name: "Live and stale instances",
+			setupMock: func(ctrl *gomock.Controller) *mocks.MockInstanceLister {
+				mockLister := mocks.NewMockInstanceLister(ctrl)
+				mockLister.EXPECT().List(gomock.Any()).Return([]instance.Info{
+					{ID: "pod-a"},
+					{ID: "pod-b"},
+					{ID: "pod-c", Stale: true},
+				}, nil)
+				return mockLister
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedLive:   2,
+			expectedStale:  1,
+		},
+		{
+			name: "Registry error",
+			setupMock: func(ctrl *gomock.Controller) *mocks.MockInstanceLister {
+				mockLister := mocks.NewMockInstanceLister(ctrl)
+				mockLister.EXPECT().List(gomock.Any()).Return(nil, errors.New("redis down"))
+				return mockLister
+			},
+			expectedStatus: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := NewService(tt.setupMock(ctrl))
+
+			app := fiber.New()
+			app.Get("/instances", service.ListInstances)
+
+			resp, err := app.Test(httptest.NewRequest("GET", "/instances", nil))
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			if tt.expectedStatus != fiber.StatusOK {
+				return
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			var response ListResponse
+			assert.NoError(t, json.Unmarshal(body, &response))
+			assert.Equal(t, tt.expectedLive, response.Live)
+			assert.Equal(t, tt.expectedStale, response.Stale)
+		})
+	}
+}
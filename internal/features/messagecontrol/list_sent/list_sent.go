@@ -1,9 +1,11 @@
 package list_sent
 
 import (
-	"github.com/atakurt/messagingApp/internal/infrastructure/db"
 	"time"
 
+	"github.com/atakurt/messagingApp/internal/features/tenants"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -12,7 +14,7 @@ type ListSentServiceInterface interface {
 }
 
 type MessageRepositoryInterface interface {
-	GetSentMessages(lastID, limit int) ([]db.Message, error)
+	GetSentMessages(tenantID uint, lastID, limit int) ([]db.Message, error)
 }
 
 type ListSentService struct {
@@ -48,7 +50,7 @@ type ListResponse struct {
 
 // ListSentMessages godoc
 // @Summary      List sent messages
-// @Description  Retrieves all messages that have been marked as sent using offset-based pagination
+// @Description  Retrieves the messages of the tenant that have been marked as sent using offset-based pagination
 // @Tags         Messages
 // @Produce      json
 // @Param        last_id  query     int  false  "Only return messages with ID > last_id"
@@ -60,7 +62,7 @@ func (l *ListSentService) ListSentMessages(c *fiber.Ctx) error {
 	lastID := c.QueryInt("last_id", 0)
 	limit := parseLimit(c.QueryInt("limit", 0), 10, 100)
 
-	messages, err := l.repository.GetSentMessages(lastID, limit)
+	messages, err := l.repository.GetSentMessages(tenants.From(c).ID, lastID, limit)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to retrieve messages",
@@ -60,7 +60,7 @@ func TestListSentMessages(t *testing.T) {
 						CreatedAt:   time.Now(),
 					},
 				}
-				mockRepo.EXPECT().GetSentMessages(0, 10).Return(messages, nil)
+				mockRepo.EXPECT().GetSentMessages(db.DefaultTenantID, 0, 10).Return(messages, nil)
 				return mockRepo
 			},
 			expectedStatus: fiber.StatusOK,
@@ -71,7 +71,7 @@ func TestListSentMessages(t *testing.T) {
 			limit:  10,
 			setupMock: func(ctrl *gomock.Controller) *mocks.MockMessageRepositoryInterface {
 				mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
-				mockRepo.EXPECT().GetSentMessages(0, 10).Return([]db.Message{}, nil)
+				mockRepo.EXPECT().GetSentMessages(db.DefaultTenantID, 0, 10).Return([]db.Message{}, nil)
 				return mockRepo
 			},
 			expectedStatus: fiber.StatusOK,
@@ -82,7 +82,7 @@ func TestListSentMessages(t *testing.T) {
 			limit:  10,
 			setupMock: func(ctrl *gomock.Controller) *mocks.MockMessageRepositoryInterface {
 				mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
-				mockRepo.EXPECT().GetSentMessages(0, 10).Return([]db.Message{}, errors.New("database error"))
+				mockRepo.EXPECT().GetSentMessages(db.DefaultTenantID, 0, 10).Return([]db.Message{}, errors.New("database error"))
 				return mockRepo
 			},
 			expectedStatus: fiber.StatusInternalServerError,
@@ -93,7 +93,7 @@ func TestListSentMessages(t *testing.T) {
 			limit:  20,
 			setupMock: func(ctrl *gomock.Controller) *mocks.MockMessageRepositoryInterface {
 				mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
-				mockRepo.EXPECT().GetSentMessages(5, 20).Return([]db.Message{}, nil)
+				mockRepo.EXPECT().GetSentMessages(db.DefaultTenantID, 5, 20).Return([]db.Message{}, nil)
 				return mockRepo
 			},
 			expectedStatus: fiber.StatusOK,
@@ -2,18 +2,29 @@ package start
 
 import (
 	"github.com/atakurt/messagingApp/internal/features/messagecontrol"
-	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
-	"github.com/atakurt/messagingApp/internal/infrastructure/scheduler"
 	"github.com/gofiber/fiber/v2"
 )
 
 // StartHandler godoc
 // @Summary Start automatic message sending
+// @Description Publishes a start command to every instance or a single instance, optionally limited to the main or retry scheduler, and returns the acknowledgements received
 // @Tags Scheduler
-// @Success 200 {string} string "Scheduler started"
+// @Accept json
+// @Produce json
+// @Param request body messagecontrol.CommandRequest false "Command target"
+// @Success 200 {object} messagecontrol.CommandResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /start [post]
-func StartHandler(ctx *fiber.Ctx, redisClient redisClient.Client) error {
-	err := scheduler.PublishCommand(ctx.Context(), redisClient, "start")
+func StartHandler(ctx *fiber.Ctx, sender messagecontrol.CommandSender) error {
+	cmd, err := messagecontrol.ParseCommandRequest(ctx, messagecontrol.CommandStart)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	acks, err := sender.Send(ctx.Context(), cmd)
 	if err != nil {
 		schedulerErr := &messagecontrol.SchedulerError{
 			Operation: "start",
@@ -24,7 +35,11 @@ func StartHandler(ctx *fiber.Ctx, redisClient redisClient.Client) error {
 		})
 	}
 
-	return ctx.JSON(fiber.Map{
-		"message": "Start command sent to all scheduler instances",
+	return ctx.JSON(messagecontrol.CommandResponse{
+		Message:   "Start command sent to scheduler instances",
+		CommandID: cmd.ID,
+		Target:    cmd.Target,
+		Component: cmd.Component,
+		Acks:      acks,
 	})
 }
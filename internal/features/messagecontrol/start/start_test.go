@@ -1,10 +1,11 @@
 package start
 
 import (
+	"encoding/json"
 	"errors"
-	"github.com/atakurt/messagingApp/internal/features/messagecontrol"
 	"testing"
 
+	"github.com/atakurt/messagingApp/internal/features/messagecontrol"
 	"github.com/atakurt/messagingApp/internal/mocks"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang/mock/gomock"
@@ -27,30 +28,71 @@ func TestSchedulerError(t *testing.T) {
 
 func TestStartHandler(t *testing.T) {
 	tests := []struct {
-		name           string
-		setupMock      func(*gomock.Controller) *mocks.MockRedisClient
-		expectedStatus int
-		expectedBody   string
+		name              string
+		body              string
+		setupMock         func(*gomock.Controller) *mocks.MockCommandSender
+		expectedStatus    int
+		expectedTarget    string
+		expectedComponent string
+		expectedAcks      int
+		expectedError     string
 	}{
 		{
-			name: "Success",
-			setupMock: func(ctrl *gomock.Controller) *mocks.MockRedisClient {
-				mockRedis := mocks.NewMockRedisClient(ctrl)
-				mockRedis.EXPECT().Publish(gomock.Any(), "scheduler:commands", "start").Return(nil)
-				return mockRedis
+			name: "Success for all instances",
+			setupMock: func(ctrl *gomock.Controller) *mocks.MockCommandSender {
+				mockSender := mocks.NewMockCommandSender(ctrl)
+				mockSender.EXPECT().Send(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ interface{}, cmd messagecontrol.Command) ([]messagecontrol.Ack, error) {
+						assert.Equal(t, messagecontrol.CommandStart, cmd.Command)
+						assert.NotEmpty(t, cmd.ID)
+						return []messagecontrol.Ack{
+							{CommandID: cmd.ID, InstanceID: "pod-a", Status: messagecontrol.AckStatusOK},
+							{CommandID: cmd.ID, InstanceID: "pod-b", Status: messagecontrol.AckStatusOK},
+						}, nil
+					})
+				return mockSender
 			},
 			expectedStatus: fiber.StatusOK,
-			expectedBody:   `{"message":"Start command sent to all scheduler instances"}`,
+			expectedTarget: messagecontrol.TargetAll,
+			expectedAcks:   2,
+		},
+		{
+			name: "Success for retry scheduler of one instance",
+			body: `{"target":"pod-a","component":"retry","issuer":"ops"}`,
+			setupMock: func(ctrl *gomock.Controller) *mocks.MockCommandSender {
+				mockSender := mocks.NewMockCommandSender(ctrl)
+				mockSender.EXPECT().Send(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ interface{}, cmd messagecontrol.Command) ([]messagecontrol.Ack, error) {
+						assert.Equal(t, "ops", cmd.Issuer)
+						return []messagecontrol.Ack{
+							{CommandID: cmd.ID, InstanceID: "pod-a", Components: []string{"retry"}, Status: messagecontrol.AckStatusOK},
+						}, nil
+					})
+				return mockSender
+			},
+			expectedStatus:    fiber.StatusOK,
+			expectedTarget:    "pod-a",
+			expectedComponent: messagecontrol.ComponentRetry,
+			expectedAcks:      1,
+		},
+		{
+			name: "Unknown component",
+			body: `{"component":"reaper"}`,
+			setupMock: func(ctrl *gomock.Controller) *mocks.MockCommandSender {
+				return mocks.NewMockCommandSender(ctrl)
+			},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedError:  `unknown component "reaper"`,
 		},
 		{
 			name: "Redis error",
-			setupMock: func(ctrl *gomock.Controller) *mocks.MockRedisClient {
-				mockRedis := mocks.NewMockRedisClient(ctrl)
-				mockRedis.EXPECT().Publish(gomock.Any(), "scheduler:commands", "start").Return(errors.New("redis connection failed"))
-				return mockRedis
+			setupMock: func(ctrl *gomock.Controller) *mocks.MockCommandSender {
+				mockSender := mocks.NewMockCommandSender(ctrl)
+				mockSender.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil, errors.New("redis connection failed"))
+				return mockSender
 			},
 			expectedStatus: fiber.StatusInternalServerError,
-			expectedBody:   `{"error":"scheduler operation 'start' failed: redis connection failed"}`,
+			expectedError:  "scheduler operation 'start' failed: redis connection failed",
 		},
 	}
 
@@ -59,18 +101,37 @@ func TestStartHandler(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			mockRedis := tt.setupMock(ctrl)
+			mockSender := tt.setupMock(ctrl)
 
 			app := fiber.New()
 			ctx := app.AcquireCtx(&fasthttp.RequestCtx{})
 			defer app.ReleaseCtx(ctx)
 
-			StartHandler(ctx, mockRedis)
+			if tt.body != "" {
+				ctx.Request().Header.SetContentType(fiber.MIMEApplicationJSON)
+				ctx.Request().SetBodyString(tt.body)
+			}
+
+			StartHandler(ctx, mockSender)
 
 			assert.Equal(t, tt.expectedStatus, ctx.Response().StatusCode())
 
-			responseBody := string(ctx.Response().Body())
-			assert.JSONEq(t, tt.expectedBody, responseBody)
+			if tt.expectedError != "" {
+				assert.JSONEq(t, `{"error":`+quote(tt.expectedError)+`}`, string(ctx.Response().Body()))
+				return
+			}
+
+			var response messagecontrol.CommandResponse
+			assert.NoError(t, json.Unmarshal(ctx.Response().Body(), &response))
+			assert.NotEmpty(t, response.CommandID)
+			assert.Equal(t, tt.expectedTarget, response.Target)
+			assert.Equal(t, tt.expectedComponent, response.Component)
+			assert.Len(t, response.Acks, tt.expectedAcks)
 		})
 	}
 }
+
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
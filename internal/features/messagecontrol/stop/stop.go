@@ -2,18 +2,29 @@ package stop
 
 import (
 	"github.com/atakurt/messagingApp/internal/features/messagecontrol"
-	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
-	"github.com/atakurt/messagingApp/internal/infrastructure/scheduler"
 	"github.com/gofiber/fiber/v2"
 )
 
 // StopHandler godoc
 // @Summary Stop automatic message sending
+// @Description Publishes a stop command to every instance or a single instance, optionally limited to the main or retry scheduler, and returns the acknowledgements received
 // @Tags Scheduler
-// @Success 200 {string} string "Scheduler stopped"
+// @Accept json
+// @Produce json
+// @Param request body messagecontrol.CommandRequest false "Command target"
+// @Success 200 {object} messagecontrol.CommandResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /stop [post]
-func StopHandler(ctx *fiber.Ctx, redisClient redisClient.Client) error {
-	err := scheduler.PublishCommand(ctx.Context(), redisClient, "stop")
+func StopHandler(ctx *fiber.Ctx, sender messagecontrol.CommandSender) error {
+	cmd, err := messagecontrol.ParseCommandRequest(ctx, messagecontrol.CommandStop)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	acks, err := sender.Send(ctx.Context(), cmd)
 	if err != nil {
 		schedulerErr := &messagecontrol.SchedulerError{
 			Operation: "stop",
@@ -24,7 +35,11 @@ func StopHandler(ctx *fiber.Ctx, redisClient redisClient.Client) error {
 		})
 	}
 
-	return ctx.JSON(fiber.Map{
-		"message": "Stop command sent to all scheduler instances",
+	return ctx.JSON(messagecontrol.CommandResponse{
+		Message:   "Stop command sent to scheduler instances",
+		CommandID: cmd.ID,
+		Target:    cmd.Target,
+		Component: cmd.Component,
+		Acks:      acks,
 	})
 }
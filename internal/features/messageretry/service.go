@@ -4,17 +4,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"gorm.io/gorm"
-
 	"github.com/atakurt/messagingApp/internal/features/sendmessages"
 	"github.com/atakurt/messagingApp/internal/infrastructure/config"
 	"github.com/atakurt/messagingApp/internal/infrastructure/db"
 	httpClient "github.com/atakurt/messagingApp/internal/infrastructure/http"
 	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/infrastructure/metrics"
+	"github.com/atakurt/messagingApp/internal/infrastructure/pricing"
+	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
 	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
 	"github.com/cenkalti/backoff/v5"
 	"go.uber.org/zap"
@@ -22,71 +25,106 @@ import (
 
 //go:generate mockgen -source=service.go -destination=../../mocks/message_retry_service_mock.go -package=mocks MessageRetryServiceInterface
 type MessageRetryServiceInterface interface {
-	ProcessMessageRetries(ctx context.Context)
+	// ProcessMessageRetries processes one batch of retries and returns the number fetched.
+	// Cancelling ctx stops starting new retries and aborts retries still waiting in backoff.
+	ProcessMessageRetries(ctx context.Context) int
+	// InFlight returns the number of retries currently being processed
+	InFlight() int
+}
+
+type Option func(*MessageRetryService)
+
+func WithSuppressionChecker(checker sendmessages.SuppressionChecker) Option {
+	return func(s *MessageRetryService) {
+		s.suppressions = checker
+	}
+}
+
+func WithBudget(budget sendmessages.BudgetChecker) Option {
+	return func(s *MessageRetryService) {
+		s.budget = budget
+	}
+}
+
+func WithTenants(tenants sendmessages.TenantLookup) Option {
+	return func(s *MessageRetryService) {
+		s.tenants = tenants
+	}
 }
 
 type MessageRetryService struct {
 	repository repository.MessageRepositoryInterface
 	httpClient httpClient.Client
+	// redisClient holds the sent markers shared with the send flow
+	redisClient redisClient.Client
+	// suppressions is optional, without it every recipient is retried
+	suppressions sendmessages.SuppressionChecker
+	// budget is optional, without it retrying is never paused for spend
+	budget sendmessages.BudgetChecker
+	// tenants is optional, without it every retry goes to webhookUrl
+	tenants  sendmessages.TenantLookup
+	inFlight atomic.Int64
 }
 
-func NewService(repository repository.MessageRepositoryInterface, httpClient httpClient.Client) *MessageRetryService {
-	return &MessageRetryService{
-		repository: repository,
-		httpClient: httpClient,
+func NewService(repository repository.MessageRepositoryInterface, httpClient httpClient.Client, redisClient redisClient.Client, opts ...Option) *MessageRetryService {
+	s := &MessageRetryService{
+		repository:  repository,
+		httpClient:  httpClient,
+		redisClient: redisClient,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func (s *MessageRetryService) ProcessMessageRetries(ctx context.Context) {
-	tx, err := s.beginTransaction()
-	if err != nil {
-		return
+// ProcessMessageRetries runs the pipeline of the send flow for one batch of retries: claim leases
+// the retries in a short statement, send calls the webhook outside any transaction, and record
+// stores each outcome in its own short transaction guarded by the lease
+func (s *MessageRetryService) ProcessMessageRetries(ctx context.Context) int {
+	if ctx.Err() != nil {
+		return 0
 	}
-	defer func() {
-		_ = tx.Rollback()
-	}()
 
-	retries, err := s.fetchPendingRetries(tx)
+	retries, err := s.claimRetries()
 	if err != nil {
-		return
+		return 0
 	}
 
-	logger.Log.Info("Found message retries", zap.Int("count", len(retries)))
+	logger.Log.Info("Claimed message retries", zap.Int("count", len(retries)))
 	if len(retries) == 0 {
-		return
+		return 0
 	}
 
 	// Process retries concurrently
-	processedCount := s.processRetriesConcurrently(ctx, tx, retries)
+	processedCount := s.processRetriesConcurrently(ctx, retries)
 
 	logger.Log.Info("Processed retries", zap.Int("count", processedCount))
+	return len(retries)
+}
 
-	// Commit the transaction
-	if err := tx.Commit().Error; err != nil {
-		logger.Log.Error("Failed to commit transaction", zap.Error(err))
-	}
+func (s *MessageRetryService) InFlight() int {
+	return int(s.inFlight.Load())
 }
 
-func (s *MessageRetryService) beginTransaction() (*db.Transaction, error) {
-	tx := s.repository.GetDB().Begin()
-	if tx.Error != nil {
-		logger.Log.Error("Failed to begin transaction", zap.Error(tx.Error))
-		return nil, tx.Error
+func (s *MessageRetryService) claimRetries() ([]db.MessageRetry, error) {
+	// over budget only critical messages are retried like they are sent, the others wait for the next period
+	maxPriority := db.PriorityBulk
+	if s.budget != nil && s.budget.Exceeded() {
+		maxPriority = db.PriorityCritical
 	}
-	return tx, nil
-}
 
-func (s *MessageRetryService) fetchPendingRetries(tx *gorm.DB) ([]db.MessageRetry, error) {
-	retries, err := s.repository.GetMessageRetries(tx, config.Cfg.Scheduler.BatchSize)
+	leaseUntil := time.Now().Add(config.Cfg.Scheduler.LeaseDuration)
+	retries, err := s.repository.ClaimRetries(config.Cfg.Instance.ID, leaseUntil, config.Cfg.Scheduler.BatchSize, maxPriority)
 	if err != nil {
-		logger.Log.Error("Failed to select message retries with locking", zap.Error(err))
+		logger.Log.Error("Failed to claim message retries", zap.Error(err))
 		return nil, err
 	}
 	return retries, nil
 }
 
 // processRetriesConcurrently processes retries in parallel using goroutines
-func (s *MessageRetryService) processRetriesConcurrently(ctx context.Context, tx *db.Transaction, retries []db.MessageRetry) int {
+func (s *MessageRetryService) processRetriesConcurrently(ctx context.Context, retries []db.MessageRetry) int {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	processedRetries := 0
@@ -95,20 +133,34 @@ func (s *MessageRetryService) processRetriesConcurrently(ctx context.Context, tx
 	maxConcurrent := config.Cfg.Scheduler.MaxRetryConcurrent
 	semaphore := make(chan struct{}, maxConcurrent)
 
-	for _, retry := range retries {
+	for i, retry := range retries {
+		// untouched retries keep their count and are released for the next batch
+		if ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+			case semaphore <- struct{}{}:
+			}
+		}
+		if ctx.Err() != nil {
+			logger.Log.Info("Retry batch cancelled, releasing remaining retries", zap.Int("count", len(retries)-i))
+			s.releaseRetries(retries[i:])
+			break
+		}
+
 		wg.Add(1)
-		semaphore <- struct{}{}
+		s.inFlight.Add(1)
 
 		retryCopy := retry
 
 		go func(retry db.MessageRetry) {
 			defer func() {
 				<-semaphore
+				s.inFlight.Add(-1)
 				wg.Done()
 			}()
 
 			// Process the retry and track if successful
-			if s.processRetry(ctx, tx, &retry, &mu) {
+			if s.processRetry(ctx, &retry) {
 				mu.Lock()
 				processedRetries++
 				mu.Unlock()
@@ -121,25 +173,28 @@ func (s *MessageRetryService) processRetriesConcurrently(ctx context.Context, tx
 	return processedRetries
 }
 
-func (s *MessageRetryService) processRetry(ctx context.Context, tx *db.Transaction, retry *db.MessageRetry, mu *sync.Mutex) bool {
-	// Increment retry count
-	newRetryCount := retry.RetryCount + 1
-	if newRetryCount > 5 {
-		msg := db.Message{
-			ID:          retry.OriginalMessageID,
-			PhoneNumber: retry.PhoneNumber,
-			Content:     retry.Content,
+func (s *MessageRetryService) processRetry(ctx context.Context, retry *db.MessageRetry) bool {
+	// a previous owner delivered the retry but could not record it
+	if messageID, sent := s.sentMarker(ctx, retry.OriginalMessageID); sent {
+		logger.Log.Warn("Message retry already sent, recording it", zap.Uint("retryID", retry.ID), zap.Uint("originalMessageID", retry.OriginalMessageID))
+		tenant, err := s.tenant(ctx, retry)
+		if err != nil {
+			logger.Log.Warn("Failed to look up tenant, pricing with the default provider", zap.Uint("retryID", retry.ID), zap.Error(err))
+			tenant = db.Tenant{ID: retry.TenantID}
 		}
+		_, provider := sendmessages.Route(tenant)
+		return s.record(retry, "sent", s.repository.RecordRetrySent(retry, config.Cfg.Instance.ID, sentMessage(retry, provider), messageID, time.Now()))
+	}
 
-		mu.Lock()
-		err := s.repository.MoveToDeadLetter(tx, msg, retry.LastError)
-		mu.Unlock()
+	// an expired message is never sent, whatever its retry count
+	if retry.Expired(time.Now()) {
+		return s.expireRetry(retry)
+	}
 
-		if err != nil {
-			logger.Log.Error("Failed to move message to dead letter queue",
-				zap.Uint("retryID", retry.ID),
-				zap.Uint("originalMessageID", retry.OriginalMessageID),
-				zap.Error(err))
+	// Increment retry count
+	newRetryCount := retry.RetryCount + 1
+	if newRetryCount > db.MaxRetries {
+		if !s.record(retry, "dead_letter", s.repository.RecordRetryDeadLetter(retry, config.Cfg.Instance.ID)) {
 			return false
 		}
 
@@ -169,22 +224,48 @@ func (s *MessageRetryService) processRetry(ctx context.Context, tx *db.Transacti
 
 	select {
 	case <-ctx.Done():
+		s.releaseRetries([]db.MessageRetry{*retry})
 		return false
 	case <-time.After(backoffDuration):
 		// Continue with retry
 	}
 
-	hookResp, err := s.sendMessageToWebhook(retry)
+	// once sending has started the retry must be recorded even if the batch is cancelled
+	ctx = context.WithoutCancel(ctx)
+
+	if retry.Expired(time.Now()) {
+		return s.expireRetry(retry)
+	}
+
+	// the recipient may have replied STOP since the first attempt
+	suppressed, err := s.isSuppressed(ctx, retry)
 	if err != nil {
-		mu.Lock()
-		updateErr := s.repository.UpdateRetryCount(tx, retry.ID, newRetryCount, err.Error())
-		mu.Unlock()
-
-		if updateErr != nil {
-			logger.Log.Error("Failed to update retry count",
-				zap.Uint("retryID", retry.ID),
-				zap.Error(updateErr))
-		}
+		s.releaseRetries([]db.MessageRetry{*retry})
+		return false
+	}
+	if suppressed {
+		return s.suppressRetry(retry)
+	}
+
+	if s.deferred(retry, time.Now()) {
+		return false
+	}
+
+	tenant, err := s.tenant(ctx, retry)
+	if err != nil {
+		// left for the next batch like a failed suppression check
+		logger.Log.Error("Failed to look up tenant",
+			zap.Uint("retryID", retry.ID),
+			zap.Uint("tenantID", retry.TenantID),
+			zap.Error(err))
+		s.releaseRetries([]db.MessageRetry{*retry})
+		return false
+	}
+	webhookURL, provider := sendmessages.Route(tenant)
+
+	hookResp, err := s.sendMessageToWebhook(retry, webhookURL)
+	if err != nil {
+		s.record(retry, "failed", s.repository.RecordRetryFailed(retry, config.Cfg.Instance.ID, newRetryCount, err.Error()))
 
 		logger.Log.Warn("Retry attempt failed",
 			zap.Uint("retryID", retry.ID),
@@ -193,42 +274,162 @@ func (s *MessageRetryService) processRetry(ctx context.Context, tx *db.Transacti
 		return false
 	}
 
+	// the marker is written before recording like in the send flow, a failed record is repaired by the next claim
+	if err := s.redisClient.Set(ctx, sendmessages.SentMarkerKey(retry.OriginalMessageID), hookResp.MessageID, time.Hour); err != nil {
+		logger.Log.Warn("Failed to cache message in Redis", zap.Uint("originalMessageID", retry.OriginalMessageID), zap.Error(err))
+	}
+
 	// Message sent successfully, update the original message
-	msg := &db.Message{ID: retry.OriginalMessageID}
-	now := time.Now()
+	if !s.record(retry, "sent", s.repository.RecordRetrySent(retry, config.Cfg.Instance.ID, sentMessage(retry, provider), hookResp.MessageID, time.Now())) {
+		return false
+	}
 
-	mu.Lock()
-	err = s.repository.UpdateMessageAsSent(tx, msg, hookResp.MessageID, now)
-	mu.Unlock()
+	logger.Log.Info("Message retry successful",
+		zap.Uint("originalMessageID", retry.OriginalMessageID),
+		zap.Int("retryCount", newRetryCount),
+		zap.String("messageId", hookResp.MessageID))
 
+	return true
+}
+
+// sentMessage returns the original message of a delivered retry, priced for provider
+func sentMessage(retry *db.MessageRetry, provider string) *db.Message {
+	msg := &db.Message{
+		ID:          retry.OriginalMessageID,
+		TenantID:    retry.TenantID,
+		PhoneNumber: retry.PhoneNumber,
+		Content:     retry.Content,
+		Provider:    provider,
+	}
+	pricing.Apply(msg)
+	return msg
+}
+
+// sentMarker returns the webhook message id if the message of the retry was already delivered
+func (s *MessageRetryService) sentMarker(ctx context.Context, messageID uint) (string, bool) {
+	value, err := s.redisClient.Get(context.WithoutCancel(ctx), sendmessages.SentMarkerKey(messageID))
+	if errors.Is(err, redisClient.Nil) {
+		return "", false
+	}
+	if err != nil {
+		// the lease guards against concurrent sends, a missing marker only risks a resend after a crash
+		logger.Log.Warn("Failed to check sent marker in Redis", zap.Uint("originalMessageID", messageID), zap.Error(err))
+		return "", false
+	}
+	return value, true
+}
+
+func (s *MessageRetryService) expireRetry(retry *db.MessageRetry) bool {
+	if !s.record(retry, "expired", s.repository.CloseRetry(retry, config.Cfg.Instance.ID, db.StatusExpired)) {
+		return false
+	}
+
+	metrics.MessagesExpired.WithLabelValues("retry").Inc()
+	logger.Log.Info("Message expired before retry",
+		zap.Uint("originalMessageID", retry.OriginalMessageID),
+		zap.Int("retryCount", retry.RetryCount))
+	return true
+}
+
+// isSuppressed checks the suppression list, a failed check releases the retry for the next batch
+func (s *MessageRetryService) isSuppressed(ctx context.Context, retry *db.MessageRetry) (bool, error) {
+	if s.suppressions == nil {
+		return false, nil
+	}
+	suppressed, err := s.suppressions.IsSuppressed(context.WithoutCancel(ctx), retry.PhoneNumber)
 	if err != nil {
-		logger.Log.Error("Failed to update original message after successful retry",
-			zap.Uint("messageID", retry.OriginalMessageID),
+		logger.Log.Error("Failed to check suppression list",
+			zap.Uint("retryID", retry.ID),
 			zap.Error(err))
+	}
+	return suppressed, err
+}
+
+func (s *MessageRetryService) suppressRetry(retry *db.MessageRetry) bool {
+	if !s.record(retry, "suppressed", s.repository.CloseRetry(retry, config.Cfg.Instance.ID, db.StatusSuppressed)) {
 		return false
 	}
 
-	logger.Log.Info("Message retry successful",
+	metrics.MessagesSuppressed.WithLabelValues("retry").Inc()
+	logger.Log.Info("Recipient is suppressed, retry dropped",
 		zap.Uint("originalMessageID", retry.OriginalMessageID),
-		zap.Int("retryCount", newRetryCount),
-		zap.String("messageId", hookResp.MessageID))
+		zap.Int("retryCount", retry.RetryCount))
+	return true
+}
 
+// deferred reports whether the retry is outside the delivery window of its message and puts it
+// back until the window opens, like the send flow does with messages
+func (s *MessageRetryService) deferred(retry *db.MessageRetry, now time.Time) bool {
+	deliverAfter, deferred := sendmessages.DeliverAfter(retry.Priority, retry.Category, retry.TimeZone, retry.CountryCode, now)
+	if !deferred {
+		return false
+	}
+	if retry.Expired(deliverAfter) {
+		s.expireRetry(retry)
+		return true
+	}
+
+	if s.record(retry, "deferred", s.repository.DeferRetry(retry, config.Cfg.Instance.ID, deliverAfter)) {
+		metrics.MessagesDeferred.WithLabelValues(retry.Category).Inc()
+		logger.Log.Info("Retry outside its delivery window, deferred",
+			zap.Uint("retryID", retry.ID),
+			zap.Uint("originalMessageID", retry.OriginalMessageID),
+			zap.String("category", retry.Category),
+			zap.Time("deliverAfter", deliverAfter))
+	}
 	return true
 }
 
-func (s *MessageRetryService) sendMessageToWebhook(retry *db.MessageRetry) (*sendmessages.HookResponse, error) {
-	payload := sendmessages.WebhookPayload{Message: retry.Content, To: retry.PhoneNumber}
+func (s *MessageRetryService) releaseRetries(retries []db.MessageRetry) {
+	for i := range retries {
+		if err := s.repository.ReleaseRetry(&retries[i], config.Cfg.Instance.ID); err != nil {
+			logger.Log.Warn("Failed to release message retry", zap.Uint("retryID", retries[i].ID), zap.Error(err))
+		}
+	}
+}
+
+// record logs the outcome of a record transaction and reports whether it was stored
+func (s *MessageRetryService) record(retry *db.MessageRetry, outcome string, err error) bool {
+	if errors.Is(err, repository.ErrLeaseLost) {
+		logger.Log.Warn("Lease lost before recording message retry", zap.Uint("retryID", retry.ID), zap.String("outcome", outcome))
+		return false
+	}
+	if err != nil {
+		logger.Log.Error("Failed to record message retry",
+			zap.Uint("retryID", retry.ID),
+			zap.Uint("originalMessageID", retry.OriginalMessageID),
+			zap.String("outcome", outcome),
+			zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// tenant returns the tenant of the retry, its webhook and provider are used to send it
+func (s *MessageRetryService) tenant(ctx context.Context, retry *db.MessageRetry) (db.Tenant, error) {
+	if s.tenants == nil || retry.TenantID == 0 {
+		return db.Tenant{ID: retry.TenantID}, nil
+	}
+	return s.tenants.Tenant(context.WithoutCancel(ctx), retry.TenantID)
+}
+
+func (s *MessageRetryService) sendMessageToWebhook(retry *db.MessageRetry, webhookURL string) (*sendmessages.HookResponse, error) {
+	payload := sendmessages.NewWebhookPayload(retry.PhoneNumber, retry.Content)
 	buf := new(bytes.Buffer)
 	if err := json.NewEncoder(buf).Encode(payload); err != nil {
 		return nil, err
 	}
 
-	resp, err := s.httpClient.Post(config.Cfg.WebhookUrl, "application/json", buf)
+	resp, err := s.httpClient.Post(webhookURL, "application/json", buf)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if err := sendmessages.CheckWebhookStatus(resp); err != nil {
+		return nil, err
+	}
+
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,251 @@
+package messageretry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func setTestConfig() {
+	logger.Log = zap.NewNop()
+	config.Cfg.Instance.ID = "pod-a"
+	config.Cfg.Scheduler.BatchSize = 2
+	config.Cfg.Scheduler.MaxRetryConcurrent = 2
+	config.Cfg.Scheduler.LeaseDuration = time.Minute
+	config.Cfg.WebhookUrl = "http://webhook"
+	config.Cfg.Phone.DefaultRegion = "TR"
+	config.Cfg.Pricing.Provider = "webhook"
+	config.Cfg.Pricing.Prices = map[string]map[string]float64{"webhook": {"90": 0.02}}
+}
+
+func webhookResponse(status int, body string) *http.Response {
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+type suppressionStub struct {
+	suppressed bool
+	err        error
+}
+
+func (s suppressionStub) IsSuppressed(ctx context.Context, phoneNumber string) (bool, error) {
+	return s.suppressed, s.err
+}
+
+func TestProcessMessageRetries(t *testing.T) {
+	setTestConfig()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+	mockHttp := mocks.NewMockClient(ctrl)
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	service := NewService(mockRepo, mockHttp, mockRedis)
+
+	retry := db.MessageRetry{ID: 3, OriginalMessageID: 7, TenantID: 1, PhoneNumber: "+905321234567", Content: "hello"}
+	mockRepo.EXPECT().ClaimRetries("pod-a", gomock.Any(), 2, db.PriorityBulk).DoAndReturn(
+		func(owner string, leaseUntil time.Time, limit int, maxPriority db.Priority) ([]db.MessageRetry, error) {
+			assert.WithinDuration(t, time.Now().Add(time.Minute), leaseUntil, time.Second)
+			return []db.MessageRetry{retry}, nil
+		})
+	mockRedis.EXPECT().Get(gomock.Any(), "message:7").Return("", redisClient.Nil)
+	mockHttp.EXPECT().Post("http://webhook", "application/json", gomock.Any()).
+		Return(webhookResponse(http.StatusAccepted, `{"message":"Accepted","messageId":"hook-1"}`), nil)
+	mockRedis.EXPECT().Set(gomock.Any(), "message:7", "hook-1", time.Hour).Return(nil)
+	mockRepo.EXPECT().RecordRetrySent(gomock.Any(), "pod-a", gomock.Any(), "hook-1", gomock.Any()).DoAndReturn(
+		func(retry *db.MessageRetry, owner string, msg *db.Message, messageID string, sentAt time.Time) error {
+			assert.Equal(t, uint(7), msg.ID)
+			assert.Equal(t, "webhook", msg.Provider)
+			if assert.NotNil(t, msg.Cost) {
+				assert.InDelta(t, 0.02, *msg.Cost, 1e-9)
+			}
+			return nil
+		})
+
+	assert.Equal(t, 1, service.ProcessMessageRetries(context.Background()))
+	assert.Equal(t, 0, service.InFlight())
+}
+
+type budgetStub bool
+
+func (b budgetStub) Exceeded() bool { return bool(b) }
+
+func TestProcessMessageRetries_OverBudget(t *testing.T) {
+	setTestConfig()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+	service := NewService(mockRepo, mocks.NewMockClient(ctrl), mocks.NewMockRedisClient(ctrl), WithBudget(budgetStub(true)))
+
+	// non-critical retries stay open until the next budget period
+	mockRepo.EXPECT().ClaimRetries("pod-a", gomock.Any(), 2, db.PriorityCritical).Return(nil, nil)
+
+	assert.Equal(t, 0, service.ProcessMessageRetries(context.Background()))
+}
+
+func TestProcessRetry(t *testing.T) {
+	setTestConfig()
+	retry := db.MessageRetry{ID: 3, OriginalMessageID: 7, PhoneNumber: "+905321234567", Content: "hello"}
+	expired := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name         string
+		retry        db.MessageRetry
+		suppressions suppressionStub
+		sentMarker   string
+		cancelled    bool
+		setup        func(*mocks.MockMessageRepositoryInterface, *mocks.MockClient)
+		processed    bool
+	}{
+		{
+			name:  "Non-2xx response counts as a failed attempt",
+			retry: retry,
+			setup: func(repo *mocks.MockMessageRepositoryInterface, client *mocks.MockClient) {
+				client.EXPECT().Post(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(webhookResponse(http.StatusInternalServerError, `{"messageId":"hook-1"}`), nil)
+				repo.EXPECT().RecordRetryFailed(gomock.Any(), "pod-a", 1, "webhook returned status 500").Return(nil)
+			},
+		},
+		{
+			name:       "Sent marker records the retry without sending again",
+			retry:      retry,
+			sentMarker: "hook-0",
+			setup: func(repo *mocks.MockMessageRepositoryInterface, client *mocks.MockClient) {
+				repo.EXPECT().RecordRetrySent(gomock.Any(), "pod-a", gomock.Any(), "hook-0", gomock.Any()).Return(nil)
+			},
+			processed: true,
+		},
+		{
+			name:  "Retry out of attempts is dead-lettered without sending",
+			retry: db.MessageRetry{ID: 3, OriginalMessageID: 7, RetryCount: db.MaxRetries},
+			setup: func(repo *mocks.MockMessageRepositoryInterface, client *mocks.MockClient) {
+				repo.EXPECT().RecordRetryDeadLetter(gomock.Any(), "pod-a").Return(nil)
+			},
+			processed: true,
+		},
+		{
+			name:  "Expired retry is closed without sending",
+			retry: db.MessageRetry{ID: 3, OriginalMessageID: 7, ExpiresAt: &expired},
+			setup: func(repo *mocks.MockMessageRepositoryInterface, client *mocks.MockClient) {
+				repo.EXPECT().CloseRetry(gomock.Any(), "pod-a", db.StatusExpired).Return(nil)
+			},
+			processed: true,
+		},
+		{
+			name:         "Suppressed recipient closes the retry",
+			retry:        retry,
+			suppressions: suppressionStub{suppressed: true},
+			setup: func(repo *mocks.MockMessageRepositoryInterface, client *mocks.MockClient) {
+				repo.EXPECT().CloseRetry(gomock.Any(), "pod-a", db.StatusSuppressed).Return(nil)
+			},
+			processed: true,
+		},
+		{
+			name:         "Failed suppression check releases the retry",
+			retry:        retry,
+			suppressions: suppressionStub{err: errors.New("redis down")},
+			setup: func(repo *mocks.MockMessageRepositoryInterface, client *mocks.MockClient) {
+				repo.EXPECT().ReleaseRetry(gomock.Any(), "pod-a").Return(nil)
+			},
+		},
+		{
+			name:      "Cancelled backoff releases the retry",
+			retry:     db.MessageRetry{ID: 3, OriginalMessageID: 7, RetryCount: 1},
+			cancelled: true,
+			setup: func(repo *mocks.MockMessageRepositoryInterface, client *mocks.MockClient) {
+				repo.EXPECT().ReleaseRetry(gomock.Any(), "pod-a").Return(nil)
+			},
+		},
+		{
+			name:  "Lost lease is not counted",
+			retry: retry,
+			setup: func(repo *mocks.MockMessageRepositoryInterface, client *mocks.MockClient) {
+				client.EXPECT().Post(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(webhookResponse(http.StatusOK, `{"messageId":"hook-1"}`), nil)
+				repo.EXPECT().RecordRetrySent(gomock.Any(), "pod-a", gomock.Any(), "hook-1", gomock.Any()).Return(repository.ErrLeaseLost)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+			mockHttp := mocks.NewMockClient(ctrl)
+			mockRedis := mocks.NewMockRedisClient(ctrl)
+			if tt.sentMarker != "" {
+				mockRedis.EXPECT().Get(gomock.Any(), "message:7").Return(tt.sentMarker, nil)
+			} else {
+				mockRedis.EXPECT().Get(gomock.Any(), "message:7").Return("", redisClient.Nil)
+			}
+			mockRedis.EXPECT().Set(gomock.Any(), "message:7", gomock.Any(), time.Hour).Return(nil).AnyTimes()
+			tt.setup(mockRepo, mockHttp)
+			service := NewService(mockRepo, mockHttp, mockRedis, WithSuppressionChecker(tt.suppressions))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.cancelled {
+				cancel()
+			}
+			defer cancel()
+
+			retry := tt.retry
+			assert.Equal(t, tt.processed, service.processRetry(ctx, &retry))
+		})
+	}
+}
+
+func TestDeferred(t *testing.T) {
+	setTestConfig()
+	config.Cfg.Delivery.Windows = map[string]config.DeliveryWindow{"marketing": {Start: "09:00", End: "21:00"}}
+	defer func() { config.Cfg.Delivery.Windows = nil }()
+	// 00:00 UTC is 03:00 in Istanbul, the window opens at 06:00 UTC
+	now := time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC)
+	opens := time.Date(2026, time.March, 10, 6, 0, 0, 0, time.UTC)
+
+	t.Run("outside the window", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+		service := NewService(mockRepo, mocks.NewMockClient(ctrl), mocks.NewMockRedisClient(ctrl))
+
+		retry := db.MessageRetry{ID: 3, OriginalMessageID: 7, Category: "marketing", CountryCode: "90"}
+		mockRepo.EXPECT().DeferRetry(&retry, "pod-a", gomock.Any()).DoAndReturn(func(_ *db.MessageRetry, _ string, deliverAfter time.Time) error {
+			assert.True(t, opens.Equal(deliverAfter), deliverAfter)
+			return nil
+		})
+		assert.True(t, service.deferred(&retry, now))
+	})
+
+	t.Run("critical messages bypass the window", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := NewService(mocks.NewMockMessageRepositoryInterface(ctrl), mocks.NewMockClient(ctrl), mocks.NewMockRedisClient(ctrl))
+
+		assert.False(t, service.deferred(&db.MessageRetry{ID: 3, Category: "marketing", CountryCode: "90", Priority: db.PriorityCritical}, now))
+	})
+
+	t.Run("expiring before the window opens", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+		service := NewService(mockRepo, mocks.NewMockClient(ctrl), mocks.NewMockRedisClient(ctrl))
+
+		expiresAt := now.Add(time.Hour)
+		retry := db.MessageRetry{ID: 3, OriginalMessageID: 7, Category: "marketing", CountryCode: "90", ExpiresAt: &expiresAt}
+		mockRepo.EXPECT().CloseRetry(&retry, "pod-a", db.StatusExpired).Return(nil)
+		assert.True(t, service.deferred(&retry, now))
+	})
+}
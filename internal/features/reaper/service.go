@@ -0,0 +1,172 @@
+package reaper
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/features/sendmessages"
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/infrastructure/metrics"
+	"github.com/atakurt/messagingApp/internal/infrastructure/pricing"
+	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	ActionMarkSent     = "mark_sent"
+	ActionResetPending = "reset_pending"
+	ActionReview       = "review"
+
+	// UnknownActionReview sends messages without a sent marker to manual review instead of resending them
+	UnknownActionReview = "review"
+)
+
+//go:generate mockgen -destination=../../mocks/mock_reaper_service.go -package=mocks github.com/atakurt/messagingApp/internal/features/reaper ReaperServiceInterface
+type ReaperServiceInterface interface {
+	// ReapStuckMessages recovers one batch of messages stuck in processing and returns the number recovered
+	ReapStuckMessages(ctx context.Context) int
+}
+
+type Option func(*ReaperService)
+
+// WithTenants prices the messages marked sent with the provider of their tenant
+func WithTenants(tenants sendmessages.TenantLookup) Option {
+	return func(s *ReaperService) {
+		s.tenants = tenants
+	}
+}
+
+type ReaperService struct {
+	repository  repository.MessageRepositoryInterface
+	redisClient redisClient.Client
+	cfg         config.Config
+	// tenants is optional, without it messages are priced with pricing.provider
+	tenants sendmessages.TenantLookup
+}
+
+func NewService(repository repository.MessageRepositoryInterface, redisClient redisClient.Client, cfg config.Config, opts ...Option) *ReaperService {
+	s := &ReaperService{
+		repository:  repository,
+		redisClient: redisClient,
+		cfg:         cfg,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *ReaperService) ReapStuckMessages(ctx context.Context) int {
+	tx := s.repository.GetDB().Begin()
+	if tx.Error != nil {
+		logger.Log.Error("Failed to begin transaction", zap.Error(tx.Error))
+		return 0
+	}
+	defer tx.Rollback()
+
+	stuckBefore := time.Now().Add(-s.cfg.Reaper.StuckAfter)
+	messages, err := s.repository.GetStuckMessages(tx, stuckBefore, s.cfg.Reaper.BatchSize)
+	if err != nil {
+		logger.Log.Error("Failed to select stuck messages", zap.Error(err))
+		return 0
+	}
+
+	metrics.ReaperStuck.Set(float64(len(messages)))
+	if len(messages) == 0 {
+		return 0
+	}
+	logger.Log.Warn("Found messages stuck in processing", zap.Int("count", len(messages)))
+
+	recovered := 0
+	for i := range messages {
+		// a savepoint keeps the status change and its audit entry together
+		savepoint := "reap_" + strconv.Itoa(int(messages[i].ID))
+		tx.SavePoint(savepoint)
+		if s.recoverMessage(ctx, tx, &messages[i]) {
+			recovered++
+			continue
+		}
+		tx.RollbackTo(savepoint)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		logger.Log.Error("Failed to commit transaction", zap.Error(err))
+		return 0
+	}
+	return recovered
+}
+
+// recoverMessage decides the fate of a stuck message from the Redis sent marker:
+// sent messages are marked done, unknown ones are reset to pending until they hit
+// the reset limit and then go to manual review
+func (s *ReaperService) recoverMessage(ctx context.Context, tx *gorm.DB, msg *db.Message) bool {
+	messageID, err := s.redisClient.Get(ctx, sendmessages.SentMarkerKey(msg.ID))
+	sent := err == nil
+	if errors.Is(err, redisClient.Nil) {
+		err = nil
+	}
+	if err != nil {
+		// without the marker we cannot tell if the message was delivered, try again next run
+		logger.Log.Warn("Failed to check sent marker, skipping", zap.Uint("messageID", msg.ID), zap.Error(err))
+		return false
+	}
+
+	audit := db.MessageAudit{
+		MessageID:  msg.ID,
+		FromStatus: db.StatusProcessing,
+		Actor:      "reaper",
+	}
+
+	switch {
+	case sent:
+		audit.Action, audit.ToStatus = ActionMarkSent, db.StatusDone
+		audit.Reason = "sent marker found in Redis"
+		s.setProvider(ctx, msg)
+		pricing.Apply(msg)
+		err = s.repository.UpdateMessageAsSent(tx, msg, messageID, time.Now())
+	case s.cfg.Reaper.UnknownAction == UnknownActionReview || msg.RecoveryAttempts >= s.cfg.Reaper.MaxResets:
+		audit.Action, audit.ToStatus = ActionReview, db.StatusReview
+		audit.Reason = "no sent marker found, delivery outcome unknown after " + strconv.Itoa(msg.RecoveryAttempts) + " resets"
+		err = s.repository.MarkMessageForReview(tx, msg, audit.Reason)
+	default:
+		audit.Action, audit.ToStatus = ActionResetPending, db.StatusPending
+		audit.Reason = "no sent marker found"
+		err = s.repository.ResetMessageToPending(tx, msg)
+	}
+	if err != nil {
+		logger.Log.Error("Failed to recover stuck message", zap.Uint("messageID", msg.ID), zap.String("action", audit.Action), zap.Error(err))
+		return false
+	}
+
+	if err := s.repository.InsertAudit(tx, audit); err != nil {
+		logger.Log.Error("Failed to write audit entry", zap.Uint("messageID", msg.ID), zap.Error(err))
+		return false
+	}
+
+	metrics.ReaperRecovered.WithLabelValues(audit.Action).Inc()
+	logger.Log.Info("Recovered stuck message",
+		zap.Uint("messageID", msg.ID),
+		zap.String("action", audit.Action),
+		zap.String("reason", audit.Reason))
+	return true
+}
+
+// setProvider sets the provider of the tenant of msg, the message was delivered already so a
+// failed lookup only prices it with the default provider
+func (s *ReaperService) setProvider(ctx context.Context, msg *db.Message) {
+	if s.tenants == nil || msg.TenantID == 0 {
+		return
+	}
+	tenant, err := s.tenants.Tenant(ctx, msg.TenantID)
+	if err != nil {
+		logger.Log.Warn("Failed to look up tenant, pricing with the default provider", zap.Uint("messageID", msg.ID), zap.Error(err))
+		return
+	}
+	_, msg.Provider = sendmessages.Route(tenant)
+}
@@ -0,0 +1,137 @@
+package reaper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func getTestConfig() config.Config {
+	cfg := config.Config{}
+	cfg.Reaper.StuckAfter = 10 * time.Minute
+	cfg.Reaper.BatchSize = 100
+	cfg.Reaper.MaxResets = 3
+	cfg.Reaper.UnknownAction = "pending"
+	return cfg
+}
+
+type auditMatcher struct {
+	action string
+	to     db.MessageStatus
+}
+
+func auditWith(action string, to db.MessageStatus) gomock.Matcher {
+	return auditMatcher{action: action, to: to}
+}
+
+func (m auditMatcher) Matches(x interface{}) bool {
+	audit, ok := x.(db.MessageAudit)
+	return ok && audit.MessageID == 7 && audit.Action == m.action &&
+		audit.FromStatus == db.StatusProcessing && audit.ToStatus == m.to && audit.Actor == "reaper"
+}
+
+func (m auditMatcher) String() string {
+	return "audit " + m.action + " to " + string(m.to)
+}
+
+func TestRecoverMessage(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	tests := []struct {
+		name      string
+		cfg       func(*config.Config)
+		msg       db.Message
+		setup     func(*mocks.MockMessageRepositoryInterface, *mocks.MockRedisClient)
+		recovered bool
+	}{
+		{
+			name: "Sent marker present marks the message sent",
+			msg:  db.Message{ID: 7},
+			setup: func(repo *mocks.MockMessageRepositoryInterface, redis *mocks.MockRedisClient) {
+				redis.EXPECT().Get(gomock.Any(), "message:7").Return("hook-1", nil)
+				repo.EXPECT().UpdateMessageAsSent(gomock.Any(), gomock.Any(), "hook-1", gomock.Any()).Return(nil)
+				repo.EXPECT().InsertAudit(gomock.Any(), auditWith(ActionMarkSent, db.StatusDone)).Return(nil)
+			},
+			recovered: true,
+		},
+		{
+			name: "No marker resets to pending",
+			msg:  db.Message{ID: 7, RecoveryAttempts: 1},
+			setup: func(repo *mocks.MockMessageRepositoryInterface, redis *mocks.MockRedisClient) {
+				redis.EXPECT().Get(gomock.Any(), "message:7").Return("", redisClient.Nil)
+				repo.EXPECT().ResetMessageToPending(gomock.Any(), gomock.Any()).Return(nil)
+				repo.EXPECT().InsertAudit(gomock.Any(), auditWith(ActionResetPending, db.StatusPending)).Return(nil)
+			},
+			recovered: true,
+		},
+		{
+			name: "No marker after max resets goes to review",
+			msg:  db.Message{ID: 7, RecoveryAttempts: 3},
+			setup: func(repo *mocks.MockMessageRepositoryInterface, redis *mocks.MockRedisClient) {
+				redis.EXPECT().Get(gomock.Any(), "message:7").Return("", redisClient.Nil)
+				repo.EXPECT().MarkMessageForReview(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				repo.EXPECT().InsertAudit(gomock.Any(), auditWith(ActionReview, db.StatusReview)).Return(nil)
+			},
+			recovered: true,
+		},
+		{
+			name: "Unknown action review skips resets",
+			cfg:  func(cfg *config.Config) { cfg.Reaper.UnknownAction = UnknownActionReview },
+			msg:  db.Message{ID: 7},
+			setup: func(repo *mocks.MockMessageRepositoryInterface, redis *mocks.MockRedisClient) {
+				redis.EXPECT().Get(gomock.Any(), "message:7").Return("", redisClient.Nil)
+				repo.EXPECT().MarkMessageForReview(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				repo.EXPECT().InsertAudit(gomock.Any(), auditWith(ActionReview, db.StatusReview)).Return(nil)
+			},
+			recovered: true,
+		},
+		{
+			name: "Redis error leaves the message for the next run",
+			msg:  db.Message{ID: 7},
+			setup: func(repo *mocks.MockMessageRepositoryInterface, redis *mocks.MockRedisClient) {
+				redis.EXPECT().Get(gomock.Any(), "message:7").Return("", errors.New("redis down"))
+			},
+			recovered: false,
+		},
+		{
+			name: "Audit failure reports the message as not recovered",
+			msg:  db.Message{ID: 7},
+			setup: func(repo *mocks.MockMessageRepositoryInterface, redis *mocks.MockRedisClient) {
+				redis.EXPECT().Get(gomock.Any(), "message:7").Return("", redisClient.Nil)
+				repo.EXPECT().ResetMessageToPending(gomock.Any(), gomock.Any()).Return(nil)
+				repo.EXPECT().InsertAudit(gomock.Any(), gomock.Any()).Return(errors.New("insert failed"))
+			},
+			recovered: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+			mockRedis := mocks.NewMockRedisClient(ctrl)
+			tt.setup(mockRepo, mockRedis)
+
+			cfg := getTestConfig()
+			if tt.cfg != nil {
+				tt.cfg(&cfg)
+			}
+			service := NewService(mockRepo, mockRedis, cfg)
+
+			msg := tt.msg
+			assert.Equal(t, tt.recovered, service.recoverMessage(context.Background(), nil, &msg))
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package sendmessages
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/infrastructure/metrics"
+	"github.com/atakurt/messagingApp/internal/infrastructure/phone"
+	"go.uber.org/zap"
+	"golang.org/x/text/unicode/norm"
+)
+
+// DedupeKey is the Redis key holding the id of the first message of the tenant with this recipient
+// and content, tenants never suppress each other's messages. The number is normalized to E.164 and
+// the content to NFC with whitespace runs collapsed, so copies that differ only in formatting share
+// a key.
+func DedupeKey(tenantID uint, phoneNumber, content string) string {
+	number, err := phone.Normalize(phoneNumber)
+	if err != nil {
+		number = strings.TrimSpace(phoneNumber)
+	}
+	content = strings.Join(strings.Fields(norm.NFC.String(content)), " ")
+
+	sum := sha256.Sum256([]byte(strconv.FormatUint(uint64(tenantID), 10) + "\x00" + number + "\x00" + content))
+	return "dedupe:" + hex.EncodeToString(sum[:])
+}
+
+// duplicate reports whether msg repeats a message sent to the same recipient within the dedupe
+// window and records it as a duplicate of that message. Redis errors let the message through,
+// a lost duplicate check must not block sending.
+func (s *MessageService) duplicate(ctx context.Context, msg *db.Message) bool {
+	window := config.Cfg.Dedupe.Window
+	if window <= 0 {
+		return false
+	}
+
+	key := DedupeKey(msg.TenantID, msg.PhoneNumber, msg.Content)
+	first, err := s.redisClient.SetNX(ctx, key, msg.ID, window)
+	if err != nil {
+		logger.Log.Warn("Failed to check for duplicate message in Redis", zap.Uint("messageID", msg.ID), zap.Error(err))
+		return false
+	}
+	if first {
+		return false
+	}
+
+	value, err := s.redisClient.Get(ctx, key)
+	if err != nil {
+		// the window ended between the two calls
+		logger.Log.Warn("Failed to read original message id from Redis", zap.Uint("messageID", msg.ID), zap.Error(err))
+		return false
+	}
+	originalID, err := strconv.ParseUint(value, 10, 64)
+	// the message itself holds the key when it was released and claimed again
+	if err != nil || uint(originalID) == msg.ID {
+		return false
+	}
+
+	if s.record(msg, "duplicate", s.repository.RecordMessageDuplicate(msg, config.Cfg.Instance.ID, uint(originalID))) {
+		metrics.MessagesDuplicate.Inc()
+		logger.Log.Info("Duplicate message not sent",
+			zap.Uint("messageID", msg.ID),
+			zap.Uint64("duplicateOf", originalID))
+	}
+	return true
+}
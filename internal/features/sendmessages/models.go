@@ -1,11 +1,42 @@
 package sendmessages
 
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/sms"
+)
+
 type WebhookPayload struct {
-	Message string `json:"message"`
-	To      string `json:"to"`
+	Message  string `json:"message"`
+	To       string `json:"to"`
+	Encoding string `json:"encoding,omitempty"`
+	Segments int    `json:"segments,omitempty"`
+}
+
+// NewWebhookPayload builds the webhook body, encoding and segments are only sent to providers
+// that need them as configured by sms.sendSegmentInfo
+func NewWebhookPayload(to, content string) WebhookPayload {
+	payload := WebhookPayload{Message: content, To: to}
+	if config.Cfg.SMS.SendSegmentInfo {
+		segmentation := sms.Analyze(content)
+		payload.Encoding = string(segmentation.Encoding)
+		payload.Segments = segmentation.Segments
+	}
+	return payload
 }
 
 type HookResponse struct {
 	Message   string `json:"message"`
 	MessageID string `json:"messageId"`
 }
+
+// CheckWebhookStatus returns an error for non-2xx webhook responses, the provider did not accept
+// the message even though it answered
+func CheckWebhookStatus(resp *http.Response) error {
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -4,16 +4,22 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"gorm.io/gorm"
+	"errors"
 	"io"
+	"math"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/atakurt/messagingApp/internal/infrastructure/adaptive"
 	"github.com/atakurt/messagingApp/internal/infrastructure/config"
 	"github.com/atakurt/messagingApp/internal/infrastructure/db"
 	httpClient "github.com/atakurt/messagingApp/internal/infrastructure/http"
 	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/infrastructure/metrics"
+	"github.com/atakurt/messagingApp/internal/infrastructure/pricing"
+	"github.com/atakurt/messagingApp/internal/infrastructure/quiethours"
 	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
 	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
 	"go.uber.org/zap"
@@ -21,95 +27,258 @@ import (
 
 //go:generate mockgen -destination=../../mocks/mock_message_service.go -package=mocks github.com/atakurt/messagingApp/internal/features/sendmessages MessageServiceInterface
 type MessageServiceInterface interface {
-	ProcessUnsentMessages(ctx context.Context)
+	// ProcessUnsentMessages sends one batch and returns the number of messages fetched.
+	// Cancelling ctx stops starting new messages, messages already being sent are completed.
+	ProcessUnsentMessages(ctx context.Context) int
+	// ProcessNext claims and sends a single message and returns false when nothing was pending
+	ProcessNext(ctx context.Context) bool
+	// InFlight returns the number of messages currently being sent
+	InFlight() int
+}
+
+// Tuner chooses the batch size and concurrency and learns from every webhook call
+type Tuner interface {
+	BatchSize() int
+	Concurrency() int
+	Observe(latency time.Duration, outcome adaptive.Outcome)
+}
+
+// staticTuner uses the configured batch size and concurrency
+type staticTuner struct{}
+
+func (staticTuner) BatchSize() int                          { return config.Cfg.Scheduler.BatchSize }
+func (staticTuner) Concurrency() int                        { return config.Cfg.Scheduler.MaxConcurrent }
+func (staticTuner) Observe(time.Duration, adaptive.Outcome) {}
+
+// SuppressionChecker tells whether a recipient asked not to receive messages
+type SuppressionChecker interface {
+	IsSuppressed(ctx context.Context, phoneNumber string) (bool, error)
+}
+
+// BudgetChecker tells whether the spend is over a budget cap, only critical messages are sent while it is
+type BudgetChecker interface {
+	Exceeded() bool
+}
+
+// TenantLookup returns the tenant of a message, its webhook and provider are used to send it
+type TenantLookup interface {
+	Tenant(ctx context.Context, id uint) (db.Tenant, error)
+}
+
+type Option func(*MessageService)
+
+func WithTuner(tuner Tuner) Option {
+	return func(s *MessageService) {
+		s.tuner = tuner
+	}
+}
+
+func WithSuppressionChecker(checker SuppressionChecker) Option {
+	return func(s *MessageService) {
+		s.suppressions = checker
+	}
+}
+
+func WithBudget(budget BudgetChecker) Option {
+	return func(s *MessageService) {
+		s.budget = budget
+	}
+}
+
+func WithTenants(tenants TenantLookup) Option {
+	return func(s *MessageService) {
+		s.tenants = tenants
+	}
+}
+
+// Route returns the webhook URL and provider for the messages of tenant, tenants without their own
+// use webhookUrl and pricing.provider
+func Route(tenant db.Tenant) (webhookURL, provider string) {
+	webhookURL, provider = tenant.WebhookURL, tenant.Provider
+	if webhookURL == "" {
+		webhookURL = config.Cfg.WebhookUrl
+	}
+	if provider == "" {
+		provider = config.Cfg.Pricing.Provider
+	}
+	return webhookURL, provider
 }
 
 type MessageService struct {
 	repository  repository.MessageRepositoryInterface
 	httpClient  httpClient.Client
 	redisClient redisClient.Client
+	tuner       Tuner
+	// suppressions is optional, without it every recipient is sent to
+	suppressions SuppressionChecker
+	// budget is optional, without it sending is never paused for spend
+	budget BudgetChecker
+	// tenants is optional, without it every message goes to webhookUrl
+	tenants  TenantLookup
+	inFlight atomic.Int64
+	// lowInFlight counts normal and bulk messages being sent, they may not use the reserved slots
+	lowInFlight atomic.Int64
 }
 
-func NewService(repository repository.MessageRepositoryInterface, httpClient httpClient.Client, redisClient redisClient.Client) *MessageService {
-	return &MessageService{
+func NewService(repository repository.MessageRepositoryInterface, httpClient httpClient.Client, redisClient redisClient.Client, opts ...Option) *MessageService {
+	s := &MessageService{
 		repository:  repository,
 		httpClient:  httpClient,
 		redisClient: redisClient,
+		tuner:       staticTuner{},
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func (s *MessageService) ProcessUnsentMessages(ctx context.Context) {
-	tx, err := s.beginTransaction()
-	if err != nil {
-		return
+// ProcessUnsentMessages runs the pipeline for one batch: claim leases the messages in a short
+// statement, send calls the webhook outside any transaction, and record stores each outcome
+// in its own short transaction guarded by the lease
+func (s *MessageService) ProcessUnsentMessages(ctx context.Context) int {
+	if ctx.Err() != nil {
+		return 0
 	}
-	defer func() {
-		_ = tx.Rollback()
-	}()
 
-	messages, err := s.fetchUnsentMessages(tx)
+	messages, err := s.claimMessages(s.tuner.BatchSize(), db.PriorityBulk)
 	if err != nil {
-		return
+		return 0
 	}
 
-	logger.Log.Info("Found unsent messages", zap.Int("count", len(messages)))
+	logger.Log.Info("Claimed unsent messages", zap.Int("count", len(messages)))
 	if len(messages) == 0 {
-		return
+		return 0
 	}
 
 	// Process messages concurrently
-	processedCount := s.processMessagesConcurrently(ctx, tx, messages)
+	processedCount := s.processMessagesConcurrently(ctx, messages)
 
 	logger.Log.Info("Processed messages", zap.Int("count", processedCount))
+	return len(messages)
+}
+
+func (s *MessageService) ProcessNext(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	// once the general slots are busy with normal and bulk messages only urgent ones are claimed
+	maxPriority := db.PriorityBulk
+	if int(s.lowInFlight.Load()) >= generalSlots(s.tuner.Concurrency()) {
+		maxPriority = db.PriorityHigh
+	}
 
-	// Commit the transaction
-	if err := tx.Commit().Error; err != nil {
-		logger.Log.Error("Failed to commit transaction", zap.Error(err))
+	messages, err := s.claimMessages(1, maxPriority)
+	if err != nil || len(messages) == 0 {
+		return false
 	}
+
+	msg := &messages[0]
+	s.track(msg, 1)
+	defer s.track(msg, -1)
+	s.processMessage(ctx, msg)
+	return true
 }
 
-func (s *MessageService) beginTransaction() (*gorm.DB, error) {
-	tx := s.repository.GetDB().Begin()
-	if tx.Error != nil {
-		logger.Log.Error("Failed to begin transaction", zap.Error(tx.Error))
-		return nil, tx.Error
+func (s *MessageService) InFlight() int {
+	return int(s.inFlight.Load())
+}
+
+// SentMarkerKey is the Redis key holding the webhook message id of a delivered message
+func SentMarkerKey(messageID uint) string {
+	return "message:" + strconv.Itoa(int(messageID))
+}
+
+// reservedSlots returns how many of the concurrency slots are kept for critical and high priority
+// messages, at least one slot is always left for the rest so bulk traffic keeps moving
+func reservedSlots(concurrency int) int {
+	if concurrency < 2 {
+		return 0
 	}
-	return tx, nil
+	reserved := int(math.Ceil(float64(concurrency) * config.Cfg.Priority.ReservedShare))
+	return max(0, min(reserved, concurrency-1))
 }
 
-func (s *MessageService) fetchUnsentMessages(tx *gorm.DB) ([]db.Message, error) {
-	messages, err := s.repository.GetUnsentMessages(tx, config.Cfg.Scheduler.BatchSize)
+func generalSlots(concurrency int) int {
+	return concurrency - reservedSlots(concurrency)
+}
+
+func (s *MessageService) claimMessages(limit int, maxPriority db.Priority) ([]db.Message, error) {
+	// over budget non-critical messages stay pending until the next period or a higher cap
+	if s.budget != nil && s.budget.Exceeded() {
+		maxPriority = db.PriorityCritical
+	}
+
+	now := time.Now()
+	leaseUntil := now.Add(config.Cfg.Scheduler.LeaseDuration)
+
+	var boostBefore time.Time
+	if config.Cfg.Priority.StarvationAge > 0 {
+		boostBefore = now.Add(-config.Cfg.Priority.StarvationAge)
+	}
+
+	messages, err := s.repository.ClaimMessages(config.Cfg.Instance.ID, leaseUntil, limit, maxPriority, boostBefore)
 	if err != nil {
-		logger.Log.Error("Failed to select unsent messages with locking", zap.Error(err))
+		logger.Log.Error("Failed to claim unsent messages", zap.Error(err))
 		return nil, err
 	}
+	for i := range messages {
+		applyDefaultExpiry(&messages[i])
+	}
 	return messages, nil
 }
 
+// applyDefaultExpiry sets the configured TTL of the message priority on messages without an expiry,
+// the expiry is carried over to the retry flow
+func applyDefaultExpiry(msg *db.Message) {
+	if msg.ExpiresAt != nil {
+		return
+	}
+	if ttl := config.Cfg.Expiry.DefaultTTL[msg.Priority.String()]; ttl > 0 {
+		expiresAt := msg.CreatedAt.Add(ttl)
+		msg.ExpiresAt = &expiresAt
+	}
+}
+
 // processMessagesConcurrently processes messages in parallel using goroutines
-func (s *MessageService) processMessagesConcurrently(ctx context.Context, tx *db.Transaction, messages []db.Message) int {
+func (s *MessageService) processMessagesConcurrently(ctx context.Context, messages []db.Message) int {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	processedMessages := 0
 
-	// Configure concurrency limits
-	maxConcurrent := config.Cfg.Scheduler.MaxConcurrent
-	semaphore := make(chan struct{}, maxConcurrent)
+	// Configure concurrency limits, urgent messages may use any slot and the rest only the general ones
+	concurrency := s.tuner.Concurrency()
+	general := make(chan struct{}, generalSlots(concurrency))
+	reserved := make(chan struct{}, reservedSlots(concurrency))
+
+	for i, msg := range messages {
+		// stop starting new messages once the batch is cancelled, e.g. while draining;
+		// the remaining claims are released so the messages go back to pending
+		var slot chan struct{}
+		if ctx.Err() == nil {
+			slot = acquireSlot(ctx, msg.Priority, general, reserved)
+		}
+		if ctx.Err() != nil {
+			logger.Log.Info("Batch cancelled, releasing remaining messages", zap.Int("count", len(messages)-i))
+			s.releaseMessages(messages[i:])
+			break
+		}
 
-	for _, msg := range messages {
 		wg.Add(1)
-		semaphore <- struct{}{}
+		s.track(&msg, 1)
 
 		msgCopy := msg
 
 		go func(msg db.Message) {
 			defer func() {
-				<-semaphore
+				<-slot
+				s.track(&msg, -1)
 				wg.Done()
 			}()
 
 			// Process the message and track if successful
-			if s.processMessage(ctx, tx, &msg) {
+			if s.processMessage(ctx, &msg) {
 				mu.Lock()
 				processedMessages++
 				mu.Unlock()
@@ -122,128 +291,213 @@ func (s *MessageService) processMessagesConcurrently(ctx context.Context, tx *db
 	return processedMessages
 }
 
-func (s *MessageService) processMessage(ctx context.Context, tx *db.Transaction, msg *db.Message) bool {
-	redisKey := "message:" + strconv.Itoa(int(msg.ID))
+// acquireSlot blocks until a slot the message may use is free and returns it, or nil once ctx is done
+func acquireSlot(ctx context.Context, priority db.Priority, general, reserved chan struct{}) chan struct{} {
+	if !priority.Urgent() {
+		reserved = nil
+	}
+	select {
+	case <-ctx.Done():
+		return nil
+	case general <- struct{}{}:
+		return general
+	case reserved <- struct{}{}:
+		return reserved
+	}
+}
+
+// track adjusts the in flight counters by delta for msg
+func (s *MessageService) track(msg *db.Message, delta int64) {
+	s.inFlight.Add(delta)
+	if !msg.Priority.Urgent() {
+		s.lowInFlight.Add(delta)
+	}
+}
+
+func (s *MessageService) processMessage(ctx context.Context, msg *db.Message) bool {
+	// once sending has started the message must be completed even if the batch is cancelled
+	ctx = context.WithoutCancel(ctx)
 
-	if !s.canProcessMessage(ctx, redisKey, msg.ID) {
+	// a previous owner delivered the message but could not record it
+	if messageID, sent := s.sentMarker(ctx, msg.ID); sent {
+		logger.Log.Warn("Message already sent, recording it", zap.Uint("messageID", msg.ID))
+		if _, err := s.route(ctx, msg); err != nil {
+			logger.Log.Warn("Failed to look up tenant, pricing with the default provider", zap.Uint("messageID", msg.ID), zap.Error(err))
+		}
+		pricing.Apply(msg)
+		return s.record(msg, "sent", s.repository.RecordMessageSent(msg, config.Cfg.Instance.ID, messageID, time.Now()))
+	}
+
+	if msg.Expired(time.Now()) {
+		s.expire(msg)
 		return false
 	}
 
-	now := time.Now()
-	err := s.repository.MarkMessageInProcess(tx, msg, now)
+	if s.suppressed(ctx, msg) {
+		return false
+	}
+
+	if s.deferred(msg, time.Now()) {
+		return false
+	}
+
+	if s.duplicate(ctx, msg) {
+		return false
+	}
 
+	webhookURL, err := s.route(ctx, msg)
 	if err != nil {
-		logger.Log.Error("Failed to mark message in process", zap.Uint("messageID", msg.ID), zap.Error(err))
+		logger.Log.Error("Failed to look up tenant", zap.Uint("messageID", msg.ID), zap.Uint("tenantID", msg.TenantID), zap.Error(err))
+		s.releaseMessages([]db.Message{*msg})
 		return false
 	}
 
-	hookResp, err := s.sendMessageToWebhook(tx, msg)
+	hookResp, err := s.sendMessageToWebhook(msg, webhookURL)
 	if err != nil {
 		return false
 	}
 
-	return s.finalizeMessageProcessing(ctx, tx, msg, hookResp, now, redisKey)
+	return s.finalizeMessageProcessing(ctx, msg, hookResp, time.Now())
 }
 
-func (s *MessageService) canProcessMessage(ctx context.Context, redisKey string, messageID uint) bool {
-	// Try to acquire a lock for this message
-	lockAcquired, err := s.redisClient.SetNX(ctx, redisKey+":lock", time.Now().String(), time.Minute)
+// route sets the provider of msg from its tenant and returns the webhook URL to send it to
+func (s *MessageService) route(ctx context.Context, msg *db.Message) (string, error) {
+	tenant := db.Tenant{ID: msg.TenantID}
+	if s.tenants != nil && msg.TenantID != 0 {
+		var err error
+		if tenant, err = s.tenants.Tenant(ctx, msg.TenantID); err != nil {
+			return "", err
+		}
+	}
+	webhookURL, provider := Route(tenant)
+	msg.Provider = provider
+	return webhookURL, nil
+}
+
+// sentMarker returns the webhook message id if the message was already delivered
+func (s *MessageService) sentMarker(ctx context.Context, messageID uint) (string, bool) {
+	value, err := s.redisClient.Get(ctx, SentMarkerKey(messageID))
+	if errors.Is(err, redisClient.Nil) {
+		return "", false
+	}
 	if err != nil {
-		logger.Log.Warn("Failed to acquire lock in Redis", zap.String("key", redisKey), zap.Error(err))
-		return false
+		// the lease guards against concurrent sends, a missing marker only risks a resend after a crash
+		logger.Log.Warn("Failed to check sent marker in Redis", zap.Uint("messageID", messageID), zap.Error(err))
+		return "", false
 	}
+	return value, true
+}
 
-	if !lockAcquired {
-		logger.Log.Warn("Message being processed by another instance, skipping", zap.Uint("messageID", messageID))
+func (s *MessageService) expire(msg *db.Message) {
+	if !s.record(msg, "expired", s.repository.RecordMessageExpired(msg, config.Cfg.Instance.ID)) {
+		return
+	}
+	metrics.MessagesExpired.WithLabelValues("send").Inc()
+	logger.Log.Info("Message expired before it was sent", zap.Uint("messageID", msg.ID), zap.Timep("expiresAt", msg.ExpiresAt))
+}
+
+// suppressed reports whether msg must not be sent and records it as suppressed. When the list
+// cannot be checked the message is released and sent later rather than risking a send to a
+// number that opted out.
+func (s *MessageService) suppressed(ctx context.Context, msg *db.Message) bool {
+	if s.suppressions == nil {
 		return false
 	}
 
-	// Check if message was already processed
-	exists, err := s.redisClient.Exists(ctx, redisKey)
+	suppressed, err := s.suppressions.IsSuppressed(ctx, msg.PhoneNumber)
 	if err != nil {
-		logger.Log.Warn("Failed to check Redis", zap.String("key", redisKey), zap.Error(err))
+		logger.Log.Error("Failed to check suppression list", zap.Uint("messageID", msg.ID), zap.Error(err))
+		s.releaseMessages([]db.Message{*msg})
+		return true
 	}
-	if exists {
-		logger.Log.Warn("Message already processed, skipping", zap.Uint("messageID", messageID))
+	if !suppressed {
 		return false
 	}
 
+	if s.record(msg, "suppressed", s.repository.RecordMessageSuppressed(msg, config.Cfg.Instance.ID)) {
+		metrics.MessagesSuppressed.WithLabelValues("send").Inc()
+		logger.Log.Info("Recipient is suppressed, message not sent", zap.Uint("messageID", msg.ID))
+	}
 	return true
 }
 
-func (s *MessageService) sendMessageToWebhook(tx *db.Transaction, msg *db.Message) (*HookResponse, error) {
-	payload := WebhookPayload{Message: msg.Content, To: msg.PhoneNumber}
-	buf := new(bytes.Buffer)
-	if err := json.NewEncoder(buf).Encode(payload); err != nil {
-		logger.Log.Error("Failed to encode payload to JSON", zap.Error(err))
-		err := s.repository.UpdateMessageAsError(tx, msg, err.Error())
-		if err != nil {
-			logger.Log.Error("Failed to update message as error", zap.Error(err))
-			return nil, err
-		}
-		return nil, err
+// deferred reports whether msg is outside the delivery window of its category and puts it back
+// until the window opens. Critical messages bypass the window, a message whose expiry comes
+// before the window opens is expired right away.
+func (s *MessageService) deferred(msg *db.Message, now time.Time) bool {
+	deliverAfter, deferred := DeliverAfter(msg.Priority, msg.Category, msg.TimeZone, msg.CountryCode, now)
+	if !deferred {
+		return false
+	}
+	if msg.Expired(deliverAfter) {
+		s.expire(msg)
+		return true
 	}
 
-	resp, err := s.httpClient.Post(config.Cfg.WebhookUrl, "application/json", buf)
-	if err != nil {
-		logger.Log.Error("Failed to send message", zap.Error(err))
-		insertErr := insertRetry(tx, msg, s, err)
-		if insertErr != nil {
-			return nil, insertErr
-		}
-		return nil, err
+	if s.record(msg, "deferred", s.repository.DeferMessage(msg, config.Cfg.Instance.ID, deliverAfter)) {
+		metrics.MessagesDeferred.WithLabelValues(msg.Category).Inc()
+		logger.Log.Info("Message outside its delivery window, deferred",
+			zap.Uint("messageID", msg.ID),
+			zap.String("category", msg.Category),
+			zap.Time("deliverAfter", deliverAfter))
 	}
-	defer resp.Body.Close()
+	return true
+}
 
-	bodyBytes, err := io.ReadAll(resp.Body)
+// DeliverAfter returns when a message may be sent under the delivery window of its category and
+// whether that is after now. Critical messages bypass the window, the recipient's local time comes
+// from timeZone or else countryCode.
+func DeliverAfter(priority db.Priority, category, timeZone, countryCode string, now time.Time) (time.Time, bool) {
+	if priority == db.PriorityCritical {
+		return now, false
+	}
+
+	window, ok, err := quiethours.For(category)
 	if err != nil {
-		logger.Log.Error("Failed to read webhook response", zap.Error(err))
-		insertErr := insertRetry(tx, msg, s, err)
-		if insertErr != nil {
-			return nil, insertErr
-		}
-		return nil, err
+		// a broken window must not hold back every message of the category
+		logger.Log.Error("Invalid delivery window, sending anyway", zap.String("category", category), zap.Error(err))
+		return now, false
+	}
+	if !ok {
+		return now, false
 	}
 
-	var hookResp HookResponse
-	if err := json.Unmarshal(bodyBytes, &hookResp); err != nil {
-		logger.Log.Error("Failed to parse webhook response", zap.ByteString("body", bodyBytes), zap.Error(err))
-		insertErr := insertRetry(tx, msg, s, err)
-		if insertErr != nil {
-			return nil, insertErr
-		}
-		return nil, err
+	location, err := quiethours.Location(timeZone, countryCode)
+	if err != nil {
+		logger.Log.Warn("Unknown recipient time zone, using UTC",
+			zap.String("timeZone", timeZone),
+			zap.String("countryCode", countryCode),
+			zap.Error(err))
+		location = time.UTC
 	}
 
-	return &hookResp, nil
+	deliverAfter := window.Next(now, location)
+	return deliverAfter, deliverAfter.After(now)
 }
 
-func insertRetry(tx *db.Transaction, msg *db.Message, s *MessageService, webhookErr error) error {
-	err := s.repository.InsertRetry(tx, *msg, webhookErr.Error())
-	if err != nil {
-		logger.Log.Error("Failed to insert message as retry", zap.Error(err))
-		return err
+func (s *MessageService) releaseMessages(messages []db.Message) {
+	for i := range messages {
+		if err := s.repository.ReleaseMessage(&messages[i], config.Cfg.Instance.ID); err != nil {
+			logger.Log.Warn("Failed to release message", zap.Uint("messageID", messages[i].ID), zap.Error(err))
+		}
 	}
-	return webhookErr
 }
 
 func (s *MessageService) finalizeMessageProcessing(
 	ctx context.Context,
-	tx *gorm.DB,
 	msg *db.Message,
 	hookResp *HookResponse,
 	timestamp time.Time,
-	redisKey string,
 ) bool {
-	if err := s.repository.UpdateMessageAsSent(tx, msg, hookResp.MessageID, timestamp); err != nil {
-		logger.Log.Error("Failed to update message", zap.Uint("messageID", msg.ID), zap.Error(err))
-		return false
+	// the marker is written before recording so a failed record can be repaired by the reaper
+	err := s.redisClient.Set(ctx, SentMarkerKey(msg.ID), hookResp.MessageID, time.Hour)
+	if err != nil {
+		logger.Log.Warn("Failed to cache message in Redis", zap.Uint("messageID", msg.ID), zap.Error(err))
 	}
 
-	// todo retry caching with exponencial backoff
-	err := s.redisClient.Set(ctx, redisKey, timestamp.String(), time.Hour)
-	if err != nil {
-		logger.Log.Warn("Failed to cache message in Redis", zap.String("key", redisKey), zap.Error(err))
+	pricing.Apply(msg)
+	if !s.record(msg, "sent", s.repository.RecordMessageSent(msg, config.Cfg.Instance.ID, hookResp.MessageID, timestamp)) {
+		return false
 	}
 
 	logger.Log.Info("Message sent and cached",
@@ -253,3 +507,59 @@ func (s *MessageService) finalizeMessageProcessing(
 
 	return true
 }
+
+// record logs the outcome of a record transaction and reports whether it was stored
+func (s *MessageService) record(msg *db.Message, outcome string, err error) bool {
+	if errors.Is(err, repository.ErrLeaseLost) {
+		logger.Log.Warn("Lease lost before recording message", zap.Uint("messageID", msg.ID), zap.String("outcome", outcome))
+		return false
+	}
+	if err != nil {
+		logger.Log.Error("Failed to record message", zap.Uint("messageID", msg.ID), zap.String("outcome", outcome), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+func (s *MessageService) sendMessageToWebhook(msg *db.Message, webhookURL string) (*HookResponse, error) {
+	payload := NewWebhookPayload(msg.PhoneNumber, msg.Content)
+	buf := new(bytes.Buffer)
+	if err := json.NewEncoder(buf).Encode(payload); err != nil {
+		logger.Log.Error("Failed to encode payload to JSON", zap.Error(err))
+		s.record(msg, "error", s.repository.RecordMessageError(msg, config.Cfg.Instance.ID, err.Error()))
+		return nil, err
+	}
+
+	startedAt := time.Now()
+	resp, err := s.httpClient.Post(webhookURL, "application/json", buf)
+	s.tuner.Observe(time.Since(startedAt), adaptive.Classify(resp, err))
+	if err != nil {
+		logger.Log.Error("Failed to send message", zap.Error(err))
+		return nil, s.recordRetry(msg, err)
+	}
+	defer resp.Body.Close()
+
+	if err := CheckWebhookStatus(resp); err != nil {
+		logger.Log.Error("Failed to send message", zap.Error(err))
+		return nil, s.recordRetry(msg, err)
+	}
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logger.Log.Error("Failed to read webhook response", zap.Error(err))
+		return nil, s.recordRetry(msg, err)
+	}
+
+	var hookResp HookResponse
+	if err := json.Unmarshal(bodyBytes, &hookResp); err != nil {
+		logger.Log.Error("Failed to parse webhook response", zap.ByteString("body", bodyBytes), zap.Error(err))
+		return nil, s.recordRetry(msg, err)
+	}
+
+	return &hookResp, nil
+}
+
+func (s *MessageService) recordRetry(msg *db.Message, webhookErr error) error {
+	s.record(msg, "retry", s.repository.RecordMessageRetry(msg, config.Cfg.Instance.ID, webhookErr.Error()))
+	return webhookErr
+}
@@ -1,12 +1,44 @@
 package sendmessages
 
 import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/adaptive"
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
 	"github.com/atakurt/messagingApp/internal/mocks"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
-	"testing"
+	"go.uber.org/zap"
 )
 
+func setTestConfig() {
+	logger.Log = zap.NewNop()
+	config.Cfg.Instance.ID = "pod-a"
+	config.Cfg.Scheduler.BatchSize = 2
+	config.Cfg.Scheduler.MaxConcurrent = 2
+	config.Cfg.Scheduler.LeaseDuration = time.Minute
+	config.Cfg.Priority.ReservedShare = 0.5
+	config.Cfg.Priority.StarvationAge = 5 * time.Minute
+	config.Cfg.WebhookUrl = "http://webhook"
+	config.Cfg.Phone.DefaultRegion = "TR"
+	config.Cfg.Pricing.Provider = "webhook"
+	config.Cfg.Pricing.Prices = map[string]map[string]float64{"webhook": {"90": 0.02}}
+}
+
+func webhookResponse(body string) *http.Response {
+	return &http.Response{StatusCode: http.StatusAccepted, Body: io.NopCloser(strings.NewReader(body))}
+}
+
 func TestNewService(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -22,3 +54,482 @@ func TestNewService(t *testing.T) {
 	assert.Equal(t, mockHttp, service.httpClient)
 	assert.Equal(t, mockRedis, service.redisClient)
 }
+
+func TestProcessUnsentMessages(t *testing.T) {
+	setTestConfig()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+	mockHttp := mocks.NewMockClient(ctrl)
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	service := NewService(mockRepo, mockHttp, mockRedis)
+
+	msg := db.Message{ID: 7, PhoneNumber: "+905321234567", Content: "hello"}
+	mockRepo.EXPECT().ClaimMessages("pod-a", gomock.Any(), 2, db.PriorityBulk, gomock.Any()).DoAndReturn(
+		func(owner string, leaseUntil time.Time, limit int, maxPriority db.Priority, boostBefore time.Time) ([]db.Message, error) {
+			assert.WithinDuration(t, time.Now().Add(time.Minute), leaseUntil, time.Second)
+			assert.WithinDuration(t, time.Now().Add(-5*time.Minute), boostBefore, time.Second)
+			return []db.Message{msg}, nil
+		})
+	mockRedis.EXPECT().Get(gomock.Any(), "message:7").Return("", redisClient.Nil)
+	mockHttp.EXPECT().Post("http://webhook", "application/json", gomock.Any()).
+		Return(webhookResponse(`{"message":"Accepted","messageId":"hook-1"}`), nil)
+	// the marker is written before the outcome is recorded
+	gomock.InOrder(
+		mockRedis.EXPECT().Set(gomock.Any(), "message:7", "hook-1", time.Hour).Return(nil),
+		mockRepo.EXPECT().RecordMessageSent(gomock.Any(), "pod-a", "hook-1", gomock.Any()).DoAndReturn(
+			func(msg *db.Message, owner, messageID string, sentAt time.Time) error {
+				// priced by the country of the number and the segments of the content
+				assert.Equal(t, "webhook", msg.Provider)
+				if assert.NotNil(t, msg.Cost) {
+					assert.InDelta(t, 0.02, *msg.Cost, 1e-9)
+				}
+				return nil
+			}),
+	)
+
+	assert.Equal(t, 1, service.ProcessUnsentMessages(context.Background()))
+	assert.Equal(t, 0, service.InFlight())
+}
+
+func TestProcessMessage(t *testing.T) {
+	setTestConfig()
+	msg := db.Message{ID: 7, PhoneNumber: "+905321234567", Content: "hello"}
+
+	tests := []struct {
+		name      string
+		setup     func(*mocks.MockMessageRepositoryInterface, *mocks.MockClient, *mocks.MockRedisClient)
+		processed bool
+	}{
+		{
+			name: "Sent marker records the message without resending",
+			setup: func(repo *mocks.MockMessageRepositoryInterface, client *mocks.MockClient, redis *mocks.MockRedisClient) {
+				redis.EXPECT().Get(gomock.Any(), "message:7").Return("hook-0", nil)
+				repo.EXPECT().RecordMessageSent(gomock.Any(), "pod-a", "hook-0", gomock.Any()).Return(nil)
+			},
+			processed: true,
+		},
+		{
+			name: "Webhook failure hands the message to the retry flow",
+			setup: func(repo *mocks.MockMessageRepositoryInterface, client *mocks.MockClient, redis *mocks.MockRedisClient) {
+				redis.EXPECT().Get(gomock.Any(), "message:7").Return("", redisClient.Nil)
+				client.EXPECT().Post(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+				repo.EXPECT().RecordMessageRetry(gomock.Any(), "pod-a", "connection refused").Return(nil)
+			},
+			processed: false,
+		},
+		{
+			name: "Invalid webhook response hands the message to the retry flow",
+			setup: func(repo *mocks.MockMessageRepositoryInterface, client *mocks.MockClient, redis *mocks.MockRedisClient) {
+				redis.EXPECT().Get(gomock.Any(), "message:7").Return("", redisClient.Nil)
+				client.EXPECT().Post(gomock.Any(), gomock.Any(), gomock.Any()).Return(webhookResponse("not json"), nil)
+				repo.EXPECT().RecordMessageRetry(gomock.Any(), "pod-a", gomock.Any()).Return(nil)
+			},
+			processed: false,
+		},
+		{
+			name: "Lost lease is not counted as processed",
+			setup: func(repo *mocks.MockMessageRepositoryInterface, client *mocks.MockClient, redis *mocks.MockRedisClient) {
+				redis.EXPECT().Get(gomock.Any(), "message:7").Return("", redisClient.Nil)
+				client.EXPECT().Post(gomock.Any(), gomock.Any(), gomock.Any()).Return(webhookResponse(`{"messageId":"hook-1"}`), nil)
+				redis.EXPECT().Set(gomock.Any(), "message:7", "hook-1", time.Hour).Return(nil)
+				repo.EXPECT().RecordMessageSent(gomock.Any(), "pod-a", "hook-1", gomock.Any()).Return(repository.ErrLeaseLost)
+			},
+			processed: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+			mockHttp := mocks.NewMockClient(ctrl)
+			mockRedis := mocks.NewMockRedisClient(ctrl)
+			tt.setup(mockRepo, mockHttp, mockRedis)
+
+			service := NewService(mockRepo, mockHttp, mockRedis)
+			m := msg
+			assert.Equal(t, tt.processed, service.processMessage(context.Background(), &m))
+		})
+	}
+}
+
+func TestProcessMessagesConcurrently_CancelledBatchReleasesClaims(t *testing.T) {
+	setTestConfig()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+	// nothing is sent, every claimed message goes back to pending
+	mockRepo.EXPECT().ReleaseMessage(gomock.Any(), "pod-a").Return(nil).Times(2)
+	service := NewService(mockRepo, mocks.NewMockClient(ctrl), mocks.NewMockRedisClient(ctrl))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	processed := service.processMessagesConcurrently(ctx, []db.Message{{ID: 1}, {ID: 2}})
+
+	assert.Equal(t, 0, processed)
+	assert.Equal(t, 0, service.InFlight())
+}
+
+type recordingTuner struct {
+	outcomes []adaptive.Outcome
+}
+
+func (t *recordingTuner) BatchSize() int   { return 5 }
+func (t *recordingTuner) Concurrency() int { return 1 }
+func (t *recordingTuner) Observe(latency time.Duration, outcome adaptive.Outcome) {
+	t.outcomes = append(t.outcomes, outcome)
+}
+
+func TestProcessUnsentMessages_UsesTuner(t *testing.T) {
+	setTestConfig()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+	mockHttp := mocks.NewMockClient(ctrl)
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	tuner := &recordingTuner{}
+	service := NewService(mockRepo, mockHttp, mockRedis, WithTuner(tuner))
+
+	mockRepo.EXPECT().ClaimMessages("pod-a", gomock.Any(), 5, db.PriorityBulk, gomock.Any()).Return([]db.Message{{ID: 7}}, nil)
+	mockRedis.EXPECT().Get(gomock.Any(), "message:7").Return("", redisClient.Nil)
+	mockHttp.EXPECT().Post(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader(""))}, nil)
+	// a 5xx is not a delivery, the message goes to the retry flow
+	mockRepo.EXPECT().RecordMessageRetry(gomock.Any(), "pod-a", "webhook returned status 503").Return(nil)
+
+	assert.Equal(t, 1, service.ProcessUnsentMessages(context.Background()))
+	assert.Equal(t, []adaptive.Outcome{adaptive.OutcomeThrottled}, tuner.outcomes)
+}
+
+func TestReservedSlots(t *testing.T) {
+	setTestConfig()
+	config.Cfg.Priority.ReservedShare = 0.25
+
+	assert.Equal(t, 0, reservedSlots(1))
+	assert.Equal(t, 1, reservedSlots(2))
+	assert.Equal(t, 1, reservedSlots(4))
+	assert.Equal(t, 2, reservedSlots(5))
+
+	config.Cfg.Priority.ReservedShare = 1
+	assert.Equal(t, 3, reservedSlots(4), "one slot is always left for normal and bulk messages")
+
+	config.Cfg.Priority.ReservedShare = 0
+	assert.Equal(t, 0, reservedSlots(4))
+}
+
+func TestAcquireSlot(t *testing.T) {
+	general := make(chan struct{}, 1)
+	reserved := make(chan struct{}, 1)
+	general <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Nil(t, acquireSlot(ctx, db.PriorityBulk, general, reserved), "bulk messages may not use the reserved slot")
+	assert.Equal(t, reserved, acquireSlot(context.Background(), db.PriorityCritical, general, reserved))
+}
+
+func TestProcessNext_ClaimsOnlyUrgentWhenGeneralSlotsAreBusy(t *testing.T) {
+	setTestConfig()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+	service := NewService(mockRepo, mocks.NewMockClient(ctrl), mocks.NewMockRedisClient(ctrl))
+
+	// two slots with half reserved, the only general slot is taken by a bulk message
+	service.track(&db.Message{Priority: db.PriorityBulk}, 1)
+	mockRepo.EXPECT().ClaimMessages("pod-a", gomock.Any(), 1, db.PriorityHigh, gomock.Any()).Return(nil, nil)
+
+	assert.False(t, service.ProcessNext(context.Background()))
+	assert.Equal(t, 1, service.InFlight())
+}
+
+type budgetChecker bool
+
+func (b budgetChecker) Exceeded() bool {
+	return bool(b)
+}
+
+func TestProcessNext_ClaimsOnlyCriticalOverBudget(t *testing.T) {
+	setTestConfig()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+	service := NewService(mockRepo, mocks.NewMockClient(ctrl), mocks.NewMockRedisClient(ctrl), WithBudget(budgetChecker(true)))
+
+	mockRepo.EXPECT().ClaimMessages("pod-a", gomock.Any(), 1, db.PriorityCritical, gomock.Any()).Return(nil, nil)
+
+	assert.False(t, service.ProcessNext(context.Background()))
+}
+
+func TestProcessMessage_ExpiredMessageIsNotSent(t *testing.T) {
+	setTestConfig()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	// no webhook call is expected
+	service := NewService(mockRepo, mocks.NewMockClient(ctrl), mockRedis)
+
+	expiresAt := time.Now().Add(-time.Second)
+	msg := db.Message{ID: 7, ExpiresAt: &expiresAt}
+	mockRedis.EXPECT().Get(gomock.Any(), "message:7").Return("", redisClient.Nil)
+	mockRepo.EXPECT().RecordMessageExpired(gomock.Any(), "pod-a").Return(nil)
+
+	assert.False(t, service.processMessage(context.Background(), &msg))
+}
+
+type tenantLookup map[uint]db.Tenant
+
+func (l tenantLookup) Tenant(_ context.Context, id uint) (db.Tenant, error) {
+	tenant, ok := l[id]
+	if !ok {
+		return tenant, repository.ErrTenantNotFound
+	}
+	return tenant, nil
+}
+
+func TestProcessMessage_TenantRoute(t *testing.T) {
+	setTestConfig()
+	lookup := tenantLookup{2: {ID: 2, WebhookURL: "http://team-b", Provider: "other"}}
+
+	t.Run("messages go to the webhook and provider of their tenant", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+		mockHttp := mocks.NewMockClient(ctrl)
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		service := NewService(mockRepo, mockHttp, mockRedis, WithTenants(lookup))
+
+		mockRedis.EXPECT().Get(gomock.Any(), "message:7").Return("", redisClient.Nil)
+		mockHttp.EXPECT().Post("http://team-b", "application/json", gomock.Any()).Return(webhookResponse(`{"messageId":"hook-1"}`), nil)
+		mockRedis.EXPECT().Set(gomock.Any(), "message:7", "hook-1", time.Hour).Return(nil)
+		mockRepo.EXPECT().RecordMessageSent(gomock.Any(), "pod-a", "hook-1", gomock.Any()).DoAndReturn(func(msg *db.Message, _, _ string, _ time.Time) error {
+			assert.Equal(t, "other", msg.Provider)
+			return nil
+		})
+
+		msg := db.Message{ID: 7, TenantID: 2, PhoneNumber: "+905321234567", Content: "hello"}
+		assert.True(t, service.processMessage(context.Background(), &msg))
+	})
+
+	t.Run("tenants without their own use the defaults", func(t *testing.T) {
+		webhookURL, provider := Route(db.Tenant{ID: db.DefaultTenantID})
+		assert.Equal(t, "http://webhook", webhookURL)
+		assert.Equal(t, "webhook", provider)
+	})
+
+	t.Run("message is released when the tenant cannot be read", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		// no webhook call is expected
+		service := NewService(mockRepo, mocks.NewMockClient(ctrl), mockRedis, WithTenants(lookup))
+
+		mockRedis.EXPECT().Get(gomock.Any(), "message:7").Return("", redisClient.Nil)
+		mockRepo.EXPECT().ReleaseMessage(gomock.Any(), "pod-a").Return(nil)
+
+		msg := db.Message{ID: 7, TenantID: 3, PhoneNumber: "+905321234567", Content: "hello"}
+		assert.False(t, service.processMessage(context.Background(), &msg))
+	})
+}
+
+type suppressionChecker struct {
+	suppressed bool
+	err        error
+}
+
+func (c suppressionChecker) IsSuppressed(context.Context, string) (bool, error) {
+	return c.suppressed, c.err
+}
+
+func TestProcessMessage_SuppressedRecipient(t *testing.T) {
+	setTestConfig()
+
+	t.Run("suppressed message is recorded and not sent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		// no webhook call is expected
+		service := NewService(mockRepo, mocks.NewMockClient(ctrl), mockRedis, WithSuppressionChecker(suppressionChecker{suppressed: true}))
+
+		msg := db.Message{ID: 7, PhoneNumber: "+905321234567"}
+		mockRedis.EXPECT().Get(gomock.Any(), "message:7").Return("", redisClient.Nil)
+		mockRepo.EXPECT().RecordMessageSuppressed(&msg, "pod-a").Return(nil)
+
+		assert.False(t, service.processMessage(context.Background(), &msg))
+	})
+
+	t.Run("failed check releases the message", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		service := NewService(mockRepo, mocks.NewMockClient(ctrl), mockRedis, WithSuppressionChecker(suppressionChecker{err: errors.New("db down")}))
+
+		msg := db.Message{ID: 7, PhoneNumber: "+905321234567"}
+		mockRedis.EXPECT().Get(gomock.Any(), "message:7").Return("", redisClient.Nil)
+		mockRepo.EXPECT().ReleaseMessage(gomock.Any(), "pod-a").Return(nil)
+
+		assert.False(t, service.processMessage(context.Background(), &msg))
+	})
+}
+
+func TestDeferred(t *testing.T) {
+	setTestConfig()
+	config.Cfg.Delivery.Windows = map[string]config.DeliveryWindow{"marketing": {Start: "09:00", End: "21:00"}}
+	// 01:00 UTC is 04:00 in Istanbul, the window opens at 06:00 UTC
+	now := time.Date(2026, time.March, 10, 1, 0, 0, 0, time.UTC)
+	opens := time.Date(2026, time.March, 10, 6, 0, 0, 0, time.UTC)
+
+	t.Run("outside the window", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+		service := NewService(mockRepo, mocks.NewMockClient(ctrl), mocks.NewMockRedisClient(ctrl))
+
+		msg := db.Message{ID: 7, Category: "marketing", CountryCode: "90"}
+		mockRepo.EXPECT().DeferMessage(&msg, "pod-a", gomock.Any()).DoAndReturn(func(_ *db.Message, _ string, deliverAfter time.Time) error {
+			assert.True(t, opens.Equal(deliverAfter), deliverAfter)
+			return nil
+		})
+		assert.True(t, service.deferred(&msg, now))
+	})
+
+	t.Run("explicit time zone inside the window", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := NewService(mocks.NewMockMessageRepositoryInterface(ctrl), mocks.NewMockClient(ctrl), mocks.NewMockRedisClient(ctrl))
+
+		msg := db.Message{ID: 7, Category: "marketing", CountryCode: "90", TimeZone: "America/Los_Angeles"}
+		assert.False(t, service.deferred(&msg, time.Date(2026, time.March, 10, 18, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("critical and uncategorized messages bypass the window", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := NewService(mocks.NewMockMessageRepositoryInterface(ctrl), mocks.NewMockClient(ctrl), mocks.NewMockRedisClient(ctrl))
+
+		assert.False(t, service.deferred(&db.Message{ID: 7, Category: "marketing", CountryCode: "90", Priority: db.PriorityCritical}, now))
+		assert.False(t, service.deferred(&db.Message{ID: 8, CountryCode: "90"}, now))
+	})
+
+	t.Run("expiring before the window opens", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+		service := NewService(mockRepo, mocks.NewMockClient(ctrl), mocks.NewMockRedisClient(ctrl))
+
+		expiresAt := now.Add(time.Hour)
+		msg := db.Message{ID: 7, Category: "marketing", CountryCode: "90", ExpiresAt: &expiresAt}
+		mockRepo.EXPECT().RecordMessageExpired(&msg, "pod-a").Return(nil)
+		assert.True(t, service.deferred(&msg, now))
+	})
+}
+
+func TestDedupeKey(t *testing.T) {
+	config.Cfg.Phone.DefaultRegion = "TR"
+
+	key := DedupeKey(1, "+905321234567", "Your order has shipped")
+	assert.Equal(t, key, DedupeKey(1, "0532 123 45 67", "  Your order\nhas  shipped "))
+	assert.NotEqual(t, key, DedupeKey(1, "+905321234568", "Your order has shipped"))
+	assert.NotEqual(t, key, DedupeKey(1, "+905321234567", "Your order has shipped!"))
+	assert.NotEqual(t, key, DedupeKey(2, "+905321234567", "Your order has shipped"))
+}
+
+func TestDuplicate(t *testing.T) {
+	setTestConfig()
+	config.Cfg.Dedupe.Window = 10 * time.Minute
+	defer func() { config.Cfg.Dedupe.Window = 0 }()
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		setupMock func(*mocks.MockMessageRepositoryInterface, *mocks.MockRedisClient, *db.Message)
+		duplicate bool
+	}{
+		{
+			name: "first message",
+			setupMock: func(repo *mocks.MockMessageRepositoryInterface, redis *mocks.MockRedisClient, msg *db.Message) {
+				redis.EXPECT().SetNX(ctx, gomock.Any(), msg.ID, 10*time.Minute).Return(true, nil)
+			},
+		},
+		{
+			name: "repeated content is recorded as a duplicate",
+			setupMock: func(repo *mocks.MockMessageRepositoryInterface, redis *mocks.MockRedisClient, msg *db.Message) {
+				redis.EXPECT().SetNX(ctx, gomock.Any(), msg.ID, 10*time.Minute).Return(false, nil)
+				redis.EXPECT().Get(ctx, gomock.Any()).Return("3", nil)
+				repo.EXPECT().RecordMessageDuplicate(msg, "pod-a", uint(3)).Return(nil)
+			},
+			duplicate: true,
+		},
+		{
+			name: "message claimed again after a release",
+			setupMock: func(repo *mocks.MockMessageRepositoryInterface, redis *mocks.MockRedisClient, msg *db.Message) {
+				redis.EXPECT().SetNX(ctx, gomock.Any(), msg.ID, 10*time.Minute).Return(false, nil)
+				redis.EXPECT().Get(ctx, gomock.Any()).Return("7", nil)
+			},
+		},
+		{
+			name: "Redis error lets the message through",
+			setupMock: func(repo *mocks.MockMessageRepositoryInterface, redis *mocks.MockRedisClient, msg *db.Message) {
+				redis.EXPECT().SetNX(ctx, gomock.Any(), msg.ID, 10*time.Minute).Return(false, errors.New("connection refused"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockRepo := mocks.NewMockMessageRepositoryInterface(ctrl)
+			mockRedis := mocks.NewMockRedisClient(ctrl)
+			service := NewService(mockRepo, mocks.NewMockClient(ctrl), mockRedis)
+
+			msg := db.Message{ID: 7, PhoneNumber: "+905321234567", Content: "Your order has shipped"}
+			tt.setupMock(mockRepo, mockRedis, &msg)
+			assert.Equal(t, tt.duplicate, service.duplicate(ctx, &msg))
+		})
+	}
+}
+
+func TestApplyDefaultExpiry(t *testing.T) {
+	setTestConfig()
+	config.Cfg.Expiry.DefaultTTL = map[string]time.Duration{"critical": 10 * time.Minute}
+	defer func() { config.Cfg.Expiry.DefaultTTL = nil }()
+
+	createdAt := time.Now()
+	otp := db.Message{Priority: db.PriorityCritical, CreatedAt: createdAt}
+	applyDefaultExpiry(&otp)
+	assert.Equal(t, createdAt.Add(10*time.Minute), *otp.ExpiresAt)
+
+	explicit := createdAt.Add(time.Minute)
+	scheduled := db.Message{Priority: db.PriorityCritical, CreatedAt: createdAt, ExpiresAt: &explicit}
+	applyDefaultExpiry(&scheduled)
+	assert.Equal(t, explicit, *scheduled.ExpiresAt)
+
+	promo := db.Message{Priority: db.PriorityBulk, CreatedAt: createdAt}
+	applyDefaultExpiry(&promo)
+	assert.Nil(t, promo.ExpiresAt)
+}
+
+func TestNewWebhookPayload(t *testing.T) {
+	setTestConfig()
+
+	assert.Equal(t, WebhookPayload{Message: "hello", To: "+905321234567"}, NewWebhookPayload("+905321234567", "hello"))
+
+	config.Cfg.SMS.SendSegmentInfo = true
+	defer func() { config.Cfg.SMS.SendSegmentInfo = false }()
+	assert.Equal(t, WebhookPayload{Message: "teşekkürler", To: "+905321234567", Encoding: "UCS-2", Segments: 1},
+		NewWebhookPayload("+905321234567", "teşekkürler"))
+}
+
+func TestCheckWebhookStatus(t *testing.T) {
+	assert.NoError(t, CheckWebhookStatus(&http.Response{StatusCode: http.StatusOK}))
+	assert.NoError(t, CheckWebhookStatus(&http.Response{StatusCode: http.StatusAccepted}))
+	assert.EqualError(t, CheckWebhookStatus(&http.Response{StatusCode: http.StatusInternalServerError}), "webhook returned status 500")
+	assert.EqualError(t, CheckWebhookStatus(&http.Response{StatusCode: http.StatusBadRequest}), "webhook returned status 400")
+	assert.Error(t, CheckWebhookStatus(&http.Response{StatusCode: http.StatusMovedPermanently}))
+}
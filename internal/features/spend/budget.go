@@ -0,0 +1,122 @@
+package spend
+
+import (
+	"sync"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/infrastructure/metrics"
+	"go.uber.org/zap"
+)
+
+// Budget caps the spend of the current UTC day and month, the send flow only claims critical
+// messages while either cap is reached. The spend is read at most once per budget.checkInterval
+// and at the start of a new day, between reads the last result is used.
+type Budget struct {
+	repository SpendRepositoryInterface
+	mu         sync.Mutex
+	status     BudgetStatus
+	checkedAt  time.Time
+}
+
+func NewBudget(repository SpendRepositoryInterface) *Budget {
+	return &Budget{repository: repository}
+}
+
+// BudgetStatus is the spend of the current budget periods against their caps
+// @Description Spend of the current UTC day and month in the configured currency
+type BudgetStatus struct {
+	Currency string `json:"currency"`
+	Daily    Period `json:"daily"`
+	Monthly  Period `json:"monthly"`
+}
+
+// Period is the spend of a budget period starting at Start, Cap is zero when the period is not capped
+type Period struct {
+	Start    time.Time `json:"start"`
+	Spent    float64   `json:"spent"`
+	Cap      float64   `json:"cap"`
+	Exceeded bool      `json:"exceeded"`
+}
+
+// Exceeded reports whether the daily or monthly cap is reached. Without caps the spend is not read.
+func (b *Budget) Exceeded() bool {
+	if config.Cfg.Budget.Daily <= 0 && config.Cfg.Budget.Monthly <= 0 {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(b.checkedAt) >= config.Cfg.Budget.CheckInterval || !b.status.Daily.Start.Equal(dayStart(now)) {
+		if _, err := b.refresh(now); err != nil {
+			// the last known status is kept, an unreadable spend neither pauses nor resumes sending
+			logger.Log.Error("Failed to read spend, keeping the last budget status", zap.Error(err))
+		}
+	}
+	return b.status.Daily.Exceeded || b.status.Monthly.Exceeded
+}
+
+// Check reads the spend now and returns the status of the budget
+func (b *Budget) Check(now time.Time) (BudgetStatus, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.refresh(now)
+}
+
+// refresh reads the spend of the periods containing now and applies it, b.mu must be held
+func (b *Budget) refresh(now time.Time) (BudgetStatus, error) {
+	b.checkedAt = now
+
+	day, month := dayStart(now), monthStart(now)
+	daily, monthly, err := b.repository.BudgetSpend(day, month)
+	if err != nil {
+		return BudgetStatus{}, err
+	}
+
+	status := BudgetStatus{
+		Currency: config.Cfg.Pricing.Currency,
+		Daily:    period(day, daily, config.Cfg.Budget.Daily),
+		Monthly:  period(month, monthly, config.Cfg.Budget.Monthly),
+	}
+
+	wasExceeded := b.status.Daily.Exceeded || b.status.Monthly.Exceeded
+	exceeded := status.Daily.Exceeded || status.Monthly.Exceeded
+	switch {
+	case exceeded && !wasExceeded:
+		logger.Log.Warn("Budget exceeded, only critical messages are sent",
+			zap.Float64("dailySpend", daily),
+			zap.Float64("monthlySpend", monthly),
+			zap.String("currency", status.Currency))
+	case !exceeded && wasExceeded:
+		logger.Log.Info("Spend is under budget, sending resumed")
+	}
+	metrics.BudgetExceeded.WithLabelValues("daily").Set(gauge(status.Daily.Exceeded))
+	metrics.BudgetExceeded.WithLabelValues("monthly").Set(gauge(status.Monthly.Exceeded))
+
+	b.status = status
+	return status, nil
+}
+
+func period(start time.Time, spent, limit float64) Period {
+	return Period{Start: start, Spent: spent, Cap: limit, Exceeded: limit > 0 && spent >= limit}
+}
+
+func dayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func gauge(exceeded bool) float64 {
+	if exceeded {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,136 @@
+package spend
+
+import (
+	"errors"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	GroupDay      = "day"
+	GroupCampaign = "campaign"
+	GroupProvider = "provider"
+	GroupCountry  = "country"
+	GroupTenant   = "tenant"
+)
+
+var groups = map[string]bool{GroupDay: true, GroupCampaign: true, GroupProvider: true, GroupCountry: true, GroupTenant: true}
+
+type SpendRepositoryInterface interface {
+	SpendBy(group string, from, to time.Time) ([]db.SpendTotal, error)
+	BudgetSpend(dayStart, monthStart time.Time) (daily, monthly float64, err error)
+}
+
+type SpendService struct {
+	repository SpendRepositoryInterface
+	budget     *Budget
+}
+
+func NewService(repository SpendRepositoryInterface, budget *Budget) *SpendService {
+	return &SpendService{
+		repository: repository,
+		budget:     budget,
+	}
+}
+
+// Spend is what the messages sent in a time window cost
+// @Description Cost of the messages sent in a time window in the configured currency, per group
+type Spend struct {
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Group    string    `json:"group"`
+	Currency string    `json:"currency"`
+	Total    float64   `json:"total"`
+	// Unpriced counts the messages whose destination had no price, they are not part of Total
+	Unpriced int             `json:"unpriced"`
+	Groups   []db.SpendTotal `json:"groups"`
+}
+
+// GetSpend godoc
+// @Summary      Get spend
+// @Description  Returns the cost of the messages sent in a time window per day, campaign, provider, country or tenant. Campaign, provider and country keys are empty for messages without one. The window defaults to the current UTC month.
+// @Tags         Spend
+// @Produce      json
+// @Param        from   query     string  false  "Start of the window, RFC 3339"
+// @Param        to     query     string  false  "End of the window, RFC 3339, exclusive"
+// @Param        group  query     string  false  "day, campaign, provider, country or tenant, defaults to day"
+// @Success      200    {object}  Spend
+// @Failure      400    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /spend [get]
+func (s *SpendService) GetSpend(c *fiber.Ctx) error {
+	from, to, group, err := window(c, time.Now())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	totals, err := s.repository.SpendBy(group, from, to)
+	if err != nil {
+		logger.Log.Error("Failed to read spend", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to read spend",
+		})
+	}
+
+	spend := Spend{From: from, To: to, Group: group, Currency: config.Cfg.Pricing.Currency, Groups: totals}
+	if spend.Groups == nil {
+		spend.Groups = []db.SpendTotal{}
+	}
+	for _, total := range totals {
+		spend.Total += total.Cost
+		spend.Unpriced += total.Unpriced
+	}
+	return c.JSON(spend)
+}
+
+// GetBudget godoc
+// @Summary      Get budget status
+// @Description  Returns the spend of the current UTC day and month against budget.daily and budget.monthly. While a cap is reached only critical messages are sent, the others stay pending until the next period or a higher cap.
+// @Tags         Spend
+// @Produce      json
+// @Success      200  {object}  BudgetStatus
+// @Failure      500  {object}  map[string]string
+// @Router       /budget [get]
+func (s *SpendService) GetBudget(c *fiber.Ctx) error {
+	status, err := s.budget.Check(time.Now())
+	if err != nil {
+		logger.Log.Error("Failed to read spend", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to read spend",
+		})
+	}
+	return c.JSON(status)
+}
+
+// window reads the time window and group of a request, the default window is the current UTC month
+func window(c *fiber.Ctx, now time.Time) (from, to time.Time, group string, err error) {
+	to = now.UTC()
+	if value := c.Query("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, group, errors.New("invalid to, use RFC 3339")
+		}
+	}
+	from = monthStart(to)
+	if value := c.Query("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, group, errors.New("invalid from, use RFC 3339")
+		}
+	}
+	from, to = from.UTC(), to.UTC()
+	if !from.Before(to) {
+		return from, to, group, errors.New("from must be before to")
+	}
+
+	group = c.Query("group", GroupDay)
+	if !groups[group] {
+		return from, to, group, errors.New("group must be day, campaign, provider, country or tenant")
+	}
+	return from, to, group, nil
+}
@@ -0,0 +1,187 @@
+package spend
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setTestConfig() {
+	logger.Log = zap.NewNop()
+	config.Cfg.Pricing.Currency = "USD"
+	config.Cfg.Budget.Daily = 10
+	config.Cfg.Budget.Monthly = 100
+	config.Cfg.Budget.CheckInterval = 30 * time.Second
+}
+
+func TestGetSpend(t *testing.T) {
+	setTestConfig()
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		url            string
+		setupMock      func(*mocks.MockSpendRepositoryInterface)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Sums the spend per group",
+			url:  "/spend?from=2026-09-01T00:00:00Z&to=2026-10-01T00:00:00Z&group=country",
+			setupMock: func(m *mocks.MockSpendRepositoryInterface) {
+				m.EXPECT().SpendBy(GroupCountry, from, to).Return([]db.SpendTotal{
+					{Key: "44", Messages: 1, Segments: 1, Unpriced: 1},
+					{Key: "90", Messages: 2, Segments: 3, Cost: 0.06},
+				}, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"group":"country","currency":"USD","total":0.06,"unpriced":1,"groups":[{"key":"44","messages":1,"segments":1,"unpriced":1,"cost":0},{"key":"90","messages":2,"segments":3,"unpriced":0,"cost":0.06}]`,
+		},
+		{
+			name: "Defaults to days of the current month",
+			url:  "/spend",
+			setupMock: func(m *mocks.MockSpendRepositoryInterface) {
+				m.EXPECT().SpendBy(GroupDay, gomock.Any(), gomock.Any()).DoAndReturn(func(group string, from, to time.Time) ([]db.SpendTotal, error) {
+					assert.Equal(t, 1, from.Day())
+					assert.Equal(t, to.Month(), from.Month())
+					return nil, nil
+				})
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"group":"day","currency":"USD","total":0,"unpriced":0,"groups":[]`,
+		},
+		{
+			name:           "Invalid group",
+			url:            "/spend?group=week",
+			setupMock:      func(m *mocks.MockSpendRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "group must be day, campaign, provider, country or tenant",
+		},
+		{
+			name:           "Invalid from",
+			url:            "/spend?from=yesterday",
+			setupMock:      func(m *mocks.MockSpendRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "invalid from, use RFC 3339",
+		},
+		{
+			name: "Query errors",
+			url:  "/spend?group=campaign",
+			setupMock: func(m *mocks.MockSpendRepositoryInterface) {
+				m.EXPECT().SpendBy(GroupCampaign, gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody:   "Failed to read spend",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockSpendRepositoryInterface(ctrl)
+			tt.setupMock(mockRepo)
+			service := NewService(mockRepo, NewBudget(mockRepo))
+
+			app := fiber.New()
+			app.Get("/spend", service.GetSpend)
+
+			resp, err := app.Test(httptest.NewRequest("GET", tt.url, nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			body, _ := io.ReadAll(resp.Body)
+			assert.Contains(t, string(body), tt.expectedBody)
+		})
+	}
+}
+
+func TestGetBudget(t *testing.T) {
+	setTestConfig()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSpendRepositoryInterface(ctrl)
+	mockRepo.EXPECT().BudgetSpend(gomock.Any(), gomock.Any()).Return(12.5, 40.0, nil)
+	service := NewService(mockRepo, NewBudget(mockRepo))
+
+	app := fiber.New()
+	app.Get("/budget", service.GetBudget)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/budget", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), `"spent":12.5,"cap":10,"exceeded":true}`)
+	assert.Contains(t, string(body), `"spent":40,"cap":100,"exceeded":false}`)
+}
+
+func TestBudgetExceeded(t *testing.T) {
+	t.Run("reads the spend once per check interval", func(t *testing.T) {
+		setTestConfig()
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockSpendRepositoryInterface(ctrl)
+		budget := NewBudget(mockRepo)
+
+		now := time.Now()
+		mockRepo.EXPECT().BudgetSpend(dayStart(now), monthStart(now)).Return(2.0, 100.0, nil).Times(1)
+
+		assert.True(t, budget.Exceeded(), "the monthly cap is reached")
+		assert.True(t, budget.Exceeded())
+	})
+
+	t.Run("keeps the last status when the spend cannot be read", func(t *testing.T) {
+		setTestConfig()
+		config.Cfg.Budget.CheckInterval = 0
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockSpendRepositoryInterface(ctrl)
+		budget := NewBudget(mockRepo)
+
+		gomock.InOrder(
+			mockRepo.EXPECT().BudgetSpend(gomock.Any(), gomock.Any()).Return(11.0, 11.0, nil),
+			mockRepo.EXPECT().BudgetSpend(gomock.Any(), gomock.Any()).Return(0.0, 0.0, errors.New("db down")),
+			mockRepo.EXPECT().BudgetSpend(gomock.Any(), gomock.Any()).Return(0.0, 0.0, nil),
+		)
+
+		assert.True(t, budget.Exceeded())
+		assert.True(t, budget.Exceeded())
+		assert.False(t, budget.Exceeded(), "sending resumes once the spend is under the caps")
+	})
+
+	t.Run("rereads the spend on a new day", func(t *testing.T) {
+		setTestConfig()
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockSpendRepositoryInterface(ctrl)
+		budget := NewBudget(mockRepo)
+
+		yesterday := time.Now().Add(-24 * time.Hour)
+		budget.status.Daily = period(dayStart(yesterday), 10, 10)
+		budget.checkedAt = time.Now()
+		mockRepo.EXPECT().BudgetSpend(gomock.Any(), gomock.Any()).Return(0.0, 50.0, nil)
+
+		assert.False(t, budget.Exceeded())
+	})
+
+	t.Run("without caps the spend is not read", func(t *testing.T) {
+		setTestConfig()
+		config.Cfg.Budget.Daily, config.Cfg.Budget.Monthly = 0, 0
+		ctrl := gomock.NewController(t)
+		// no query is expected
+		budget := NewBudget(mocks.NewMockSpendRepositoryInterface(ctrl))
+
+		assert.False(t, budget.Exceeded())
+	})
+}
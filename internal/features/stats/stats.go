@@ -0,0 +1,206 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	BucketHour = "hour"
+	BucketDay  = "day"
+)
+
+type StatsRepositoryInterface interface {
+	CountByStatus(from, to time.Time) (map[db.MessageStatus]int, error)
+	SentVolume(from, to time.Time, bucket string) ([]db.VolumeBucket, error)
+	AverageDeliverySeconds(from, to time.Time) (*float64, error)
+	RetryOutcomes(from, to time.Time) (retried, sent int, err error)
+	CountDeadLettered(from, to time.Time) (int, error)
+	TopErrors(from, to time.Time, limit int) ([]db.ErrorCount, error)
+}
+
+// StatsService computes statistics over a time window, results are cached in Redis for
+// stats.cacheTTL so dashboards polling the same window share one computation
+type StatsService struct {
+	repository  StatsRepositoryInterface
+	redisClient redisClient.Client
+}
+
+func NewService(repository StatsRepositoryInterface, redisClient redisClient.Client) *StatsService {
+	return &StatsService{
+		repository:  repository,
+		redisClient: redisClient,
+	}
+}
+
+// Stats are the statistics of a time window
+// @Description Message statistics, counts and rates cover the messages created in the window, volume and delivery time the messages sent in it
+type Stats struct {
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Bucket string    `json:"bucket"`
+	Total  int       `json:"total"`
+	// ByStatus counts the messages by their current status
+	ByStatus map[db.MessageStatus]int `json:"by_status"`
+	Volume   []db.VolumeBucket        `json:"volume"`
+	// AverageDeliverySeconds is the average time from creation to sending, null when nothing was sent
+	AverageDeliverySeconds *float64 `json:"average_delivery_seconds"`
+	// Retried counts the messages that went to the retry flow, RetrySuccessRate the share of them sent in the end
+	Retried          int      `json:"retried"`
+	RetrySuccessRate *float64 `json:"retry_success_rate"`
+	// DeadLettered counts the messages that exhausted their retries, DeadLetterRate is their share of all messages
+	DeadLettered   int             `json:"dead_lettered"`
+	DeadLetterRate *float64        `json:"dead_letter_rate"`
+	TopErrors      []db.ErrorCount `json:"top_errors"`
+}
+
+func CacheKey(from, to time.Time, bucket string) string {
+	return fmt.Sprintf("stats:%s:%d:%d", bucket, from.Unix(), to.Unix())
+}
+
+// GetStats godoc
+// @Summary      Get statistics
+// @Description  Returns message counts by status, sent volume per hour or day, the average time from creation to sending, the retry success and dead letter rates and the most frequent errors over a time window. The window defaults to the last 24 hours and may span up to stats.maxWindow, the bucket defaults to hour for windows up to two days and to day otherwise.
+// @Tags         Stats
+// @Produce      json
+// @Param        from    query     string  false  "Start of the window, RFC 3339"
+// @Param        to      query     string  false  "End of the window, RFC 3339, exclusive"
+// @Param        bucket  query     string  false  "hour or day"
+// @Success      200     {object}  Stats
+// @Failure      400     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /stats [get]
+func (s *StatsService) GetStats(c *fiber.Ctx) error {
+	from, to, bucket, err := window(c, time.Now())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	stats, err := s.Get(c.UserContext(), from, to, bucket)
+	if err != nil {
+		logger.Log.Error("Failed to compute stats", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to compute stats",
+		})
+	}
+	return c.JSON(stats)
+}
+
+// Get returns the statistics of the window from the cache or computes and caches them
+func (s *StatsService) Get(ctx context.Context, from, to time.Time, bucket string) (Stats, error) {
+	key := CacheKey(from, to, bucket)
+	cached, err := s.redisClient.Get(ctx, key)
+	switch {
+	case err == nil:
+		var stats Stats
+		if err := json.Unmarshal([]byte(cached), &stats); err == nil {
+			return stats, nil
+		}
+		logger.Log.Warn("Ignoring unreadable cached stats", zap.String("key", key))
+	case !errors.Is(err, redisClient.Nil):
+		logger.Log.Warn("Failed to read cached stats", zap.String("key", key), zap.Error(err))
+	}
+
+	stats, err := s.compute(from, to, bucket)
+	if err != nil {
+		return stats, err
+	}
+
+	if encoded, err := json.Marshal(stats); err == nil {
+		if err := s.redisClient.Set(ctx, key, string(encoded), config.Cfg.Stats.CacheTTL); err != nil {
+			logger.Log.Warn("Failed to cache stats", zap.String("key", key), zap.Error(err))
+		}
+	}
+	return stats, nil
+}
+
+func (s *StatsService) compute(from, to time.Time, bucket string) (Stats, error) {
+	stats := Stats{From: from, To: to, Bucket: bucket}
+	var err error
+
+	if stats.ByStatus, err = s.repository.CountByStatus(from, to); err != nil {
+		return stats, err
+	}
+	for _, count := range stats.ByStatus {
+		stats.Total += count
+	}
+	if stats.Volume, err = s.repository.SentVolume(from, to, bucket); err != nil {
+		return stats, err
+	}
+	if stats.AverageDeliverySeconds, err = s.repository.AverageDeliverySeconds(from, to); err != nil {
+		return stats, err
+	}
+
+	retried, sent, err := s.repository.RetryOutcomes(from, to)
+	if err != nil {
+		return stats, err
+	}
+	stats.Retried, stats.RetrySuccessRate = retried, rate(sent, retried)
+
+	if stats.DeadLettered, err = s.repository.CountDeadLettered(from, to); err != nil {
+		return stats, err
+	}
+	stats.DeadLetterRate = rate(stats.DeadLettered, stats.Total)
+
+	if stats.TopErrors, err = s.repository.TopErrors(from, to, config.Cfg.Stats.TopErrors); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+// window reads the time window and bucket of a request. The default window ends at the current
+// minute so repeated requests hit the same cache entry.
+func window(c *fiber.Ctx, now time.Time) (from, to time.Time, bucket string, err error) {
+	to = now.UTC().Truncate(time.Minute)
+	if value := c.Query("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, bucket, errors.New("invalid to, use RFC 3339")
+		}
+	}
+	from = to.Add(-24 * time.Hour)
+	if value := c.Query("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, bucket, errors.New("invalid from, use RFC 3339")
+		}
+	}
+	from, to = from.UTC(), to.UTC()
+
+	switch {
+	case !from.Before(to):
+		return from, to, bucket, errors.New("from must be before to")
+	case to.Sub(from) > config.Cfg.Stats.MaxWindow:
+		return from, to, bucket, fmt.Errorf("the window is longer than %s", config.Cfg.Stats.MaxWindow)
+	}
+
+	bucket = c.Query("bucket")
+	switch {
+	case bucket == "" && to.Sub(from) <= 48*time.Hour:
+		bucket = BucketHour
+	case bucket == "":
+		bucket = BucketDay
+	case bucket != BucketHour && bucket != BucketDay:
+		return from, to, bucket, errors.New("bucket must be hour or day")
+	}
+	return from, to, bucket, nil
+}
+
+// rate returns part divided by total, nil when total is zero
+func rate(part, total int) *float64 {
+	if total == 0 {
+		return nil
+	}
+	value := float64(part) / float64(total)
+	return &value
+}
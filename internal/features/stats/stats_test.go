@@ -0,0 +1,207 @@
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setTestConfig() {
+	logger.Log = zap.NewNop()
+	config.Cfg.Stats.CacheTTL = 30 * time.Second
+	config.Cfg.Stats.MaxWindow = 90 * 24 * time.Hour
+	config.Cfg.Stats.TopErrors = 10
+}
+
+func TestGetStats(t *testing.T) {
+	setTestConfig()
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	key := CacheKey(from, to, BucketDay)
+	average := 4.5
+
+	expectQueries := func(m *mocks.MockStatsRepositoryInterface) {
+		m.EXPECT().CountByStatus(from, to).Return(map[db.MessageStatus]int{db.StatusDone: 90, db.StatusError: 10}, nil)
+		m.EXPECT().SentVolume(from, to, BucketDay).Return([]db.VolumeBucket{{Start: from, Sent: 90}}, nil)
+		m.EXPECT().AverageDeliverySeconds(from, to).Return(&average, nil)
+		m.EXPECT().RetryOutcomes(from, to).Return(20, 15, nil)
+		m.EXPECT().CountDeadLettered(from, to).Return(5, nil)
+		m.EXPECT().TopErrors(from, to, 10).Return([]db.ErrorCount{{Error: "timeout", Count: 7}}, nil)
+	}
+
+	tests := []struct {
+		name           string
+		url            string
+		setupMock      func(*mocks.MockStatsRepositoryInterface, *mocks.MockRedisClient)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Computes and caches the stats of a window",
+			url:  "/stats?from=2026-09-01T00:00:00Z&to=2026-10-01T00:00:00Z",
+			setupMock: func(m *mocks.MockStatsRepositoryInterface, redis *mocks.MockRedisClient) {
+				redis.EXPECT().Get(gomock.Any(), key).Return("", redisClient.Nil)
+				expectQueries(m)
+				redis.EXPECT().Set(gomock.Any(), key, gomock.Any(), 30*time.Second).Return(nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"total":100,"by_status":{"done":90,"error":10},"volume":[{"start":"2026-09-01T00:00:00Z","sent":90}],"average_delivery_seconds":4.5,"retried":20,"retry_success_rate":0.75,"dead_lettered":5,"dead_letter_rate":0.05,"top_errors":[{"error":"timeout","count":7}]`,
+		},
+		{
+			name: "Serves cached stats",
+			url:  "/stats?from=2026-09-01T00:00:00Z&to=2026-10-01T00:00:00Z",
+			setupMock: func(m *mocks.MockStatsRepositoryInterface, redis *mocks.MockRedisClient) {
+				redis.EXPECT().Get(gomock.Any(), key).Return(`{"bucket":"day","total":3}`, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"total":3`,
+		},
+		{
+			name: "Computes the stats when Redis fails",
+			url:  "/stats?from=2026-09-01T00:00:00Z&to=2026-10-01T00:00:00Z",
+			setupMock: func(m *mocks.MockStatsRepositoryInterface, redis *mocks.MockRedisClient) {
+				redis.EXPECT().Get(gomock.Any(), key).Return("", errors.New("connection refused"))
+				expectQueries(m)
+				redis.EXPECT().Set(gomock.Any(), key, gomock.Any(), gomock.Any()).Return(errors.New("connection refused"))
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"total":100`,
+		},
+		{
+			name: "Rates are null without messages",
+			url:  "/stats?from=2026-09-01T00:00:00Z&to=2026-09-01T01:00:00Z",
+			setupMock: func(m *mocks.MockStatsRepositoryInterface, redis *mocks.MockRedisClient) {
+				redis.EXPECT().Get(gomock.Any(), gomock.Any()).Return("", redisClient.Nil)
+				m.EXPECT().CountByStatus(gomock.Any(), gomock.Any()).Return(map[db.MessageStatus]int{}, nil)
+				m.EXPECT().SentVolume(gomock.Any(), gomock.Any(), BucketHour).Return(nil, nil)
+				m.EXPECT().AverageDeliverySeconds(gomock.Any(), gomock.Any()).Return(nil, nil)
+				m.EXPECT().RetryOutcomes(gomock.Any(), gomock.Any()).Return(0, 0, nil)
+				m.EXPECT().CountDeadLettered(gomock.Any(), gomock.Any()).Return(0, nil)
+				m.EXPECT().TopErrors(gomock.Any(), gomock.Any(), 10).Return(nil, nil)
+				redis.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"average_delivery_seconds":null,"retried":0,"retry_success_rate":null,"dead_lettered":0,"dead_letter_rate":null`,
+		},
+		{
+			name: "Query errors",
+			url:  "/stats",
+			setupMock: func(m *mocks.MockStatsRepositoryInterface, redis *mocks.MockRedisClient) {
+				redis.EXPECT().Get(gomock.Any(), gomock.Any()).Return("", redisClient.Nil)
+				m.EXPECT().CountByStatus(gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			expectedStatus: fiber.StatusInternalServerError,
+			expectedBody:   "Failed to compute stats",
+		},
+		{
+			name:           "Invalid bucket",
+			url:            "/stats?bucket=week",
+			setupMock:      func(m *mocks.MockStatsRepositoryInterface, redis *mocks.MockRedisClient) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "bucket must be hour or day",
+		},
+		{
+			name:           "Window too long",
+			url:            "/stats?from=2025-01-01T00:00:00Z&to=2026-01-01T00:00:00Z",
+			setupMock:      func(m *mocks.MockStatsRepositoryInterface, redis *mocks.MockRedisClient) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "the window is longer than 2160h0m0s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockStatsRepositoryInterface(ctrl)
+			mockRedis := mocks.NewMockRedisClient(ctrl)
+			tt.setupMock(mockRepo, mockRedis)
+
+			app := fiber.New()
+			app.Get("/stats", NewService(mockRepo, mockRedis).GetStats)
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, tt.url, nil))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			body, _ := io.ReadAll(resp.Body)
+			assert.Contains(t, string(body), tt.expectedBody)
+			if resp.StatusCode == fiber.StatusOK {
+				assert.True(t, json.Valid(body))
+			}
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	setTestConfig()
+	now := time.Date(2026, 10, 19, 12, 30, 45, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		query  string
+		from   time.Time
+		to     time.Time
+		bucket string
+		err    string
+	}{
+		{
+			name:   "Defaults to the last 24 hours by hour",
+			from:   time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC),
+			to:     time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC),
+			bucket: BucketHour,
+		},
+		{
+			name:   "Long windows default to days",
+			query:  "from=2026-10-01T00:00:00%2B03:00",
+			from:   time.Date(2026, 9, 30, 21, 0, 0, 0, time.UTC),
+			to:     time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC),
+			bucket: BucketDay,
+		},
+		{
+			name:  "From after to",
+			query: "from=2026-10-20T00:00:00Z",
+			err:   "from must be before to",
+		},
+		{
+			name:  "Invalid time",
+			query: "to=yesterday",
+			err:   "invalid to, use RFC 3339",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/stats", func(c *fiber.Ctx) error {
+				from, to, bucket, err := window(c, now)
+				if tt.err != "" {
+					assert.EqualError(t, err, tt.err)
+					return nil
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.from, from)
+				assert.Equal(t, tt.to, to)
+				assert.Equal(t, tt.bucket, bucket)
+				return nil
+			})
+			_, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/stats?"+tt.query, nil))
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package suppression
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/phone"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SuppressionRequest is the body of a suppression add
+// @Description Phone number to suppress and why
+type SuppressionRequest struct {
+	PhoneNumber string `json:"phone_number" example:"+905321234567"`
+	Reason      string `json:"reason,omitempty" example:"customer request"`
+}
+
+// SuppressionListResponse is a page of suppressed numbers
+// @Description Paginated list of suppressed numbers ordered by number
+type SuppressionListResponse struct {
+	After string           `json:"after"`
+	Limit int              `json:"limit"`
+	Data  []db.Suppression `json:"data"`
+}
+
+// AddSuppression godoc
+// @Summary      Suppress a phone number
+// @Description  Adds a phone number to the suppression list, messages to it are marked as suppressed instead of being sent. The number is normalized to E.164, adding a number twice keeps the first reason. The list is shared by every tenant, only admin keys change it.
+// @Tags         Suppressions
+// @Accept       json
+// @Produce      json
+// @Param        suppression  body      SuppressionRequest  true  "Suppression"
+// @Success      201          {object}  db.Suppression
+// @Failure      400          {object}  map[string]string
+// @Failure      403          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Router       /suppressions [post]
+func (s *SuppressionService) AddSuppression(c *fiber.Ctx) error {
+	var req SuppressionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+
+	suppression, err := s.Add(c.UserContext(), req.PhoneNumber, req.Reason, SourceAPI)
+	if err != nil {
+		return suppressionError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(suppression)
+}
+
+// RemoveSuppression godoc
+// @Summary      Lift a suppression
+// @Description  Removes a phone number from the suppression list, a leading + must be URL encoded as %2B. Only admin keys lift a suppression.
+// @Tags         Suppressions
+// @Param        phone  path  string  true  "Phone number"
+// @Success      204
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /suppressions/{phone} [delete]
+func (s *SuppressionService) RemoveSuppression(c *fiber.Ctx) error {
+	number, err := url.PathUnescape(c.Params("phone"))
+	if err != nil {
+		return badRequest(c, "invalid phone number")
+	}
+
+	if err := s.Remove(c.UserContext(), number); err != nil {
+		return suppressionError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListSuppressions godoc
+// @Summary      List suppressed numbers
+// @Description  Lists suppressed numbers ordered by number using keyset pagination. The list holds the numbers of every tenant, only admin keys read it.
+// @Tags         Suppressions
+// @Produce      json
+// @Param        after  query     string  false  "Only return numbers sorting after this number"
+// @Param        limit  query     int     false  "Maximum number of suppressions to return (max 100)"
+// @Success      200    {object}  SuppressionListResponse
+// @Failure      403    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /suppressions [get]
+func (s *SuppressionService) ListSuppressions(c *fiber.Ctx) error {
+	after := c.Query("after")
+	limit := c.QueryInt("limit", 0)
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	suppressions, err := s.List(after, limit)
+	if err != nil {
+		return suppressionError(c, err)
+	}
+	return c.JSON(SuppressionListResponse{After: after, Limit: limit, Data: suppressions})
+}
+
+func badRequest(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": message,
+	})
+}
+
+func suppressionError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	message := "Failed to access suppressions"
+	switch {
+	case errors.Is(err, phone.ErrInvalid):
+		status, message = fiber.StatusBadRequest, err.Error()
+	case errors.Is(err, repository.ErrSuppressionNotFound):
+		status, message = fiber.StatusNotFound, err.Error()
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": message,
+	})
+}
@@ -0,0 +1,108 @@
+package suppression
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuppressionHandlers(t *testing.T) {
+	setTestConfig()
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		setupMocks     func(*mocks.MockSuppressionRepositoryInterface, *mocks.MockRedisClient)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "Add suppression",
+			method: fiber.MethodPost,
+			url:    "/suppressions",
+			body:   `{"phone_number":"0532 123 45 67","reason":"complaint"}`,
+			setupMocks: func(r *mocks.MockSuppressionRepositoryInterface, c *mocks.MockRedisClient) {
+				r.EXPECT().AddSuppression(gomock.Any()).Return(nil)
+				c.EXPECT().Set(gomock.Any(), "suppression:+905321234567", "1", gomock.Any()).Return(nil)
+			},
+			expectedStatus: fiber.StatusCreated,
+			expectedBody:   `"source":"api"`,
+		},
+		{
+			name:           "Add rejects an invalid number",
+			method:         fiber.MethodPost,
+			url:            "/suppressions",
+			body:           `{"phone_number":"abc"}`,
+			setupMocks:     func(*mocks.MockSuppressionRepositoryInterface, *mocks.MockRedisClient) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "invalid phone number",
+		},
+		{
+			name:   "List suppressions",
+			method: fiber.MethodGet,
+			url:    "/suppressions?after=%2B90&limit=500",
+			setupMocks: func(r *mocks.MockSuppressionRepositoryInterface, c *mocks.MockRedisClient) {
+				r.EXPECT().ListSuppressions("+90", 100).Return([]db.Suppression{{PhoneNumber: "+905321234567", Source: SourceInbound}}, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"limit":100`,
+		},
+		{
+			name:   "Remove suppression",
+			method: fiber.MethodDelete,
+			url:    "/suppressions/%2B905321234567",
+			setupMocks: func(r *mocks.MockSuppressionRepositoryInterface, c *mocks.MockRedisClient) {
+				r.EXPECT().RemoveSuppression("+905321234567").Return(nil)
+				c.EXPECT().Set(gomock.Any(), "suppression:+905321234567", "0", gomock.Any()).Return(nil)
+			},
+			expectedStatus: fiber.StatusNoContent,
+		},
+		{
+			name:   "Remove unknown number",
+			method: fiber.MethodDelete,
+			url:    "/suppressions/%2B905321234567",
+			setupMocks: func(r *mocks.MockSuppressionRepositoryInterface, c *mocks.MockRedisClient) {
+				r.EXPECT().RemoveSuppression("+905321234567").Return(repository.ErrSuppressionNotFound)
+			},
+			expectedStatus: fiber.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockSuppressionRepositoryInterface(ctrl)
+			mockRedis := mocks.NewMockRedisClient(ctrl)
+			tt.setupMocks(mockRepo, mockRedis)
+			service := NewService(mockRepo, mockRedis)
+
+			app := fiber.New()
+			app.Post("/suppressions", service.AddSuppression)
+			app.Get("/suppressions", service.ListSuppressions)
+			app.Delete("/suppressions/:phone", service.RemoveSuppression)
+
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			body, _ := io.ReadAll(resp.Body)
+			if tt.expectedBody != "" {
+				assert.Contains(t, string(body), tt.expectedBody)
+			}
+		})
+	}
+}
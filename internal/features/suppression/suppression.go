@@ -0,0 +1,121 @@
+package suppression
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/infrastructure/phone"
+	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
+	"go.uber.org/zap"
+)
+
+// Sources record how a number was suppressed
+const (
+	SourceAPI     = "api"
+	SourceInbound = "inbound"
+)
+
+type SuppressionRepositoryInterface interface {
+	AddSuppression(suppression *db.Suppression) error
+	RemoveSuppression(phoneNumber string) error
+	IsSuppressed(phoneNumber string) (bool, error)
+	ListSuppressions(after string, limit int) ([]db.Suppression, error)
+}
+
+// SuppressionService keeps the suppression list in the database and caches lookups in Redis,
+// the cache is shared by every instance and updated on every change
+type SuppressionService struct {
+	repository  SuppressionRepositoryInterface
+	redisClient redisClient.Client
+}
+
+func NewService(repository SuppressionRepositoryInterface, redisClient redisClient.Client) *SuppressionService {
+	return &SuppressionService{
+		repository:  repository,
+		redisClient: redisClient,
+	}
+}
+
+func CacheKey(phoneNumber string) string {
+	return "suppression:" + phoneNumber
+}
+
+// Add suppresses phoneNumber, it is normalized to E.164 first
+func (s *SuppressionService) Add(ctx context.Context, phoneNumber, reason, source string) (db.Suppression, error) {
+	number, err := phone.Normalize(phoneNumber)
+	if err != nil {
+		return db.Suppression{}, err
+	}
+
+	suppression := db.Suppression{PhoneNumber: number, Reason: reason, Source: source}
+	if err := s.repository.AddSuppression(&suppression); err != nil {
+		return suppression, err
+	}
+	s.cache(ctx, number, true)
+	return suppression, nil
+}
+
+// Remove lifts the suppression of phoneNumber
+func (s *SuppressionService) Remove(ctx context.Context, phoneNumber string) error {
+	number, err := phone.Normalize(phoneNumber)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repository.RemoveSuppression(number); err != nil {
+		return err
+	}
+	s.cache(ctx, number, false)
+	return nil
+}
+
+// IsSuppressed reports whether phoneNumber must not receive messages. Numbers that cannot be
+// normalized are looked up as they are.
+func (s *SuppressionService) IsSuppressed(ctx context.Context, phoneNumber string) (bool, error) {
+	number, err := phone.Normalize(phoneNumber)
+	if err != nil {
+		number = strings.TrimSpace(phoneNumber)
+	}
+
+	value, err := s.redisClient.Get(ctx, CacheKey(number))
+	switch {
+	case err == nil:
+		return value == "1", nil
+	case !errors.Is(err, redisClient.Nil):
+		// the database is the source of truth, a broken cache only costs a query
+		logger.Log.Warn("Failed to read suppression cache", zap.String("phoneNumber", number), zap.Error(err))
+	}
+
+	suppressed, err := s.repository.IsSuppressed(number)
+	if err != nil {
+		return false, err
+	}
+	// a change made since the query already wrote the cache, it must not be overwritten
+	if _, err := s.redisClient.SetNX(ctx, CacheKey(number), cacheValue(suppressed), config.Cfg.Suppression.CacheTTL); err != nil {
+		logger.Log.Warn("Failed to fill suppression cache", zap.String("phoneNumber", number), zap.Error(err))
+	}
+	return suppressed, nil
+}
+
+func (s *SuppressionService) List(after string, limit int) ([]db.Suppression, error) {
+	return s.repository.ListSuppressions(after, limit)
+}
+
+// cache overwrites the cached state of number after a change. Numbers that are not suppressed
+// are cached too so sending to them does not query the database every time.
+func (s *SuppressionService) cache(ctx context.Context, number string, suppressed bool) {
+	if err := s.redisClient.Set(ctx, CacheKey(number), cacheValue(suppressed), config.Cfg.Suppression.CacheTTL); err != nil {
+		logger.Log.Warn("Failed to update suppression cache", zap.String("phoneNumber", number), zap.Error(err))
+	}
+}
+
+func cacheValue(suppressed bool) string {
+	if suppressed {
+		return "1"
+	}
+	return "0"
+}
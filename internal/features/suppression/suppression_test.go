@@ -0,0 +1,106 @@
+package suppression
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func setTestConfig() {
+	logger.Log = zap.NewNop()
+	config.Cfg.Phone.DefaultRegion = "TR"
+	config.Cfg.Suppression.CacheTTL = time.Minute
+}
+
+func TestIsSuppressed(t *testing.T) {
+	setTestConfig()
+	ctx := context.Background()
+
+	t.Run("cached", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockSuppressionRepositoryInterface(ctrl)
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		service := NewService(mockRepo, mockRedis)
+
+		mockRedis.EXPECT().Get(ctx, "suppression:+905321234567").Return("1", nil)
+
+		suppressed, err := service.IsSuppressed(ctx, "0532 123 45 67")
+		assert.NoError(t, err)
+		assert.True(t, suppressed)
+	})
+
+	t.Run("cache miss fills the cache without overwriting", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockSuppressionRepositoryInterface(ctrl)
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		service := NewService(mockRepo, mockRedis)
+
+		mockRedis.EXPECT().Get(ctx, "suppression:+905321234567").Return("", redisClient.Nil)
+		mockRepo.EXPECT().IsSuppressed("+905321234567").Return(false, nil)
+		mockRedis.EXPECT().SetNX(ctx, "suppression:+905321234567", "0", time.Minute).Return(true, nil)
+
+		suppressed, err := service.IsSuppressed(ctx, "+905321234567")
+		assert.NoError(t, err)
+		assert.False(t, suppressed)
+	})
+
+	t.Run("broken cache falls back to the database", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockSuppressionRepositoryInterface(ctrl)
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		service := NewService(mockRepo, mockRedis)
+
+		mockRedis.EXPECT().Get(ctx, "suppression:+905321234567").Return("", errors.New("connection refused"))
+		mockRepo.EXPECT().IsSuppressed("+905321234567").Return(true, nil)
+		mockRedis.EXPECT().SetNX(ctx, "suppression:+905321234567", "1", time.Minute).Return(false, errors.New("connection refused"))
+
+		suppressed, err := service.IsSuppressed(ctx, "+905321234567")
+		assert.NoError(t, err)
+		assert.True(t, suppressed)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockSuppressionRepositoryInterface(ctrl)
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		service := NewService(mockRepo, mockRedis)
+
+		mockRedis.EXPECT().Get(ctx, "suppression:12345").Return("", redisClient.Nil)
+		mockRepo.EXPECT().IsSuppressed("12345").Return(false, errors.New("db down"))
+
+		_, err := service.IsSuppressed(ctx, "12345")
+		assert.Error(t, err)
+	})
+}
+
+func TestAddAndRemoveOverwriteTheCache(t *testing.T) {
+	setTestConfig()
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	mockRepo := mocks.NewMockSuppressionRepositoryInterface(ctrl)
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	service := NewService(mockRepo, mockRedis)
+
+	mockRepo.EXPECT().AddSuppression(&db.Suppression{PhoneNumber: "+905321234567", Reason: "asked", Source: SourceAPI}).Return(nil)
+	mockRedis.EXPECT().Set(ctx, "suppression:+905321234567", "1", time.Minute).Return(nil)
+	suppression, err := service.Add(ctx, "05321234567", "asked", SourceAPI)
+	assert.NoError(t, err)
+	assert.Equal(t, "+905321234567", suppression.PhoneNumber)
+
+	mockRepo.EXPECT().RemoveSuppression("+905321234567").Return(nil)
+	mockRedis.EXPECT().Set(ctx, "suppression:+905321234567", "0", time.Minute).Return(nil)
+	assert.NoError(t, service.Remove(ctx, "+90 532 123 45 67"))
+
+	_, err = service.Add(ctx, "not a number", "", SourceAPI)
+	assert.Error(t, err)
+}
@@ -0,0 +1,104 @@
+package templates
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"golang.org/x/text/language"
+)
+
+// CanonicalLocale validates a BCP 47 tag and returns it in canonical form, e.g. tr-tr becomes tr-TR
+func CanonicalLocale(locale string) (string, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return "", fmt.Errorf("invalid locale %q", locale)
+	}
+	return tag.String(), nil
+}
+
+// FallbackChain returns the locales tried for locale in order: the locale itself, its parents
+// with the last subtag removed and then the configured fallback locales, e.g. tr-TR, tr, en
+func FallbackChain(locale string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+	add := func(locale string) {
+		canonical, err := CanonicalLocale(locale)
+		if err != nil || seen[canonical] {
+			return
+		}
+		seen[canonical] = true
+		chain = append(chain, canonical)
+	}
+
+	for locale != "" {
+		add(locale)
+		cut := strings.LastIndexAny(locale, "-_")
+		if cut < 0 {
+			break
+		}
+		locale = locale[:cut]
+	}
+	for _, fallback := range config.Cfg.Templates.FallbackLocales {
+		add(fallback)
+	}
+	return chain
+}
+
+// Resolve picks the body of template for locale following FallbackChain.
+// The default body is used when no locale of the chain has a variant, the returned locale is then empty.
+func Resolve(template db.Template, locale string) (body string, resolved string) {
+	for _, candidate := range FallbackChain(locale) {
+		if variant, ok := template.Locales[candidate]; ok {
+			return variant, candidate
+		}
+	}
+	return template.Body, ""
+}
+
+// NormalizeLocales canonicalizes the locale keys of variants
+func NormalizeLocales(variants map[string]string) (map[string]string, error) {
+	normalized := make(map[string]string, len(variants))
+	for locale, body := range variants {
+		canonical, err := CanonicalLocale(locale)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := normalized[canonical]; exists {
+			return nil, fmt.Errorf("locale %s is given more than once", canonical)
+		}
+		normalized[canonical] = body
+	}
+	return normalized, nil
+}
+
+// ValidateLocales checks every locale variant like Validate and that it declares the same
+// variables as the default body, so a message renders with the same variables in any locale
+func ValidateLocales(body string, variants map[string]string) error {
+	expected := variableSet(body)
+
+	locales := make([]string, 0, len(variants))
+	for locale := range variants {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+
+	for _, locale := range locales {
+		if err := Validate(variants[locale]); err != nil {
+			return fmt.Errorf("locale %s: %w", locale, err)
+		}
+		if actual := variableSet(variants[locale]); actual != expected {
+			return fmt.Errorf("locale %s declares variables [%s], the default body declares [%s]", locale, actual, expected)
+		}
+	}
+	return nil
+}
+
+// variableSet returns the sorted variable names of body joined with a comma
+func variableSet(body string) string {
+	names := Placeholders(body)
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
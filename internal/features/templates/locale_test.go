@@ -0,0 +1,58 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFallbackChain(t *testing.T) {
+	setTestConfig()
+
+	assert.Equal(t, []string{"tr-TR", "tr", "en"}, FallbackChain("tr-tr"))
+	assert.Equal(t, []string{"en-GB", "en"}, FallbackChain("en-GB"))
+	assert.Equal(t, []string{"en"}, FallbackChain(""))
+	assert.Equal(t, []string{"en"}, FallbackChain("not a locale"))
+}
+
+func TestResolve(t *testing.T) {
+	setTestConfig()
+
+	template := db.Template{
+		Body:    "Code {{code}}",
+		Locales: map[string]string{"tr": "Kodunuz {{code}}", "en": "Your code is {{code}}"},
+	}
+
+	body, locale := Resolve(template, "tr-TR")
+	assert.Equal(t, "Kodunuz {{code}}", body)
+	assert.Equal(t, "tr", locale)
+
+	body, locale = Resolve(template, "de-DE")
+	assert.Equal(t, "Your code is {{code}}", body)
+	assert.Equal(t, "en", locale)
+
+	body, locale = Resolve(db.Template{Body: "Code {{code}}"}, "de-DE")
+	assert.Equal(t, "Code {{code}}", body)
+	assert.Empty(t, locale)
+}
+
+func TestNormalizeLocales(t *testing.T) {
+	locales, err := NormalizeLocales(map[string]string{"tr-tr": "a", "EN": "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"tr-TR": "a", "en": "b"}, locales)
+
+	_, err = NormalizeLocales(map[string]string{"tr-TR": "a", "tr-tr": "b"})
+	assert.EqualError(t, err, "locale tr-TR is given more than once")
+
+	_, err = NormalizeLocales(map[string]string{"??": "a"})
+	assert.EqualError(t, err, `invalid locale "??"`)
+}
+
+func TestValidateLocales(t *testing.T) {
+	setTestConfig()
+	assert.NoError(t, ValidateLocales("Hi {{name}}, code {{code}}", map[string]string{"tr": "Kod {{code}}, merhaba {{name}}"}))
+	assert.EqualError(t, ValidateLocales("Hi {{name}}", map[string]string{"tr": "Merhaba"}),
+		"locale tr declares variables [], the default body declares [name]")
+	assert.Error(t, ValidateLocales("Hi {{name}}", map[string]string{"tr": "Merhaba {{name"}))
+}
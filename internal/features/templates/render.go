@@ -0,0 +1,65 @@
+package templates
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/sms"
+)
+
+// placeholder matches {{name}}, surrounding spaces inside the braces are allowed
+var placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Placeholders returns the distinct variable names used in body in order of appearance
+func Placeholders(body string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range placeholder.FindAllStringSubmatch(body, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// Validate checks that every {{ in body opens a well formed placeholder and that the
+// text outside the placeholders fits in the allowed number of segments
+func Validate(body string) error {
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("template body is empty")
+	}
+
+	static := placeholder.ReplaceAllString(body, "")
+	if strings.Contains(static, "{{") || strings.Contains(static, "}}") {
+		return fmt.Errorf("template body contains a malformed placeholder")
+	}
+	if _, err := sms.Check(static, config.Cfg.SMS.MaxSegments); err != nil {
+		return fmt.Errorf("template text is too long: %w", err)
+	}
+	return nil
+}
+
+// Render substitutes variables into body, every placeholder must have a variable and
+// the rendered content must fit in the allowed number of segments
+func Render(body string, variables map[string]string) (string, error) {
+	var missing []string
+	for _, name := range Placeholders(body) {
+		if _, ok := variables[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing template variables: %s", strings.Join(missing, ", "))
+	}
+
+	content := placeholder.ReplaceAllStringFunc(body, func(match string) string {
+		return variables[placeholder.FindStringSubmatch(match)[1]]
+	})
+	if _, err := sms.Check(content, config.Cfg.SMS.MaxSegments); err != nil {
+		return "", fmt.Errorf("rendered content is too long: %w", err)
+	}
+	return content, nil
+}
@@ -0,0 +1,42 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func setTestConfig() {
+	config.Cfg.SMS.MaxSegments = 1
+	config.Cfg.Templates.FallbackLocales = []string{"en"}
+}
+
+func TestPlaceholders(t *testing.T) {
+	assert.Equal(t, []string{"name", "code"}, Placeholders("Hi {{name}}, your code is {{ code }}. Bye {{name}}"))
+	assert.Empty(t, Placeholders("No variables"))
+}
+
+func TestValidate(t *testing.T) {
+	setTestConfig()
+	assert.NoError(t, Validate("Your code is {{code}}"))
+	assert.Error(t, Validate("  "))
+	assert.Error(t, Validate("Your code is {{code"))
+	assert.Error(t, Validate("Your code is {{1code}}"))
+	assert.Error(t, Validate(strings.Repeat("a", 161)))
+	assert.NoError(t, Validate(strings.Repeat("a", 150)+"{{long_variable_name}}"))
+}
+
+func TestRender(t *testing.T) {
+	setTestConfig()
+	content, err := Render("Hi {{name}}, your code is {{ code }}", map[string]string{"name": "Ada", "code": "1234"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Hi Ada, your code is 1234", content)
+
+	_, err = Render("Hi {{name}}, your code is {{code}}", map[string]string{"name": "Ada"})
+	assert.EqualError(t, err, "missing template variables: code")
+
+	_, err = Render("Note: {{text}}", map[string]string{"text": strings.Repeat("ş", 160)})
+	assert.EqualError(t, err, "rendered content is too long: content needs 3 UCS-2 segments, at most 1 are allowed")
+}
@@ -0,0 +1,238 @@
+package templates
+
+import (
+	"errors"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+type TemplateRepositoryInterface interface {
+	CreateTemplate(template *db.Template) error
+	GetTemplate(id uint) (db.Template, error)
+	ListTemplates(lastID, limit int) ([]db.Template, error)
+	ListTemplateVersions(id uint) ([]db.TemplateVersion, error)
+	UpdateTemplate(id uint, body string, locales map[string]string) (db.Template, error)
+	DeleteTemplate(id uint) error
+}
+
+type TemplateService struct {
+	repository TemplateRepositoryInterface
+}
+
+func NewService(repository TemplateRepositoryInterface) *TemplateService {
+	return &TemplateService{
+		repository: repository,
+	}
+}
+
+// TemplateRequest is the body of a template create or update
+// @Description Template name, default body and bodies per locale, placeholders are written as {{name}}
+type TemplateRequest struct {
+	Name    string            `json:"name" example:"otp"`
+	Body    string            `json:"body" example:"Your code is {{code}}"`
+	Locales map[string]string `json:"locales,omitempty"`
+}
+
+// validate checks the bodies and returns the locale variants with canonical keys
+func (r TemplateRequest) validate() (map[string]string, error) {
+	if err := Validate(r.Body); err != nil {
+		return nil, err
+	}
+	locales, err := NormalizeLocales(r.Locales)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateLocales(r.Body, locales); err != nil {
+		return nil, err
+	}
+	return locales, nil
+}
+
+// TemplateListResponse is a page of templates
+// @Description Paginated list of templates
+type TemplateListResponse struct {
+	LastID int           `json:"last_id"`
+	Limit  int           `json:"limit"`
+	Data   []db.Template `json:"data"`
+}
+
+// CreateTemplate godoc
+// @Summary      Create a template
+// @Description  Stores a named template as version 1. Placeholders are written as {{name}} and filled from the variables of a message. Every locale variant must declare the same variables as the default body.
+// @Tags         Templates
+// @Accept       json
+// @Produce      json
+// @Param        template  body      TemplateRequest  true  "Template"
+// @Success      201       {object}  db.Template
+// @Failure      400       {object}  map[string]string
+// @Failure      409       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /templates [post]
+func (s *TemplateService) CreateTemplate(c *fiber.Ctx) error {
+	var req TemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+	if req.Name == "" {
+		return badRequest(c, "name is required")
+	}
+	locales, err := req.validate()
+	if err != nil {
+		return badRequest(c, err.Error())
+	}
+
+	template := db.Template{Name: req.Name, Body: req.Body, Locales: locales}
+	if err := s.repository.CreateTemplate(&template); err != nil {
+		return templateError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(template)
+}
+
+// ListTemplates godoc
+// @Summary      List templates
+// @Description  Lists the current version of every template using keyset pagination
+// @Tags         Templates
+// @Produce      json
+// @Param        last_id  query     int  false  "Only return templates with ID > last_id"
+// @Param        limit    query     int  false  "Maximum number of templates to return (max 100)"
+// @Success      200      {object}  TemplateListResponse
+// @Failure      500      {object}  map[string]string
+// @Router       /templates [get]
+func (s *TemplateService) ListTemplates(c *fiber.Ctx) error {
+	lastID := c.QueryInt("last_id", 0)
+	limit := c.QueryInt("limit", 0)
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	templates, err := s.repository.ListTemplates(lastID, limit)
+	if err != nil {
+		return templateError(c, err)
+	}
+	return c.JSON(TemplateListResponse{LastID: lastID, Limit: limit, Data: templates})
+}
+
+// GetTemplate godoc
+// @Summary      Get a template
+// @Description  Returns the current version of a template
+// @Tags         Templates
+// @Produce      json
+// @Param        id   path      int  true  "Template ID"
+// @Success      200  {object}  db.Template
+// @Failure      404  {object}  map[string]string
+// @Router       /templates/{id} [get]
+func (s *TemplateService) GetTemplate(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return badRequest(c, "invalid template id")
+	}
+
+	template, err := s.repository.GetTemplate(uint(id))
+	if err != nil {
+		return templateError(c, err)
+	}
+	return c.JSON(template)
+}
+
+// ListTemplateVersions godoc
+// @Summary      List template versions
+// @Description  Returns every body the template had, messages record the version they were rendered from
+// @Tags         Templates
+// @Produce      json
+// @Param        id   path      int  true  "Template ID"
+// @Success      200  {array}   db.TemplateVersion
+// @Failure      404  {object}  map[string]string
+// @Router       /templates/{id}/versions [get]
+func (s *TemplateService) ListTemplateVersions(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return badRequest(c, "invalid template id")
+	}
+
+	versions, err := s.repository.ListTemplateVersions(uint(id))
+	if err != nil {
+		return templateError(c, err)
+	}
+	return c.JSON(versions)
+}
+
+// UpdateTemplate godoc
+// @Summary      Update a template
+// @Description  Replaces the body and locale variants under a new version, the name cannot be changed. Messages already enqueued keep their rendered content. Templates are shared by every tenant, only admin keys change them.
+// @Tags         Templates
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int              true  "Template ID"
+// @Param        template  body      TemplateRequest  true  "Template, the name is ignored"
+// @Success      200       {object}  db.Template
+// @Failure      400       {object}  map[string]string
+// @Failure      403       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Router       /templates/{id} [put]
+func (s *TemplateService) UpdateTemplate(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return badRequest(c, "invalid template id")
+	}
+
+	var req TemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+	locales, err := req.validate()
+	if err != nil {
+		return badRequest(c, err.Error())
+	}
+
+	template, err := s.repository.UpdateTemplate(uint(id), req.Body, locales)
+	if err != nil {
+		return templateError(c, err)
+	}
+	return c.JSON(template)
+}
+
+// DeleteTemplate godoc
+// @Summary      Delete a template
+// @Description  Removes a template from use, messages rendered from it keep their template id and version. Only admin keys delete templates.
+// @Tags         Templates
+// @Param        id   path  int  true  "Template ID"
+// @Success      204
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /templates/{id} [delete]
+func (s *TemplateService) DeleteTemplate(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return badRequest(c, "invalid template id")
+	}
+
+	if err := s.repository.DeleteTemplate(uint(id)); err != nil {
+		return templateError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func badRequest(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": message,
+	})
+}
+
+func templateError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	message := "Failed to access templates"
+	switch {
+	case errors.Is(err, repository.ErrTemplateNotFound):
+		status, message = fiber.StatusNotFound, err.Error()
+	case errors.Is(err, repository.ErrTemplateExists):
+		status, message = fiber.StatusConflict, err.Error()
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": message,
+	})
+}
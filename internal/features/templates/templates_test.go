@@ -0,0 +1,177 @@
+package templates
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateHandlers(t *testing.T) {
+	setTestConfig()
+	otp := db.Template{ID: 3, Name: "otp", Version: 2, Body: "Your code is {{code}}"}
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		setupMock      func(*mocks.MockTemplateRepositoryInterface)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "Create template",
+			method: fiber.MethodPost,
+			url:    "/templates",
+			body:   `{"name":"otp","body":"Your code is {{code}}"}`,
+			setupMock: func(m *mocks.MockTemplateRepositoryInterface) {
+				m.EXPECT().CreateTemplate(gomock.Any()).DoAndReturn(func(template *db.Template) error {
+					assert.Equal(t, "otp", template.Name)
+					template.ID, template.Version = 3, 1
+					return nil
+				})
+			},
+			expectedStatus: fiber.StatusCreated,
+			expectedBody:   `"version":1`,
+		},
+		{
+			name:           "Create rejects a malformed placeholder",
+			method:         fiber.MethodPost,
+			url:            "/templates",
+			body:           `{"name":"otp","body":"Your code is {{code"}`,
+			setupMock:      func(m *mocks.MockTemplateRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "malformed placeholder",
+		},
+		{
+			name:           "Create rejects locales with different variables",
+			method:         fiber.MethodPost,
+			url:            "/templates",
+			body:           `{"name":"otp","body":"Your code is {{code}}","locales":{"tr":"Kodunuz {{kod}}"}}`,
+			setupMock:      func(m *mocks.MockTemplateRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "locale tr declares variables [kod], the default body declares [code]",
+		},
+		{
+			name:   "Create reports a taken name",
+			method: fiber.MethodPost,
+			url:    "/templates",
+			body:   `{"name":"otp","body":"Your code is {{code}}"}`,
+			setupMock: func(m *mocks.MockTemplateRepositoryInterface) {
+				m.EXPECT().CreateTemplate(gomock.Any()).Return(repository.ErrTemplateExists)
+			},
+			expectedStatus: fiber.StatusConflict,
+			expectedBody:   repository.ErrTemplateExists.Error(),
+		},
+		{
+			name:   "Get template",
+			method: fiber.MethodGet,
+			url:    "/templates/3",
+			setupMock: func(m *mocks.MockTemplateRepositoryInterface) {
+				m.EXPECT().GetTemplate(uint(3)).Return(otp, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"name":"otp"`,
+		},
+		{
+			name:   "Get unknown template",
+			method: fiber.MethodGet,
+			url:    "/templates/4",
+			setupMock: func(m *mocks.MockTemplateRepositoryInterface) {
+				m.EXPECT().GetTemplate(uint(4)).Return(db.Template{}, repository.ErrTemplateNotFound)
+			},
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody:   repository.ErrTemplateNotFound.Error(),
+		},
+		{
+			name:   "List templates",
+			method: fiber.MethodGet,
+			url:    "/templates?last_id=2&limit=500",
+			setupMock: func(m *mocks.MockTemplateRepositoryInterface) {
+				m.EXPECT().ListTemplates(2, 100).Return([]db.Template{otp}, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"limit":100`,
+		},
+		{
+			name:   "List versions",
+			method: fiber.MethodGet,
+			url:    "/templates/3/versions",
+			setupMock: func(m *mocks.MockTemplateRepositoryInterface) {
+				m.EXPECT().ListTemplateVersions(uint(3)).Return([]db.TemplateVersion{{TemplateID: 3, Version: 1, Body: "Code: {{code}}"}}, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"body":"Code: {{code}}"`,
+		},
+		{
+			name:   "Update bumps the version",
+			method: fiber.MethodPut,
+			url:    "/templates/3",
+			body:   `{"body":"Your code is {{code}}","locales":{"tr-tr":"Kodunuz {{code}}"}}`,
+			setupMock: func(m *mocks.MockTemplateRepositoryInterface) {
+				m.EXPECT().UpdateTemplate(uint(3), "Your code is {{code}}", map[string]string{"tr-TR": "Kodunuz {{code}}"}).Return(otp, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"version":2`,
+		},
+		{
+			name:   "Delete template",
+			method: fiber.MethodDelete,
+			url:    "/templates/3",
+			setupMock: func(m *mocks.MockTemplateRepositoryInterface) {
+				m.EXPECT().DeleteTemplate(uint(3)).Return(nil)
+			},
+			expectedStatus: fiber.StatusNoContent,
+		},
+		{
+			name:           "Invalid id",
+			method:         fiber.MethodDelete,
+			url:            "/templates/abc",
+			setupMock:      func(m *mocks.MockTemplateRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "invalid template id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockTemplateRepositoryInterface(ctrl)
+			tt.setupMock(mockRepo)
+			service := NewService(mockRepo)
+
+			app := fiber.New()
+			app.Post("/templates", service.CreateTemplate)
+			app.Get("/templates", service.ListTemplates)
+			app.Get("/templates/:id", service.GetTemplate)
+			app.Get("/templates/:id/versions", service.ListTemplateVersions)
+			app.Put("/templates/:id", service.UpdateTemplate)
+			app.Delete("/templates/:id", service.DeleteTemplate)
+
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			body, _ := io.ReadAll(resp.Body)
+			if tt.expectedBody != "" {
+				assert.Contains(t, string(body), tt.expectedBody)
+			}
+			if resp.StatusCode == fiber.StatusOK {
+				assert.True(t, json.Valid(body))
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package tenants
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// TenantRequest is the body of a tenant create or update
+// @Description Tenant settings, webhook_url and provider default to webhookUrl and pricing.provider, zero limits are unlimited
+type TenantRequest struct {
+	Name       string `json:"name" example:"team-b"`
+	WebhookURL string `json:"webhook_url,omitempty" example:"https://hooks.example.com/sms"`
+	Provider   string `json:"provider,omitempty" example:"webhook"`
+	RateLimit  int    `json:"rate_limit,omitempty" example:"50"`
+	DailyQuota int    `json:"daily_quota,omitempty" example:"100000"`
+}
+
+// APIKeyRequest is the body of an API key create
+// @Description Optional name telling the key apart, admin keys may only be created for the default tenant
+type APIKeyRequest struct {
+	Name  string `json:"name,omitempty" example:"ci"`
+	Admin bool   `json:"admin,omitempty"`
+}
+
+// APIKeyResponse is a new API key, the key itself is only returned once
+type APIKeyResponse struct {
+	db.APIKey
+	Key string `json:"key"`
+}
+
+func (r *TenantRequest) validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	switch {
+	case r.Name == "" || len(r.Name) > 255:
+		return errors.New("name is required and at most 255 characters")
+	case len(r.Provider) > 50:
+		return errors.New("provider is at most 50 characters")
+	case r.RateLimit < 0 || r.DailyQuota < 0:
+		return errors.New("rate_limit and daily_quota must not be negative")
+	}
+	if r.WebhookURL != "" {
+		u, err := url.ParseRequestURI(r.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("webhook_url must be an http or https URL")
+		}
+	}
+	return nil
+}
+
+// CreateTenant godoc
+// @Summary      Create a tenant
+// @Description  Creates a tenant, create an API key for it to send as the tenant. Only the default tenant manages tenants.
+// @Tags         Tenants
+// @Accept       json
+// @Produce      json
+// @Param        tenant  body      TenantRequest  true  "Tenant"
+// @Success      201     {object}  db.Tenant
+// @Failure      400     {object}  map[string]string
+// @Failure      403     {object}  map[string]string
+// @Failure      409     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /tenants [post]
+func (s *TenantService) CreateTenant(c *fiber.Ctx) error {
+	var req TenantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+	if err := req.validate(); err != nil {
+		return badRequest(c, err.Error())
+	}
+
+	tenant := db.Tenant{
+		Name:       req.Name,
+		WebhookURL: req.WebhookURL,
+		Provider:   req.Provider,
+		RateLimit:  req.RateLimit,
+		DailyQuota: req.DailyQuota,
+	}
+	if err := s.repository.CreateTenant(&tenant); err != nil {
+		return tenantError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(tenant)
+}
+
+// ListTenants godoc
+// @Summary      List tenants
+// @Description  Lists every tenant ordered by ID
+// @Tags         Tenants
+// @Produce      json
+// @Success      200  {array}   db.Tenant
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /tenants [get]
+func (s *TenantService) ListTenants(c *fiber.Ctx) error {
+	tenants, err := s.repository.ListTenants()
+	if err != nil {
+		return tenantError(c, err)
+	}
+	if tenants == nil {
+		tenants = []db.Tenant{}
+	}
+	return c.JSON(tenants)
+}
+
+// GetTenant godoc
+// @Summary      Get a tenant
+// @Tags         Tenants
+// @Produce      json
+// @Param        id   path      int  true  "Tenant ID"
+// @Success      200  {object}  db.Tenant
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /tenants/{id} [get]
+func (s *TenantService) GetTenant(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return badRequest(c, "invalid tenant id")
+	}
+
+	tenant, err := s.repository.GetTenant(uint(id))
+	if err != nil {
+		return tenantError(c, err)
+	}
+	return c.JSON(tenant)
+}
+
+// UpdateTenant godoc
+// @Summary      Update a tenant
+// @Description  Replaces the settings of a tenant. Other instances apply the change once their cache entry expires after tenants.cacheTTL.
+// @Tags         Tenants
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int            true  "Tenant ID"
+// @Param        tenant  body      TenantRequest  true  "Tenant"
+// @Success      200     {object}  db.Tenant
+// @Failure      400     {object}  map[string]string
+// @Failure      403     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Failure      409     {object}  map[string]string
+// @Router       /tenants/{id} [put]
+func (s *TenantService) UpdateTenant(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return badRequest(c, "invalid tenant id")
+	}
+	var req TenantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+	if err := req.validate(); err != nil {
+		return badRequest(c, err.Error())
+	}
+
+	tenant := db.Tenant{
+		ID:         uint(id),
+		Name:       req.Name,
+		WebhookURL: req.WebhookURL,
+		Provider:   req.Provider,
+		RateLimit:  req.RateLimit,
+		DailyQuota: req.DailyQuota,
+	}
+	if err := s.repository.UpdateTenant(&tenant); err != nil {
+		return tenantError(c, err)
+	}
+	s.forget(tenant.ID)
+
+	updated, err := s.repository.GetTenant(tenant.ID)
+	if err != nil {
+		return tenantError(c, err)
+	}
+	return c.JSON(updated)
+}
+
+// CreateAPIKey godoc
+// @Summary      Create an API key
+// @Description  Creates an API key for a tenant, send it in the X-API-Key header. The key is only returned in this response, it is stored hashed. Admin keys of the default tenant may call the operator and tenant endpoints.
+// @Tags         Tenants
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int            true   "Tenant ID"
+// @Param        key  body      APIKeyRequest  false  "API key"
+// @Success      201  {object}  APIKeyResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /tenants/{id}/api-keys [post]
+func (s *TenantService) CreateAPIKey(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return badRequest(c, "invalid tenant id")
+	}
+	var req APIKeyRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return badRequest(c, "invalid request body")
+		}
+	}
+	if len(req.Name) > 255 {
+		return badRequest(c, "name is at most 255 characters")
+	}
+	if req.Admin && uint(id) != db.DefaultTenantID {
+		return badRequest(c, "admin keys belong to the default tenant")
+	}
+
+	if _, err := s.repository.GetTenant(uint(id)); err != nil {
+		return tenantError(c, err)
+	}
+
+	key, prefix, err := NewKey()
+	if err != nil {
+		return tenantError(c, err)
+	}
+	apiKey := db.APIKey{TenantID: uint(id), Name: req.Name, Prefix: prefix, KeyHash: HashKey(key), Admin: req.Admin}
+	if err := s.repository.CreateAPIKey(&apiKey); err != nil {
+		return tenantError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(APIKeyResponse{APIKey: apiKey, Key: key})
+}
+
+// ListAPIKeys godoc
+// @Summary      List API keys
+// @Description  Lists the API keys of a tenant including revoked ones, only their prefixes are shown
+// @Tags         Tenants
+// @Produce      json
+// @Param        id   path      int  true  "Tenant ID"
+// @Success      200  {array}   db.APIKey
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /tenants/{id}/api-keys [get]
+func (s *TenantService) ListAPIKeys(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return badRequest(c, "invalid tenant id")
+	}
+
+	keys, err := s.repository.ListAPIKeys(uint(id))
+	if err != nil {
+		return tenantError(c, err)
+	}
+	if keys == nil {
+		keys = []db.APIKey{}
+	}
+	return c.JSON(keys)
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke an API key
+// @Description  Stops an API key from authenticating. Other instances accept it until their cache entry expires after tenants.cacheTTL.
+// @Tags         Tenants
+// @Param        id     path  int  true  "Tenant ID"
+// @Param        keyId  path  int  true  "API key ID"
+// @Success      204
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /tenants/{id}/api-keys/{keyId} [delete]
+func (s *TenantService) RevokeAPIKey(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return badRequest(c, "invalid tenant id")
+	}
+	keyID, err := c.ParamsInt("keyId")
+	if err != nil || keyID <= 0 {
+		return badRequest(c, "invalid api key id")
+	}
+
+	if err := s.repository.RevokeAPIKey(uint(id), uint(keyID)); err != nil {
+		return tenantError(c, err)
+	}
+	s.forgetKeys(uint(id))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func badRequest(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error": message,
+	})
+}
+
+func tenantError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	message := "Failed to access tenants"
+	switch {
+	case errors.Is(err, repository.ErrTenantNotFound), errors.Is(err, repository.ErrAPIKeyNotFound):
+		status, message = fiber.StatusNotFound, err.Error()
+	case errors.Is(err, repository.ErrTenantExists):
+		status, message = fiber.StatusConflict, err.Error()
+	default:
+		logger.Log.Error("Failed to access tenants", zap.Error(err))
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": message,
+	})
+}
@@ -0,0 +1,117 @@
+package tenants
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// APIKeyHeader carries the API key of a request, "Authorization: Bearer <key>" is accepted as well
+const APIKeyHeader = "X-API-Key"
+
+const (
+	tenantLocal = "tenant"
+	adminLocal  = "admin"
+)
+
+// Middleware resolves the tenant of every request from its API key and applies the rate limit of
+// the tenant. Requests without a key act as the default tenant with admin rights unless
+// tenants.requireAPIKey is set, a deployment that does not require keys is operated without them.
+// Requests for which public returns true skip both, e.g. probes and provider callbacks.
+func (s *TenantService) Middleware(public func(c *fiber.Ctx) bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if public != nil && public(c) {
+			return c.Next()
+		}
+
+		var tenant db.Tenant
+		var key db.APIKey
+		var err error
+		header := apiKey(c)
+		switch {
+		case header != "":
+			tenant, key, err = s.Authenticate(c.UserContext(), header)
+		case config.Cfg.Tenants.RequireAPIKey:
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "api key required",
+			})
+		default:
+			tenant, err = s.Tenant(c.UserContext(), db.DefaultTenantID)
+			key.Admin = true
+		}
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "invalid api key",
+			})
+		}
+		if err != nil {
+			logger.Log.Error("Failed to authenticate request", zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to authenticate request",
+			})
+		}
+
+		if !s.Allow(c.UserContext(), tenant, time.Now()) {
+			c.Set(fiber.HeaderRetryAfter, "1")
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "rate limit exceeded",
+			})
+		}
+
+		Set(c, tenant)
+		SetAdmin(c, key.Admin)
+		return c.Next()
+	}
+}
+
+// AdminOnly limits a route to admin keys, they operate the deployment and manage the tenants. Requests
+// without a key pass it while tenants.requireAPIKey is off.
+func AdminOnly(c *fiber.Ctx) error {
+	if !IsAdmin(c) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "an admin api key is required",
+		})
+	}
+	return c.Next()
+}
+
+// IsAdmin reports whether a request was made with an admin key, or without a key while keys are optional
+func IsAdmin(c *fiber.Ctx) bool {
+	admin, _ := c.Locals(adminLocal).(bool)
+	return admin
+}
+
+// From returns the tenant of a request, requests that did not pass the middleware belong to the default tenant
+func From(c *fiber.Ctx) db.Tenant {
+	if tenant, ok := c.Locals(tenantLocal).(db.Tenant); ok {
+		return tenant
+	}
+	return db.Tenant{ID: db.DefaultTenantID}
+}
+
+// Set makes tenant the tenant of a request
+func Set(c *fiber.Ctx, tenant db.Tenant) {
+	c.Locals(tenantLocal, tenant)
+}
+
+// SetAdmin records whether a request was made with admin rights
+func SetAdmin(c *fiber.Ctx, admin bool) {
+	c.Locals(adminLocal, admin)
+}
+
+func apiKey(c *fiber.Ctx) string {
+	if key := c.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
@@ -0,0 +1,241 @@
+package tenants
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/infrastructure/metrics"
+	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"go.uber.org/zap"
+)
+
+// KeyPrefix starts every API key, it makes leaked keys easy to find
+const KeyPrefix = "msk_"
+
+const minAdminKeyLength = 32
+
+var ErrQuotaExceeded = errors.New("daily quota exceeded")
+
+// reserveScript adds ARGV[1] to the counter in KEYS[1] unless the total would pass ARGV[2],
+// a new counter expires after ARGV[3] milliseconds. It returns 1 when the amount was added.
+const reserveScript = `
+local count = tonumber(redis.call("GET", KEYS[1]) or "0")
+if count + tonumber(ARGV[1]) > tonumber(ARGV[2]) then
+	return 0
+end
+if redis.call("INCRBY", KEYS[1], ARGV[1]) == tonumber(ARGV[1]) then
+	redis.call("PEXPIRE", KEYS[1], ARGV[3])
+end
+return 1`
+
+// releaseScript takes ARGV[1] back from the counter in KEYS[1], never below zero
+const releaseScript = `
+local count = tonumber(redis.call("GET", KEYS[1]) or "0")
+local amount = math.min(count, tonumber(ARGV[1]))
+if amount > 0 then
+	redis.call("DECRBY", KEYS[1], amount)
+end
+return amount`
+
+type TenantRepositoryInterface interface {
+	CreateTenant(tenant *db.Tenant) error
+	GetTenant(id uint) (db.Tenant, error)
+	ListTenants() ([]db.Tenant, error)
+	UpdateTenant(tenant *db.Tenant) error
+	CreateAPIKey(key *db.APIKey) error
+	ListAPIKeys(tenantID uint) ([]db.APIKey, error)
+	RevokeAPIKey(tenantID, id uint) error
+	FindAPIKey(keyHash string) (db.APIKey, error)
+}
+
+// TenantService resolves API keys to tenants and enforces their rate limits and quotas. Tenants and
+// keys are cached in memory for tenants.cacheTTL, the counters are kept in Redis so every instance
+// shares them.
+type TenantService struct {
+	repository  TenantRepositoryInterface
+	redisClient redisClient.Client
+	mu          sync.Mutex
+	tenants     map[uint]cached[db.Tenant]
+	keys        map[string]cached[db.APIKey]
+}
+
+type cached[T any] struct {
+	value     T
+	expiresAt time.Time
+}
+
+func NewService(repository TenantRepositoryInterface, redisClient redisClient.Client) *TenantService {
+	return &TenantService{
+		repository:  repository,
+		redisClient: redisClient,
+		tenants:     make(map[uint]cached[db.Tenant]),
+		keys:        make(map[string]cached[db.APIKey]),
+	}
+}
+
+// Tenant returns the tenant with id, ErrTenantNotFound is returned by the repository if there is none
+func (s *TenantService) Tenant(ctx context.Context, id uint) (db.Tenant, error) {
+	s.mu.Lock()
+	entry, ok := s.tenants[id]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.value, nil
+	}
+
+	tenant, err := s.repository.GetTenant(id)
+	if err != nil {
+		return tenant, err
+	}
+	s.mu.Lock()
+	s.tenants[id] = cached[db.Tenant]{value: tenant, expiresAt: time.Now().Add(config.Cfg.Tenants.CacheTTL)}
+	s.mu.Unlock()
+	return tenant, nil
+}
+
+// Authenticate returns an API key and its tenant, ErrAPIKeyNotFound is returned by the repository for
+// unknown and revoked keys
+func (s *TenantService) Authenticate(ctx context.Context, key string) (db.Tenant, db.APIKey, error) {
+	hash := HashKey(key)
+
+	s.mu.Lock()
+	entry, ok := s.keys[hash]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		tenant, err := s.Tenant(ctx, entry.value.TenantID)
+		return tenant, entry.value, err
+	}
+
+	apiKey, err := s.repository.FindAPIKey(hash)
+	if err != nil {
+		return db.Tenant{}, apiKey, err
+	}
+	s.mu.Lock()
+	s.keys[hash] = cached[db.APIKey]{value: apiKey, expiresAt: time.Now().Add(config.Cfg.Tenants.CacheTTL)}
+	s.mu.Unlock()
+	tenant, err := s.Tenant(ctx, apiKey.TenantID)
+	return tenant, apiKey, err
+}
+
+// BootstrapAdminKey stores key as an admin key of the default tenant unless it is stored already,
+// so the first tenants and keys can be created with it
+func (s *TenantService) BootstrapAdminKey(key string) error {
+	if len(key) < minAdminKeyLength {
+		return fmt.Errorf("the admin key must be at least %d characters", minAdminKeyLength)
+	}
+	hash := HashKey(key)
+	_, err := s.repository.FindAPIKey(hash)
+	if !errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return err
+	}
+
+	prefix := key[:len(KeyPrefix)+8]
+	if err := s.repository.CreateAPIKey(&db.APIKey{TenantID: db.DefaultTenantID, Name: "bootstrap", Prefix: prefix, KeyHash: hash, Admin: true}); err != nil {
+		return fmt.Errorf("store the admin key, a revoked key cannot be used again: %w", err)
+	}
+	logger.Log.Info("Admin API key stored", zap.String("prefix", prefix))
+	return nil
+}
+
+// Allow reports whether tenant may make another request in the current second. Requests are
+// allowed when the counter cannot be read, a broken Redis must not take the API down.
+func (s *TenantService) Allow(ctx context.Context, tenant db.Tenant, now time.Time) bool {
+	if tenant.RateLimit <= 0 {
+		return true
+	}
+	key := "ratelimit:" + strconv.FormatUint(uint64(tenant.ID), 10) + ":" + strconv.FormatInt(now.Unix(), 10)
+	allowed, err := s.reserve(ctx, key, 1, tenant.RateLimit, 2*time.Second)
+	if err != nil {
+		logger.Log.Warn("Failed to count request, allowing it", zap.Uint("tenantID", tenant.ID), zap.Error(err))
+		return true
+	}
+	if !allowed {
+		metrics.TenantRejected.WithLabelValues("rate_limit").Inc()
+	}
+	return allowed
+}
+
+// Reserve counts messages against the quota of tenant for the current UTC day, ErrQuotaExceeded
+// is returned and nothing is counted when they do not fit. Like Allow it fails open.
+func (s *TenantService) Reserve(ctx context.Context, tenant db.Tenant, messages int) error {
+	if tenant.DailyQuota <= 0 || messages <= 0 {
+		return nil
+	}
+	reserved, err := s.reserve(ctx, quotaKey(tenant.ID), messages, tenant.DailyQuota, 48*time.Hour)
+	if err != nil {
+		logger.Log.Warn("Failed to count quota, allowing the messages", zap.Uint("tenantID", tenant.ID), zap.Error(err))
+		return nil
+	}
+	if !reserved {
+		metrics.TenantRejected.WithLabelValues("daily_quota").Inc()
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// Release gives back messages reserved today that were not stored after all
+func (s *TenantService) Release(ctx context.Context, tenant db.Tenant, messages int) {
+	if tenant.DailyQuota <= 0 || messages <= 0 {
+		return
+	}
+	if _, err := s.redisClient.Eval(ctx, releaseScript, []string{quotaKey(tenant.ID)}, messages); err != nil {
+		logger.Log.Warn("Failed to release quota", zap.Uint("tenantID", tenant.ID), zap.Error(err))
+	}
+}
+
+func quotaKey(tenantID uint) string {
+	return "quota:" + strconv.FormatUint(uint64(tenantID), 10) + ":" + time.Now().UTC().Format(time.DateOnly)
+}
+
+func (s *TenantService) reserve(ctx context.Context, key string, amount, limit int, ttl time.Duration) (bool, error) {
+	reserved, err := s.redisClient.Eval(ctx, reserveScript, []string{key}, amount, limit, ttl.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+	return reserved == int64(1), nil
+}
+
+// forget drops a tenant from the cache of this instance after a change, other instances pick the
+// change up when their entry expires
+func (s *TenantService) forget(id uint) {
+	s.mu.Lock()
+	delete(s.tenants, id)
+	s.mu.Unlock()
+}
+
+// forgetKeys drops the cached keys of a tenant after one was revoked
+func (s *TenantService) forgetKeys(tenantID uint) {
+	s.mu.Lock()
+	for hash, entry := range s.keys {
+		if entry.value.TenantID == tenantID {
+			delete(s.keys, hash)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// NewKey returns a random API key and the part of it shown in listings
+func NewKey() (key, prefix string, err error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	key = KeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, key[:len(KeyPrefix)+8], nil
+}
+
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,435 @@
+package tenants
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setTestConfig() {
+	logger.Log = zap.NewNop()
+	config.Cfg.Tenants.RequireAPIKey = false
+	config.Cfg.Tenants.CacheTTL = time.Minute
+}
+
+func TestMiddleware(t *testing.T) {
+	teamB := db.Tenant{ID: 2, Name: "team-b", RateLimit: 5}
+
+	tests := []struct {
+		name           string
+		url            string
+		header         map[string]string
+		requireKey     bool
+		setupMock      func(*mocks.MockTenantRepositoryInterface, *mocks.MockRedisClient)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Requests without a key act as the default tenant",
+			url:  "/whoami",
+			setupMock: func(m *mocks.MockTenantRepositoryInterface, r *mocks.MockRedisClient) {
+				m.EXPECT().GetTenant(db.DefaultTenantID).Return(db.Tenant{ID: db.DefaultTenantID, Name: "default"}, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   "1",
+		},
+		{
+			name:           "A key is required when configured",
+			url:            "/whoami",
+			requireKey:     true,
+			setupMock:      func(m *mocks.MockTenantRepositoryInterface, r *mocks.MockRedisClient) {},
+			expectedStatus: fiber.StatusUnauthorized,
+			expectedBody:   "api key required",
+		},
+		{
+			name:   "Key authenticates as its tenant",
+			url:    "/whoami",
+			header: map[string]string{APIKeyHeader: "msk_secret"},
+			setupMock: func(m *mocks.MockTenantRepositoryInterface, r *mocks.MockRedisClient) {
+				m.EXPECT().FindAPIKey(HashKey("msk_secret")).Return(db.APIKey{ID: 3, TenantID: 2}, nil)
+				m.EXPECT().GetTenant(uint(2)).Return(teamB, nil)
+				r.EXPECT().Eval(gomock.Any(), reserveScript, gomock.Any(), 1, 5, int64(2000)).Return(int64(1), nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   "2",
+		},
+		{
+			name:   "Bearer token is accepted",
+			url:    "/whoami",
+			header: map[string]string{fiber.HeaderAuthorization: "Bearer msk_secret"},
+			setupMock: func(m *mocks.MockTenantRepositoryInterface, r *mocks.MockRedisClient) {
+				m.EXPECT().FindAPIKey(HashKey("msk_secret")).Return(db.APIKey{ID: 3, TenantID: 2}, nil)
+				m.EXPECT().GetTenant(uint(2)).Return(teamB, nil)
+				r.EXPECT().Eval(gomock.Any(), reserveScript, gomock.Any(), 1, 5, int64(2000)).Return(int64(1), nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   "2",
+		},
+		{
+			name:   "Unknown or revoked key",
+			url:    "/whoami",
+			header: map[string]string{APIKeyHeader: "msk_revoked"},
+			setupMock: func(m *mocks.MockTenantRepositoryInterface, r *mocks.MockRedisClient) {
+				m.EXPECT().FindAPIKey(HashKey("msk_revoked")).Return(db.APIKey{}, repository.ErrAPIKeyNotFound)
+			},
+			expectedStatus: fiber.StatusUnauthorized,
+			expectedBody:   "invalid api key",
+		},
+		{
+			name:   "Requests over the rate limit",
+			url:    "/whoami",
+			header: map[string]string{APIKeyHeader: "msk_secret"},
+			setupMock: func(m *mocks.MockTenantRepositoryInterface, r *mocks.MockRedisClient) {
+				m.EXPECT().FindAPIKey(HashKey("msk_secret")).Return(db.APIKey{ID: 3, TenantID: 2}, nil)
+				m.EXPECT().GetTenant(uint(2)).Return(teamB, nil)
+				r.EXPECT().Eval(gomock.Any(), reserveScript, gomock.Any(), 1, 5, int64(2000)).Return(int64(0), nil)
+			},
+			expectedStatus: fiber.StatusTooManyRequests,
+			expectedBody:   "rate limit exceeded",
+		},
+		{
+			name:   "Rate limit fails open",
+			url:    "/whoami",
+			header: map[string]string{APIKeyHeader: "msk_secret"},
+			setupMock: func(m *mocks.MockTenantRepositoryInterface, r *mocks.MockRedisClient) {
+				m.EXPECT().FindAPIKey(HashKey("msk_secret")).Return(db.APIKey{ID: 3, TenantID: 2}, nil)
+				m.EXPECT().GetTenant(uint(2)).Return(teamB, nil)
+				r.EXPECT().Eval(gomock.Any(), reserveScript, gomock.Any(), gomock.Any()).Return(nil, errors.New("redis down"))
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   "2",
+		},
+		{
+			name:           "Public routes need no key",
+			url:            "/health",
+			requireKey:     true,
+			setupMock:      func(m *mocks.MockTenantRepositoryInterface, r *mocks.MockRedisClient) {},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:   "Admin key passes admin routes",
+			url:    "/admin",
+			header: map[string]string{APIKeyHeader: "msk_admin"},
+			setupMock: func(m *mocks.MockTenantRepositoryInterface, r *mocks.MockRedisClient) {
+				m.EXPECT().FindAPIKey(HashKey("msk_admin")).Return(db.APIKey{ID: 4, TenantID: db.DefaultTenantID, Admin: true}, nil)
+				m.EXPECT().GetTenant(db.DefaultTenantID).Return(db.Tenant{ID: db.DefaultTenantID}, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:   "Other keys are refused on admin routes",
+			url:    "/admin",
+			header: map[string]string{APIKeyHeader: "msk_secret"},
+			setupMock: func(m *mocks.MockTenantRepositoryInterface, r *mocks.MockRedisClient) {
+				m.EXPECT().FindAPIKey(HashKey("msk_secret")).Return(db.APIKey{ID: 3, TenantID: db.DefaultTenantID}, nil)
+				m.EXPECT().GetTenant(db.DefaultTenantID).Return(db.Tenant{ID: db.DefaultTenantID}, nil)
+			},
+			expectedStatus: fiber.StatusForbidden,
+			expectedBody:   "an admin api key is required",
+		},
+		{
+			name: "Requests without a key pass admin routes while keys are optional",
+			url:  "/admin",
+			setupMock: func(m *mocks.MockTenantRepositoryInterface, r *mocks.MockRedisClient) {
+				m.EXPECT().GetTenant(db.DefaultTenantID).Return(db.Tenant{ID: db.DefaultTenantID}, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:           "Requests without a key are refused on admin routes when keys are required",
+			url:            "/admin",
+			requireKey:     true,
+			setupMock:      func(m *mocks.MockTenantRepositoryInterface, r *mocks.MockRedisClient) {},
+			expectedStatus: fiber.StatusUnauthorized,
+			expectedBody:   "api key required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestConfig()
+			config.Cfg.Tenants.RequireAPIKey = tt.requireKey
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockTenantRepositoryInterface(ctrl)
+			mockRedis := mocks.NewMockRedisClient(ctrl)
+			tt.setupMock(mockRepo, mockRedis)
+			service := NewService(mockRepo, mockRedis)
+
+			app := fiber.New()
+			app.Use(service.Middleware(func(c *fiber.Ctx) bool { return c.Path() == "/health" }))
+			app.Get("/whoami", func(c *fiber.Ctx) error {
+				return c.SendString(strconv.FormatUint(uint64(From(c).ID), 10))
+			})
+			app.Get("/health", func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+			app.Get("/admin", AdminOnly, func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			req := httptest.NewRequest(fiber.MethodGet, tt.url, nil)
+			for name, value := range tt.header {
+				req.Header.Set(name, value)
+			}
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			body, _ := io.ReadAll(resp.Body)
+			assert.Contains(t, string(body), tt.expectedBody)
+		})
+	}
+}
+
+func TestAuthenticate_CachesKeys(t *testing.T) {
+	setTestConfig()
+	ctrl := gomock.NewController(t)
+	mockRepo := mocks.NewMockTenantRepositoryInterface(ctrl)
+	service := NewService(mockRepo, mocks.NewMockRedisClient(ctrl))
+
+	mockRepo.EXPECT().FindAPIKey(HashKey("msk_secret")).Return(db.APIKey{ID: 3, TenantID: 2}, nil).Times(1)
+	mockRepo.EXPECT().GetTenant(uint(2)).Return(db.Tenant{ID: 2}, nil).Times(1)
+
+	for i := 0; i < 3; i++ {
+		tenant, key, err := service.Authenticate(context.Background(), "msk_secret")
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), tenant.ID)
+		assert.Equal(t, uint(3), key.ID)
+	}
+}
+
+func TestBootstrapAdminKey(t *testing.T) {
+	setTestConfig()
+	adminKey := "msk_" + strings.Repeat("a", 32)
+
+	t.Run("stores a new key", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockTenantRepositoryInterface(ctrl)
+		mockRepo.EXPECT().FindAPIKey(HashKey(adminKey)).Return(db.APIKey{}, repository.ErrAPIKeyNotFound)
+		mockRepo.EXPECT().CreateAPIKey(&db.APIKey{TenantID: db.DefaultTenantID, Name: "bootstrap", Prefix: "msk_aaaaaaaa", KeyHash: HashKey(adminKey), Admin: true}).Return(nil)
+
+		assert.NoError(t, NewService(mockRepo, nil).BootstrapAdminKey(adminKey))
+	})
+
+	t.Run("keeps a stored key", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRepo := mocks.NewMockTenantRepositoryInterface(ctrl)
+		mockRepo.EXPECT().FindAPIKey(HashKey(adminKey)).Return(db.APIKey{ID: 1, Admin: true}, nil)
+
+		assert.NoError(t, NewService(mockRepo, nil).BootstrapAdminKey(adminKey))
+	})
+
+	t.Run("refuses a short key", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		assert.Error(t, NewService(mocks.NewMockTenantRepositoryInterface(ctrl), nil).BootstrapAdminKey("secret"))
+	})
+}
+
+func TestReserve(t *testing.T) {
+	setTestConfig()
+	ctx := context.Background()
+	tenant := db.Tenant{ID: 2, DailyQuota: 100}
+	key := "quota:2:" + time.Now().UTC().Format(time.DateOnly)
+
+	t.Run("messages within the quota", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		mockRedis.EXPECT().Eval(ctx, reserveScript, []string{key}, 40, 100, (48*time.Hour).Milliseconds()).Return(int64(1), nil)
+
+		assert.NoError(t, NewService(nil, mockRedis).Reserve(ctx, tenant, 40))
+	})
+
+	t.Run("messages over the quota", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		mockRedis.EXPECT().Eval(ctx, reserveScript, []string{key}, 40, 100, gomock.Any()).Return(int64(0), nil)
+
+		assert.ErrorIs(t, NewService(nil, mockRedis).Reserve(ctx, tenant, 40), ErrQuotaExceeded)
+	})
+
+	t.Run("unlimited tenants are not counted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		// no Redis call is expected
+		assert.NoError(t, NewService(nil, mocks.NewMockRedisClient(ctrl)).Reserve(ctx, db.Tenant{ID: 1}, 40))
+	})
+}
+
+func TestRelease(t *testing.T) {
+	setTestConfig()
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	key := "quota:2:" + time.Now().UTC().Format(time.DateOnly)
+	mockRedis.EXPECT().Eval(ctx, releaseScript, []string{key}, 40).Return(int64(40), nil)
+
+	service := NewService(nil, mockRedis)
+	service.Release(ctx, db.Tenant{ID: 2, DailyQuota: 100}, 40)
+	// unlimited tenants were never counted
+	service.Release(ctx, db.Tenant{ID: 1}, 40)
+}
+
+func TestTenantHandlers(t *testing.T) {
+	setTestConfig()
+	teamB := db.Tenant{ID: 2, Name: "team-b", WebhookURL: "https://hooks.example.com/sms"}
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		setupMock      func(*mocks.MockTenantRepositoryInterface)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "Create tenant",
+			method: fiber.MethodPost,
+			url:    "/tenants",
+			body:   `{"name":" team-b ","webhook_url":"https://hooks.example.com/sms","rate_limit":50,"daily_quota":1000}`,
+			setupMock: func(m *mocks.MockTenantRepositoryInterface) {
+				m.EXPECT().CreateTenant(gomock.Any()).DoAndReturn(func(tenant *db.Tenant) error {
+					assert.Equal(t, "team-b", tenant.Name)
+					assert.Equal(t, 50, tenant.RateLimit)
+					assert.Equal(t, 1000, tenant.DailyQuota)
+					tenant.ID = 2
+					return nil
+				})
+			},
+			expectedStatus: fiber.StatusCreated,
+			expectedBody:   `"id":2,"name":"team-b"`,
+		},
+		{
+			name:           "Create rejects an invalid webhook",
+			method:         fiber.MethodPost,
+			url:            "/tenants",
+			body:           `{"name":"team-b","webhook_url":"ftp://example.com"}`,
+			setupMock:      func(m *mocks.MockTenantRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "webhook_url must be an http or https URL",
+		},
+		{
+			name:   "Create rejects a taken name",
+			method: fiber.MethodPost,
+			url:    "/tenants",
+			body:   `{"name":"team-b"}`,
+			setupMock: func(m *mocks.MockTenantRepositoryInterface) {
+				m.EXPECT().CreateTenant(gomock.Any()).Return(repository.ErrTenantExists)
+			},
+			expectedStatus: fiber.StatusConflict,
+			expectedBody:   repository.ErrTenantExists.Error(),
+		},
+		{
+			name:   "Update tenant",
+			method: fiber.MethodPut,
+			url:    "/tenants/2",
+			body:   `{"name":"team-b","daily_quota":0}`,
+			setupMock: func(m *mocks.MockTenantRepositoryInterface) {
+				m.EXPECT().UpdateTenant(gomock.Any()).DoAndReturn(func(tenant *db.Tenant) error {
+					assert.Equal(t, uint(2), tenant.ID)
+					return nil
+				})
+				m.EXPECT().GetTenant(uint(2)).Return(teamB, nil)
+			},
+			expectedStatus: fiber.StatusOK,
+			expectedBody:   `"name":"team-b"`,
+		},
+		{
+			name:   "Create API key returns the key once",
+			method: fiber.MethodPost,
+			url:    "/tenants/2/api-keys",
+			body:   `{"name":"ci"}`,
+			setupMock: func(m *mocks.MockTenantRepositoryInterface) {
+				m.EXPECT().GetTenant(uint(2)).Return(teamB, nil)
+				m.EXPECT().CreateAPIKey(gomock.Any()).DoAndReturn(func(key *db.APIKey) error {
+					assert.Equal(t, uint(2), key.TenantID)
+					assert.True(t, strings.HasPrefix(key.Prefix, KeyPrefix))
+					assert.Len(t, key.KeyHash, 64)
+					key.ID = 3
+					return nil
+				})
+			},
+			expectedStatus: fiber.StatusCreated,
+			expectedBody:   `"key":"msk_`,
+		},
+		{
+			name:           "Admin keys belong to the default tenant",
+			method:         fiber.MethodPost,
+			url:            "/tenants/2/api-keys",
+			body:           `{"admin":true}`,
+			setupMock:      func(m *mocks.MockTenantRepositoryInterface) {},
+			expectedStatus: fiber.StatusBadRequest,
+			expectedBody:   "admin keys belong to the default tenant",
+		},
+		{
+			name:   "Create API key for an unknown tenant",
+			method: fiber.MethodPost,
+			url:    "/tenants/9/api-keys",
+			setupMock: func(m *mocks.MockTenantRepositoryInterface) {
+				m.EXPECT().GetTenant(uint(9)).Return(db.Tenant{}, repository.ErrTenantNotFound)
+			},
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody:   repository.ErrTenantNotFound.Error(),
+		},
+		{
+			name:   "Revoke API key",
+			method: fiber.MethodDelete,
+			url:    "/tenants/2/api-keys/3",
+			setupMock: func(m *mocks.MockTenantRepositoryInterface) {
+				m.EXPECT().RevokeAPIKey(uint(2), uint(3)).Return(nil)
+			},
+			expectedStatus: fiber.StatusNoContent,
+		},
+		{
+			name:   "Revoke a key of another tenant",
+			method: fiber.MethodDelete,
+			url:    "/tenants/1/api-keys/3",
+			setupMock: func(m *mocks.MockTenantRepositoryInterface) {
+				m.EXPECT().RevokeAPIKey(uint(1), uint(3)).Return(repository.ErrAPIKeyNotFound)
+			},
+			expectedStatus: fiber.StatusNotFound,
+			expectedBody:   repository.ErrAPIKeyNotFound.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockTenantRepositoryInterface(ctrl)
+			tt.setupMock(mockRepo)
+			service := NewService(mockRepo, mocks.NewMockRedisClient(ctrl))
+
+			app := fiber.New()
+			app.Post("/tenants", service.CreateTenant)
+			app.Put("/tenants/:id", service.UpdateTenant)
+			app.Post("/tenants/:id/api-keys", service.CreateAPIKey)
+			app.Delete("/tenants/:id/api-keys/:keyId", service.RevokeAPIKey)
+
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			body, _ := io.ReadAll(resp.Body)
+			assert.Contains(t, string(body), tt.expectedBody)
+		})
+	}
+}
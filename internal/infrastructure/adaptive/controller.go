@@ -0,0 +1,145 @@
+package adaptive
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/infrastructure/metrics"
+	"go.uber.org/zap"
+)
+
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	// OutcomeError is a failure that says nothing about gateway capacity, e.g. a 400
+	OutcomeError Outcome = "error"
+	// OutcomeThrottled is a timeout, 5xx or 429 and signals that the gateway is saturated
+	OutcomeThrottled Outcome = "throttled"
+)
+
+// Classify maps a webhook call result to an outcome
+func Classify(resp *http.Response, err error) Outcome {
+	if err != nil {
+		var netErr net.Error
+		if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			return OutcomeThrottled
+		}
+		return OutcomeError
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		return OutcomeThrottled
+	case resp.StatusCode >= http.StatusBadRequest:
+		return OutcomeError
+	default:
+		return OutcomeSuccess
+	}
+}
+
+// Controller sizes batches and concurrency with AIMD: after every window of webhook calls
+// it adds a step while latency and throttling are within target, and multiplies by the
+// decrease factor otherwise, always within the configured bounds
+type Controller struct {
+	minBatchSize, maxBatchSize     int
+	minConcurrency, maxConcurrency int
+	targetLatency                  time.Duration
+	maxThrottleRate                float64
+	window                         int
+	increaseStep                   int
+	decreaseFactor                 float64
+
+	mu           sync.RWMutex
+	batchSize    int
+	concurrency  int
+	samples      int
+	throttled    int
+	totalLatency time.Duration
+}
+
+func New(cfg config.Config) *Controller {
+	c := &Controller{
+		minBatchSize:    cfg.Adaptive.MinBatchSize,
+		maxBatchSize:    cfg.Adaptive.MaxBatchSize,
+		minConcurrency:  cfg.Adaptive.MinConcurrency,
+		maxConcurrency:  cfg.Adaptive.MaxConcurrency,
+		targetLatency:   cfg.Adaptive.TargetLatency,
+		maxThrottleRate: cfg.Adaptive.MaxErrorRate,
+		window:          max(cfg.Adaptive.Window, 1),
+		increaseStep:    max(cfg.Adaptive.IncreaseStep, 1),
+		decreaseFactor:  cfg.Adaptive.DecreaseFactor,
+	}
+	// start from the static configuration
+	c.batchSize = clamp(cfg.Scheduler.BatchSize, c.minBatchSize, c.maxBatchSize)
+	c.concurrency = clamp(cfg.Scheduler.MaxConcurrent, c.minConcurrency, c.maxConcurrency)
+	c.publish()
+	return c
+}
+
+func (c *Controller) BatchSize() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.batchSize
+}
+
+func (c *Controller) Concurrency() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.concurrency
+}
+
+// MaxConcurrency is the upper bound of Concurrency, stream mode starts this many workers
+func (c *Controller) MaxConcurrency() int {
+	return c.maxConcurrency
+}
+
+// Observe records one webhook call and adjusts the limits once the window is full
+func (c *Controller) Observe(latency time.Duration, outcome Outcome) {
+	metrics.WebhookRequests.WithLabelValues(string(outcome)).Inc()
+	metrics.WebhookLatency.Observe(latency.Seconds())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.samples++
+	c.totalLatency += latency
+	if outcome == OutcomeThrottled {
+		c.throttled++
+	}
+	if c.samples < c.window {
+		return
+	}
+
+	throttleRate := float64(c.throttled) / float64(c.samples)
+	avgLatency := c.totalLatency / time.Duration(c.samples)
+	if throttleRate > c.maxThrottleRate || avgLatency > c.targetLatency {
+		c.batchSize = clamp(int(float64(c.batchSize)*c.decreaseFactor), c.minBatchSize, c.maxBatchSize)
+		c.concurrency = clamp(int(float64(c.concurrency)*c.decreaseFactor), c.minConcurrency, c.maxConcurrency)
+		logger.Log.Info("Webhook saturated, decreasing limits",
+			zap.Float64("throttleRate", throttleRate),
+			zap.Duration("avgLatency", avgLatency),
+			zap.Int("batchSize", c.batchSize),
+			zap.Int("concurrency", c.concurrency))
+	} else {
+		c.batchSize = clamp(c.batchSize+c.increaseStep, c.minBatchSize, c.maxBatchSize)
+		c.concurrency = clamp(c.concurrency+c.increaseStep, c.minConcurrency, c.maxConcurrency)
+	}
+
+	c.samples, c.throttled, c.totalLatency = 0, 0, 0
+	c.publish()
+}
+
+func (c *Controller) publish() {
+	metrics.AdaptiveBatchSize.Set(float64(c.batchSize))
+	metrics.AdaptiveConcurrency.Set(float64(c.concurrency))
+}
+
+func clamp(value, lower, upper int) int {
+	return min(max(value, lower), upper)
+}
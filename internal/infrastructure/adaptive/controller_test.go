@@ -0,0 +1,106 @@
+package adaptive
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func getTestConfig() config.Config {
+	cfg := config.Config{}
+	cfg.Scheduler.BatchSize = 10
+	cfg.Scheduler.MaxConcurrent = 4
+	cfg.Adaptive.MinBatchSize = 2
+	cfg.Adaptive.MaxBatchSize = 12
+	cfg.Adaptive.MinConcurrency = 1
+	cfg.Adaptive.MaxConcurrency = 5
+	cfg.Adaptive.TargetLatency = 100 * time.Millisecond
+	cfg.Adaptive.MaxErrorRate = 0.2
+	cfg.Adaptive.Window = 4
+	cfg.Adaptive.IncreaseStep = 1
+	cfg.Adaptive.DecreaseFactor = 0.5
+	return cfg
+}
+
+func observe(c *Controller, n int, latency time.Duration, outcome Outcome) {
+	for i := 0; i < n; i++ {
+		c.Observe(latency, outcome)
+	}
+}
+
+func TestController(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	t.Run("Starts from the static configuration within bounds", func(t *testing.T) {
+		cfg := getTestConfig()
+		cfg.Scheduler.BatchSize = 50
+		c := New(cfg)
+		assert.Equal(t, 12, c.BatchSize())
+		assert.Equal(t, 4, c.Concurrency())
+		assert.Equal(t, 5, c.MaxConcurrency())
+	})
+
+	t.Run("Adjusts only once the window is full", func(t *testing.T) {
+		c := New(getTestConfig())
+		observe(c, 3, 10*time.Millisecond, OutcomeSuccess)
+		assert.Equal(t, 10, c.BatchSize())
+
+		c.Observe(10*time.Millisecond, OutcomeSuccess)
+		assert.Equal(t, 11, c.BatchSize())
+		assert.Equal(t, 5, c.Concurrency())
+	})
+
+	t.Run("Increases additively up to the max", func(t *testing.T) {
+		c := New(getTestConfig())
+		observe(c, 20, 10*time.Millisecond, OutcomeSuccess)
+		assert.Equal(t, 12, c.BatchSize())
+		assert.Equal(t, 5, c.Concurrency())
+	})
+
+	t.Run("Decreases multiplicatively on throttling", func(t *testing.T) {
+		c := New(getTestConfig())
+		observe(c, 3, 10*time.Millisecond, OutcomeSuccess)
+		c.Observe(10*time.Millisecond, OutcomeThrottled)
+		assert.Equal(t, 5, c.BatchSize())
+		assert.Equal(t, 2, c.Concurrency())
+
+		observe(c, 8, 10*time.Millisecond, OutcomeThrottled)
+		assert.Equal(t, 2, c.BatchSize())
+		assert.Equal(t, 1, c.Concurrency())
+	})
+
+	t.Run("Decreases on high latency", func(t *testing.T) {
+		c := New(getTestConfig())
+		observe(c, 4, time.Second, OutcomeSuccess)
+		assert.Equal(t, 5, c.BatchSize())
+	})
+
+	t.Run("Plain errors do not shrink the limits", func(t *testing.T) {
+		c := New(getTestConfig())
+		observe(c, 4, 10*time.Millisecond, OutcomeError)
+		assert.Equal(t, 11, c.BatchSize())
+	})
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassify(t *testing.T) {
+	assert.Equal(t, OutcomeSuccess, Classify(&http.Response{StatusCode: http.StatusAccepted}, nil))
+	assert.Equal(t, OutcomeError, Classify(&http.Response{StatusCode: http.StatusBadRequest}, nil))
+	assert.Equal(t, OutcomeThrottled, Classify(&http.Response{StatusCode: http.StatusTooManyRequests}, nil))
+	assert.Equal(t, OutcomeThrottled, Classify(&http.Response{StatusCode: http.StatusBadGateway}, nil))
+	assert.Equal(t, OutcomeThrottled, Classify(nil, timeoutError{}))
+	assert.Equal(t, OutcomeThrottled, Classify(nil, context.DeadlineExceeded))
+	assert.Equal(t, OutcomeError, Classify(nil, errors.New("connection refused")))
+}
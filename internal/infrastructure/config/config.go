@@ -20,6 +20,10 @@ type Config struct {
 		BatchSize          int
 		MaxConcurrent      int
 		MaxRetryConcurrent int
+		LeaseDuration      time.Duration
+		Mode               string
+		IdleBackoff        time.Duration
+		MaxIdleBackoff     time.Duration
 	}
 	Database struct {
 		DSN string
@@ -36,11 +40,190 @@ type Config struct {
 		ExpectContinueTimeout time.Duration
 	}
 
+	Instance struct {
+		ID                string
+		Version           string
+		HeartbeatInterval time.Duration
+		TTL               time.Duration
+		StaleAfter        time.Duration
+	}
+
+	Commands struct {
+		AckTimeout time.Duration
+	}
+
+	Leader struct {
+		Enabled       bool
+		Backend       string
+		LeaseTTL      time.Duration
+		RenewInterval time.Duration
+		LockID        int64
+	}
+
+	Drain struct {
+		Timeout time.Duration
+	}
+
+	Adaptive struct {
+		Enabled        bool
+		MinBatchSize   int
+		MaxBatchSize   int
+		MinConcurrency int
+		MaxConcurrency int
+		TargetLatency  time.Duration
+		MaxErrorRate   float64
+		Window         int
+		IncreaseStep   int
+		DecreaseFactor float64
+	}
+
+	Priority struct {
+		ReservedShare float64
+		StarvationAge time.Duration
+	}
+
+	Phone struct {
+		DefaultRegion string
+	}
+
+	SMS struct {
+		MaxSegments     int
+		SendSegmentInfo bool
+	}
+
+	Templates struct {
+		FallbackLocales []string
+	}
+
+	Delivery struct {
+		// Windows maps a message category to the hours it may be delivered in the recipient's time zone
+		Windows map[string]DeliveryWindow
+	}
+
+	Dedupe struct {
+		// Window is how long a recipient is protected from the same content, zero disables deduplication
+		Window time.Duration
+	}
+
+	Inbound struct {
+		// AutoReplies maps a keyword to the message sent back to whoever texts it, e.g. HELP
+		AutoReplies map[string]string
+		// WebhookSecret signs the callbacks of the provider, set it to the secret configured there
+		WebhookSecret string
+	}
+
+	Suppression struct {
+		CacheTTL      time.Duration
+		StopKeywords  []string
+		StartKeywords []string
+	}
+
+	Expiry struct {
+		DefaultTTL map[string]time.Duration
+	}
+
+	Campaigns struct {
+		Enabled bool
+		// Interval is the pause between expansions when no campaign has recipients left to expand
+		Interval time.Duration
+		// ChunkSize is the number of recipients expanded into messages per transaction
+		ChunkSize     int
+		MaxRecipients int
+	}
+
+	Imports struct {
+		// MaxFileSize is the largest upload accepted in bytes, other requests keep the default body limit
+		MaxFileSize int
+		// BatchSize is the number of rows stored per transaction
+		BatchSize int
+		// StaleAfter fails processing imports without progress for this long, their instance stopped
+		// before finishing them. 0 leaves them processing.
+		StaleAfter time.Duration
+	}
+
+	Exports struct {
+		// Dir is where export files are written, on the local disk of each instance
+		Dir string
+		// PageSize is the number of messages read per query
+		PageSize int
+		// LinkTTL is how long a download link stays valid
+		LinkTTL time.Duration
+		// SigningKey signs download links, set it to the same value on every instance
+		SigningKey string
+		// Retention is how long export files are kept, 0 keeps them forever
+		Retention time.Duration
+		// SweepInterval is how often files older than Retention are removed
+		SweepInterval time.Duration
+	}
+
+	Stats struct {
+		CacheTTL time.Duration
+		// MaxWindow is the longest time window a request may cover
+		MaxWindow time.Duration
+		// TopErrors is the number of most frequent errors returned
+		TopErrors int
+	}
+
+	Pricing struct {
+		// Provider is the provider messages are sent through, its prices are applied
+		Provider string
+		// Currency is the currency of every price, spend and budget
+		Currency string
+		// Prices maps a provider to the price of one segment per country calling code,
+		// the default entry applies to countries without their own price
+		Prices map[string]map[string]float64
+	}
+
+	Budget struct {
+		// Daily and Monthly cap the spend of a UTC day and month, zero disables the cap
+		Daily   float64
+		Monthly float64
+		// CheckInterval is how long the spend is reused before it is read again
+		CheckInterval time.Duration
+	}
+
+	Tenants struct {
+		// RequireAPIKey rejects requests without an API key, otherwise they act as the default tenant
+		RequireAPIKey bool
+		// CacheTTL is how long tenants and API keys are kept in memory, a revoked key works until it expires
+		CacheTTL time.Duration
+		// AdminKey is stored as an admin key of the default tenant at startup, it creates the first keys
+		AdminKey string
+	}
+
+	Reaper struct {
+		Enabled       bool
+		Interval      time.Duration
+		StuckAfter    time.Duration
+		BatchSize     int
+		MaxResets     int
+		UnknownAction string
+	}
+
 	WebhookUrl string
 }
 
+// DeliveryWindow is a daily window written as HH:MM times, e.g. 09:00 to 21:00
+type DeliveryWindow struct {
+	Start string
+	End   string
+}
+
 var Cfg Config
 
+// redacted replaces secrets in logged configs
+const redacted = "[redacted]"
+
+// Redacted returns a copy of c that is safe to log, secrets that are set are replaced
+func (c Config) Redacted() Config {
+	for _, secret := range []*string{&c.Exports.SigningKey, &c.Inbound.WebhookSecret, &c.Tenants.AdminKey} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	return c
+}
+
 func Init() {
 	configPath := os.Getenv("APP_CONFIG_PATH")
 	if configPath == "" {
@@ -56,6 +239,69 @@ func Init() {
 	viper.SetDefault("scheduler.enabled", true)
 	viper.SetDefault("scheduler.maxConcurrent", 1)
 	viper.SetDefault("scheduler.maxRetryConcurrent", 1)
+	viper.SetDefault("scheduler.leaseDuration", 2*time.Minute)
+	viper.SetDefault("scheduler.mode", "ticker")
+	viper.SetDefault("scheduler.idleBackoff", time.Second)
+	viper.SetDefault("scheduler.maxIdleBackoff", 30*time.Second)
+	viper.SetDefault("instance.version", "dev")
+	viper.SetDefault("instance.heartbeatInterval", 15*time.Second)
+	viper.SetDefault("instance.ttl", 2*time.Minute)
+	viper.SetDefault("instance.staleAfter", 45*time.Second)
+	viper.SetDefault("commands.ackTimeout", 2*time.Second)
+	viper.SetDefault("leader.enabled", false)
+	viper.SetDefault("leader.backend", "redis")
+	viper.SetDefault("leader.leaseTTL", 15*time.Second)
+	viper.SetDefault("leader.renewInterval", 5*time.Second)
+	viper.SetDefault("leader.lockID", 727001)
+	viper.SetDefault("drain.timeout", 25*time.Second)
+	viper.SetDefault("adaptive.enabled", false)
+	viper.SetDefault("adaptive.minBatchSize", 1)
+	viper.SetDefault("adaptive.maxBatchSize", 100)
+	viper.SetDefault("adaptive.minConcurrency", 1)
+	viper.SetDefault("adaptive.maxConcurrency", 16)
+	viper.SetDefault("adaptive.targetLatency", 500*time.Millisecond)
+	viper.SetDefault("adaptive.maxErrorRate", 0.05)
+	viper.SetDefault("adaptive.window", 20)
+	viper.SetDefault("adaptive.increaseStep", 1)
+	viper.SetDefault("adaptive.decreaseFactor", 0.5)
+	viper.SetDefault("priority.reservedShare", 0.25)
+	viper.SetDefault("priority.starvationAge", 5*time.Minute)
+	viper.SetDefault("phone.defaultRegion", "TR")
+	viper.SetDefault("sms.maxSegments", 3)
+	viper.SetDefault("sms.sendSegmentInfo", false)
+	viper.SetDefault("templates.fallbackLocales", []string{"en"})
+	viper.SetDefault("inbound.autoReplies", map[string]string{"HELP": "Reply STOP to unsubscribe, START to subscribe again."})
+	viper.SetDefault("dedupe.window", 10*time.Minute)
+	viper.SetDefault("suppression.cacheTTL", 10*time.Minute)
+	viper.SetDefault("suppression.stopKeywords", []string{"STOP", "STOPALL", "UNSUBSCRIBE", "CANCEL", "END", "QUIT", "IPTAL"})
+	viper.SetDefault("suppression.startKeywords", []string{"START", "UNSTOP"})
+	viper.SetDefault("campaigns.enabled", true)
+	viper.SetDefault("campaigns.interval", time.Second)
+	viper.SetDefault("campaigns.chunkSize", 500)
+	viper.SetDefault("campaigns.maxRecipients", 100000)
+	viper.SetDefault("imports.maxFileSize", 50<<20)
+	viper.SetDefault("imports.batchSize", 500)
+	viper.SetDefault("exports.dir", "./exports")
+	viper.SetDefault("exports.pageSize", 1000)
+	viper.SetDefault("exports.linkTTL", time.Hour)
+	viper.SetDefault("exports.retention", 7*24*time.Hour)
+	viper.SetDefault("exports.sweepInterval", time.Hour)
+	viper.SetDefault("stats.cacheTTL", 30*time.Second)
+	viper.SetDefault("stats.maxWindow", 90*24*time.Hour)
+	viper.SetDefault("stats.topErrors", 10)
+	viper.SetDefault("pricing.provider", "webhook")
+	viper.SetDefault("pricing.currency", "USD")
+	viper.SetDefault("budget.daily", 0)
+	viper.SetDefault("budget.monthly", 0)
+	viper.SetDefault("budget.checkInterval", 30*time.Second)
+	viper.SetDefault("tenants.requireAPIKey", false)
+	viper.SetDefault("tenants.cacheTTL", time.Minute)
+	viper.SetDefault("reaper.enabled", true)
+	viper.SetDefault("reaper.interval", time.Minute)
+	viper.SetDefault("reaper.stuckAfter", 10*time.Minute)
+	viper.SetDefault("reaper.batchSize", 100)
+	viper.SetDefault("reaper.maxResets", 3)
+	viper.SetDefault("reaper.unknownAction", "pending")
 	viper.AutomaticEnv()
 
 	viper.BindEnv("DATABASE_DSN")
@@ -63,6 +309,10 @@ func Init() {
 	viper.BindEnv("WEBHOOK_URL")
 	viper.BindEnv("SCHEDULER_INTERVAL")
 	viper.BindEnv("SCHEDULER_BATCHSIZE")
+	viper.BindEnv("INSTANCE_ID")
+	viper.BindEnv("VERSION")
+	viper.BindEnv("LEADER_ENABLED")
+	viper.BindEnv("EXPORTS_SIGNING_KEY")
 
 	if err := viper.ReadInConfig(); err != nil {
 		logger.Log.Fatal("Error reading config", zap.Error(err))
@@ -86,6 +336,22 @@ func Init() {
 		logger.Log.Info("webhookUrl overridden by env", zap.String("webhookUrl", webhookUrl))
 	}
 
+	// secrets are not logged, see Redacted
+	if signingKey := viper.GetString("EXPORTS_SIGNING_KEY"); signingKey != "" {
+		Cfg.Exports.SigningKey = signingKey
+		logger.Log.Info("exports.signingKey overridden by env")
+	}
+
+	if webhookSecret := viper.GetString("INBOUND_WEBHOOK_SECRET"); webhookSecret != "" {
+		Cfg.Inbound.WebhookSecret = webhookSecret
+		logger.Log.Info("inbound.webhookSecret overridden by env")
+	}
+
+	if adminKey := viper.GetString("TENANTS_ADMIN_KEY"); adminKey != "" {
+		Cfg.Tenants.AdminKey = adminKey
+		logger.Log.Info("tenants.adminKey overridden by env")
+	}
+
 	if interval := viper.GetDuration("SCHEDULER_INTERVAL"); interval != 0 {
 		Cfg.Scheduler.Interval = interval
 		logger.Log.Info("scheduler.interval overridden", zap.Duration("interval", interval))
@@ -101,10 +367,32 @@ func Init() {
 		logger.Log.Info("scheduler.maxConcurrent overridden", zap.Int("maxConcurrent", maxConcurrent))
 	}
 
+	if instanceID := viper.GetString("INSTANCE_ID"); instanceID != "" {
+		Cfg.Instance.ID = instanceID
+		logger.Log.Info("instance.id overridden by env", zap.String("instance.id", instanceID))
+	}
+	if Cfg.Instance.ID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			logger.Log.Fatal("Unable to resolve instance id from hostname", zap.Error(err))
+		}
+		Cfg.Instance.ID = hostname
+	}
+
+	if version := viper.GetString("VERSION"); version != "" {
+		Cfg.Instance.Version = version
+		logger.Log.Info("instance.version overridden by env", zap.String("instance.version", version))
+	}
+
+	if viper.IsSet("LEADER_ENABLED") {
+		Cfg.Leader.Enabled = viper.GetBool("LEADER_ENABLED")
+		logger.Log.Info("leader.enabled overridden by env", zap.Bool("leader.enabled", Cfg.Leader.Enabled))
+	}
+
 	logger.Log.Info("scheduler.enabled", zap.Bool("enabled", Cfg.Scheduler.Enabled))
 
 	logger.Log.Info("Loaded config file", zap.String("file", viper.ConfigFileUsed()))
-	logger.Log.Info("Loaded config value", zap.Any("cfg", Cfg))
+	logger.Log.Info("Loaded config value", zap.Any("cfg", Cfg.Redacted()))
 
 	viper.WatchConfig()
 	viper.OnConfigChange(func(e fsnotify.Event) {
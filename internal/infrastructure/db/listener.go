@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// MessagesInsertedChannel is notified by the messages insert trigger in init.sql
+const MessagesInsertedChannel = "messages_inserted"
+
+const listenerReconnectDelay = 5 * time.Second
+
+// NotificationListener turns Postgres notifications on a channel into wake-ups.
+// It holds its own connection outside the pool since LISTEN is bound to the session.
+type NotificationListener struct {
+	dsn     string
+	channel string
+	wakeups chan struct{}
+}
+
+func NewNotificationListener(dsn, channel string) *NotificationListener {
+	return &NotificationListener{
+		dsn:     dsn,
+		channel: channel,
+		// a single slot coalesces bursts of notifications into one wake-up
+		wakeups: make(chan struct{}, 1),
+	}
+}
+
+func (l *NotificationListener) Wakeups() <-chan struct{} {
+	return l.wakeups
+}
+
+// Run listens until ctx is cancelled and reconnects when the connection drops
+func (l *NotificationListener) Run(ctx context.Context) {
+	for {
+		if err := l.listen(ctx); err != nil && ctx.Err() == nil {
+			logger.Log.Warn("Notification listener disconnected", zap.String("channel", l.channel), zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenerReconnectDelay):
+		}
+	}
+}
+
+func (l *NotificationListener) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, l.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.channel}.Sanitize()); err != nil {
+		return err
+	}
+	logger.Log.Info("Listening for notifications", zap.String("channel", l.channel))
+
+	// messages inserted while disconnected are picked up as soon as we are back
+	l.notify()
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+		l.notify()
+	}
+}
+
+func (l *NotificationListener) notify() {
+	select {
+	case l.wakeups <- struct{}{}:
+	default:
+	}
+}
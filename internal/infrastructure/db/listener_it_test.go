@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"go.uber.org/zap"
+)
+
+func TestNotificationListenerIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	logger.Log = zap.NewNop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	postgresContainer, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "postgres:17.0-alpine3.20",
+			ExposedPorts: []string{"5432/tcp"},
+			Env: map[string]string{
+				"POSTGRES_DB":       "messages",
+				"POSTGRES_USER":     "postgres",
+				"POSTGRES_PASSWORD": "postgres",
+			},
+			Files: []testcontainers.ContainerFile{{
+				HostFilePath:      "../../../data/postgresql/init.sql",
+				ContainerFilePath: "/docker-entrypoint-initdb.d/init.sql",
+				FileMode:          0o644,
+			}},
+			WaitingFor: wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(time.Minute),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	defer postgresContainer.Terminate(context.Background())
+
+	host, err := postgresContainer.Host(ctx)
+	require.NoError(t, err)
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	require.NoError(t, err)
+	dsn := fmt.Sprintf("host=%s port=%d user=postgres password=postgres dbname=messages sslmode=disable", host, port.Int())
+
+	listener := NewNotificationListener(dsn, MessagesInsertedChannel)
+	go listener.Run(ctx)
+
+	// the first wake-up is sent once the listener is connected
+	select {
+	case <-listener.Wakeups():
+	case <-time.After(10 * time.Second):
+		t.Fatal("Listener did not connect")
+	}
+
+	conn, err := pgx.Connect(ctx, dsn)
+	require.NoError(t, err)
+	defer conn.Close(ctx)
+	_, err = conn.Exec(ctx, "INSERT INTO messages (phone_number, content) VALUES ('+905321234567', 'hello')")
+	require.NoError(t, err)
+
+	select {
+	case <-listener.Wakeups():
+	case <-time.After(5 * time.Second):
+		t.Fatal("No wake-up after insert")
+	}
+}
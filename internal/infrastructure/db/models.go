@@ -1,6 +1,43 @@
 package db
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultTenantID is the tenant of requests without an API key and of rows stored before tenants existed
+const DefaultTenantID uint = 1
+
+// Tenant is a team sharing the deployment. Its messages, campaigns, imports and exports are only
+// visible to requests made with one of its API keys.
+type Tenant struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Name string `json:"name"`
+	// WebhookURL and Provider override webhookUrl and pricing.provider for the messages of the tenant
+	WebhookURL string `json:"webhook_url,omitempty"`
+	Provider   string `json:"provider,omitempty"`
+	// RateLimit caps the API requests per second and DailyQuota the messages enqueued per UTC day, zero is unlimited
+	RateLimit  int       `json:"rate_limit"`
+	DailyQuota int       `json:"daily_quota"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// APIKey authenticates requests as its tenant, only the SHA-256 hash of the key is stored
+type APIKey struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	TenantID uint   `json:"tenant_id"`
+	Name     string `json:"name,omitempty"`
+	// Prefix is the start of the key, it tells keys apart in listings
+	Prefix  string `json:"prefix"`
+	KeyHash string `json:"-"`
+	// Admin keys belong to the default tenant and may call the operator and tenant endpoints
+	Admin     bool       `json:"admin"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
 
 type MessageStatus string
 
@@ -9,35 +46,388 @@ const (
 	StatusProcessing MessageStatus = "processing"
 	StatusDone       MessageStatus = "done"
 	StatusError      MessageStatus = "error"
+	// StatusReview marks messages whose delivery outcome is unknown and needs a manual decision
+	StatusReview MessageStatus = "review"
+	// StatusExpired marks messages that were not sent before their expiry and never will be
+	StatusExpired MessageStatus = "expired"
+	// StatusSuppressed marks messages not sent because the recipient is on the suppression list
+	StatusSuppressed MessageStatus = "suppressed"
+	// StatusDuplicate marks messages not sent because the same content went to the recipient shortly before
+	StatusDuplicate MessageStatus = "duplicate"
+	// StatusPaused holds the queued messages of a paused campaign, they are not claimed until it resumes
+	StatusPaused MessageStatus = "paused"
+	// StatusCancelled marks queued messages of a cancelled campaign, they are never sent
+	StatusCancelled MessageStatus = "cancelled"
+)
+
+// Priority orders pending messages, lower values are sent first.
+// The zero value is normal so messages created without a priority are not promoted.
+type Priority int
+
+const (
+	PriorityCritical Priority = -2
+	PriorityHigh     Priority = -1
+	PriorityNormal   Priority = 0
+	PriorityBulk     Priority = 1
 )
 
+var priorityNames = map[Priority]string{
+	PriorityCritical: "critical",
+	PriorityHigh:     "high",
+	PriorityNormal:   "normal",
+	PriorityBulk:     "bulk",
+}
+
+func ParsePriority(name string) (Priority, error) {
+	for priority, priorityName := range priorityNames {
+		if priorityName == name {
+			return priority, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown priority %q", name)
+}
+
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("priority(%d)", int(p))
+}
+
+// Urgent reports whether messages of this priority may use the reserved concurrency
+func (p Priority) Urgent() bool {
+	return p <= PriorityHigh
+}
+
+func (p Priority) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *Priority) UnmarshalText(text []byte) error {
+	priority, err := ParsePriority(string(text))
+	if err != nil {
+		return err
+	}
+	*p = priority
+	return nil
+}
+
 type Message struct {
 	ID          uint          `gorm:"primaryKey" json:"id"`
+	TenantID    uint          `gorm:"not null;default:1" json:"tenant_id"`
 	PhoneNumber string        `json:"phone_number"`
 	Content     string        `json:"content"`
 	Status      MessageStatus `gorm:"default:pending" json:"status"`
-	MessageID   string        `json:"message_id,omitempty"`
-	LastError   string        `json:"last_error,omitempty"`
-	CreatedAt   time.Time     `json:"created_at"`
-	ProcessedAt time.Time     `json:"processed_at,omitempty"`
-	SentAt      time.Time     `json:"sent_at,omitempty"`
+	Priority    Priority      `json:"priority"`
+	// CountryCode and NumberType are derived from the E.164 phone number, e.g. 90 and mobile
+	CountryCode string    `json:"country_code,omitempty"`
+	NumberType  string    `json:"number_type,omitempty"`
+	MessageID   string    `json:"message_id,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	ProcessedAt time.Time `json:"processed_at,omitempty"`
+	SentAt      time.Time `json:"sent_at,omitempty"`
+	// ExpiresAt is when the message becomes worthless, nil means it never expires
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Category selects the delivery window, e.g. marketing, TimeZone overrides the zone inferred from CountryCode
+	Category string `json:"category,omitempty"`
+	TimeZone string `json:"time_zone,omitempty"`
+	// DeliverAfter defers a message outside its delivery window, it is not claimed before then
+	DeliverAfter *time.Time `json:"deliver_after,omitempty"`
+	// DuplicateOf is the message a duplicate repeated
+	DuplicateOf *uint `json:"duplicate_of,omitempty"`
+	// CampaignID is the campaign the message was expanded from
+	CampaignID *uint `json:"campaign_id,omitempty"`
+	// ImportID is the bulk import the message was loaded from
+	ImportID *uint `json:"import_id,omitempty"`
+	// TemplateID and TemplateVersion identify the template the content was rendered from
+	TemplateID      *uint `json:"template_id,omitempty"`
+	TemplateVersion int   `json:"template_version,omitempty"`
+	// Locale is the template locale the content was rendered in, empty for the default body
+	Locale string `json:"locale,omitempty"`
+	// Encoding and Segments describe how the content is sent, Segments is the billable part count
+	Encoding string `json:"encoding,omitempty"`
+	Segments int    `json:"segments,omitempty"`
+	// Provider is who the message was sent through and Cost what sending it cost in the configured
+	// currency, Cost is nil until the message is sent and when its destination has no price
+	Provider string   `json:"provider,omitempty"`
+	Cost     *float64 `json:"cost,omitempty"`
+	// RecoveryAttempts counts how many times the reaper reset this message to pending
+	RecoveryAttempts int `json:"recovery_attempts,omitempty"`
+	// LeaseOwner is the instance that claimed the message, the claim is void after LeaseExpiresAt
+	LeaseOwner     string    `json:"-"`
+	LeaseExpiresAt time.Time `json:"-"`
+}
+
+// Expired reports whether the message expired at now
+func (m Message) Expired(now time.Time) bool {
+	return m.ExpiresAt != nil && !now.Before(*m.ExpiresAt)
 }
 
+// MaxRetries is the number of retries of a message, it moves to the dead letter table when they all failed
+const MaxRetries = 5
+
 type MessageRetry struct {
 	ID                uint   `gorm:"primaryKey"`
 	OriginalMessageID uint   `gorm:"not null;index"`
+	TenantID          uint   `gorm:"not null;default:1"`
 	PhoneNumber       string `gorm:"not null"`
 	Content           string `gorm:"not null"`
 	RetryCount        int    `gorm:"not null;default:0"`
 	LastError         string
+	ExpiresAt         *time.Time
 	CreatedAt         time.Time
+	// DeliverAfter defers a retry outside the delivery window of its message, it is not claimed before then
+	DeliverAfter *time.Time
+	// LeaseOwner is the instance that claimed the retry, the claim is void after LeaseExpiresAt
+	LeaseOwner     string    `json:"-"`
+	LeaseExpiresAt time.Time `json:"-"`
+	// Priority, Category, TimeZone and CountryCode are read from the message when the retry is claimed
+	Priority    Priority `gorm:"->"`
+	Category    string   `gorm:"->"`
+	TimeZone    string   `gorm:"->"`
+	CountryCode string   `gorm:"->"`
+}
+
+// Expired reports whether the retried message expired at now
+func (r MessageRetry) Expired(now time.Time) bool {
+	return r.ExpiresAt != nil && !now.Before(*r.ExpiresAt)
 }
 
 type MessageDeadLetter struct {
 	ID                uint   `gorm:"primaryKey"`
 	OriginalMessageID uint   `gorm:"not null;index"`
+	TenantID          uint   `gorm:"not null;default:1"`
 	PhoneNumber       string `gorm:"not null"`
 	Content           string `gorm:"not null"`
 	LastError         string
 	FailedAt          time.Time `gorm:"autoCreateTime"`
 }
+
+// Template is a named message body with {{variable}} placeholders, every update bumps its version.
+// Locales holds the body per locale, Body is used when no locale variant matches.
+type Template struct {
+	ID        uint              `gorm:"primaryKey" json:"id"`
+	Name      string            `gorm:"not null" json:"name"`
+	Version   int               `gorm:"not null" json:"version"`
+	Body      string            `gorm:"not null" json:"body"`
+	Locales   map[string]string `gorm:"serializer:json" json:"locales,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	DeletedAt gorm.DeletedAt    `json:"-"`
+}
+
+// TemplateVersion keeps every body a template had so sent messages can be traced to their text
+type TemplateVersion struct {
+	TemplateID uint              `gorm:"primaryKey" json:"template_id"`
+	Version    int               `gorm:"primaryKey" json:"version"`
+	Body       string            `gorm:"not null" json:"body"`
+	Locales    map[string]string `gorm:"serializer:json" json:"locales,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+type CampaignStatus string
+
+const (
+	CampaignRunning   CampaignStatus = "running"
+	CampaignPaused    CampaignStatus = "paused"
+	CampaignCancelled CampaignStatus = "cancelled"
+	// CampaignFailed marks campaigns that could not be expanded, e.g. their template was deleted
+	CampaignFailed CampaignStatus = "failed"
+)
+
+// Campaign sends a template to a list of recipients. The recipients are expanded into messages
+// in the background, the template version is pinned when the campaign is created.
+type Campaign struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	TenantID        uint           `gorm:"not null;default:1" json:"tenant_id"`
+	Name            string         `json:"name"`
+	TemplateID      uint           `json:"template_id"`
+	TemplateVersion int            `json:"template_version"`
+	Locale          string         `json:"locale,omitempty"`
+	Priority        Priority       `json:"priority"`
+	Category        string         `json:"category,omitempty"`
+	Status          CampaignStatus `json:"status"`
+	// Recipients is the size of the audience, Expanded how many recipients were turned into messages
+	Recipients int `json:"recipients"`
+	Expanded   int `json:"expanded"`
+	// Invalid counts expanded recipients that could not become a message, e.g. with an invalid number
+	Invalid   int    `json:"invalid"`
+	LastError string `json:"last_error,omitempty"`
+	// ExpandedAt is when the last recipient was expanded
+	ExpandedAt *time.Time `json:"expanded_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// CampaignRecipient is a member of a campaign audience, Position keeps the order of the list
+type CampaignRecipient struct {
+	CampaignID  uint              `gorm:"primaryKey" json:"-"`
+	Position    int               `gorm:"primaryKey" json:"-"`
+	PhoneNumber string            `json:"phone_number"`
+	Locale      string            `json:"locale,omitempty"`
+	Variables   map[string]string `gorm:"serializer:json" json:"variables,omitempty"`
+	// Error tells why the recipient did not become a message
+	Error string `json:"error,omitempty"`
+}
+
+type ImportStatus string
+
+const (
+	ImportProcessing ImportStatus = "processing"
+	ImportDone       ImportStatus = "done"
+	// ImportFailed marks imports stopped by an unreadable file or a storage error, rows stored before stay enqueued
+	ImportFailed ImportStatus = "failed"
+)
+
+// Import is a bulk load of messages from an uploaded CSV or JSONL file
+type Import struct {
+	ID       uint         `gorm:"primaryKey" json:"id"`
+	TenantID uint         `gorm:"not null;default:1" json:"tenant_id"`
+	FileName string       `json:"file_name"`
+	Format   string       `json:"format"`
+	Status   ImportStatus `json:"status"`
+	// Rows counts the rows read so far, each row is either imported or failed
+	Rows        int        `json:"rows"`
+	Imported    int        `json:"imported"`
+	Failed      int        `json:"failed"`
+	LastError   string     `json:"last_error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// ImportError tells why a row of an import was not enqueued, Line is the line of the row in the file
+type ImportError struct {
+	ImportID uint   `gorm:"primaryKey" json:"-"`
+	Line     int    `gorm:"primaryKey" json:"line"`
+	Error    string `json:"error"`
+}
+
+type ExportStatus string
+
+const (
+	ExportProcessing ExportStatus = "processing"
+	ExportDone       ExportStatus = "done"
+	ExportFailed     ExportStatus = "failed"
+)
+
+// MessageFilter selects the messages of an export, empty fields match every message
+type MessageFilter struct {
+	Status MessageStatus `json:"status,omitempty"`
+	// From and To bound the creation time of the messages, To is exclusive
+	From       *time.Time `json:"from,omitempty"`
+	To         *time.Time `json:"to,omitempty"`
+	CampaignID *uint      `json:"campaign_id,omitempty"`
+	// Provider is the provider that sent the messages
+	Provider string `json:"provider,omitempty"`
+	// TenantID is the tenant of the export, it is stored on the export rather than in the filter
+	TenantID uint `json:"-"`
+}
+
+// Export is a report of messages written to a file on the disk of the instance that ran it
+type Export struct {
+	ID       uint          `gorm:"primaryKey" json:"id"`
+	TenantID uint          `gorm:"not null;default:1" json:"tenant_id"`
+	Format   string        `json:"format"`
+	Filter   MessageFilter `gorm:"serializer:json" json:"filter"`
+	Status   ExportStatus  `json:"status"`
+	Rows     int           `json:"rows"`
+	// Size is the size of the file in bytes
+	Size        int64      `json:"size"`
+	Path        string     `json:"-"`
+	LastError   string     `json:"last_error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// VolumeBucket is the number of messages sent in an hour or a day starting at Start
+type VolumeBucket struct {
+	Start time.Time `json:"start"`
+	Sent  int       `json:"sent"`
+}
+
+// ErrorCount is how many messages failed with the same last error
+type ErrorCount struct {
+	Error string `json:"error"`
+	Count int    `json:"count"`
+}
+
+// SpendTotal is what the messages sent in a group, e.g. a day or a campaign, cost. Unpriced counts
+// the messages of the group whose destination had no price, they are not part of Cost.
+type SpendTotal struct {
+	Key      string  `json:"key"`
+	Messages int     `json:"messages"`
+	Segments int     `json:"segments"`
+	Unpriced int     `json:"unpriced"`
+	Cost     float64 `json:"cost"`
+}
+
+// Suppression is a phone number that must not receive messages, e.g. after replying STOP
+type Suppression struct {
+	PhoneNumber string    `gorm:"primaryKey" json:"phone_number"`
+	Reason      string    `json:"reason,omitempty"`
+	Source      string    `gorm:"not null" json:"source"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// InboundMessage is a message a recipient sent us (mobile originated), delivered by the provider
+type InboundMessage struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	From string `gorm:"column:from_number" json:"from"`
+	To   string `gorm:"column:to_number" json:"to,omitempty"`
+	Body string `json:"body"`
+	// Keyword is the routed keyword the body started with, e.g. STOP
+	Keyword    string `json:"keyword,omitempty"`
+	ProviderID string `json:"provider_id,omitempty"`
+	// ReplyTo is the last message delivered to the sender before this one was received
+	ReplyTo    *uint     `json:"reply_to,omitempty"`
+	ReceivedAt time.Time `json:"received_at"`
+	// ReadAt is when support read the message in its conversation, nil while unread
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type Direction string
+
+const (
+	DirectionInbound  Direction = "inbound"
+	DirectionOutbound Direction = "outbound"
+)
+
+// ConversationEntry is a message of a conversation thread, a Message sent to the number or an
+// InboundMessage received from it
+type ConversationEntry struct {
+	Direction Direction `json:"direction"`
+	ID        uint      `json:"id"`
+	Body      string    `json:"body"`
+	// Status is the delivery status of outbound messages
+	Status MessageStatus `json:"status,omitempty"`
+	// At is when the message was received, or sent and until then enqueued
+	At     time.Time  `json:"at"`
+	ReadAt *time.Time `json:"read_at,omitempty"`
+}
+
+// Conversation summarizes the thread with a number that replied at least once
+type Conversation struct {
+	PhoneNumber    string    `json:"phone_number"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+	// UnreadCount is the number of replies not read yet
+	UnreadCount int `json:"unread_count"`
+}
+
+// MessageAudit records a status change made outside the normal send flow
+type MessageAudit struct {
+	ID         uint          `gorm:"primaryKey" json:"id"`
+	MessageID  uint          `gorm:"not null;index" json:"message_id"`
+	Action     string        `gorm:"not null" json:"action"`
+	FromStatus MessageStatus `json:"from_status"`
+	ToStatus   MessageStatus `json:"to_status"`
+	Reason     string        `json:"reason,omitempty"`
+	Actor      string        `json:"actor"`
+	CreatedAt  time.Time     `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (MessageAudit) TableName() string {
+	return "message_audit"
+}
@@ -0,0 +1,184 @@
+package drain
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+type State string
+
+const (
+	StateIdle     State = "idle"
+	StateDraining State = "draining"
+	StateDrained  State = "drained"
+	StateTimedOut State = "timed_out"
+)
+
+// Drainable is a component that can stop taking new work and finish what it has in flight
+type Drainable interface {
+	Drain(ctx context.Context) error
+	InFlight() int
+}
+
+// ComponentProgress is the drain state of a single component
+type ComponentProgress struct {
+	State    State  `json:"state"`
+	InFlight int    `json:"in_flight"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Progress is the drain state of this instance
+type Progress struct {
+	State      State                        `json:"state"`
+	StartedAt  *time.Time                   `json:"started_at,omitempty"`
+	Deadline   *time.Time                   `json:"deadline,omitempty"`
+	FinishedAt *time.Time                   `json:"finished_at,omitempty"`
+	InFlight   int                          `json:"in_flight"`
+	Components map[string]ComponentProgress `json:"components"`
+}
+
+// Coordinator drains all registered components at once with a shared deadline.
+// A drain is one-way, once started the instance is expected to shut down.
+type Coordinator struct {
+	timeout time.Duration
+
+	mu         sync.Mutex
+	names      []string
+	components map[string]Drainable
+	states     map[string]ComponentProgress
+	state      State
+	startedAt  time.Time
+	deadline   time.Time
+	finishedAt time.Time
+	done       chan struct{}
+}
+
+func NewCoordinator(timeout time.Duration) *Coordinator {
+	return &Coordinator{
+		timeout:    timeout,
+		components: make(map[string]Drainable),
+		states:     make(map[string]ComponentProgress),
+		state:      StateIdle,
+		done:       make(chan struct{}),
+	}
+}
+
+func (c *Coordinator) Register(name string, component Drainable) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.components[name]; !exists {
+		c.names = append(c.names, name)
+		sort.Strings(c.names)
+	}
+	c.components[name] = component
+	c.states[name] = ComponentProgress{State: StateIdle}
+}
+
+// Start begins draining in the background and returns false if a drain was already started
+func (c *Coordinator) Start() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state != StateIdle {
+		return false
+	}
+
+	c.state = StateDraining
+	c.startedAt = time.Now()
+	c.deadline = c.startedAt.Add(c.timeout)
+	logger.Log.Info("Drain started", zap.Duration("timeout", c.timeout))
+
+	ctx, cancel := context.WithDeadline(context.Background(), c.deadline)
+	var wg sync.WaitGroup
+	for _, name := range c.names {
+		component := c.components[name]
+		c.states[name] = ComponentProgress{State: StateDraining}
+
+		wg.Add(1)
+		go func(name string, component Drainable) {
+			defer wg.Done()
+			c.finish(name, component.Drain(ctx))
+		}(name, component)
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+		c.complete()
+	}()
+	return true
+}
+
+// Wait blocks until the drain finishes or ctx is done and returns the progress at that point
+func (c *Coordinator) Wait(ctx context.Context) Progress {
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+	}
+	return c.Progress()
+}
+
+// Drain starts the drain if needed and waits for it to finish
+func (c *Coordinator) Drain(ctx context.Context) Progress {
+	c.Start()
+	return c.Wait(ctx)
+}
+
+func (c *Coordinator) Progress() Progress {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	progress := Progress{
+		State:      c.state,
+		Components: make(map[string]ComponentProgress, len(c.names)),
+	}
+	if !c.startedAt.IsZero() {
+		startedAt, deadline := c.startedAt, c.deadline
+		progress.StartedAt = &startedAt
+		progress.Deadline = &deadline
+	}
+	if !c.finishedAt.IsZero() {
+		finishedAt := c.finishedAt
+		progress.FinishedAt = &finishedAt
+	}
+
+	for _, name := range c.names {
+		component := c.states[name]
+		component.InFlight = c.components[name].InFlight()
+		progress.InFlight += component.InFlight
+		progress.Components[name] = component
+	}
+	return progress
+}
+
+func (c *Coordinator) finish(name string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		c.states[name] = ComponentProgress{State: StateTimedOut, Error: err.Error()}
+		return
+	}
+	c.states[name] = ComponentProgress{State: StateDrained}
+}
+
+func (c *Coordinator) complete() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.state = StateDrained
+	for _, component := range c.states {
+		if component.State == StateTimedOut {
+			c.state = StateTimedOut
+		}
+	}
+	c.finishedAt = time.Now()
+	close(c.done)
+
+	logger.Log.Info("Drain finished",
+		zap.String("state", string(c.state)),
+		zap.Duration("duration", c.finishedAt.Sub(c.startedAt)))
+}
@@ -0,0 +1,116 @@
+package drain
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type fakeComponent struct {
+	release  chan struct{}
+	inFlight atomic.Int64
+	calls    atomic.Int64
+}
+
+func newFakeComponent(inFlight int) *fakeComponent {
+	f := &fakeComponent{release: make(chan struct{})}
+	f.inFlight.Store(int64(inFlight))
+	return f
+}
+
+func (f *fakeComponent) Drain(ctx context.Context) error {
+	f.calls.Add(1)
+	select {
+	case <-f.release:
+		f.inFlight.Store(0)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *fakeComponent) InFlight() int {
+	return int(f.inFlight.Load())
+}
+
+func TestCoordinator_Drain(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	main := newFakeComponent(2)
+	retry := newFakeComponent(1)
+	coordinator := NewCoordinator(time.Second)
+	coordinator.Register("main", main)
+	coordinator.Register("retry", retry)
+
+	progress := coordinator.Progress()
+	assert.Equal(t, StateIdle, progress.State)
+	assert.Nil(t, progress.StartedAt)
+	assert.Equal(t, 3, progress.InFlight)
+
+	assert.True(t, coordinator.Start())
+	assert.False(t, coordinator.Start())
+
+	progress = coordinator.Progress()
+	assert.Equal(t, StateDraining, progress.State)
+	assert.NotNil(t, progress.StartedAt)
+	assert.NotNil(t, progress.Deadline)
+	assert.Equal(t, 3, progress.InFlight)
+
+	close(main.release)
+	close(retry.release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	progress = coordinator.Wait(ctx)
+
+	assert.Equal(t, StateDrained, progress.State)
+	assert.NotNil(t, progress.FinishedAt)
+	assert.Equal(t, 0, progress.InFlight)
+	assert.Equal(t, StateDrained, progress.Components["main"].State)
+	assert.Equal(t, StateDrained, progress.Components["retry"].State)
+	assert.Equal(t, int64(1), main.calls.Load())
+}
+
+func TestCoordinator_DrainTimesOut(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	stuck := newFakeComponent(1)
+	done := newFakeComponent(0)
+	close(done.release)
+
+	coordinator := NewCoordinator(20 * time.Millisecond)
+	coordinator.Register("main", stuck)
+	coordinator.Register("retry", done)
+
+	progress := coordinator.Drain(context.Background())
+
+	assert.Equal(t, StateTimedOut, progress.State)
+	assert.Equal(t, 1, progress.InFlight)
+	assert.Equal(t, StateTimedOut, progress.Components["main"].State)
+	assert.Equal(t, context.DeadlineExceeded.Error(), progress.Components["main"].Error)
+	assert.Equal(t, StateDrained, progress.Components["retry"].State)
+}
+
+func TestCoordinator_WaitReturnsWhenContextDone(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	stuck := newFakeComponent(1)
+	coordinator := NewCoordinator(time.Minute)
+	coordinator.Register("main", stuck)
+	coordinator.Start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	progress := coordinator.Wait(ctx)
+
+	assert.Equal(t, StateDraining, progress.State)
+	assert.Nil(t, progress.FinishedAt)
+
+	close(stuck.release)
+	assert.Equal(t, StateDrained, coordinator.Wait(context.Background()).State)
+}
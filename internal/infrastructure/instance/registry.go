@@ -0,0 +1,168 @@
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
+	"go.uber.org/zap"
+)
+
+const keyPrefix = "instances:"
+
+// ComponentStatus is the runtime state of a scheduler on one instance
+type ComponentStatus struct {
+	Running             bool      `json:"running"`
+	LastTickAt          time.Time `json:"last_tick_at,omitempty"`
+	LastBatchSize       int       `json:"last_batch_size"`
+	LastBatchDurationMs int64     `json:"last_batch_duration_ms"`
+	// BatchSize and Concurrency are the limits in effect when the scheduler sizes them adaptively
+	BatchSize   int `json:"batch_size,omitempty"`
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+// StatusReporter is implemented by components that publish their state through the registry
+type StatusReporter interface {
+	Status() ComponentStatus
+}
+
+// Info is the heartbeat record an instance stores in Redis
+type Info struct {
+	ID            string                     `json:"id"`
+	Version       string                     `json:"version"`
+	StartedAt     time.Time                  `json:"started_at"`
+	LastHeartbeat time.Time                  `json:"last_heartbeat"`
+	Components    map[string]ComponentStatus `json:"components"`
+	Stale         bool                       `json:"stale"`
+}
+
+type Registry struct {
+	redisClient redisClient.Client
+	id          string
+	version     string
+	startedAt   time.Time
+	interval    time.Duration
+	ttl         time.Duration
+	staleAfter  time.Duration
+
+	mu         sync.Mutex
+	components map[string]StatusReporter
+}
+
+func NewRegistry(redisClient redisClient.Client, cfg config.Config) *Registry {
+	return &Registry{
+		redisClient: redisClient,
+		id:          cfg.Instance.ID,
+		version:     cfg.Instance.Version,
+		startedAt:   time.Now(),
+		interval:    cfg.Instance.HeartbeatInterval,
+		ttl:         cfg.Instance.TTL,
+		staleAfter:  cfg.Instance.StaleAfter,
+		components:  make(map[string]StatusReporter),
+	}
+}
+
+func (r *Registry) ID() string {
+	return r.id
+}
+
+// Register adds a component whose status is included in every heartbeat
+func (r *Registry) Register(name string, reporter StatusReporter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.components[name] = reporter
+}
+
+// Heartbeat writes the current snapshot of this instance to Redis and refreshes its TTL
+func (r *Registry) Heartbeat(ctx context.Context) error {
+	payload, err := json.Marshal(r.snapshot())
+	if err != nil {
+		return err
+	}
+	return r.redisClient.Set(ctx, keyPrefix+r.id, payload, r.ttl)
+}
+
+// Run refreshes the heartbeat periodically so idle or stopped instances stay visible
+func (r *Registry) Run(ctx context.Context) {
+	if err := r.Heartbeat(ctx); err != nil {
+		logger.Log.Warn("Failed to register instance", zap.String("instanceID", r.id), zap.Error(err))
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Heartbeat(ctx); err != nil {
+				logger.Log.Warn("Failed to refresh instance heartbeat", zap.String("instanceID", r.id), zap.Error(err))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Deregister removes this instance from the registry, used on graceful shutdown
+func (r *Registry) Deregister(ctx context.Context) error {
+	return r.redisClient.Del(ctx, keyPrefix+r.id)
+}
+
+// List returns every instance that still has a heartbeat record, flagging those
+// that have not refreshed within the stale threshold
+func (r *Registry) List(ctx context.Context) ([]Info, error) {
+	keys, err := r.redisClient.Keys(ctx, keyPrefix+"*")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	instances := make([]Info, 0, len(keys))
+	for _, key := range keys {
+		payload, err := r.redisClient.Get(ctx, key)
+		if errors.Is(err, redisClient.Nil) {
+			// expired between SCAN and GET
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var info Info
+		if err := json.Unmarshal([]byte(payload), &info); err != nil {
+			logger.Log.Warn("Skipping malformed instance record", zap.String("key", key), zap.Error(err))
+			continue
+		}
+		info.Stale = now.Sub(info.LastHeartbeat) > r.staleAfter
+		instances = append(instances, info)
+	}
+
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].ID < instances[j].ID
+	})
+	return instances, nil
+}
+
+func (r *Registry) snapshot() Info {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	components := make(map[string]ComponentStatus, len(r.components))
+	for name, reporter := range r.components {
+		components[name] = reporter.Status()
+	}
+
+	return Info{
+		ID:            r.id,
+		Version:       r.version,
+		StartedAt:     r.startedAt,
+		LastHeartbeat: time.Now(),
+		Components:    components,
+	}
+}
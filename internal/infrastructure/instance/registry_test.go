@@ -0,0 +1,99 @@
+package instance_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/instance"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type staticReporter struct {
+	status instance.ComponentStatus
+}
+
+func (r staticReporter) Status() instance.ComponentStatus {
+	return r.status
+}
+
+func getTestConfig() config.Config {
+	cfg := config.Config{}
+	cfg.Instance.ID = "pod-a"
+	cfg.Instance.Version = "1.2.3"
+	cfg.Instance.HeartbeatInterval = time.Second
+	cfg.Instance.TTL = time.Minute
+	cfg.Instance.StaleAfter = 30 * time.Second
+	return cfg
+}
+
+func TestRegistry_Heartbeat(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	registry := instance.NewRegistry(mockRedis, getTestConfig())
+	registry.Register("main", staticReporter{status: instance.ComponentStatus{Running: true, LastBatchSize: 2}})
+
+	mockRedis.EXPECT().
+		Set(gomock.Any(), "instances:pod-a", gomock.Any(), time.Minute).
+		DoAndReturn(func(_ context.Context, _ string, value interface{}, _ time.Duration) error {
+			var info instance.Info
+			assert.NoError(t, json.Unmarshal(value.([]byte), &info))
+			assert.Equal(t, "pod-a", info.ID)
+			assert.Equal(t, "1.2.3", info.Version)
+			assert.True(t, info.Components["main"].Running)
+			assert.Equal(t, 2, info.Components["main"].LastBatchSize)
+			return nil
+		})
+
+	assert.NoError(t, registry.Heartbeat(context.Background()))
+}
+
+func TestRegistry_List(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	registry := instance.NewRegistry(mockRedis, getTestConfig())
+
+	live, _ := json.Marshal(instance.Info{ID: "pod-b", LastHeartbeat: time.Now()})
+	stale, _ := json.Marshal(instance.Info{ID: "pod-a", LastHeartbeat: time.Now().Add(-time.Minute)})
+
+	mockRedis.EXPECT().Keys(gomock.Any(), "instances:*").
+		Return([]string{"instances:pod-b", "instances:pod-a", "instances:pod-gone", "instances:pod-bad"}, nil)
+	mockRedis.EXPECT().Get(gomock.Any(), "instances:pod-b").Return(string(live), nil)
+	mockRedis.EXPECT().Get(gomock.Any(), "instances:pod-a").Return(string(stale), nil)
+	mockRedis.EXPECT().Get(gomock.Any(), "instances:pod-gone").Return("", redisClient.Nil)
+	mockRedis.EXPECT().Get(gomock.Any(), "instances:pod-bad").Return("{", nil)
+
+	infos, err := registry.List(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, infos, 2)
+	assert.Equal(t, "pod-a", infos[0].ID)
+	assert.True(t, infos[0].Stale)
+	assert.Equal(t, "pod-b", infos[1].ID)
+	assert.False(t, infos[1].Stale)
+}
+
+func TestRegistry_Deregister(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	registry := instance.NewRegistry(mockRedis, getTestConfig())
+
+	mockRedis.EXPECT().Del(gomock.Any(), "instances:pod-a").Return(nil)
+
+	assert.NoError(t, registry.Deregister(context.Background()))
+}
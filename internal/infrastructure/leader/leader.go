@@ -0,0 +1,89 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
+	"go.uber.org/zap"
+)
+
+const (
+	BackendRedis    = "redis"
+	BackendPostgres = "postgres"
+)
+
+// Elector campaigns for scheduler leadership so that only one instance drives the scheduler
+type Elector interface {
+	// Run campaigns and renews leadership until ctx is cancelled
+	Run(ctx context.Context)
+	// IsLeader reports whether this instance currently holds a valid lease
+	IsLeader() bool
+	// Leader returns the instance ID of the current leader, empty when there is none
+	Leader(ctx context.Context) (string, error)
+	// Resign releases leadership if held so another instance can take over immediately
+	Resign(ctx context.Context) error
+	Backend() string
+}
+
+// New creates the elector for the configured backend
+func New(cfg config.Config, redis redisClient.Client, sqlDB *sql.DB) Elector {
+	if cfg.Leader.Backend == BackendPostgres {
+		return NewPostgresElector(sqlDB, cfg)
+	}
+	return NewRedisElector(redis, cfg)
+}
+
+// state tracks the local view of leadership; leadership is only trusted until the lease
+// deadline so an instance that cannot renew steps down before another one takes over
+type state struct {
+	mu       sync.RWMutex
+	leader   bool
+	deadline time.Time
+}
+
+func (s *state) isLeader() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.leader && time.Now().Before(s.deadline)
+}
+
+func (s *state) acquired(instanceID string, deadline time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.leader {
+		logger.Log.Info("Acquired scheduler leadership", zap.String("instanceID", instanceID))
+	}
+	s.leader = true
+	s.deadline = deadline
+}
+
+func (s *state) lost(instanceID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.leader {
+		logger.Log.Warn("Lost scheduler leadership", zap.String("instanceID", instanceID))
+	}
+	s.leader = false
+	s.deadline = time.Time{}
+}
+
+func runLoop(ctx context.Context, interval time.Duration, campaign func(ctx context.Context)) {
+	campaign(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			campaign(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+// applicationNamePrefix marks the session holding the advisory lock so the leader can be looked up in pg_stat_activity
+const applicationNamePrefix = "messagingApp:"
+
+// PostgresElector holds leadership through a session level advisory lock on a dedicated
+// connection; if the leader dies its session ends and the lock is released.
+// It requires a direct or session pooled connection, transaction pooling breaks session locks.
+type PostgresElector struct {
+	db            *sql.DB
+	instanceID    string
+	lockID        int64
+	leaseTTL      time.Duration
+	renewInterval time.Duration
+	state         state
+
+	connMu sync.Mutex
+	conn   *sql.Conn
+}
+
+func NewPostgresElector(db *sql.DB, cfg config.Config) *PostgresElector {
+	return &PostgresElector{
+		db:            db,
+		instanceID:    cfg.Instance.ID,
+		lockID:        cfg.Leader.LockID,
+		leaseTTL:      cfg.Leader.LeaseTTL,
+		renewInterval: cfg.Leader.RenewInterval,
+	}
+}
+
+func (e *PostgresElector) Run(ctx context.Context) {
+	runLoop(ctx, e.renewInterval, e.campaign)
+}
+
+func (e *PostgresElector) IsLeader() bool {
+	return e.state.isLeader()
+}
+
+func (e *PostgresElector) Backend() string {
+	return BackendPostgres
+}
+
+func (e *PostgresElector) Leader(ctx context.Context) (string, error) {
+	var applicationName string
+	err := e.db.QueryRowContext(ctx, `
+		SELECT a.application_name
+		FROM pg_locks l
+		JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE l.locktype = 'advisory'
+		  AND l.granted
+		  AND l.classid = ($1::bigint >> 32)::oid
+		  AND l.objid = ($1::bigint & 4294967295)::oid
+		  AND l.objsubid = 1`, e.lockID).Scan(&applicationName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(applicationName, applicationNamePrefix), nil
+}
+
+func (e *PostgresElector) Resign(ctx context.Context) error {
+	e.connMu.Lock()
+	defer e.connMu.Unlock()
+
+	e.state.lost(e.instanceID)
+	return e.closeConn(ctx)
+}
+
+func (e *PostgresElector) campaign(ctx context.Context) {
+	e.connMu.Lock()
+	defer e.connMu.Unlock()
+
+	deadline := time.Now().Add(e.leaseTTL)
+
+	// already leader, make sure the session holding the lock is still alive
+	if e.conn != nil {
+		if err := e.conn.PingContext(ctx); err != nil {
+			logger.Log.Warn("Leader session lost", zap.Error(err))
+			_ = e.closeConn(ctx)
+			e.state.lost(e.instanceID)
+			return
+		}
+		e.state.acquired(e.instanceID, deadline)
+		return
+	}
+
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		logger.Log.Warn("Failed to open leader election connection", zap.Error(err))
+		e.state.lost(e.instanceID)
+		return
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", e.lockID).Scan(&acquired); err != nil {
+		logger.Log.Warn("Failed to acquire advisory lock", zap.Error(err))
+		_ = conn.Close()
+		e.state.lost(e.instanceID)
+		return
+	}
+	if !acquired {
+		_ = conn.Close()
+		e.state.lost(e.instanceID)
+		return
+	}
+
+	if _, err := conn.ExecContext(ctx, "SELECT set_config('application_name', $1, false)", applicationNamePrefix+e.instanceID); err != nil {
+		logger.Log.Warn("Failed to tag leader session", zap.Error(err))
+	}
+
+	e.conn = conn
+	e.state.acquired(e.instanceID, deadline)
+}
+
+// closeConn releases the lock and the session tag before the connection goes back to the pool,
+// otherwise other queries would run on a session named after the leader. A connection that
+// cannot be cleaned up is discarded instead.
+func (e *PostgresElector) closeConn(ctx context.Context) error {
+	if e.conn == nil {
+		return nil
+	}
+
+	_, err := e.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", e.lockID)
+	if err == nil {
+		_, err = e.conn.ExecContext(ctx, "RESET application_name")
+	}
+	if err != nil {
+		_ = e.conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+	_ = e.conn.Close()
+	e.conn = nil
+	return err
+}
@@ -0,0 +1,96 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"go.uber.org/zap"
+)
+
+func TestPostgresElectorIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+	logger.Log = zap.NewNop()
+
+	ctx := context.Background()
+	postgresContainer, err := startPostgresContainer(ctx)
+	require.NoError(t, err)
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	require.NoError(t, err)
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	require.NoError(t, err)
+
+	dsn := fmt.Sprintf("host=%s port=%d user=postgres password=postgres dbname=messages sslmode=disable", host, port.Int())
+	sqlDB, err := sql.Open("pgx", dsn)
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	cfgA := getTestConfig()
+	cfgA.Leader.Backend = BackendPostgres
+	cfgA.Leader.LockID = 42
+	cfgB := cfgA
+	cfgB.Instance.ID = "pod-b"
+
+	electorA := NewPostgresElector(sqlDB, cfgA)
+	electorB := NewPostgresElector(sqlDB, cfgB)
+
+	// first campaigner wins
+	electorA.campaign(ctx)
+	electorB.campaign(ctx)
+	assert.True(t, electorA.IsLeader())
+	assert.False(t, electorB.IsLeader())
+
+	leaderID, err := electorB.Leader(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "pod-a", leaderID)
+
+	// handover after resign
+	require.NoError(t, electorA.Resign(ctx))
+	electorB.campaign(ctx)
+	assert.False(t, electorA.IsLeader())
+	assert.True(t, electorB.IsLeader())
+
+	leaderID, err = electorA.Leader(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "pod-b", leaderID)
+
+	// pooled connections are not left tagged as the old leader
+	require.NoError(t, electorB.Resign(ctx))
+	var tagged int
+	require.NoError(t, sqlDB.QueryRowContext(ctx,
+		"SELECT count(*) FROM pg_stat_activity WHERE application_name LIKE $1", applicationNamePrefix+"%").Scan(&tagged))
+	assert.Zero(t, tagged)
+}
+
+// Helper function to start a Postgres container
+func startPostgresContainer(ctx context.Context) (testcontainers.Container, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        "postgres:17.0-alpine3.20",
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_DB":       "messages",
+			"POSTGRES_USER":     "postgres",
+			"POSTGRES_PASSWORD": "postgres",
+		},
+		WaitingFor: wait.ForLog("database system is ready to accept connections").
+			WithOccurrence(2).
+			WithStartupTimeout(time.Minute),
+	}
+
+	return testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+}
@@ -0,0 +1,101 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
+	"go.uber.org/zap"
+)
+
+const leaseKey = "scheduler:leader"
+
+// renewScript extends the lease only if it is still owned by the caller
+const renewScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`
+
+// releaseScript deletes the lease only if it is still owned by the caller
+const releaseScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`
+
+// RedisElector holds leadership through a Redis key with a TTL; when the leader stops
+// renewing, the key expires and the next campaigning instance takes over
+type RedisElector struct {
+	redisClient   redisClient.Client
+	instanceID    string
+	leaseTTL      time.Duration
+	renewInterval time.Duration
+	state         state
+}
+
+func NewRedisElector(redisClient redisClient.Client, cfg config.Config) *RedisElector {
+	return &RedisElector{
+		redisClient:   redisClient,
+		instanceID:    cfg.Instance.ID,
+		leaseTTL:      cfg.Leader.LeaseTTL,
+		renewInterval: cfg.Leader.RenewInterval,
+	}
+}
+
+func (e *RedisElector) Run(ctx context.Context) {
+	runLoop(ctx, e.renewInterval, e.campaign)
+}
+
+func (e *RedisElector) IsLeader() bool {
+	return e.state.isLeader()
+}
+
+func (e *RedisElector) Backend() string {
+	return BackendRedis
+}
+
+func (e *RedisElector) Leader(ctx context.Context) (string, error) {
+	id, err := e.redisClient.Get(ctx, leaseKey)
+	if errors.Is(err, redisClient.Nil) {
+		return "", nil
+	}
+	return id, err
+}
+
+func (e *RedisElector) Resign(ctx context.Context) error {
+	e.state.lost(e.instanceID)
+	_, err := e.redisClient.Eval(ctx, releaseScript, []string{leaseKey}, e.instanceID)
+	return err
+}
+
+func (e *RedisElector) campaign(ctx context.Context) {
+	deadline := time.Now().Add(e.leaseTTL)
+
+	renewed, err := e.redisClient.Eval(ctx, renewScript, []string{leaseKey}, e.instanceID, e.leaseTTL.Milliseconds())
+	if err != nil {
+		// keep the local lease until its deadline, Redis may recover before it expires
+		logger.Log.Warn("Failed to renew leader lease", zap.Error(err))
+		return
+	}
+	if n, ok := renewed.(int64); ok && n == 1 {
+		e.state.acquired(e.instanceID, deadline)
+		return
+	}
+
+	acquired, err := e.redisClient.SetNX(ctx, leaseKey, e.instanceID, e.leaseTTL)
+	if err != nil {
+		logger.Log.Warn("Failed to acquire leader lease", zap.Error(err))
+		e.state.lost(e.instanceID)
+		return
+	}
+	if acquired {
+		e.state.acquired(e.instanceID, deadline)
+		return
+	}
+
+	e.state.lost(e.instanceID)
+}
@@ -0,0 +1,127 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func getTestConfig() config.Config {
+	cfg := config.Config{}
+	cfg.Instance.ID = "pod-a"
+	cfg.Leader.Enabled = true
+	cfg.Leader.Backend = BackendRedis
+	cfg.Leader.LeaseTTL = 15 * time.Second
+	cfg.Leader.RenewInterval = 5 * time.Second
+	return cfg
+}
+
+func TestRedisElector_Campaign(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctx := context.Background()
+
+	t.Run("Acquires free lease", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		mockRedis.EXPECT().Eval(gomock.Any(), renewScript, []string{leaseKey}, "pod-a", int64(15000)).Return(int64(0), nil)
+		mockRedis.EXPECT().SetNX(gomock.Any(), leaseKey, "pod-a", 15*time.Second).Return(true, nil)
+
+		elector := NewRedisElector(mockRedis, getTestConfig())
+		elector.campaign(ctx)
+
+		assert.True(t, elector.IsLeader())
+	})
+
+	t.Run("Renews owned lease", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		mockRedis.EXPECT().Eval(gomock.Any(), renewScript, []string{leaseKey}, "pod-a", int64(15000)).Return(int64(1), nil)
+
+		elector := NewRedisElector(mockRedis, getTestConfig())
+		elector.campaign(ctx)
+
+		assert.True(t, elector.IsLeader())
+	})
+
+	t.Run("Follows when another instance holds the lease", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		mockRedis.EXPECT().Eval(gomock.Any(), renewScript, []string{leaseKey}, "pod-a", int64(15000)).Return(int64(0), nil)
+		mockRedis.EXPECT().SetNX(gomock.Any(), leaseKey, "pod-a", 15*time.Second).Return(false, nil)
+
+		elector := NewRedisElector(mockRedis, getTestConfig())
+		elector.campaign(ctx)
+
+		assert.False(t, elector.IsLeader())
+	})
+
+	t.Run("Steps down once the local lease expires without renewal", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cfg := getTestConfig()
+		cfg.Leader.LeaseTTL = 20 * time.Millisecond
+
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+		mockRedis.EXPECT().Eval(gomock.Any(), renewScript, []string{leaseKey}, "pod-a", int64(20)).Return(int64(1), nil)
+		mockRedis.EXPECT().Eval(gomock.Any(), renewScript, []string{leaseKey}, "pod-a", int64(20)).Return(nil, errors.New("redis down"))
+
+		elector := NewRedisElector(mockRedis, cfg)
+		elector.campaign(ctx)
+		assert.True(t, elector.IsLeader())
+
+		elector.campaign(ctx)
+		time.Sleep(30 * time.Millisecond)
+		assert.False(t, elector.IsLeader())
+	})
+}
+
+func TestRedisElector_Leader(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	elector := NewRedisElector(mockRedis, getTestConfig())
+
+	mockRedis.EXPECT().Get(gomock.Any(), leaseKey).Return("pod-b", nil)
+	leaderID, err := elector.Leader(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "pod-b", leaderID)
+
+	mockRedis.EXPECT().Get(gomock.Any(), leaseKey).Return("", redisClient.Nil)
+	leaderID, err = elector.Leader(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, leaderID)
+}
+
+func TestRedisElector_Resign(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	mockRedis.EXPECT().Eval(gomock.Any(), renewScript, []string{leaseKey}, "pod-a", int64(15000)).Return(int64(1), nil)
+	mockRedis.EXPECT().Eval(gomock.Any(), releaseScript, []string{leaseKey}, "pod-a").Return(int64(1), nil)
+
+	elector := NewRedisElector(mockRedis, getTestConfig())
+	elector.campaign(context.Background())
+	assert.True(t, elector.IsLeader())
+
+	assert.NoError(t, elector.Resign(context.Background()))
+	assert.False(t, elector.IsLeader())
+}
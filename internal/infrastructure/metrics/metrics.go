@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "messaging"
+
+var (
+	// ReaperRecovered counts stuck messages recovered by the reaper per action taken
+	ReaperRecovered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "reaper",
+		Name:      "recovered_total",
+		Help:      "Messages stuck in processing recovered by the reaper, by action.",
+	}, []string{"action"})
+
+	// ReaperStuck is the number of stuck messages found by the last reaper run
+	ReaperStuck = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "reaper",
+		Name:      "stuck_messages",
+		Help:      "Messages stuck in processing found by the last reaper run.",
+	})
+)
+
+var (
+	// WebhookRequests counts webhook calls by outcome
+	WebhookRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhook",
+		Name:      "requests_total",
+		Help:      "Webhook calls by outcome: success, error or throttled.",
+	}, []string{"outcome"})
+
+	// WebhookLatency is the webhook call latency
+	WebhookLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "webhook",
+		Name:      "latency_seconds",
+		Help:      "Webhook call latency.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	// AdaptiveBatchSize is the batch size currently chosen by the adaptive controller
+	AdaptiveBatchSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "adaptive",
+		Name:      "batch_size",
+		Help:      "Batch size currently used by the scheduler.",
+	})
+
+	// AdaptiveConcurrency is the concurrency currently chosen by the adaptive controller
+	AdaptiveConcurrency = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "adaptive",
+		Name:      "concurrency",
+		Help:      "Concurrent webhook calls currently allowed by the scheduler.",
+	})
+)
+
+// MessagesExpired counts messages dropped because they expired before they were sent
+var MessagesExpired = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "messages",
+	Name:      "expired_total",
+	Help:      "Messages marked as expired instead of being sent, by flow: send or retry.",
+}, []string{"flow"})
+
+// MessagesSuppressed counts messages not sent because the recipient is on the suppression list
+var MessagesSuppressed = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "messages",
+	Name:      "suppressed_total",
+	Help:      "Messages marked as suppressed instead of being sent, by flow: send or retry.",
+}, []string{"flow"})
+
+// MessagesDeferred counts messages put back because they were claimed outside their delivery window
+var MessagesDeferred = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "messages",
+	Name:      "deferred_total",
+	Help:      "Messages deferred to the next opening of their delivery window, by category.",
+}, []string{"category"})
+
+// MessagesDuplicate counts messages not sent because they repeated a recent message to the same recipient
+var MessagesDuplicate = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "messages",
+	Name:      "duplicate_total",
+	Help:      "Messages marked as duplicate instead of being sent.",
+})
+
+// CampaignRecipientsExpanded counts campaign recipients turned into messages or skipped as invalid
+var CampaignRecipientsExpanded = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "campaigns",
+	Name:      "recipients_expanded_total",
+	Help:      "Campaign recipients expanded, by result: queued or invalid.",
+}, []string{"result"})
+
+// ImportRows counts rows of imported files by result
+var ImportRows = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "imports",
+	Name:      "rows_total",
+	Help:      "Rows of imported files, by result: imported or failed.",
+}, []string{"result"})
+
+// ExportRows counts messages written to export files
+var ExportRows = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "exports",
+	Name:      "rows_total",
+	Help:      "Messages written to export files.",
+})
+
+// Spend sums the cost of sent messages in the configured currency
+var Spend = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "pricing",
+	Name:      "spend_total",
+	Help:      "Cost of sent messages in the configured currency, by provider.",
+}, []string{"provider"})
+
+// MessagesUnpriced counts sent messages whose destination has no price
+var MessagesUnpriced = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "pricing",
+	Name:      "messages_unpriced_total",
+	Help:      "Sent messages without a price for their destination, by provider.",
+}, []string{"provider"})
+
+// BudgetExceeded is 1 while the spend of a budget period is over its cap
+var BudgetExceeded = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "budget",
+	Name:      "exceeded",
+	Help:      "1 while the spend of the budget period is over its cap, by period: daily or monthly.",
+}, []string{"period"})
+
+// TenantRejected counts requests and messages refused for a tenant limit
+var TenantRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "tenants",
+	Name:      "rejected_total",
+	Help:      "Requests and messages refused for a tenant limit, by limit: rate_limit or daily_quota.",
+}, []string{"limit"})
+
+// Handler exposes the default Prometheus registry
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.Handler())
+}
@@ -1,6 +1,9 @@
 package monitoring
 
 import (
+	"context"
+	"errors"
+
 	"github.com/atakurt/messagingApp/internal/infrastructure/db"
 	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
 	"github.com/gofiber/fiber/v2"
@@ -9,35 +12,144 @@ import (
 type MonitoringInterface interface {
 	Readiness(c *fiber.Ctx) error
 	Liveness(c *fiber.Ctx) error
+	Health(c *fiber.Ctx) error
+}
+
+// LeaderInfo exposes the leader election state in the health output
+//
+//go:generate mockgen -destination=../../mocks/mock_leader_info.go -package=mocks github.com/atakurt/messagingApp/internal/infrastructure/monitoring LeaderInfo
+type LeaderInfo interface {
+	IsLeader() bool
+	Leader(ctx context.Context) (string, error)
+	Backend() string
+}
+
+type Option func(*MonitoringService)
+
+func WithInstanceID(instanceID string) Option {
+	return func(s *MonitoringService) {
+		s.instanceID = instanceID
+	}
+}
+
+func WithLeader(leader LeaderInfo) Option {
+	return func(s *MonitoringService) {
+		s.leader = leader
+	}
 }
 
 type MonitoringService struct {
-	db    db.DBInterface
-	redis redisClient.Client
+	db         db.DBInterface
+	redis      redisClient.Client
+	instanceID string
+	leader     LeaderInfo
+}
+
+// LeaderStatus describes the scheduler leader as seen by this instance
+type LeaderStatus struct {
+	Enabled  bool   `json:"enabled"`
+	Backend  string `json:"backend,omitempty"`
+	LeaderID string `json:"leader_id,omitempty"`
+	IsLeader bool   `json:"is_leader"`
+	Error    string `json:"error,omitempty"`
+}
+
+// HealthResponse is the detailed health of this instance
+type HealthResponse struct {
+	Status     string            `json:"status"`
+	InstanceID string            `json:"instance_id,omitempty"`
+	Checks     map[string]string `json:"checks"`
+	Leader     LeaderStatus      `json:"leader"`
 }
 
-func NewMonitoringService(dbInstance db.DBInterface, redis redisClient.Client) *MonitoringService {
-	return &MonitoringService{db: dbInstance, redis: redis}
+func NewMonitoringService(dbInstance db.DBInterface, redis redisClient.Client, opts ...Option) *MonitoringService {
+	s := &MonitoringService{db: dbInstance, redis: redis}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *MonitoringService) Readiness(c *fiber.Ctx) error {
+	if err := s.checkDB(); err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).SendString(err.Error())
+	}
+
+	if err := s.checkRedis(c.Context()); err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).SendString(err.Error())
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (s *MonitoringService) Liveness(c *fiber.Ctx) error {
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// Health godoc
+// @Summary      Instance health
+// @Description  Reports database and Redis connectivity and the current scheduler leader
+// @Tags         Monitoring
+// @Produce      json
+// @Success      200  {object}  HealthResponse
+// @Failure      503  {object}  HealthResponse
+// @Router       /health [get]
+func (s *MonitoringService) Health(c *fiber.Ctx) error {
+	response := HealthResponse{
+		Status:     "ok",
+		InstanceID: s.instanceID,
+		Checks:     map[string]string{"database": "ok", "redis": "ok"},
+		Leader:     s.leaderStatus(c.Context()),
+	}
+
+	if err := s.checkDB(); err != nil {
+		response.Status = "degraded"
+		response.Checks["database"] = err.Error()
+	}
+	if err := s.checkRedis(c.Context()); err != nil {
+		response.Status = "degraded"
+		response.Checks["redis"] = err.Error()
+	}
+
+	if response.Status != "ok" {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(response)
+	}
+	return c.JSON(response)
+}
+
+func (s *MonitoringService) checkDB() error {
 	sqlDB, err := s.db.GetSQLDB()
 	if err != nil {
-		return c.Status(fiber.StatusServiceUnavailable).SendString("DB connection error")
+		return errors.New("DB connection error")
 	}
 	if err := sqlDB.Ping(); err != nil {
-		return c.Status(fiber.StatusServiceUnavailable).SendString("DB ping failed")
+		return errors.New("DB ping failed")
 	}
+	return nil
+}
 
-	// Redis check
-	status := s.redis.Ping(c.Context())
+func (s *MonitoringService) checkRedis(ctx context.Context) error {
+	status := s.redis.Ping(ctx)
 	if status.Err() != nil {
-		return c.Status(fiber.StatusServiceUnavailable).SendString("Redis ping failed")
+		return errors.New("Redis ping failed")
 	}
-
-	return c.SendStatus(fiber.StatusOK)
+	return nil
 }
 
-func (s *MonitoringService) Liveness(c *fiber.Ctx) error {
-	return c.SendStatus(fiber.StatusOK)
+func (s *MonitoringService) leaderStatus(ctx context.Context) LeaderStatus {
+	if s.leader == nil {
+		return LeaderStatus{Enabled: false}
+	}
+
+	status := LeaderStatus{
+		Enabled:  true,
+		Backend:  s.leader.Backend(),
+		IsLeader: s.leader.IsLeader(),
+	}
+	leaderID, err := s.leader.Leader(ctx)
+	if err != nil {
+		status.Error = err.Error()
+	}
+	status.LeaderID = leaderID
+	return status
 }
@@ -1,10 +1,12 @@
 package monitoring
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
 
 	"github.com/atakurt/messagingApp/internal/mocks"
+	goRedis "github.com/go-redis/redis/v8"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -66,3 +68,38 @@ func TestMonitoringService_Readiness_DBConnectionError(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, fiber.StatusServiceUnavailable, ctx.Response().StatusCode())
 }
+
+func TestMonitoringService_Health_ReportsLeader(t *testing.T) {
+	// given
+	ctrl := gomock.NewController(t)
+
+	mockRedisClient := mocks.NewMockRedisClient(ctrl)
+	mockRedisClient.EXPECT().Ping(gomock.Any()).Return(goRedis.NewStatusResult("PONG", nil))
+	mockDB := mocks.NewMockDBInterface(ctrl)
+	mockDB.EXPECT().GetSQLDB().Return(nil, errors.New("DB connection error"))
+	mockLeader := mocks.NewMockLeaderInfo(ctrl)
+	mockLeader.EXPECT().Backend().Return("redis")
+	mockLeader.EXPECT().IsLeader().Return(false)
+	mockLeader.EXPECT().Leader(gomock.Any()).Return("pod-b", nil)
+
+	service := NewMonitoringService(mockDB, mockRedisClient, WithInstanceID("pod-a"), WithLeader(mockLeader))
+
+	app := fiber.New()
+	ctx := app.AcquireCtx(&fasthttp.RequestCtx{})
+	defer app.ReleaseCtx(ctx)
+
+	// when
+	err := service.Health(ctx)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusServiceUnavailable, ctx.Response().StatusCode())
+
+	var response HealthResponse
+	assert.NoError(t, json.Unmarshal(ctx.Response().Body(), &response))
+	assert.Equal(t, "degraded", response.Status)
+	assert.Equal(t, "pod-a", response.InstanceID)
+	assert.Equal(t, "DB connection error", response.Checks["database"])
+	assert.Equal(t, "ok", response.Checks["redis"])
+	assert.Equal(t, LeaderStatus{Enabled: true, Backend: "redis", LeaderID: "pod-b", IsLeader: false}, response.Leader)
+}
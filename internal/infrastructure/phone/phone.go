@@ -0,0 +1,159 @@
+package phone
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+)
+
+type Type string
+
+const (
+	TypeMobile            Type = "mobile"
+	TypeFixedLine         Type = "fixed_line"
+	TypeFixedLineOrMobile Type = "fixed_line_or_mobile"
+	TypeTollFree          Type = "toll_free"
+	TypePremiumRate       Type = "premium_rate"
+	TypeUnknown           Type = "unknown"
+)
+
+// ErrInvalid is wrapped by every parse error
+var ErrInvalid = errors.New("invalid phone number")
+
+// Number is a phone number normalized to E.164
+type Number struct {
+	// E164 is the number in E.164 format, e.g. +905321234567
+	E164 string
+	// CountryCode is the country calling code without the plus, e.g. 90
+	CountryCode string
+	// Region is the ISO 3166 code of the numbering plan, e.g. TR
+	Region string
+	Type   Type
+}
+
+// separators are the characters people put between digits
+const separators = " -.()/\t"
+
+// E.164 numbers of regions without a numbering plan are checked by length, calling code included
+const (
+	minDigits = 7
+	maxDigits = 15
+)
+
+// Parse normalizes raw to E.164. Numbers starting with + or 00 are international, any other number
+// is read as a national number of defaultRegion, with or without its trunk prefix.
+func Parse(raw, defaultRegion string) (Number, error) {
+	digits, international, err := clean(raw)
+	if err != nil {
+		return Number{}, err
+	}
+
+	if international {
+		return parseInternational(raw, digits)
+	}
+
+	r, ok := regionsByCode[strings.ToUpper(defaultRegion)]
+	if !ok {
+		return Number{}, fmt.Errorf("%w %q: national numbers need a supported default region, got %q", ErrInvalid, raw, defaultRegion)
+	}
+	if nsn, ok := r.nationalNumber(digits); ok {
+		return r.number(nsn), nil
+	}
+	// the country calling code written without a plus, e.g. 905321234567
+	if rest, found := strings.CutPrefix(digits, r.callingCode); found {
+		if nsn, ok := r.nationalNumber(rest); ok {
+			return r.number(nsn), nil
+		}
+	}
+	return Number{}, fmt.Errorf("%w %q: not a valid %s number", ErrInvalid, raw, r.code)
+}
+
+// Normalize returns raw in E.164 format using the configured default region
+func Normalize(raw string) (string, error) {
+	number, err := Parse(raw, config.Cfg.Phone.DefaultRegion)
+	if err != nil {
+		return "", err
+	}
+	return number.E164, nil
+}
+
+// parseInternational validates numbers of regions with a numbering plan against it, numbers of other
+// assigned calling codes only need a valid E.164 length
+func parseInternational(raw, digits string) (Number, error) {
+	// calling codes are prefix free, at most one of the candidates is assigned
+	for length := 1; length <= 3 && length < len(digits); length++ {
+		callingCode := digits[:length]
+		if r, ok := regionsByCallingCode[callingCode]; ok {
+			if nsn, ok := r.nationalNumber(digits[length:]); ok {
+				return r.number(nsn), nil
+			}
+			return Number{}, fmt.Errorf("%w %q: not a valid %s number", ErrInvalid, raw, r.code)
+		}
+		if !assignedCallingCodes[callingCode] {
+			continue
+		}
+		if len(digits) < minDigits || len(digits) > maxDigits {
+			return Number{}, fmt.Errorf("%w %q: expected %d to %d digits", ErrInvalid, raw, minDigits, maxDigits)
+		}
+		return Number{E164: "+" + digits, CountryCode: callingCode, Type: TypeUnknown}, nil
+	}
+	return Number{}, fmt.Errorf("%w %q: unknown country calling code", ErrInvalid, raw)
+}
+
+// clean strips separators and the international prefix, it reports whether the number was international
+func clean(raw string) (string, bool, error) {
+	number := strings.TrimSpace(raw)
+	if number == "" {
+		return "", false, fmt.Errorf("%w: phone number is empty", ErrInvalid)
+	}
+
+	international := false
+	if rest, found := strings.CutPrefix(number, "+"); found {
+		number, international = rest, true
+	}
+
+	var digits strings.Builder
+	for _, c := range number {
+		switch {
+		case c >= '0' && c <= '9':
+			digits.WriteRune(c)
+		case strings.ContainsRune(separators, c):
+		default:
+			return "", false, fmt.Errorf("%w %q: unexpected character %q", ErrInvalid, raw, c)
+		}
+	}
+
+	number = digits.String()
+	if rest, found := strings.CutPrefix(number, "00"); found && !international {
+		number, international = rest, true
+	}
+	if number == "" {
+		return "", false, fmt.Errorf("%w %q: no digits", ErrInvalid, raw)
+	}
+	return number, international, nil
+}
+
+// nationalNumber returns the national significant number of digits, a leading trunk prefix is
+// dropped, e.g. +90 (0)532 is accepted
+func (r *region) nationalNumber(digits string) (string, bool) {
+	if r.pattern.MatchString(digits) {
+		return digits, true
+	}
+	if r.trunkPrefix != "" {
+		if rest, found := strings.CutPrefix(digits, r.trunkPrefix); found && r.pattern.MatchString(rest) {
+			return rest, true
+		}
+	}
+	return "", false
+}
+
+func (r *region) number(nsn string) Number {
+	return Number{
+		E164:        "+" + r.callingCode + nsn,
+		CountryCode: r.callingCode,
+		Region:      r.code,
+		Type:        r.numberType(nsn),
+	}
+}
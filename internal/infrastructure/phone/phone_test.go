@@ -0,0 +1,63 @@
+package phone
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		region   string
+		expected Number
+	}{
+		{"E.164", "+905321234567", "TR", Number{"+905321234567", "90", "TR", TypeMobile}},
+		{"Spaced international", "+90 532 123 45 67", "TR", Number{"+905321234567", "90", "TR", TypeMobile}},
+		{"International with 00", "0090 532 123 4567", "TR", Number{"+905321234567", "90", "TR", TypeMobile}},
+		{"National with trunk prefix", "0532 123 45 67", "TR", Number{"+905321234567", "90", "TR", TypeMobile}},
+		{"National without trunk prefix", "(532) 123-45-67", "TR", Number{"+905321234567", "90", "TR", TypeMobile}},
+		{"Calling code without plus", "905321234567", "TR", Number{"+905321234567", "90", "TR", TypeMobile}},
+		{"Trunk prefix after calling code", "+90 (0)532 123 45 67", "TR", Number{"+905321234567", "90", "TR", TypeMobile}},
+		{"Istanbul fixed line", "0212 123 45 67", "TR", Number{"+902121234567", "90", "TR", TypeFixedLine}},
+		{"Toll free", "0800 123 45 67", "TR", Number{"+908001234567", "90", "TR", TypeTollFree}},
+		{"Default region does not apply to international numbers", "+44 7911 123456", "TR", Number{"+447911123456", "44", "GB", TypeMobile}},
+		{"North American number", "(415) 555-2671", "US", Number{"+14155552671", "1", "US", TypeFixedLineOrMobile}},
+		{"German mobile", "0151 23456789", "DE", Number{"+4915123456789", "49", "DE", TypeMobile}},
+		{"Region without a numbering plan", "+81 90 1234 5678", "TR", Number{"+819012345678", "81", "", TypeUnknown}},
+		{"Three digit calling code without a numbering plan", "00971 50 123 4567", "TR", Number{"+971501234567", "971", "", TypeUnknown}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			number, err := Parse(tt.raw, tt.region)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, number)
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		raw      string
+		region   string
+		expected string
+	}{
+		{"", "TR", "invalid phone number: phone number is empty"},
+		{"0532 123", "TR", `invalid phone number "0532 123": not a valid TR number`},
+		{"+90 532 123 45 67 8", "TR", `invalid phone number "+90 532 123 45 67 8": not a valid TR number`},
+		{"0532-CALL-NOW", "TR", `invalid phone number "0532-CALL-NOW": unexpected character 'C'`},
+		{"+999 123456", "TR", `invalid phone number "+999 123456": unknown country calling code`},
+		{"+81 90 1234 5678 9012", "TR", `invalid phone number "+81 90 1234 5678 9012": expected 7 to 15 digits`},
+		{"0532 123 45 67", "", `invalid phone number "0532 123 45 67": national numbers need a supported default region, got ""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			_, err := Parse(tt.raw, tt.region)
+			assert.ErrorIs(t, err, ErrInvalid)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package phone
+
+import (
+	"regexp"
+	"strings"
+)
+
+// region holds the numbering plan details needed to normalize and classify numbers of a country
+type region struct {
+	code        string
+	callingCode string
+	// trunkPrefix is dialled before national numbers inside the country, e.g. 0 in 0532 123 45 67
+	trunkPrefix string
+	// timeZone is the IANA zone of most recipients, countries spanning several zones use their most populous one
+	timeZone string
+	// pattern matches a valid national significant number, the number without calling code and trunk prefix
+	pattern  *regexp.Regexp
+	prefixes []typePrefix
+	// fallback is the type of valid numbers that match none of the prefixes
+	fallback Type
+}
+
+// typePrefix classifies national significant numbers starting with prefix, the longest prefix wins
+type typePrefix struct {
+	prefix     string
+	numberType Type
+}
+
+// regions are the supported numbering plans. Regions sharing a calling code list the one used to
+// resolve international numbers first.
+var regions = []region{
+	{
+		code: "TR", callingCode: "90", trunkPrefix: "0", timeZone: "Europe/Istanbul",
+		pattern: regexp.MustCompile(`^[2-58-9]\d{9}$`),
+		prefixes: []typePrefix{
+			{"5", TypeMobile}, {"2", TypeFixedLine}, {"3", TypeFixedLine}, {"4", TypeFixedLine},
+			{"800", TypeTollFree}, {"900", TypePremiumRate},
+		},
+		fallback: TypeUnknown,
+	},
+	{
+		code: "US", callingCode: "1", trunkPrefix: "1", timeZone: "America/New_York",
+		pattern: regexp.MustCompile(`^[2-9]\d{2}[2-9]\d{6}$`),
+		prefixes: []typePrefix{
+			{"800", TypeTollFree}, {"833", TypeTollFree}, {"844", TypeTollFree}, {"855", TypeTollFree},
+			{"866", TypeTollFree}, {"877", TypeTollFree}, {"888", TypeTollFree}, {"900", TypePremiumRate},
+		},
+		// the North American plan does not tell mobile and fixed line numbers apart
+		fallback: TypeFixedLineOrMobile,
+	},
+	{
+		code: "CA", callingCode: "1", trunkPrefix: "1", timeZone: "America/Toronto",
+		pattern: regexp.MustCompile(`^[2-9]\d{2}[2-9]\d{6}$`),
+		prefixes: []typePrefix{
+			{"800", TypeTollFree}, {"833", TypeTollFree}, {"844", TypeTollFree}, {"855", TypeTollFree},
+			{"866", TypeTollFree}, {"877", TypeTollFree}, {"888", TypeTollFree}, {"900", TypePremiumRate},
+		},
+		fallback: TypeFixedLineOrMobile,
+	},
+	{
+		code: "GB", callingCode: "44", trunkPrefix: "0", timeZone: "Europe/London",
+		pattern: regexp.MustCompile(`^[1-9]\d{8,9}$`),
+		prefixes: []typePrefix{
+			{"1", TypeFixedLine}, {"2", TypeFixedLine}, {"7", TypeMobile}, {"70", TypeUnknown}, {"76", TypeUnknown},
+			{"800", TypeTollFree}, {"808", TypeTollFree}, {"9", TypePremiumRate},
+		},
+		fallback: TypeUnknown,
+	},
+	{
+		code: "DE", callingCode: "49", trunkPrefix: "0", timeZone: "Europe/Berlin",
+		pattern: regexp.MustCompile(`^[1-9]\d{5,13}$`),
+		prefixes: []typePrefix{
+			{"15", TypeMobile}, {"16", TypeMobile}, {"17", TypeMobile},
+			{"800", TypeTollFree}, {"900", TypePremiumRate},
+		},
+		fallback: TypeFixedLine,
+	},
+	{
+		code: "FR", callingCode: "33", trunkPrefix: "0", timeZone: "Europe/Paris",
+		pattern: regexp.MustCompile(`^[1-9]\d{8}$`),
+		prefixes: []typePrefix{
+			{"1", TypeFixedLine}, {"2", TypeFixedLine}, {"3", TypeFixedLine}, {"4", TypeFixedLine}, {"5", TypeFixedLine},
+			{"6", TypeMobile}, {"7", TypeMobile}, {"80", TypeTollFree}, {"89", TypePremiumRate},
+		},
+		fallback: TypeUnknown,
+	},
+	{
+		code: "NL", callingCode: "31", trunkPrefix: "0", timeZone: "Europe/Amsterdam",
+		pattern: regexp.MustCompile(`^[1-9]\d{8}$`),
+		prefixes: []typePrefix{
+			{"6", TypeMobile}, {"800", TypeTollFree}, {"90", TypePremiumRate},
+		},
+		fallback: TypeFixedLine,
+	},
+	{
+		code: "AZ", callingCode: "994", trunkPrefix: "0", timeZone: "Asia/Baku",
+		pattern: regexp.MustCompile(`^[1-9]\d{8}$`),
+		prefixes: []typePrefix{
+			{"10", TypeMobile}, {"50", TypeMobile}, {"51", TypeMobile}, {"55", TypeMobile},
+			{"60", TypeMobile}, {"70", TypeMobile}, {"77", TypeMobile}, {"99", TypeMobile},
+			{"88", TypeTollFree},
+		},
+		fallback: TypeFixedLine,
+	},
+}
+
+// callingCodes are the country calling codes assigned by the ITU. Numbers of calling codes without a
+// numbering plan above are accepted by their length alone, their region and type are unknown.
+var callingCodes = strings.Fields(`
+	1 7
+	20 27 30 31 32 33 34 36 39 40 41 43 44 45 46 47 48 49 51 52 53 54 55 56 57 58
+	60 61 62 63 64 65 66 81 82 84 86 90 91 92 93 94 95 98
+	211 212 213 216 218 220 221 222 223 224 225 226 227 228 229 230 231 232 233 234 235 236 237
+	238 239 240 241 242 243 244 245 246 247 248 249 250 251 252 253 254 255 256 257 258 260 261
+	262 263 264 265 266 267 268 269 290 291 297 298 299
+	350 351 352 353 354 355 356 357 358 359 370 371 372 373 374 375 376 377 378 379 380 381 382
+	383 385 386 387 389 420 421 423
+	500 501 502 503 504 505 506 507 508 509 590 591 592 593 594 595 596 597 598 599
+	670 672 673 674 675 676 677 678 679 680 681 682 683 685 686 687 688 689 690 691 692
+	850 852 853 855 856 880 886
+	960 961 962 963 964 965 966 967 968 970 971 972 973 974 975 976 977 992 993 994 995 996 998`)
+
+var (
+	assignedCallingCodes = make(map[string]bool)
+	regionsByCode        = make(map[string]*region)
+	regionsByCallingCode = make(map[string]*region)
+)
+
+func init() {
+	for _, code := range callingCodes {
+		assignedCallingCodes[code] = true
+	}
+	for i := range regions {
+		r := &regions[i]
+		regionsByCode[r.code] = r
+		if _, exists := regionsByCallingCode[r.callingCode]; !exists {
+			regionsByCallingCode[r.callingCode] = r
+		}
+	}
+}
+
+// TimeZone returns the IANA time zone of numbers with the country calling code, empty when
+// the calling code is not supported
+func TimeZone(countryCode string) string {
+	if r, ok := regionsByCallingCode[countryCode]; ok {
+		return r.timeZone
+	}
+	return ""
+}
+
+// numberType returns the type of the longest matching prefix of nsn
+func (r *region) numberType(nsn string) Type {
+	numberType, longest := r.fallback, 0
+	for _, p := range r.prefixes {
+		if len(p.prefix) > longest && len(nsn) >= len(p.prefix) && nsn[:len(p.prefix)] == p.prefix {
+			numberType, longest = p.numberType, len(p.prefix)
+		}
+	}
+	return numberType
+}
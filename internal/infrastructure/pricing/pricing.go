@@ -0,0 +1,68 @@
+package pricing
+
+import (
+	"math"
+	"strings"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/atakurt/messagingApp/internal/infrastructure/metrics"
+	"github.com/atakurt/messagingApp/internal/infrastructure/phone"
+	"github.com/atakurt/messagingApp/internal/infrastructure/sms"
+)
+
+// DefaultCountry is the price table entry of countries without their own price
+const DefaultCountry = "default"
+
+// Price returns the price of one segment sent through provider to a country calling code,
+// ok is false when the provider has neither a price for the country nor a default price
+func Price(provider, countryCode string) (price float64, ok bool) {
+	prices, ok := config.Cfg.Pricing.Prices[strings.ToLower(provider)]
+	if !ok {
+		return 0, false
+	}
+	if price, ok := prices[countryCode]; ok {
+		return price, true
+	}
+	price, ok = prices[DefaultCountry]
+	return price, ok
+}
+
+// Cost returns the price of sending segments segments, rounded to the six decimals the cost is stored with
+func Cost(provider, countryCode string, segments int) (float64, bool) {
+	price, ok := Price(provider, countryCode)
+	if !ok {
+		return 0, false
+	}
+	return math.Round(price*float64(segments)*1e6) / 1e6, true
+}
+
+// Apply sets the cost of sending msg with its provider, the cost stays nil when the destination
+// has no price. Messages without a provider, e.g. of tenants without their own, use the configured
+// one. Messages stored without a country code or segments, e.g. before they were kept, are priced
+// from the phone number and the content.
+func Apply(msg *db.Message) {
+	if msg.Provider == "" {
+		msg.Provider = config.Cfg.Pricing.Provider
+	}
+
+	countryCode := msg.CountryCode
+	if countryCode == "" {
+		if number, err := phone.Parse(msg.PhoneNumber, config.Cfg.Phone.DefaultRegion); err == nil {
+			countryCode = number.CountryCode
+		}
+	}
+	segments := msg.Segments
+	if segments == 0 {
+		segments = sms.Analyze(msg.Content).Segments
+	}
+
+	cost, ok := Cost(msg.Provider, countryCode, segments)
+	if !ok {
+		msg.Cost = nil
+		metrics.MessagesUnpriced.WithLabelValues(msg.Provider).Inc()
+		return
+	}
+	msg.Cost = &cost
+	metrics.Spend.WithLabelValues(msg.Provider).Add(cost)
+}
@@ -0,0 +1,77 @@
+package pricing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func setTestConfig() {
+	config.Cfg.Pricing.Provider = "webhook"
+	config.Cfg.Pricing.Prices = map[string]map[string]float64{
+		"webhook": {"90": 0.02, DefaultCountry: 0.05},
+		"other":   {"90": 0.01},
+	}
+	config.Cfg.Phone.DefaultRegion = "TR"
+}
+
+func TestCost(t *testing.T) {
+	setTestConfig()
+
+	tests := []struct {
+		name        string
+		provider    string
+		countryCode string
+		segments    int
+		expected    float64
+		ok          bool
+	}{
+		{"Country price", "webhook", "90", 3, 0.06, true},
+		{"Default price", "webhook", "44", 2, 0.1, true},
+		{"Provider names are case insensitive", "Webhook", "90", 1, 0.02, true},
+		{"No default price", "other", "44", 1, 0, false},
+		{"Unknown provider", "unknown", "90", 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cost, ok := Cost(tt.provider, tt.countryCode, tt.segments)
+			assert.Equal(t, tt.ok, ok)
+			assert.InDelta(t, tt.expected, cost, 1e-9)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	setTestConfig()
+
+	msg := &db.Message{PhoneNumber: "+905321234567", CountryCode: "90", Segments: 2}
+	Apply(msg)
+	assert.Equal(t, "webhook", msg.Provider)
+	if assert.NotNil(t, msg.Cost) {
+		assert.InDelta(t, 0.04, *msg.Cost, 1e-9)
+	}
+
+	// stored before the country code and segments were kept
+	msg = &db.Message{PhoneNumber: "+905321234567", Content: strings.Repeat("ş", 71)}
+	Apply(msg)
+	if assert.NotNil(t, msg.Cost) {
+		assert.InDelta(t, 0.04, *msg.Cost, 1e-9)
+	}
+
+	config.Cfg.Pricing.Provider = "other"
+	msg = &db.Message{PhoneNumber: "+447911123456", CountryCode: "44", Segments: 1}
+	Apply(msg)
+	assert.Equal(t, "other", msg.Provider)
+	assert.Nil(t, msg.Cost, "a destination without a price is not priced")
+
+	msg = &db.Message{PhoneNumber: "+905321234567", CountryCode: "90", Segments: 1, Provider: "webhook"}
+	Apply(msg)
+	assert.Equal(t, "webhook", msg.Provider, "the provider of the tenant is kept")
+	if assert.NotNil(t, msg.Cost) {
+		assert.InDelta(t, 0.02, *msg.Cost, 1e-9)
+	}
+}
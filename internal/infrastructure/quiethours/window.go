@@ -0,0 +1,101 @@
+package quiethours
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	// embeds the IANA database so recipient zones resolve in images without zoneinfo
+	_ "time/tzdata"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/phone"
+)
+
+// Window is a daily delivery window in the recipient's local time, in minutes since midnight.
+// A window whose end is before its start spans midnight, e.g. 22:00-06:00, and a window whose
+// start equals its end is open all day.
+type Window struct {
+	Start int
+	End   int
+}
+
+// ParseWindow reads a window written as HH:MM times, the end is exclusive
+func ParseWindow(start, end string) (Window, error) {
+	startMinute, err := parseClock(start)
+	if err != nil {
+		return Window{}, err
+	}
+	endMinute, err := parseClock(end)
+	if err != nil {
+		return Window{}, err
+	}
+	return Window{Start: startMinute, End: endMinute}, nil
+}
+
+// For returns the configured window of a message category, ok is false when messages of the
+// category may be sent at any time
+func For(category string) (window Window, ok bool, err error) {
+	configured, ok := config.Cfg.Delivery.Windows[strings.ToLower(category)]
+	if !ok {
+		return Window{}, false, nil
+	}
+	window, err = ParseWindow(configured.Start, configured.End)
+	if err != nil {
+		return Window{}, false, fmt.Errorf("delivery window of %q: %w", category, err)
+	}
+	return window, true, nil
+}
+
+// Location returns the time zone of a recipient: timeZone when it is set, otherwise the zone
+// inferred from the E.164 country calling code and UTC for unsupported codes
+func Location(timeZone, countryCode string) (*time.Location, error) {
+	if timeZone == "" {
+		timeZone = phone.TimeZone(countryCode)
+	}
+	if timeZone == "" {
+		return time.UTC, nil
+	}
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", timeZone)
+	}
+	return location, nil
+}
+
+// Allows reports whether t falls inside the window in loc
+func (w Window) Allows(t time.Time, loc *time.Location) bool {
+	local := t.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	switch {
+	case w.Start == w.End:
+		return true
+	case w.Start < w.End:
+		return minute >= w.Start && minute < w.End
+	default:
+		return minute >= w.Start || minute < w.End
+	}
+}
+
+// Next returns t when it is inside the window, otherwise the next time the window opens in loc
+func (w Window) Next(t time.Time, loc *time.Location) time.Time {
+	if w.Allows(t, loc) {
+		return t
+	}
+
+	local := t.In(loc)
+	day := local.Day()
+	// outside the window the clock is between the end and the start, the window opens today
+	// unless its start already passed
+	if local.Hour()*60+local.Minute() >= w.Start {
+		day++
+	}
+	return time.Date(local.Year(), local.Month(), day, w.Start/60, w.Start%60, 0, 0, loc)
+}
+
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
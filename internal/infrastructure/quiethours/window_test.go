@@ -0,0 +1,78 @@
+package quiethours
+
+import (
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindowNext(t *testing.T) {
+	istanbul, err := time.LoadLocation("Europe/Istanbul")
+	assert.NoError(t, err)
+	day := func(d, hour, minute int) time.Time {
+		return time.Date(2026, time.March, d, hour, minute, 0, 0, istanbul)
+	}
+
+	daytime := Window{Start: 9 * 60, End: 21 * 60}
+	overnight := Window{Start: 22 * 60, End: 6 * 60}
+
+	tests := []struct {
+		name     string
+		window   Window
+		now      time.Time
+		expected time.Time
+	}{
+		{"inside", daytime, day(10, 12, 0), day(10, 12, 0)},
+		{"before the start", daytime, day(10, 3, 0), day(10, 9, 0)},
+		{"at the end", daytime, day(10, 21, 0), day(11, 9, 0)},
+		{"overnight inside after midnight", overnight, day(10, 2, 0), day(10, 2, 0)},
+		{"overnight outside", overnight, day(10, 12, 0), day(10, 22, 0)},
+		{"open all day", Window{Start: 0, End: 0}, day(10, 3, 0), day(10, 3, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.expected.Equal(tt.window.Next(tt.now, istanbul)), tt.window.Next(tt.now, istanbul))
+		})
+	}
+
+	// 03:00 UTC is 06:00 in Istanbul, still outside a window opening at 09:00 local time
+	assert.Equal(t, day(10, 9, 0).UTC(), daytime.Next(time.Date(2026, time.March, 10, 3, 0, 0, 0, time.UTC), istanbul).UTC())
+}
+
+func TestParseWindowAndFor(t *testing.T) {
+	config.Cfg.Delivery.Windows = map[string]config.DeliveryWindow{
+		"marketing": {Start: "09:00", End: "21:30"},
+		"broken":    {Start: "9am", End: "21:00"},
+	}
+
+	window, ok, err := For("Marketing")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Window{Start: 540, End: 1290}, window)
+
+	_, ok, err = For("transactional")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = For("broken")
+	assert.Error(t, err)
+}
+
+func TestLocation(t *testing.T) {
+	location, err := Location("", "90")
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Istanbul", location.String())
+
+	location, err = Location("America/Los_Angeles", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "America/Los_Angeles", location.String())
+
+	location, err = Location("", "999")
+	assert.NoError(t, err)
+	assert.Equal(t, time.UTC, location)
+
+	_, err = Location("Mars/Olympus", "90")
+	assert.Error(t, err)
+}
@@ -12,12 +12,25 @@ type Client interface {
 	Exists(ctx context.Context, key string) (bool, error)
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
 	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
-	Subscribe(ctx context.Context, channel string) *PubSub
+	Get(ctx context.Context, key string) (string, error)
+	Del(ctx context.Context, keys ...string) error
+	Keys(ctx context.Context, pattern string) ([]string, error)
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+	Subscribe(ctx context.Context, channel string) Subscription
 	Publish(ctx context.Context, channel string, message interface{}) error
 	Ping(ctx context.Context) *redis.StatusCmd
 	Close(ctx context.Context) error
 }
 
+// Subscription is a live Pub/Sub subscription to a channel
+//
+//go:generate mockgen -destination=../../mocks/mock_subscription.go -package=mocks github.com/atakurt/messagingApp/internal/infrastructure/redis Subscription
+type Subscription interface {
+	Receive(ctx context.Context) (interface{}, error)
+	ReceiveMessage(ctx context.Context) (*redis.Message, error)
+	Close() error
+}
+
 // PubSub represents a Redis Pub/Sub subscription
 type PubSub struct {
 	pubsub *redis.PubSub
@@ -30,6 +43,11 @@ func NewPubSub(pubsub *redis.PubSub) *PubSub {
 	}
 }
 
+// Receive returns the next reply of the subscription, the first one confirms the subscription
+func (p *PubSub) Receive(ctx context.Context) (interface{}, error) {
+	return p.pubsub.Receive(ctx)
+}
+
 // ReceiveMessage receives a message from the subscription
 func (p *PubSub) ReceiveMessage(ctx context.Context) (*redis.Message, error) {
 	return p.pubsub.ReceiveMessage(ctx)
@@ -40,6 +58,9 @@ func (p *PubSub) Close() error {
 	return p.pubsub.Close()
 }
 
+// Nil is returned by Get when the key does not exist
+var Nil = redis.Nil
+
 type RedisClient struct {
 	client *redis.Client
 	ctx    context.Context
@@ -65,7 +86,29 @@ func (r *RedisClient) SetNX(ctx context.Context, key string, value interface{},
 	return r.client.SetNX(ctx, key, value, expiration).Result()
 }
 
-func (r *RedisClient) Subscribe(ctx context.Context, channel string) *PubSub {
+func (r *RedisClient) Get(ctx context.Context, key string) (string, error) {
+	return r.client.Get(ctx, key).Result()
+}
+
+func (r *RedisClient) Del(ctx context.Context, keys ...string) error {
+	return r.client.Del(ctx, keys...).Err()
+}
+
+// Keys returns all keys matching pattern using SCAN so large keyspaces are not blocked
+func (r *RedisClient) Keys(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	iter := r.client.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+func (r *RedisClient) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	return r.client.Eval(ctx, script, keys, args...).Result()
+}
+
+func (r *RedisClient) Subscribe(ctx context.Context, channel string) Subscription {
 	pubsub := r.client.Subscribe(ctx, channel)
 	return &PubSub{
 		pubsub: pubsub,
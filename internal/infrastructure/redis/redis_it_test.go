@@ -80,6 +80,32 @@ func TestRedisClientIntegration(t *testing.T) {
 		assert.False(t, success)
 	})
 
+	// Test Get, Keys and Del
+	t.Run("Get Keys and Del", func(t *testing.T) {
+		key := "test-get:1"
+
+		_, err := redisClient.Get(ctx, key)
+		assert.ErrorIs(t, err, Nil)
+
+		err = redisClient.Set(ctx, key, "test-value", 10*time.Second)
+		assert.NoError(t, err)
+
+		value, err := redisClient.Get(ctx, key)
+		assert.NoError(t, err)
+		assert.Equal(t, "test-value", value)
+
+		keys, err := redisClient.Keys(ctx, "test-get:*")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{key}, keys)
+
+		err = redisClient.Del(ctx, key)
+		assert.NoError(t, err)
+
+		exists, err := redisClient.Exists(ctx, key)
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+
 	// Test Subscribe
 	t.Run("Subscribe", func(t *testing.T) {
 		channel := "test-subscribe-channel"
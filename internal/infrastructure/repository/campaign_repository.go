@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//go:generate mockgen -destination=../../mocks/mock_campaign_repository.go -package=mocks github.com/atakurt/messagingApp/internal/infrastructure/repository CampaignRepositoryInterface
+type CampaignRepositoryInterface interface {
+	GetDB() *gorm.DB
+	CreateCampaign(campaign *db.Campaign, recipients []db.CampaignRecipient) error
+	GetCampaign(id uint) (db.Campaign, error)
+	ListCampaigns(tenantID uint, lastID, limit int) ([]db.Campaign, error)
+	CountCampaignMessages(id uint) (map[db.MessageStatus]int, error)
+	PauseCampaign(id uint) error
+	ResumeCampaign(id uint) error
+	CancelCampaign(id uint) error
+	LockExpandableCampaign(tx *gorm.DB) (db.Campaign, error)
+	GetRecipients(tx *gorm.DB, campaignID uint, from, limit int) ([]db.CampaignRecipient, error)
+	RecordExpansion(tx *gorm.DB, campaign db.Campaign, messages []db.Message, invalid []db.CampaignRecipient, expanded int) error
+	FailCampaign(tx *gorm.DB, id uint, reason string) error
+}
+
+var (
+	ErrCampaignNotFound = errors.New("campaign not found")
+	// ErrCampaignState is returned when a campaign cannot make the requested transition, e.g. resuming a running campaign
+	ErrCampaignState = errors.New("campaign status does not allow this action")
+)
+
+// insertBatchSize keeps inserts of large audiences under the Postgres parameter limit
+const insertBatchSize = 500
+
+type CampaignRepository struct {
+	db *gorm.DB
+}
+
+func NewCampaignRepository(db *gorm.DB) *CampaignRepository {
+	return &CampaignRepository{db: db}
+}
+
+func (r *CampaignRepository) GetDB() *gorm.DB {
+	return r.db
+}
+
+// CreateCampaign stores campaign as running with its recipients, they are expanded into messages later
+func (r *CampaignRepository) CreateCampaign(campaign *db.Campaign, recipients []db.CampaignRecipient) error {
+	campaign.Status = db.CampaignRunning
+	campaign.Recipients = len(recipients)
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(campaign).Error; err != nil {
+			return err
+		}
+		for i := range recipients {
+			recipients[i].CampaignID = campaign.ID
+			recipients[i].Position = i
+		}
+		return tx.CreateInBatches(recipients, insertBatchSize).Error
+	})
+}
+
+func (r *CampaignRepository) GetCampaign(id uint) (db.Campaign, error) {
+	var campaign db.Campaign
+	err := r.db.First(&campaign, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return campaign, ErrCampaignNotFound
+	}
+	return campaign, err
+}
+
+func (r *CampaignRepository) ListCampaigns(tenantID uint, lastID, limit int) ([]db.Campaign, error) {
+	var campaigns []db.Campaign
+	err := r.db.
+		Where("tenant_id = ? AND id > ?", tenantID, lastID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&campaigns).Error
+	return campaigns, err
+}
+
+// CountCampaignMessages returns the number of messages of the campaign in each status
+func (r *CampaignRepository) CountCampaignMessages(id uint) (map[db.MessageStatus]int, error) {
+	var rows []struct {
+		Status db.MessageStatus
+		Count  int
+	}
+	err := r.db.Model(&db.Message{}).
+		Select("status, COUNT(*) AS count").
+		Where("campaign_id = ?", id).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[db.MessageStatus]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// PauseCampaign stops expanding a running campaign and holds its pending messages
+func (r *CampaignRepository) PauseCampaign(id uint) error {
+	return r.transition(id, []db.CampaignStatus{db.CampaignRunning}, db.CampaignPaused,
+		[]db.MessageStatus{db.StatusPending}, db.StatusPaused)
+}
+
+// ResumeCampaign releases the held messages of a paused campaign and continues expanding it
+func (r *CampaignRepository) ResumeCampaign(id uint) error {
+	return r.transition(id, []db.CampaignStatus{db.CampaignPaused}, db.CampaignRunning,
+		[]db.MessageStatus{db.StatusPaused}, db.StatusPending)
+}
+
+// CancelCampaign stops a campaign for good, its queued messages are never sent. Messages already
+// being sent are completed.
+func (r *CampaignRepository) CancelCampaign(id uint) error {
+	return r.transition(id, []db.CampaignStatus{db.CampaignRunning, db.CampaignPaused}, db.CampaignCancelled,
+		[]db.MessageStatus{db.StatusPending, db.StatusPaused}, db.StatusCancelled)
+}
+
+// transition moves the campaign and its queued messages in one transaction. Updating the campaign
+// row waits for an expansion holding it, so messages inserted by that expansion are moved too.
+func (r *CampaignRepository) transition(id uint, from []db.CampaignStatus, to db.CampaignStatus, messagesFrom []db.MessageStatus, messagesTo db.MessageStatus) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&db.Campaign{}).
+			Where("id = ? AND status IN ?", id, from).
+			Updates(map[string]interface{}{"Status": to, "UpdatedAt": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if _, err := r.GetCampaign(id); err != nil {
+				return err
+			}
+			return ErrCampaignState
+		}
+
+		return tx.Model(&db.Message{}).
+			Where("campaign_id = ? AND status IN ?", id, messagesFrom).
+			Update("status", messagesTo).Error
+	})
+}
+
+// LockExpandableCampaign locks the oldest running campaign with recipients left to expand,
+// campaigns locked by other instances are skipped
+func (r *CampaignRepository) LockExpandableCampaign(tx *gorm.DB) (db.Campaign, error) {
+	var campaign db.Campaign
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND expanded < recipients", db.CampaignRunning).
+		Order("id ASC").
+		First(&campaign).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return campaign, ErrCampaignNotFound
+	}
+	return campaign, err
+}
+
+// GetRecipients returns up to limit recipients of the campaign starting at position from
+func (r *CampaignRepository) GetRecipients(tx *gorm.DB, campaignID uint, from, limit int) ([]db.CampaignRecipient, error) {
+	var recipients []db.CampaignRecipient
+	err := tx.
+		Where("campaign_id = ? AND position >= ?", campaignID, from).
+		Order("position ASC").
+		Limit(limit).
+		Find(&recipients).Error
+	return recipients, err
+}
+
+// RecordExpansion inserts the messages of an expanded chunk, records why the invalid recipients were
+// skipped and advances the campaign by expanded recipients
+func (r *CampaignRepository) RecordExpansion(tx *gorm.DB, campaign db.Campaign, messages []db.Message, invalid []db.CampaignRecipient, expanded int) error {
+	for i := range messages {
+		messages[i].Status = db.StatusPending
+	}
+	if len(messages) > 0 {
+		err := tx.Omit("ProcessedAt", "SentAt", "LeaseOwner", "LeaseExpiresAt").CreateInBatches(messages, insertBatchSize).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, recipient := range invalid {
+		err := tx.Model(&db.CampaignRecipient{}).
+			Where("campaign_id = ? AND position = ?", recipient.CampaignID, recipient.Position).
+			Update("error", recipient.Error).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	update := map[string]interface{}{
+		"Expanded":  gorm.Expr("expanded + ?", expanded),
+		"Invalid":   gorm.Expr("invalid + ?", len(invalid)),
+		"UpdatedAt": now,
+	}
+	if campaign.Expanded+expanded >= campaign.Recipients {
+		update["ExpandedAt"] = now
+	}
+	return tx.Model(&db.Campaign{}).Where("id = ?", campaign.ID).Updates(update).Error
+}
+
+// FailCampaign stops expanding a campaign that cannot be expanded, messages already queued are still sent
+func (r *CampaignRepository) FailCampaign(tx *gorm.DB, id uint, reason string) error {
+	return tx.Model(&db.Campaign{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"Status":    db.CampaignFailed,
+		"LastError": reason,
+		"UpdatedAt": time.Now(),
+	}).Error
+}
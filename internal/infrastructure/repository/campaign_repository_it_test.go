@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestCampaignRepositoryIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	postgresContainer, err := startPostgresContainer(ctx)
+	require.NoError(t, err)
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	require.NoError(t, err)
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	require.NoError(t, err)
+
+	dsn := fmt.Sprintf("host=%s port=%d user=postgres password=postgres dbname=messages sslmode=disable", host, port.Int())
+	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	repo := NewCampaignRepository(gormDB)
+
+	template := db.Template{Name: "spring", Body: "Hi {{name}}"}
+	require.NoError(t, NewTemplateRepository(gormDB).CreateTemplate(&template))
+
+	campaign := db.Campaign{Name: "spring", TemplateID: template.ID, TemplateVersion: template.Version, Priority: db.PriorityBulk}
+	require.NoError(t, repo.CreateCampaign(&campaign, []db.CampaignRecipient{
+		{PhoneNumber: "+905551112233", Variables: map[string]string{"name": "Ali"}},
+		{PhoneNumber: "12"},
+		{PhoneNumber: "+905551112234", Variables: map[string]string{"name": "Ayse"}},
+	}))
+	assert.Equal(t, db.CampaignRunning, campaign.Status)
+	assert.Equal(t, 3, campaign.Recipients)
+
+	// first chunk of two recipients, one of them invalid
+	tx := gormDB.Begin()
+	locked, err := repo.LockExpandableCampaign(tx)
+	require.NoError(t, err)
+	assert.Equal(t, campaign.ID, locked.ID)
+
+	other := gormDB.Begin()
+	_, err = repo.LockExpandableCampaign(other)
+	assert.ErrorIs(t, err, ErrCampaignNotFound, "a locked campaign is skipped")
+	other.Rollback()
+
+	recipients, err := repo.GetRecipients(tx, campaign.ID, locked.Expanded, 2)
+	require.NoError(t, err)
+	require.Len(t, recipients, 2)
+	recipients[1].Error = "invalid phone number"
+	first := []db.Message{{PhoneNumber: recipients[0].PhoneNumber, Content: "Hi Ali", CampaignID: &campaign.ID}}
+	require.NoError(t, repo.RecordExpansion(tx, locked, first, recipients[1:], 2))
+	require.NoError(t, tx.Commit().Error)
+
+	require.NoError(t, repo.PauseCampaign(campaign.ID))
+	assert.ErrorIs(t, repo.PauseCampaign(campaign.ID), ErrCampaignState)
+	counts, err := repo.CountCampaignMessages(campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[db.MessageStatus]int{db.StatusPaused: 1}, counts)
+
+	_, err = repo.LockExpandableCampaign(gormDB)
+	assert.ErrorIs(t, err, ErrCampaignNotFound, "a paused campaign is not expanded")
+
+	require.NoError(t, repo.ResumeCampaign(campaign.ID))
+	counts, err = repo.CountCampaignMessages(campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[db.MessageStatus]int{db.StatusPending: 1}, counts)
+
+	// last chunk completes the expansion
+	tx = gormDB.Begin()
+	locked, err = repo.LockExpandableCampaign(tx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, locked.Expanded)
+	recipients, err = repo.GetRecipients(tx, campaign.ID, locked.Expanded, 2)
+	require.NoError(t, err)
+	require.Len(t, recipients, 1)
+	last := []db.Message{{PhoneNumber: recipients[0].PhoneNumber, Content: "Hi Ayse", CampaignID: &campaign.ID}}
+	require.NoError(t, repo.RecordExpansion(tx, locked, last, nil, 1))
+	require.NoError(t, tx.Commit().Error)
+
+	stored, err := repo.GetCampaign(campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stored.Expanded)
+	assert.Equal(t, 1, stored.Invalid)
+	assert.NotNil(t, stored.ExpandedAt)
+
+	var skipped db.CampaignRecipient
+	require.NoError(t, gormDB.Where("campaign_id = ? AND position = 1", campaign.ID).First(&skipped).Error)
+	assert.Equal(t, "invalid phone number", skipped.Error)
+
+	require.NoError(t, repo.CancelCampaign(campaign.ID))
+	assert.ErrorIs(t, repo.ResumeCampaign(campaign.ID), ErrCampaignState)
+	assert.ErrorIs(t, repo.CancelCampaign(campaign.ID+1), ErrCampaignNotFound)
+	counts, err = repo.CountCampaignMessages(campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[db.MessageStatus]int{db.StatusCancelled: 2}, counts)
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -destination=../../mocks/mock_conversation_repository.go -package=mocks github.com/atakurt/messagingApp/internal/infrastructure/repository ConversationRepositoryInterface
+type ConversationRepositoryInterface interface {
+	GetThread(phoneNumber string, before *ThreadPosition, limit int) ([]db.ConversationEntry, error)
+	ListConversations(before *ConversationPosition, limit int) ([]db.Conversation, error)
+	MarkRead(phoneNumber string, readAt time.Time) (int64, error)
+}
+
+// ThreadPosition is the keyset of a thread entry, entries are ordered by time, direction and id
+type ThreadPosition struct {
+	At        time.Time
+	Direction db.Direction
+	ID        uint
+}
+
+// ConversationPosition is the keyset of a conversation, conversations are ordered by last activity and number
+type ConversationPosition struct {
+	LastActivityAt time.Time
+	PhoneNumber    string
+}
+
+type ConversationRepository struct {
+	db *gorm.DB
+}
+
+func NewConversationRepository(db *gorm.DB) *ConversationRepository {
+	return &ConversationRepository{db: db}
+}
+
+// thread merges the messages sent to a number with the replies received from it, an outbound
+// message is placed at the time it was sent or, until then, enqueued
+const thread = `
+	SELECT 'outbound' AS direction, id, content AS body, status, COALESCE(sent_at, created_at) AS at, NULL AS read_at
+	FROM messages WHERE phone_number = @phone
+	UNION ALL
+	SELECT 'inbound' AS direction, id, body, '' AS status, received_at AS at, read_at
+	FROM inbound_messages WHERE from_number = @phone`
+
+// GetThread returns up to limit entries of the conversation with phoneNumber that come before
+// before, the latest ones when before is nil, in chronological order
+func (r *ConversationRepository) GetThread(phoneNumber string, before *ThreadPosition, limit int) ([]db.ConversationEntry, error) {
+	args := map[string]interface{}{"phone": phoneNumber, "limit": limit}
+	where := ""
+	if before != nil {
+		where = "WHERE (at, direction, id) < (@at, @direction, @id)"
+		args["at"], args["direction"], args["id"] = before.At, before.Direction, before.ID
+	}
+
+	var entries []db.ConversationEntry
+	err := r.db.Raw(`
+		SELECT * FROM (`+thread+`) thread
+		`+where+`
+		ORDER BY at DESC, direction DESC, id DESC
+		LIMIT @limit`, args).Scan(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// the page is selected newest first, the thread reads oldest first
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// ListConversations returns the numbers that replied at least once ordered by their last activity
+// in either direction, newest first, starting after before
+func (r *ConversationRepository) ListConversations(before *ConversationPosition, limit int) ([]db.Conversation, error) {
+	args := map[string]interface{}{"limit": limit}
+	where := ""
+	if before != nil {
+		where = "WHERE (last_activity_at, phone_number) < (@at, @phone)"
+		args["at"], args["phone"] = before.LastActivityAt, before.PhoneNumber
+	}
+
+	var conversations []db.Conversation
+	err := r.db.Raw(`
+		SELECT * FROM (
+			SELECT i.from_number AS phone_number,
+				GREATEST(MAX(i.received_at), (
+					SELECT MAX(COALESCE(m.sent_at, m.created_at)) FROM messages m WHERE m.phone_number = i.from_number
+				)) AS last_activity_at,
+				COUNT(*) FILTER (WHERE i.read_at IS NULL) AS unread_count
+			FROM inbound_messages i
+			GROUP BY i.from_number
+		) conversations
+		`+where+`
+		ORDER BY last_activity_at DESC, phone_number DESC
+		LIMIT @limit`, args).Scan(&conversations).Error
+	return conversations, err
+}
+
+// MarkRead marks the unread replies from phoneNumber as read and returns how many there were
+func (r *ConversationRepository) MarkRead(phoneNumber string, readAt time.Time) (int64, error) {
+	result := r.db.Model(&db.InboundMessage{}).
+		Where("from_number = ? AND read_at IS NULL", phoneNumber).
+		Update("read_at", readAt)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestConversationRepositoryIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	postgresContainer, err := startPostgresContainer(ctx)
+	require.NoError(t, err)
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	require.NoError(t, err)
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	require.NoError(t, err)
+
+	dsn := fmt.Sprintf("host=%s port=%d user=postgres password=postgres dbname=messages sslmode=disable", host, port.Int())
+	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	repo := NewConversationRepository(gormDB)
+	inbound := NewInboundRepository(gormDB)
+	messages := NewMessageRepository(gormDB)
+
+	const number = "+905551112233"
+	start := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+
+	sent := db.Message{PhoneNumber: number, Content: "Your order has shipped"}
+	require.NoError(t, messages.CreateMessage(&sent))
+	require.NoError(t, gormDB.Model(&sent).Updates(map[string]interface{}{"status": db.StatusDone, "sent_at": start}).Error)
+
+	replyTo, err := inbound.LastSentMessageID(number)
+	require.NoError(t, err)
+	require.NotNil(t, replyTo)
+	assert.Equal(t, sent.ID, *replyTo)
+
+	reply := db.InboundMessage{From: number, Body: "Thanks", ProviderID: "mo-1", ReplyTo: replyTo, ReceivedAt: start.Add(time.Minute)}
+	require.NoError(t, inbound.CreateInboundMessage(&reply))
+	again := db.InboundMessage{From: number, Body: "Thanks", ProviderID: "mo-1", ReceivedAt: start.Add(time.Minute)}
+	require.NoError(t, inbound.CreateInboundMessage(&again))
+	assert.Equal(t, reply.ID, again.ID, "a redelivered message is stored once")
+
+	followUp := db.InboundMessage{From: number, Body: "When does it arrive?", ReceivedAt: start.Add(2 * time.Minute)}
+	require.NoError(t, inbound.CreateInboundMessage(&followUp))
+
+	entries, err := repo.GetThread(number, nil, 2)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "Thanks", entries[0].Body)
+	assert.Equal(t, "When does it arrive?", entries[1].Body)
+
+	older, err := repo.GetThread(number, &ThreadPosition{At: entries[0].At, Direction: entries[0].Direction, ID: entries[0].ID}, 2)
+	require.NoError(t, err)
+	require.Len(t, older, 1)
+	assert.Equal(t, db.DirectionOutbound, older[0].Direction)
+	assert.Equal(t, db.StatusDone, older[0].Status)
+
+	conversations, err := repo.ListConversations(nil, 10)
+	require.NoError(t, err)
+	require.Len(t, conversations, 1)
+	assert.Equal(t, number, conversations[0].PhoneNumber)
+	assert.Equal(t, 2, conversations[0].UnreadCount)
+
+	read, err := repo.MarkRead(number, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), read)
+
+	fromNumber, err := inbound.ListInboundMessages(number, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, fromNumber, 2)
+}
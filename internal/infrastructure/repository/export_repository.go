@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -destination=../../mocks/mock_export_repository.go -package=mocks github.com/atakurt/messagingApp/internal/infrastructure/repository ExportRepositoryInterface
+type ExportRepositoryInterface interface {
+	CreateExport(export *db.Export) error
+	GetExport(id uint) (db.Export, error)
+	ListExports(tenantID uint, lastID, limit int) ([]db.Export, error)
+	CompleteExport(export db.Export) error
+	ExportMessages(filter db.MessageFilter, lastID uint, limit int) ([]db.Message, error)
+}
+
+var ErrExportNotFound = errors.New("export not found")
+
+type ExportRepository struct {
+	db *gorm.DB
+}
+
+func NewExportRepository(db *gorm.DB) *ExportRepository {
+	return &ExportRepository{db: db}
+}
+
+// CreateExport stores export as processing
+func (r *ExportRepository) CreateExport(export *db.Export) error {
+	export.Status = db.ExportProcessing
+	return r.db.Create(export).Error
+}
+
+func (r *ExportRepository) GetExport(id uint) (db.Export, error) {
+	var export db.Export
+	err := r.db.First(&export, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return export, ErrExportNotFound
+	}
+	return export, err
+}
+
+func (r *ExportRepository) ListExports(tenantID uint, lastID, limit int) ([]db.Export, error) {
+	var exports []db.Export
+	err := r.db.
+		Where("tenant_id = ? AND id > ?", tenantID, lastID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&exports).Error
+	return exports, err
+}
+
+// CompleteExport records the outcome of export, its status, rows, size, path and last error
+func (r *ExportRepository) CompleteExport(export db.Export) error {
+	return r.db.Model(&db.Export{}).Where("id = ?", export.ID).Updates(map[string]interface{}{
+		"Status":      export.Status,
+		"Rows":        export.Rows,
+		"Size":        export.Size,
+		"Path":        export.Path,
+		"LastError":   export.LastError,
+		"CompletedAt": time.Now(),
+	}).Error
+}
+
+// ExportMessages returns up to limit messages matching filter with ID > lastID in ID order
+func (r *ExportRepository) ExportMessages(filter db.MessageFilter, lastID uint, limit int) ([]db.Message, error) {
+	query := r.db.Where("id > ?", lastID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if filter.CampaignID != nil {
+		query = query.Where("campaign_id = ?", *filter.CampaignID)
+	}
+	if filter.Provider != "" {
+		query = query.Where("provider = ?", filter.Provider)
+	}
+	if filter.TenantID != 0 {
+		query = query.Where("tenant_id = ?", filter.TenantID)
+	}
+
+	var messages []db.Message
+	err := query.
+		Order("id ASC").
+		Limit(limit).
+		Find(&messages).Error
+	return messages, err
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -destination=../../mocks/mock_import_repository.go -package=mocks github.com/atakurt/messagingApp/internal/infrastructure/repository ImportRepositoryInterface
+type ImportRepositoryInterface interface {
+	CreateImport(job *db.Import) error
+	GetImport(id uint) (db.Import, error)
+	RecordImportBatch(id uint, messages []db.Message, rowErrors []db.ImportError) error
+	CompleteImport(id uint, status db.ImportStatus, lastError string) error
+	FailStaleImports(before time.Time, lastError string) (int64, error)
+	ListImportErrors(id uint, lastLine, limit int) ([]db.ImportError, error)
+}
+
+var (
+	ErrImportNotFound = errors.New("import not found")
+	// ErrImportNotProcessing is returned for batches of an import that already ended, e.g. failed as stale
+	ErrImportNotProcessing = errors.New("import is no longer processing")
+)
+
+type ImportRepository struct {
+	db *gorm.DB
+}
+
+func NewImportRepository(db *gorm.DB) *ImportRepository {
+	return &ImportRepository{db: db}
+}
+
+// CreateImport stores job as processing
+func (r *ImportRepository) CreateImport(job *db.Import) error {
+	job.Status = db.ImportProcessing
+	return r.db.Create(job).Error
+}
+
+func (r *ImportRepository) GetImport(id uint) (db.Import, error) {
+	var job db.Import
+	err := r.db.First(&job, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return job, ErrImportNotFound
+	}
+	return job, err
+}
+
+// RecordImportBatch enqueues the messages of a batch of rows, stores the errors of the rejected rows
+// and advances the progress of the import in one transaction
+func (r *ImportRepository) RecordImportBatch(id uint, messages []db.Message, rowErrors []db.ImportError) error {
+	for i := range messages {
+		messages[i].Status = db.StatusPending
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(messages) > 0 {
+			err := tx.Omit("ProcessedAt", "SentAt", "LeaseOwner", "LeaseExpiresAt").CreateInBatches(messages, insertBatchSize).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(rowErrors) > 0 {
+			if err := tx.CreateInBatches(rowErrors, insertBatchSize).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&db.Import{}).Where("id = ? AND status = ?", id, db.ImportProcessing).Updates(map[string]interface{}{
+			"Rows":      gorm.Expr("rows + ?", len(messages)+len(rowErrors)),
+			"Imported":  gorm.Expr("imported + ?", len(messages)),
+			"Failed":    gorm.Expr("failed + ?", len(rowErrors)),
+			"UpdatedAt": time.Now(),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrImportNotProcessing
+		}
+		return nil
+	})
+}
+
+// CompleteImport ends the import with status, lastError tells why a failed import stopped. Imports
+// that already ended keep their status.
+func (r *ImportRepository) CompleteImport(id uint, status db.ImportStatus, lastError string) error {
+	now := time.Now()
+	return r.db.Model(&db.Import{}).Where("id = ? AND status = ?", id, db.ImportProcessing).Updates(map[string]interface{}{
+		"Status":      status,
+		"LastError":   lastError,
+		"UpdatedAt":   now,
+		"CompletedAt": now,
+	}).Error
+}
+
+// FailStaleImports fails the processing imports without progress since before, their instance stopped
+// before finishing them. It returns the number of imports failed.
+func (r *ImportRepository) FailStaleImports(before time.Time, lastError string) (int64, error) {
+	now := time.Now()
+	result := r.db.Model(&db.Import{}).Where("status = ? AND updated_at < ?", db.ImportProcessing, before).Updates(map[string]interface{}{
+		"Status":      db.ImportFailed,
+		"LastError":   lastError,
+		"UpdatedAt":   now,
+		"CompletedAt": now,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// ListImportErrors returns the errors of rows after lastLine in file order
+func (r *ImportRepository) ListImportErrors(id uint, lastLine, limit int) ([]db.ImportError, error) {
+	var rowErrors []db.ImportError
+	err := r.db.
+		Where("import_id = ? AND line > ?", id, lastLine).
+		Order("line ASC").
+		Limit(limit).
+		Find(&rowErrors).Error
+	return rowErrors, err
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//go:generate mockgen -destination=../../mocks/mock_inbound_repository.go -package=mocks github.com/atakurt/messagingApp/internal/infrastructure/repository InboundRepositoryInterface
+type InboundRepositoryInterface interface {
+	CreateInboundMessage(msg *db.InboundMessage) error
+	ListInboundMessages(phoneNumber string, lastID, limit int) ([]db.InboundMessage, error)
+	LastSentMessageID(phoneNumber string) (*uint, error)
+}
+
+type InboundRepository struct {
+	db *gorm.DB
+}
+
+func NewInboundRepository(db *gorm.DB) *InboundRepository {
+	return &InboundRepository{db: db}
+}
+
+// CreateInboundMessage stores msg once per provider id, a message the provider delivers again is
+// loaded into msg instead
+func (r *InboundRepository) CreateInboundMessage(msg *db.InboundMessage) error {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "provider_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Neq{Column: "provider_id", Value: ""}}},
+		DoNothing:   true,
+	}).Create(msg)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+	return r.db.Where("provider_id = ?", msg.ProviderID).First(msg).Error
+}
+
+// ListInboundMessages returns inbound messages in arrival order, only from phoneNumber when it is set
+func (r *InboundRepository) ListInboundMessages(phoneNumber string, lastID, limit int) ([]db.InboundMessage, error) {
+	query := r.db.Where("id > ?", lastID)
+	if phoneNumber != "" {
+		query = query.Where("from_number = ?", phoneNumber)
+	}
+
+	var messages []db.InboundMessage
+	err := query.Order("id ASC").Limit(limit).Find(&messages).Error
+	return messages, err
+}
+
+// LastSentMessageID returns the id of the last message delivered to phoneNumber, nil when there is none
+func (r *InboundRepository) LastSentMessageID(phoneNumber string) (*uint, error) {
+	var msg db.Message
+	err := r.db.
+		Select("id").
+		Where("phone_number = ? AND status = ?", phoneNumber, db.StatusDone).
+		Order("sent_at DESC, id DESC").
+		First(&msg).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &msg.ID, nil
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"errors"
+	"sort"
 	"time"
 
 	"github.com/atakurt/messagingApp/internal/infrastructure/db"
@@ -10,18 +12,48 @@ import (
 
 //go:generate mockgen -destination=../../mocks/mock_repository.go -package=mocks github.com/atakurt/messagingApp/internal/infrastructure/repository MessageRepositoryInterface
 type MessageRepositoryInterface interface {
-	GetUnsentMessages(tx *gorm.DB, limit int) ([]db.Message, error)
-	MarkMessageInProcess(tx *gorm.DB, msg *db.Message, processedAt time.Time) error
-	UpdateMessageAsError(tx *gorm.DB, msg *db.Message, errMsg string) error
-	GetSentMessages(lastID, limit int) ([]db.Message, error)
+	CreateMessage(msg *db.Message) error
+	ClaimMessages(owner string, leaseUntil time.Time, limit int, maxPriority db.Priority, boostBefore time.Time) ([]db.Message, error)
+	RecordMessageSent(msg *db.Message, owner string, messageID string, sentAt time.Time) error
+	RecordMessageRetry(msg *db.Message, owner string, errMsg string) error
+	RecordMessageError(msg *db.Message, owner string, errMsg string) error
+	RecordMessageExpired(msg *db.Message, owner string) error
+	RecordMessageSuppressed(msg *db.Message, owner string) error
+	RecordMessageDuplicate(msg *db.Message, owner string, originalID uint) error
+	DeferMessage(msg *db.Message, owner string, deliverAfter time.Time) error
+	ReleaseMessage(msg *db.Message, owner string) error
+	GetSentMessages(tenantID uint, lastID, limit int) ([]db.Message, error)
 	UpdateMessageAsSent(tx *gorm.DB, msg *db.Message, messageID string, sentAt time.Time) error
 	InsertRetry(tx *gorm.DB, msg db.Message, errMsg string) error
-	GetMessageRetries(tx *gorm.DB, limit int) ([]db.MessageRetry, error)
-	UpdateRetryCount(tx *gorm.DB, retryID uint, count int, errMsg string) error
+	ClaimRetries(owner string, leaseUntil time.Time, limit int, maxPriority db.Priority) ([]db.MessageRetry, error)
+	RecordRetrySent(retry *db.MessageRetry, owner string, msg *db.Message, messageID string, sentAt time.Time) error
+	RecordRetryFailed(retry *db.MessageRetry, owner string, count int, errMsg string) error
+	RecordRetryDeadLetter(retry *db.MessageRetry, owner string) error
+	CloseRetry(retry *db.MessageRetry, owner string, status db.MessageStatus) error
+	DeferRetry(retry *db.MessageRetry, owner string, deliverAfter time.Time) error
+	ReleaseRetry(retry *db.MessageRetry, owner string) error
 	MoveToDeadLetter(tx *gorm.DB, msg db.Message, errMsg string) error
+	GetStuckMessages(tx *gorm.DB, stuckBefore time.Time, limit int) ([]db.Message, error)
+	ResetMessageToPending(tx *gorm.DB, msg *db.Message) error
+	MarkMessageForReview(tx *gorm.DB, msg *db.Message, reason string) error
+	InsertAudit(tx *gorm.DB, audit db.MessageAudit) error
 	GetDB() *gorm.DB
 }
 
+const (
+	expiredError    = "expired before it was sent"
+	suppressedError = "recipient is on the suppression list"
+)
+
+// closedErrors is the last error recorded on messages closed without being sent
+var closedErrors = map[db.MessageStatus]string{
+	db.StatusExpired:    expiredError,
+	db.StatusSuppressed: suppressedError,
+}
+
+// ErrLeaseLost is returned when a message is recorded by an instance that no longer holds its lease
+var ErrLeaseLost = errors.New("message lease lost")
+
 type MessageRepository struct {
 	db *gorm.DB
 }
@@ -30,35 +62,161 @@ func NewMessageRepository(db *gorm.DB) *MessageRepository {
 	return &MessageRepository{db: db}
 }
 
-func (r *MessageRepository) GetUnsentMessages(tx *gorm.DB, limit int) ([]db.Message, error) {
+// CreateMessage enqueues msg as pending, columns set by the send flow are left to the database
+func (r *MessageRepository) CreateMessage(msg *db.Message) error {
+	msg.Status = db.StatusPending
+	return r.db.Omit("ProcessedAt", "SentAt", "LeaseOwner", "LeaseExpiresAt").Create(msg).Error
+}
+
+// ClaimMessages leases up to limit pending messages to owner in one short statement.
+// The rows are moved to processing, other instances skip them until the lease is released or reaped.
+// Messages are claimed and returned in priority then age order, normal and bulk messages created before boostBefore
+// rank one priority higher so they are not starved by a steady stream of urgent traffic.
+// Tenants take turns within a rank: the oldest message of every tenant comes before the second of any,
+// so the backlog of one tenant cannot starve the others; ties between tenants are broken at random
+// so claims of a single message rotate between them too.
+// Only messages whose effective priority is at most maxPriority are claimed, deferred messages wait for deliver_after.
+func (r *MessageRepository) ClaimMessages(owner string, leaseUntil time.Time, limit int, maxPriority db.Priority, boostBefore time.Time) ([]db.Message, error) {
 	var messages []db.Message
-	err := tx.Clauses(
-		clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"},
-	).Limit(limit).
-		Where("status = ?", db.StatusPending).
-		Find(&messages).Error
-	return messages, err
+	now := time.Now()
+	err := r.db.Raw(`
+		UPDATE messages
+		SET status = ?, lease_owner = ?, lease_expires_at = ?, processed_at = ?
+		WHERE id IN (
+			SELECT id FROM (
+				SELECT id, rank, ROW_NUMBER() OVER (PARTITION BY tenant_id, rank ORDER BY id) AS turn
+				FROM (
+					SELECT id, tenant_id, MIN(rank) AS rank FROM (
+						SELECT c.* FROM tenants t CROSS JOIN LATERAL (
+							SELECT id, tenant_id, priority - 1 AS rank FROM messages
+							WHERE tenant_id = t.id AND status = ? AND priority >= ? AND priority - 1 <= ? AND created_at < ?
+								AND (deliver_after IS NULL OR deliver_after <= ?)
+							ORDER BY created_at, id
+							LIMIT ?
+							FOR UPDATE SKIP LOCKED
+						) c
+						UNION ALL
+						SELECT c.* FROM tenants t CROSS JOIN LATERAL (
+							SELECT id, tenant_id, priority AS rank FROM messages
+							WHERE tenant_id = t.id AND status = ? AND priority <= ? AND (deliver_after IS NULL OR deliver_after <= ?)
+							ORDER BY priority, id
+							LIMIT ?
+							FOR UPDATE SKIP LOCKED
+						) c
+					) candidates
+					GROUP BY id, tenant_id
+				) ranked
+			) turns
+			ORDER BY rank, turn, random()
+			LIMIT ?
+		)
+		RETURNING *`,
+		db.StatusProcessing, owner, leaseUntil, now,
+		db.StatusPending, db.PriorityNormal, maxPriority, boostBefore, now, limit,
+		db.StatusPending, maxPriority, now, limit,
+		limit,
+	).Scan(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the order of the subquery
+	sort.SliceStable(messages, func(i, j int) bool {
+		if messages[i].Priority != messages[j].Priority {
+			return messages[i].Priority < messages[j].Priority
+		}
+		return messages[i].ID < messages[j].ID
+	})
+	return messages, nil
 }
 
-func (r *MessageRepository) MarkMessageInProcess(tx *gorm.DB, msg *db.Message, processedAt time.Time) error {
-	return tx.Model(msg).Updates(map[string]interface{}{
-		"Status":      db.StatusProcessing,
-		"ProcessedAt": processedAt,
-	}).Error
+// RecordMessageSent marks a claimed message as sent with the provider and cost set on msg,
+// ErrLeaseLost is returned if owner no longer holds the lease
+func (r *MessageRepository) RecordMessageSent(msg *db.Message, owner string, messageID string, sentAt time.Time) error {
+	return r.updateLeased(r.db, msg, owner, map[string]interface{}{
+		"Status":    db.StatusDone,
+		"SentAt":    sentAt,
+		"MessageID": messageID,
+		"Provider":  msg.Provider,
+		"Cost":      msg.Cost,
+	})
 }
 
-func (r *MessageRepository) UpdateMessageAsError(tx *gorm.DB, msg *db.Message, errMsg string) error {
-	update := map[string]interface{}{
+// RecordMessageRetry hands a claimed message over to the retry flow, the message stays in processing
+func (r *MessageRepository) RecordMessageRetry(msg *db.Message, owner string, errMsg string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := r.updateLeased(tx, msg, owner, map[string]interface{}{}); err != nil {
+			return err
+		}
+		return r.InsertRetry(tx, *msg, errMsg)
+	})
+}
+
+func (r *MessageRepository) RecordMessageError(msg *db.Message, owner string, errMsg string) error {
+	return r.updateLeased(r.db, msg, owner, map[string]interface{}{
 		"Status":    db.StatusError,
 		"LastError": errMsg,
+	})
+}
+
+// RecordMessageExpired marks a claimed message as expired, it is never sent
+func (r *MessageRepository) RecordMessageExpired(msg *db.Message, owner string) error {
+	return r.updateLeased(r.db, msg, owner, map[string]interface{}{
+		"Status":    db.StatusExpired,
+		"LastError": expiredError,
+	})
+}
+
+// RecordMessageSuppressed marks a claimed message as suppressed, it is never sent
+func (r *MessageRepository) RecordMessageSuppressed(msg *db.Message, owner string) error {
+	return r.updateLeased(r.db, msg, owner, map[string]interface{}{
+		"Status":    db.StatusSuppressed,
+		"LastError": suppressedError,
+	})
+}
+
+// RecordMessageDuplicate marks a claimed message as a duplicate of originalID, it is never sent
+func (r *MessageRepository) RecordMessageDuplicate(msg *db.Message, owner string, originalID uint) error {
+	return r.updateLeased(r.db, msg, owner, map[string]interface{}{
+		"Status":      db.StatusDuplicate,
+		"DuplicateOf": originalID,
+	})
+}
+
+// DeferMessage returns a claimed message to pending, it is not claimed again before deliverAfter
+func (r *MessageRepository) DeferMessage(msg *db.Message, owner string, deliverAfter time.Time) error {
+	return r.updateLeased(r.db, msg, owner, map[string]interface{}{
+		"Status":       db.StatusPending,
+		"DeliverAfter": deliverAfter,
+	})
+}
+
+// ReleaseMessage returns a claimed but unsent message to pending
+func (r *MessageRepository) ReleaseMessage(msg *db.Message, owner string) error {
+	return r.updateLeased(r.db, msg, owner, map[string]interface{}{
+		"Status": db.StatusPending,
+	})
+}
+
+// updateLeased applies update and clears the lease only while owner still holds it
+func (r *MessageRepository) updateLeased(tx *gorm.DB, msg *db.Message, owner string, update map[string]interface{}) error {
+	update["LeaseOwner"] = nil
+	update["LeaseExpiresAt"] = nil
+	result := tx.Model(msg).Where("status = ? AND lease_owner = ?", db.StatusProcessing, owner).Updates(update)
+	if result.Error != nil {
+		return result.Error
 	}
-	return tx.Model(msg).Updates(update).Error
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
 }
 
-func (r *MessageRepository) GetSentMessages(lastID, limit int) ([]db.Message, error) {
+// GetSentMessages returns a page of the sent messages of a tenant
+func (r *MessageRepository) GetSentMessages(tenantID uint, lastID, limit int) ([]db.Message, error) {
 	var messages []db.Message
 	result := r.db.
-		Where("status = ? AND id > ?", db.StatusDone, lastID).
+		Where("tenant_id = ? AND status = ? AND id > ?", tenantID, db.StatusDone, lastID).
 		Order("id ASC").
 		Limit(limit).
 		Find(&messages)
@@ -66,11 +224,16 @@ func (r *MessageRepository) GetSentMessages(lastID, limit int) ([]db.Message, er
 	return messages, result.Error
 }
 
+// UpdateMessageAsSent marks msg as sent with the provider and cost set on it
 func (r *MessageRepository) UpdateMessageAsSent(tx *gorm.DB, msg *db.Message, messageID string, sentAt time.Time) error {
 	update := map[string]interface{}{
-		"Status":    db.StatusDone,
-		"SentAt":    sentAt,
-		"MessageID": messageID,
+		"Status":         db.StatusDone,
+		"SentAt":         sentAt,
+		"MessageID":      messageID,
+		"Provider":       msg.Provider,
+		"Cost":           msg.Cost,
+		"LeaseOwner":     nil,
+		"LeaseExpiresAt": nil,
 	}
 	return tx.Model(msg).Updates(update).Error
 }
@@ -78,36 +241,128 @@ func (r *MessageRepository) UpdateMessageAsSent(tx *gorm.DB, msg *db.Message, me
 func (r *MessageRepository) InsertRetry(tx *gorm.DB, msg db.Message, errMsg string) error {
 	retry := db.MessageRetry{
 		OriginalMessageID: msg.ID,
+		TenantID:          msg.TenantID,
 		PhoneNumber:       msg.PhoneNumber,
 		Content:           msg.Content,
 		RetryCount:        1,
 		LastError:         errMsg,
+		ExpiresAt:         msg.ExpiresAt,
 		CreatedAt:         time.Now(),
 	}
-	return tx.Create(&retry).Error
+	return tx.Omit("LeaseOwner", "LeaseExpiresAt").Create(&retry).Error
 }
 
-func (r *MessageRepository) GetMessageRetries(tx *gorm.DB, limit int) ([]db.MessageRetry, error) {
+// ClaimRetries leases up to limit retries to owner in one short statement like ClaimMessages,
+// other instances skip them until the lease is recorded, released or expires. Only retries of
+// messages still in processing are claimed, the retry of a sent or closed message is done.
+// Only retries of messages whose priority is at most maxPriority are claimed, deferred retries wait
+// for deliver_after. The priority, category and recipient time zone of the message are returned with the retry.
+func (r *MessageRepository) ClaimRetries(owner string, leaseUntil time.Time, limit int, maxPriority db.Priority) ([]db.MessageRetry, error) {
 	var retries []db.MessageRetry
-	err := tx.Clauses(
-		clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"},
-	).Limit(limit).
-		Where("retry_count < ?", 5).
-		Find(&retries).Error
-	return retries, err
+	now := time.Now()
+	err := r.db.Raw(`
+		UPDATE message_retries
+		SET lease_owner = ?, lease_expires_at = ?
+		FROM messages m
+		WHERE m.id = message_retries.original_message_id AND message_retries.id IN (
+			SELECT r.id FROM message_retries r
+			JOIN messages m ON m.id = r.original_message_id
+			WHERE r.retry_count <= ? AND m.status = ? AND m.priority <= ?
+				AND (r.lease_expires_at IS NULL OR r.lease_expires_at < ?)
+				AND (r.deliver_after IS NULL OR r.deliver_after <= ?)
+			ORDER BY r.id
+			LIMIT ?
+			FOR UPDATE OF r SKIP LOCKED
+		)
+		RETURNING message_retries.*, m.priority, m.category, m.time_zone, m.country_code`,
+		owner, leaseUntil, db.MaxRetries, db.StatusProcessing, maxPriority, now, now, limit,
+	).Scan(&retries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the order of the subquery
+	sort.Slice(retries, func(i, j int) bool { return retries[i].ID < retries[j].ID })
+	return retries, nil
 }
 
-func (r *MessageRepository) UpdateRetryCount(tx *gorm.DB, retryID uint, count int, errMsg string) error {
-	update := map[string]interface{}{
+// RecordRetrySent marks the message of a claimed retry as sent with the provider and cost set on msg.
+// The retry is kept so the statistics can tell retried messages apart, ErrLeaseLost is returned if
+// owner no longer holds its lease.
+func (r *MessageRepository) RecordRetrySent(retry *db.MessageRetry, owner string, msg *db.Message, messageID string, sentAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := r.updateLeasedRetry(tx, retry, owner, map[string]interface{}{}); err != nil {
+			return err
+		}
+		return r.UpdateMessageAsSent(tx, msg, messageID, sentAt)
+	})
+}
+
+// RecordRetryFailed stores the attempt count and error of a claimed retry, it is tried again by a later batch
+func (r *MessageRepository) RecordRetryFailed(retry *db.MessageRetry, owner string, count int, errMsg string) error {
+	return r.updateLeasedRetry(r.db, retry, owner, map[string]interface{}{
 		"RetryCount": count,
 		"LastError":  errMsg,
+	})
+}
+
+// RecordRetryDeadLetter moves the message of a claimed retry that ran out of attempts to the dead letter table
+// and marks it as failed, its retry is kept for the statistics but no longer claimed.
+func (r *MessageRepository) RecordRetryDeadLetter(retry *db.MessageRetry, owner string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := r.updateLeasedRetry(tx, retry, owner, map[string]interface{}{}); err != nil {
+			return err
+		}
+		msg := db.Message{
+			ID:          retry.OriginalMessageID,
+			TenantID:    retry.TenantID,
+			PhoneNumber: retry.PhoneNumber,
+			Content:     retry.Content,
+		}
+		if err := r.MoveToDeadLetter(tx, msg, retry.LastError); err != nil {
+			return err
+		}
+		return tx.Model(&db.Message{}).
+			Where("id = ? AND status = ?", retry.OriginalMessageID, db.StatusProcessing).
+			Updates(map[string]interface{}{
+				"Status":         db.StatusError,
+				"LastError":      retry.LastError,
+				"LeaseOwner":     nil,
+				"LeaseExpiresAt": nil,
+			}).Error
+	})
+}
+
+// DeferRetry returns a claimed retry unsent, it is not claimed again before deliverAfter
+func (r *MessageRepository) DeferRetry(retry *db.MessageRetry, owner string, deliverAfter time.Time) error {
+	return r.updateLeasedRetry(r.db, retry, owner, map[string]interface{}{
+		"DeliverAfter": deliverAfter,
+	})
+}
+
+// ReleaseRetry returns a claimed but unsent retry, a later batch claims it again
+func (r *MessageRepository) ReleaseRetry(retry *db.MessageRetry, owner string) error {
+	return r.updateLeasedRetry(r.db, retry, owner, map[string]interface{}{})
+}
+
+// updateLeasedRetry applies update and clears the lease only while owner still holds it
+func (r *MessageRepository) updateLeasedRetry(tx *gorm.DB, retry *db.MessageRetry, owner string, update map[string]interface{}) error {
+	update["LeaseOwner"] = nil
+	update["LeaseExpiresAt"] = nil
+	result := tx.Model(retry).Where("lease_owner = ?", owner).Updates(update)
+	if result.Error != nil {
+		return result.Error
 	}
-	return tx.Model(&db.MessageRetry{}).Where("id = ?", retryID).Updates(update).Error
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
 }
 
 func (r *MessageRepository) MoveToDeadLetter(tx *gorm.DB, msg db.Message, errMsg string) error {
 	deadLetter := db.MessageDeadLetter{
 		OriginalMessageID: msg.ID,
+		TenantID:          msg.TenantID,
 		PhoneNumber:       msg.PhoneNumber,
 		Content:           msg.Content,
 		LastError:         errMsg,
@@ -116,6 +371,65 @@ func (r *MessageRepository) MoveToDeadLetter(tx *gorm.DB, msg db.Message, errMsg
 	return tx.Create(&deadLetter).Error
 }
 
+// CloseRetry drops a claimed retry that must not be sent and moves its message to status, expired
+// or suppressed. ErrLeaseLost is returned if owner no longer holds the lease of the retry.
+func (r *MessageRepository) CloseRetry(retry *db.MessageRetry, owner string, status db.MessageStatus) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("lease_owner = ?", owner).Delete(&db.MessageRetry{}, retry.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrLeaseLost
+		}
+		return tx.Model(&db.Message{}).
+			Where("id = ? AND status = ?", retry.OriginalMessageID, db.StatusProcessing).
+			Updates(map[string]interface{}{
+				"Status":         status,
+				"LastError":      closedErrors[status],
+				"LeaseOwner":     nil,
+				"LeaseExpiresAt": nil,
+			}).Error
+	})
+}
+
+// GetStuckMessages returns messages left in processing whose lease expired before stuckBefore;
+// messages without a lease count from processed_at. Messages owned by the retry flow are excluded.
+func (r *MessageRepository) GetStuckMessages(tx *gorm.DB, stuckBefore time.Time, limit int) ([]db.Message, error) {
+	var messages []db.Message
+	err := tx.Clauses(
+		clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"},
+	).Limit(limit).
+		Where("status = ? AND COALESCE(lease_expires_at, processed_at) < ?", db.StatusProcessing, stuckBefore).
+		Where("NOT EXISTS (SELECT 1 FROM message_retries r WHERE r.original_message_id = messages.id)").
+		Where("NOT EXISTS (SELECT 1 FROM message_dead_letters d WHERE d.original_message_id = messages.id)").
+		Order("processed_at ASC").
+		Find(&messages).Error
+	return messages, err
+}
+
+func (r *MessageRepository) ResetMessageToPending(tx *gorm.DB, msg *db.Message) error {
+	return tx.Model(msg).Updates(map[string]interface{}{
+		"Status":           db.StatusPending,
+		"RecoveryAttempts": gorm.Expr("recovery_attempts + 1"),
+		"LeaseOwner":       nil,
+		"LeaseExpiresAt":   nil,
+	}).Error
+}
+
+func (r *MessageRepository) MarkMessageForReview(tx *gorm.DB, msg *db.Message, reason string) error {
+	return tx.Model(msg).Updates(map[string]interface{}{
+		"Status":         db.StatusReview,
+		"LastError":      reason,
+		"LeaseOwner":     nil,
+		"LeaseExpiresAt": nil,
+	}).Error
+}
+
+func (r *MessageRepository) InsertAudit(tx *gorm.DB, audit db.MessageAudit) error {
+	return tx.Create(&audit).Error
+}
+
 func (r *MessageRepository) GetDB() *gorm.DB {
 	return r.db
 }
@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestMessageRepositoryLeaseIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	postgresContainer, err := startPostgresContainer(ctx)
+	require.NoError(t, err)
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	require.NoError(t, err)
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	require.NoError(t, err)
+
+	dsn := fmt.Sprintf("host=%s port=%d user=postgres password=postgres dbname=messages sslmode=disable", host, port.Int())
+	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	repo := NewMessageRepository(gormDB)
+
+	leaseUntil := time.Now().Add(time.Minute)
+
+	t.Run("Claims are exclusive", func(t *testing.T) {
+		claimedA, err := repo.ClaimMessages("pod-a", leaseUntil, 2, db.PriorityBulk, time.Time{})
+		require.NoError(t, err)
+		claimedB, err := repo.ClaimMessages("pod-b", leaseUntil, 2, db.PriorityBulk, time.Time{})
+		require.NoError(t, err)
+
+		require.Len(t, claimedA, 2)
+		require.Len(t, claimedB, 2)
+		for _, msg := range claimedA {
+			assert.Equal(t, db.StatusProcessing, msg.Status)
+			assert.Equal(t, "pod-a", msg.LeaseOwner)
+			for _, other := range claimedB {
+				assert.NotEqual(t, msg.ID, other.ID)
+			}
+		}
+	})
+
+	t.Run("Records only while holding the lease", func(t *testing.T) {
+		claimed, err := repo.ClaimMessages("pod-a", leaseUntil, 1, db.PriorityBulk, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		msg := claimed[0]
+
+		assert.ErrorIs(t, repo.RecordMessageSent(&msg, "pod-b", "hook-1", time.Now()), ErrLeaseLost)
+		require.NoError(t, repo.RecordMessageSent(&msg, "pod-a", "hook-1", time.Now()))
+		assert.ErrorIs(t, repo.RecordMessageSent(&msg, "pod-a", "hook-1", time.Now()), ErrLeaseLost)
+
+		var stored db.Message
+		require.NoError(t, gormDB.First(&stored, msg.ID).Error)
+		assert.Equal(t, db.StatusDone, stored.Status)
+		assert.Equal(t, "hook-1", stored.MessageID)
+		assert.Empty(t, stored.LeaseOwner)
+	})
+
+	t.Run("Retry keeps the message in processing and adds a retry", func(t *testing.T) {
+		claimed, err := repo.ClaimMessages("pod-a", leaseUntil, 1, db.PriorityBulk, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		msg := claimed[0]
+
+		require.NoError(t, repo.RecordMessageRetry(&msg, "pod-a", "timeout"))
+
+		var stored db.Message
+		require.NoError(t, gormDB.First(&stored, msg.ID).Error)
+		assert.Equal(t, db.StatusProcessing, stored.Status)
+		assert.Empty(t, stored.LeaseOwner)
+
+		var retries int64
+		require.NoError(t, gormDB.Model(&db.MessageRetry{}).Where("original_message_id = ?", msg.ID).Count(&retries).Error)
+		assert.Equal(t, int64(1), retries)
+	})
+
+	t.Run("Retries are leased until recorded", func(t *testing.T) {
+		claimed, err := repo.ClaimMessages("pod-a", leaseUntil, 1, db.PriorityBulk, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		msg := claimed[0]
+		require.NoError(t, repo.RecordMessageRetry(&msg, "pod-a", "timeout"))
+
+		retry, ok := claimRetry(t, repo, "pod-a", msg.ID)
+		require.True(t, ok)
+		assert.Equal(t, "pod-a", retry.LeaseOwner)
+		_, ok = claimRetry(t, repo, "pod-b", msg.ID)
+		assert.False(t, ok, "a leased retry is not claimed twice")
+
+		assert.ErrorIs(t, repo.RecordRetryFailed(&retry, "pod-b", 2, "webhook returned status 503"), ErrLeaseLost)
+		require.NoError(t, repo.RecordRetryFailed(&retry, "pod-a", 2, "webhook returned status 503"))
+
+		retry, ok = claimRetry(t, repo, "pod-b", msg.ID)
+		require.True(t, ok, "a recorded retry is claimed again")
+		assert.Equal(t, 2, retry.RetryCount)
+		require.NoError(t, repo.RecordRetrySent(&retry, "pod-b", &msg, "hook-2", time.Now()))
+
+		var stored db.Message
+		require.NoError(t, gormDB.First(&stored, msg.ID).Error)
+		assert.Equal(t, db.StatusDone, stored.Status)
+		assert.Equal(t, "hook-2", stored.MessageID)
+		_, ok = claimRetry(t, repo, "pod-b", msg.ID)
+		assert.False(t, ok, "the retry of a sent message is done")
+	})
+
+	t.Run("Retries out of attempts reach the dead letter table", func(t *testing.T) {
+		msg := db.Message{PhoneNumber: "+905321234571", Content: "hello"}
+		require.NoError(t, repo.CreateMessage(&msg))
+		require.NoError(t, gormDB.Model(&msg).Updates(map[string]interface{}{"Status": db.StatusProcessing}).Error)
+		require.NoError(t, repo.InsertRetry(gormDB, msg, "timeout"))
+		require.NoError(t, gormDB.Model(&db.MessageRetry{}).Where("original_message_id = ?", msg.ID).
+			Update("retry_count", db.MaxRetries).Error)
+
+		retry, ok := claimRetry(t, repo, "pod-a", msg.ID)
+		require.True(t, ok, "the last retry is claimed to be dead-lettered")
+		assert.ErrorIs(t, repo.RecordRetryDeadLetter(&retry, "pod-b"), ErrLeaseLost)
+		require.NoError(t, repo.RecordRetryDeadLetter(&retry, "pod-a"))
+
+		var deadLetter db.MessageDeadLetter
+		require.NoError(t, gormDB.Where("original_message_id = ?", msg.ID).First(&deadLetter).Error)
+		assert.Equal(t, "timeout", deadLetter.LastError)
+		var stored db.Message
+		require.NoError(t, gormDB.First(&stored, msg.ID).Error)
+		assert.Equal(t, db.StatusError, stored.Status)
+		_, ok = claimRetry(t, repo, "pod-a", msg.ID)
+		assert.False(t, ok, "the retry of a dead-lettered message is done")
+	})
+
+	t.Run("Deferred retries wait for their delivery window", func(t *testing.T) {
+		msg := db.Message{PhoneNumber: "+905321234570", Content: "promo", Category: "marketing", TimeZone: "Europe/Istanbul"}
+		require.NoError(t, repo.CreateMessage(&msg))
+		require.NoError(t, gormDB.Model(&msg).Updates(map[string]interface{}{"Status": db.StatusProcessing}).Error)
+		require.NoError(t, repo.InsertRetry(gormDB, msg, "timeout"))
+
+		retry, ok := claimRetry(t, repo, "pod-a", msg.ID)
+		require.True(t, ok)
+		assert.Equal(t, "marketing", retry.Category, "the retry carries the delivery window of its message")
+		assert.Equal(t, "Europe/Istanbul", retry.TimeZone)
+
+		require.NoError(t, repo.DeferRetry(&retry, "pod-a", time.Now().Add(time.Hour)))
+		_, ok = claimRetry(t, repo, "pod-a", msg.ID)
+		assert.False(t, ok)
+	})
+
+	t.Run("Only retries of urgent enough messages are claimed", func(t *testing.T) {
+		critical := db.Message{PhoneNumber: "+905321234570", Content: "otp", Priority: db.PriorityCritical}
+		require.NoError(t, repo.CreateMessage(&critical))
+		claimed, err := repo.ClaimMessages("pod-a", leaseUntil, 1, db.PriorityCritical, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		require.NoError(t, repo.RecordMessageRetry(&claimed[0], "pod-a", "timeout"))
+
+		retries, err := repo.ClaimRetries("pod-a", leaseUntil, 100, db.PriorityCritical)
+		require.NoError(t, err)
+		require.Len(t, retries, 1, "retries of normal messages wait")
+		assert.Equal(t, critical.ID, retries[0].OriginalMessageID)
+	})
+
+	t.Run("Expiring a retry marks the message expired", func(t *testing.T) {
+		claimed, err := repo.ClaimMessages("pod-a", leaseUntil, 1, db.PriorityBulk, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		msg := claimed[0]
+		expiresAt := time.Now().Add(-time.Minute)
+		msg.ExpiresAt = &expiresAt
+		require.NoError(t, repo.RecordMessageRetry(&msg, "pod-a", "timeout"))
+
+		retry, ok := claimRetry(t, repo, "pod-a", msg.ID)
+		require.True(t, ok)
+		require.NotNil(t, retry.ExpiresAt)
+		assert.ErrorIs(t, repo.CloseRetry(&retry, "pod-b", db.StatusExpired), ErrLeaseLost)
+		require.NoError(t, repo.CloseRetry(&retry, "pod-a", db.StatusExpired))
+
+		var stored db.Message
+		require.NoError(t, gormDB.First(&stored, msg.ID).Error)
+		assert.Equal(t, db.StatusExpired, stored.Status)
+		assert.ErrorIs(t, gormDB.First(&db.MessageRetry{}, retry.ID).Error, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("Release returns the message to pending", func(t *testing.T) {
+		claimed, err := repo.ClaimMessages("pod-a", leaseUntil, 1, db.PriorityBulk, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		msg := claimed[0]
+
+		require.NoError(t, repo.ReleaseMessage(&msg, "pod-a"))
+
+		var stored db.Message
+		require.NoError(t, gormDB.First(&stored, msg.ID).Error)
+		assert.Equal(t, db.StatusPending, stored.Status)
+	})
+
+	t.Run("Expired leases are reported as stuck", func(t *testing.T) {
+		claimed, err := repo.ClaimMessages("pod-c", time.Now().Add(-time.Minute), 1, db.PriorityBulk, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+
+		stuck, err := repo.GetStuckMessages(gormDB, time.Now(), 100)
+		require.NoError(t, err)
+
+		var ids []uint
+		for _, msg := range stuck {
+			ids = append(ids, msg.ID)
+		}
+		assert.Contains(t, ids, claimed[0].ID)
+	})
+
+	t.Run("Claims urgent and aged messages first", func(t *testing.T) {
+		aged := db.Message{PhoneNumber: "+905321234569", Content: "aged", Priority: db.PriorityNormal, CreatedAt: time.Now().Add(-time.Hour)}
+		critical := db.Message{PhoneNumber: "+905321234569", Content: "otp", Priority: db.PriorityCritical}
+		high := db.Message{PhoneNumber: "+905321234569", Content: "alert", Priority: db.PriorityHigh}
+		bulk := db.Message{PhoneNumber: "+905321234569", Content: "promo", Priority: db.PriorityBulk, CreatedAt: time.Now().Add(-time.Hour)}
+		for _, msg := range []*db.Message{&aged, &critical, &high, &bulk} {
+			require.NoError(t, gormDB.Create(msg).Error)
+		}
+
+		// the aged normal message ranks as high, the aged bulk message only as normal
+		claimed, err := repo.ClaimMessages("pod-a", leaseUntil, 10, db.PriorityHigh, time.Now().Add(-30*time.Minute))
+		require.NoError(t, err)
+
+		var ids []uint
+		for _, msg := range claimed {
+			ids = append(ids, msg.ID)
+		}
+		assert.Equal(t, []uint{critical.ID, high.ID, aged.ID}, ids)
+	})
+
+	t.Run("Tenants take turns", func(t *testing.T) {
+		tenant := db.Tenant{Name: "team-b"}
+		require.NoError(t, gormDB.Create(&tenant).Error)
+		// the default tenant still has the seeded backlog of normal messages
+		msg := db.Message{TenantID: tenant.ID, PhoneNumber: "+905321234569", Content: "hi"}
+		require.NoError(t, repo.CreateMessage(&msg))
+
+		claimed, err := repo.ClaimMessages("pod-a", leaseUntil, 2, db.PriorityNormal, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, claimed, 2)
+		assert.ElementsMatch(t, []uint{db.DefaultTenantID, tenant.ID}, []uint{claimed[0].TenantID, claimed[1].TenantID})
+
+		sent, err := repo.GetSentMessages(tenant.ID, 0, 10)
+		require.NoError(t, err)
+		assert.Empty(t, sent)
+	})
+}
+
+// claimRetry claims the open retries for owner and returns the one of messageID
+func claimRetry(t *testing.T, repo *MessageRepository, owner string, messageID uint) (db.MessageRetry, bool) {
+	retries, err := repo.ClaimRetries(owner, time.Now().Add(time.Minute), 100, db.PriorityBulk)
+	require.NoError(t, err)
+	for _, retry := range retries {
+		if retry.OriginalMessageID == messageID {
+			return retry, true
+		}
+	}
+	return db.MessageRetry{}, false
+}
+
+// Helper function to start a Postgres container with the application schema
+func startPostgresContainer(ctx context.Context) (testcontainers.Container, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        "postgres:17.0-alpine3.20",
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_DB":       "messages",
+			"POSTGRES_USER":     "postgres",
+			"POSTGRES_PASSWORD": "postgres",
+		},
+		Files: []testcontainers.ContainerFile{{
+			HostFilePath:      "../../../data/postgresql/init.sql",
+			ContainerFilePath: "/docker-entrypoint-initdb.d/init.sql",
+			FileMode:          0o644,
+		}},
+		WaitingFor: wait.ForLog("database system is ready to accept connections").
+			WithOccurrence(2).
+			WithStartupTimeout(time.Minute),
+	}
+
+	return testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+}
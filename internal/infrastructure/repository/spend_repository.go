@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -destination=../../mocks/mock_spend_repository.go -package=mocks github.com/atakurt/messagingApp/internal/infrastructure/repository SpendRepositoryInterface
+type SpendRepositoryInterface interface {
+	SpendBy(group string, from, to time.Time) ([]db.SpendTotal, error)
+	BudgetSpend(dayStart, monthStart time.Time) (daily, monthly float64, err error)
+}
+
+// spendGroups maps a spend group to the key column, messages without a campaign, provider or
+// country share the empty key
+var spendGroups = map[string]string{
+	"day":      "to_char(sent_at, 'YYYY-MM-DD')",
+	"campaign": "COALESCE(campaign_id::text, '')",
+	"provider": "COALESCE(provider, '')",
+	"country":  "COALESCE(country_code, '')",
+	"tenant":   "tenant_id::text",
+}
+
+// SpendRepository sums the cost of sent messages by their sent time
+type SpendRepository struct {
+	db *gorm.DB
+}
+
+func NewSpendRepository(db *gorm.DB) *SpendRepository {
+	return &SpendRepository{db: db}
+}
+
+// SpendBy returns the spend of the messages sent in the half open window per day, campaign,
+// provider, country or tenant, ordered by key
+func (r *SpendRepository) SpendBy(group string, from, to time.Time) ([]db.SpendTotal, error) {
+	key, ok := spendGroups[group]
+	if !ok {
+		return nil, fmt.Errorf("unknown spend group %q", group)
+	}
+
+	var totals []db.SpendTotal
+	err := r.db.Model(&db.Message{}).
+		Select(key+` AS key, COUNT(*) AS messages, COALESCE(SUM(segments), 0) AS segments,
+			COUNT(*) FILTER (WHERE cost IS NULL) AS unpriced, COALESCE(SUM(cost), 0) AS cost`).
+		Where("status = ? AND sent_at >= ? AND sent_at < ?", db.StatusDone, from, to).
+		Group("key").
+		Order("key ASC").
+		Scan(&totals).Error
+	return totals, err
+}
+
+// BudgetSpend returns the cost of the messages sent since dayStart and since monthStart in one scan,
+// dayStart is never before monthStart
+func (r *SpendRepository) BudgetSpend(dayStart, monthStart time.Time) (daily, monthly float64, err error) {
+	var row struct {
+		Daily   float64
+		Monthly float64
+	}
+	err = r.db.Model(&db.Message{}).
+		Select("COALESCE(SUM(cost) FILTER (WHERE sent_at >= ?), 0) AS daily, COALESCE(SUM(cost), 0) AS monthly", dayStart).
+		Where("status = ? AND sent_at >= ?", db.StatusDone, monthStart).
+		Scan(&row).Error
+	return row.Daily, row.Monthly, err
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestSpendRepositoryIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	postgresContainer, err := startPostgresContainer(ctx)
+	require.NoError(t, err)
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	require.NoError(t, err)
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	require.NoError(t, err)
+
+	dsn := fmt.Sprintf("host=%s port=%d user=postgres password=postgres dbname=messages sslmode=disable", host, port.Int())
+	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	repo := NewSpendRepository(gormDB)
+	messages := NewMessageRepository(gormDB)
+
+	monthStart := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	dayStart := monthStart.Add(9 * 24 * time.Hour)
+	send := func(sentAt time.Time, countryCode string, segments int, cost *float64) {
+		msg := db.Message{PhoneNumber: "+905551112233", Content: "Hi", CountryCode: countryCode, Segments: segments}
+		require.NoError(t, messages.CreateMessage(&msg))
+		msg.Provider, msg.Cost = "webhook", cost
+		require.NoError(t, messages.UpdateMessageAsSent(gormDB, &msg, "hook", sentAt))
+	}
+	price := func(cost float64) *float64 { return &cost }
+
+	send(monthStart.Add(time.Hour), "90", 1, price(0.02))
+	send(dayStart.Add(time.Hour), "90", 2, price(0.04))
+	send(dayStart.Add(2*time.Hour), "44", 1, nil)
+	send(monthStart.Add(-time.Hour), "90", 1, price(1))
+
+	daily, monthly, err := repo.BudgetSpend(dayStart, monthStart)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.04, daily, 1e-9)
+	assert.InDelta(t, 0.06, monthly, 1e-9)
+
+	byCountry, err := repo.SpendBy("country", monthStart, dayStart.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, byCountry, 2)
+	assert.Equal(t, db.SpendTotal{Key: "44", Messages: 1, Segments: 1, Unpriced: 1}, byCountry[0])
+	assert.Equal(t, "90", byCountry[1].Key)
+	assert.Equal(t, 3, byCountry[1].Segments)
+	assert.InDelta(t, 0.06, byCountry[1].Cost, 1e-9)
+
+	byDay, err := repo.SpendBy("day", monthStart, dayStart.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, byDay, 2)
+	assert.Equal(t, "2026-09-01", byDay[0].Key)
+	assert.Equal(t, "2026-09-10", byDay[1].Key)
+	assert.Equal(t, 2, byDay[1].Messages)
+
+	_, err = repo.SpendBy("week", monthStart, dayStart)
+	assert.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -destination=../../mocks/mock_stats_repository.go -package=mocks github.com/atakurt/messagingApp/internal/infrastructure/repository StatsRepositoryInterface
+type StatsRepositoryInterface interface {
+	CountByStatus(from, to time.Time) (map[db.MessageStatus]int, error)
+	SentVolume(from, to time.Time, bucket string) ([]db.VolumeBucket, error)
+	AverageDeliverySeconds(from, to time.Time) (*float64, error)
+	RetryOutcomes(from, to time.Time) (retried, sent int, err error)
+	CountDeadLettered(from, to time.Time) (int, error)
+	TopErrors(from, to time.Time, limit int) ([]db.ErrorCount, error)
+}
+
+// StatsRepository computes statistics with SQL aggregates. Windows are half open, from is included
+// and to is not; messages are selected by creation time except for sent volume and delivery time,
+// which use the sent time.
+type StatsRepository struct {
+	db *gorm.DB
+}
+
+func NewStatsRepository(db *gorm.DB) *StatsRepository {
+	return &StatsRepository{db: db}
+}
+
+// CountByStatus returns the number of messages created in the window by current status
+func (r *StatsRepository) CountByStatus(from, to time.Time) (map[db.MessageStatus]int, error) {
+	var rows []struct {
+		Status db.MessageStatus
+		Count  int
+	}
+	err := r.db.Model(&db.Message{}).
+		Select("status, COUNT(*) AS count").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[db.MessageStatus]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// SentVolume returns the number of messages sent in the window per hour or day, buckets without
+// messages are left out
+func (r *StatsRepository) SentVolume(from, to time.Time, bucket string) ([]db.VolumeBucket, error) {
+	var volume []db.VolumeBucket
+	err := r.db.Model(&db.Message{}).
+		Select("date_trunc(?, sent_at) AS start, COUNT(*) AS sent", bucket).
+		Where("status = ? AND sent_at >= ? AND sent_at < ?", db.StatusDone, from, to).
+		Group("start").
+		Order("start ASC").
+		Scan(&volume).Error
+	return volume, err
+}
+
+// AverageDeliverySeconds returns the average time from creation to sending of the messages sent
+// in the window, nil when none was sent
+func (r *StatsRepository) AverageDeliverySeconds(from, to time.Time) (*float64, error) {
+	var row struct {
+		Average *float64
+	}
+	err := r.db.Model(&db.Message{}).
+		Select("AVG(EXTRACT(EPOCH FROM sent_at - created_at)) AS average").
+		Where("status = ? AND sent_at >= ? AND sent_at < ?", db.StatusDone, from, to).
+		Scan(&row).Error
+	return row.Average, err
+}
+
+// RetryOutcomes returns how many messages created in the window went to the retry flow and how
+// many of them were sent in the end
+func (r *StatsRepository) RetryOutcomes(from, to time.Time) (retried, sent int, err error) {
+	var row struct {
+		Retried int
+		Sent    int
+	}
+	err = r.db.Model(&db.Message{}).
+		Select("COUNT(*) AS retried, COUNT(*) FILTER (WHERE messages.status = ?) AS sent", db.StatusDone).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Where("EXISTS (SELECT 1 FROM message_retries r WHERE r.original_message_id = messages.id)").
+		Scan(&row).Error
+	return row.Retried, row.Sent, err
+}
+
+// CountDeadLettered returns how many messages created in the window ended in the dead letter table
+func (r *StatsRepository) CountDeadLettered(from, to time.Time) (int, error) {
+	var count int64
+	err := r.db.Model(&db.Message{}).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Where("EXISTS (SELECT 1 FROM message_dead_letters d WHERE d.original_message_id = messages.id)").
+		Count(&count).Error
+	return int(count), err
+}
+
+// TopErrors returns the most frequent last errors of the messages created in the window
+func (r *StatsRepository) TopErrors(from, to time.Time, limit int) ([]db.ErrorCount, error) {
+	var counts []db.ErrorCount
+	err := r.db.Model(&db.Message{}).
+		Select("last_error AS error, COUNT(*) AS count").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Where("last_error IS NOT NULL AND last_error <> ''").
+		Group("last_error").
+		Order("count DESC, last_error ASC").
+		Limit(limit).
+		Scan(&counts).Error
+	return counts, err
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestStatsRepositoryIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	postgresContainer, err := startPostgresContainer(ctx)
+	require.NoError(t, err)
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	require.NoError(t, err)
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	require.NoError(t, err)
+
+	dsn := fmt.Sprintf("host=%s port=%d user=postgres password=postgres dbname=messages sslmode=disable", host, port.Int())
+	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	repo := NewStatsRepository(gormDB)
+	messages := NewMessageRepository(gormDB)
+
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	create := func(createdAt time.Time, status db.MessageStatus, sentAfter time.Duration, lastError string) db.Message {
+		msg := db.Message{PhoneNumber: "+905551112233", Content: "Hi"}
+		require.NoError(t, messages.CreateMessage(&msg))
+		update := map[string]interface{}{"created_at": createdAt, "status": status, "last_error": lastError}
+		if status == db.StatusDone {
+			update["sent_at"] = createdAt.Add(sentAfter)
+		}
+		require.NoError(t, gormDB.Model(&msg).Updates(update).Error)
+		return msg
+	}
+
+	create(from.Add(time.Hour), db.StatusDone, 2*time.Second, "")
+	retriedSent := create(from.Add(time.Hour+time.Minute), db.StatusDone, 4*time.Second, "")
+	retriedFailed := create(from.Add(3*time.Hour), db.StatusError, 0, "timeout")
+	create(from.Add(4*time.Hour), db.StatusError, 0, "timeout")
+	create(from.Add(5*time.Hour), db.StatusError, 0, "bad number")
+	create(from.Add(-time.Hour), db.StatusError, 0, "outside the window")
+
+	require.NoError(t, messages.InsertRetry(gormDB, retriedSent, "timeout"))
+	require.NoError(t, messages.InsertRetry(gormDB, retriedFailed, "timeout"))
+	require.NoError(t, messages.MoveToDeadLetter(gormDB, retriedFailed, "timeout"))
+
+	counts, err := repo.CountByStatus(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, map[db.MessageStatus]int{db.StatusDone: 2, db.StatusError: 3}, counts)
+
+	volume, err := repo.SentVolume(from, to, "hour")
+	require.NoError(t, err)
+	require.Len(t, volume, 1)
+	assert.Equal(t, 2, volume[0].Sent)
+	assert.True(t, volume[0].Start.Equal(from.Add(time.Hour)))
+
+	average, err := repo.AverageDeliverySeconds(from, to)
+	require.NoError(t, err)
+	require.NotNil(t, average)
+	assert.InDelta(t, 3, *average, 0.001)
+
+	average, err = repo.AverageDeliverySeconds(to, to.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Nil(t, average)
+
+	retried, sent, err := repo.RetryOutcomes(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, 2, retried)
+	assert.Equal(t, 1, sent)
+
+	deadLettered, err := repo.CountDeadLettered(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deadLettered)
+
+	topErrors, err := repo.TopErrors(from, to, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []db.ErrorCount{{Error: "timeout", Count: 2}}, topErrors)
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//go:generate mockgen -destination=../../mocks/mock_suppression_repository.go -package=mocks github.com/atakurt/messagingApp/internal/infrastructure/repository SuppressionRepositoryInterface
+type SuppressionRepositoryInterface interface {
+	AddSuppression(suppression *db.Suppression) error
+	RemoveSuppression(phoneNumber string) error
+	IsSuppressed(phoneNumber string) (bool, error)
+	ListSuppressions(after string, limit int) ([]db.Suppression, error)
+}
+
+var ErrSuppressionNotFound = errors.New("phone number is not suppressed")
+
+type SuppressionRepository struct {
+	db *gorm.DB
+}
+
+func NewSuppressionRepository(db *gorm.DB) *SuppressionRepository {
+	return &SuppressionRepository{db: db}
+}
+
+// AddSuppression stores suppression, an existing entry for the number keeps its original reason
+func (r *SuppressionRepository) AddSuppression(suppression *db.Suppression) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(suppression).Error
+}
+
+func (r *SuppressionRepository) RemoveSuppression(phoneNumber string) error {
+	result := r.db.Delete(&db.Suppression{}, "phone_number = ?", phoneNumber)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSuppressionNotFound
+	}
+	return nil
+}
+
+func (r *SuppressionRepository) IsSuppressed(phoneNumber string) (bool, error) {
+	var count int64
+	err := r.db.Model(&db.Suppression{}).Where("phone_number = ?", phoneNumber).Count(&count).Error
+	return count > 0, err
+}
+
+// ListSuppressions returns suppressed numbers ordered by number, starting after the given number
+func (r *SuppressionRepository) ListSuppressions(after string, limit int) ([]db.Suppression, error) {
+	var suppressions []db.Suppression
+	err := r.db.
+		Where("phone_number > ?", after).
+		Order("phone_number ASC").
+		Limit(limit).
+		Find(&suppressions).Error
+	return suppressions, err
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//go:generate mockgen -destination=../../mocks/mock_template_repository.go -package=mocks github.com/atakurt/messagingApp/internal/infrastructure/repository TemplateRepositoryInterface
+type TemplateRepositoryInterface interface {
+	CreateTemplate(template *db.Template) error
+	GetTemplate(id uint) (db.Template, error)
+	ListTemplates(lastID, limit int) ([]db.Template, error)
+	ListTemplateVersions(id uint) ([]db.TemplateVersion, error)
+	UpdateTemplate(id uint, body string, locales map[string]string) (db.Template, error)
+	DeleteTemplate(id uint) error
+}
+
+var (
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrTemplateExists   = errors.New("template name already exists")
+)
+
+// uniqueViolation is the Postgres error code for a unique constraint violation
+const uniqueViolation = "23505"
+
+type TemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewTemplateRepository(db *gorm.DB) *TemplateRepository {
+	return &TemplateRepository{db: db}
+}
+
+// CreateTemplate stores template as version 1, ErrTemplateExists is returned if the name is taken
+func (r *TemplateRepository) CreateTemplate(template *db.Template) error {
+	template.Version = 1
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(template).Error; err != nil {
+			return err
+		}
+		return tx.Create(&db.TemplateVersion{
+			TemplateID: template.ID,
+			Version:    template.Version,
+			Body:       template.Body,
+			Locales:    template.Locales,
+		}).Error
+	})
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrTemplateExists
+	}
+	return err
+}
+
+func (r *TemplateRepository) GetTemplate(id uint) (db.Template, error) {
+	var template db.Template
+	err := r.db.First(&template, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return template, ErrTemplateNotFound
+	}
+	return template, err
+}
+
+func (r *TemplateRepository) ListTemplates(lastID, limit int) ([]db.Template, error) {
+	var templates []db.Template
+	err := r.db.
+		Where("id > ?", lastID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&templates).Error
+	return templates, err
+}
+
+func (r *TemplateRepository) ListTemplateVersions(id uint) ([]db.TemplateVersion, error) {
+	if _, err := r.GetTemplate(id); err != nil {
+		return nil, err
+	}
+
+	var versions []db.TemplateVersion
+	err := r.db.
+		Where("template_id = ?", id).
+		Order("version ASC").
+		Find(&versions).Error
+	return versions, err
+}
+
+// UpdateTemplate replaces the body and locale variants of a template under a new version, earlier versions are kept
+func (r *TemplateRepository) UpdateTemplate(id uint, body string, locales map[string]string) (db.Template, error) {
+	var template db.Template
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&template, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTemplateNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		template.Version++
+		template.Body = body
+		template.Locales = locales
+		if err := tx.Save(&template).Error; err != nil {
+			return err
+		}
+		return tx.Create(&db.TemplateVersion{
+			TemplateID: template.ID,
+			Version:    template.Version,
+			Body:       template.Body,
+			Locales:    template.Locales,
+		}).Error
+	})
+	return template, err
+}
+
+// DeleteTemplate hides a template from new messages, messages already rendered keep referencing it
+func (r *TemplateRepository) DeleteTemplate(id uint) error {
+	result := r.db.Delete(&db.Template{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestTemplateRepositoryIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	postgresContainer, err := startPostgresContainer(ctx)
+	require.NoError(t, err)
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	require.NoError(t, err)
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	require.NoError(t, err)
+
+	dsn := fmt.Sprintf("host=%s port=%d user=postgres password=postgres dbname=messages sslmode=disable", host, port.Int())
+	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	repo := NewTemplateRepository(gormDB)
+
+	template := db.Template{Name: "otp", Body: "Code: {{code}}"}
+	require.NoError(t, repo.CreateTemplate(&template))
+	assert.Equal(t, 1, template.Version)
+
+	assert.ErrorIs(t, repo.CreateTemplate(&db.Template{Name: "otp", Body: "Other"}), ErrTemplateExists)
+
+	updated, err := repo.UpdateTemplate(template.ID, "Your code is {{code}}", map[string]string{"tr": "Kodunuz {{code}}"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+
+	versions, err := repo.ListTemplateVersions(template.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, "Code: {{code}}", versions[0].Body)
+	assert.Equal(t, "Your code is {{code}}", versions[1].Body)
+	assert.Equal(t, map[string]string{"tr": "Kodunuz {{code}}"}, versions[1].Locales)
+
+	// a message keeps referencing the template after it is deleted
+	messages := NewMessageRepository(gormDB)
+	msg := db.Message{PhoneNumber: "+905321234567", Content: "Your code is 1234", TemplateID: &template.ID, TemplateVersion: 2}
+	require.NoError(t, messages.CreateMessage(&msg))
+
+	require.NoError(t, repo.DeleteTemplate(template.ID))
+	_, err = repo.GetTemplate(template.ID)
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+	assert.ErrorIs(t, repo.DeleteTemplate(template.ID), ErrTemplateNotFound)
+
+	// the name can be reused once the template is deleted
+	require.NoError(t, repo.CreateTemplate(&db.Template{Name: "otp", Body: "New code: {{code}}"}))
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -destination=../../mocks/mock_tenant_repository.go -package=mocks github.com/atakurt/messagingApp/internal/infrastructure/repository TenantRepositoryInterface
+type TenantRepositoryInterface interface {
+	CreateTenant(tenant *db.Tenant) error
+	GetTenant(id uint) (db.Tenant, error)
+	ListTenants() ([]db.Tenant, error)
+	UpdateTenant(tenant *db.Tenant) error
+	CreateAPIKey(key *db.APIKey) error
+	ListAPIKeys(tenantID uint) ([]db.APIKey, error)
+	RevokeAPIKey(tenantID, id uint) error
+	FindAPIKey(keyHash string) (db.APIKey, error)
+}
+
+var (
+	ErrTenantNotFound = errors.New("tenant not found")
+	ErrTenantExists   = errors.New("tenant name already exists")
+	ErrAPIKeyNotFound = errors.New("api key not found")
+)
+
+type TenantRepository struct {
+	db *gorm.DB
+}
+
+func NewTenantRepository(db *gorm.DB) *TenantRepository {
+	return &TenantRepository{db: db}
+}
+
+// CreateTenant stores tenant, ErrTenantExists is returned if the name is taken
+func (r *TenantRepository) CreateTenant(tenant *db.Tenant) error {
+	return tenantError(r.db.Create(tenant).Error)
+}
+
+func (r *TenantRepository) GetTenant(id uint) (db.Tenant, error) {
+	var tenant db.Tenant
+	err := r.db.First(&tenant, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tenant, ErrTenantNotFound
+	}
+	return tenant, err
+}
+
+// ListTenants returns every tenant, there are few of them
+func (r *TenantRepository) ListTenants() ([]db.Tenant, error) {
+	var tenants []db.Tenant
+	err := r.db.Order("id ASC").Find(&tenants).Error
+	return tenants, err
+}
+
+// UpdateTenant stores the name, provider and limits of tenant, zero limits are stored as well
+func (r *TenantRepository) UpdateTenant(tenant *db.Tenant) error {
+	tenant.UpdatedAt = time.Now()
+	result := r.db.Model(tenant).Updates(map[string]interface{}{
+		"Name":       tenant.Name,
+		"WebhookURL": tenant.WebhookURL,
+		"Provider":   tenant.Provider,
+		"RateLimit":  tenant.RateLimit,
+		"DailyQuota": tenant.DailyQuota,
+		"UpdatedAt":  tenant.UpdatedAt,
+	})
+	if result.Error != nil {
+		return tenantError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrTenantNotFound
+	}
+	return nil
+}
+
+func (r *TenantRepository) CreateAPIKey(key *db.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+// ListAPIKeys returns the keys of a tenant including revoked ones
+func (r *TenantRepository) ListAPIKeys(tenantID uint) ([]db.APIKey, error) {
+	var keys []db.APIKey
+	err := r.db.Where("tenant_id = ?", tenantID).Order("id ASC").Find(&keys).Error
+	return keys, err
+}
+
+// RevokeAPIKey stops a key of the tenant from authenticating, revoking a revoked key is a no-op
+func (r *TenantRepository) RevokeAPIKey(tenantID, id uint) error {
+	result := r.db.Model(&db.APIKey{}).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		Update("revoked_at", gorm.Expr("COALESCE(revoked_at, ?)", time.Now()))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// FindAPIKey returns the active key with the hash, revoked keys are not found
+func (r *TenantRepository) FindAPIKey(keyHash string) (db.APIKey, error) {
+	var key db.APIKey
+	err := r.db.Where("key_hash = ? AND revoked_at IS NULL", keyHash).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return key, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+func tenantError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrTenantExists
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestTenantRepositoryIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	postgresContainer, err := startPostgresContainer(ctx)
+	require.NoError(t, err)
+	defer postgresContainer.Terminate(ctx)
+
+	host, err := postgresContainer.Host(ctx)
+	require.NoError(t, err)
+	port, err := postgresContainer.MappedPort(ctx, "5432")
+	require.NoError(t, err)
+
+	dsn := fmt.Sprintf("host=%s port=%d user=postgres password=postgres dbname=messages sslmode=disable", host, port.Int())
+	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	repo := NewTenantRepository(gormDB)
+
+	t.Run("Default tenant exists", func(t *testing.T) {
+		tenant, err := repo.GetTenant(db.DefaultTenantID)
+		require.NoError(t, err)
+		assert.Equal(t, "default", tenant.Name)
+	})
+
+	t.Run("Create and update tenants", func(t *testing.T) {
+		tenant := db.Tenant{Name: "team-a", RateLimit: 10}
+		require.NoError(t, repo.CreateTenant(&tenant))
+		assert.NotZero(t, tenant.ID)
+
+		assert.ErrorIs(t, repo.CreateTenant(&db.Tenant{Name: "team-a"}), ErrTenantExists)
+
+		tenant.Provider, tenant.RateLimit = "other", 0
+		require.NoError(t, repo.UpdateTenant(&tenant))
+		stored, err := repo.GetTenant(tenant.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "other", stored.Provider)
+		assert.Zero(t, stored.RateLimit, "zero limits are stored")
+
+		assert.ErrorIs(t, repo.UpdateTenant(&db.Tenant{ID: 999, Name: "ghost"}), ErrTenantNotFound)
+	})
+
+	t.Run("Revoked keys are not found", func(t *testing.T) {
+		tenant := db.Tenant{Name: "team-keys"}
+		require.NoError(t, repo.CreateTenant(&tenant))
+		key := db.APIKey{TenantID: tenant.ID, Prefix: "msk_abcdefgh", KeyHash: "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"}
+		require.NoError(t, repo.CreateAPIKey(&key))
+
+		found, err := repo.FindAPIKey(key.KeyHash)
+		require.NoError(t, err)
+		assert.Equal(t, tenant.ID, found.TenantID)
+
+		assert.ErrorIs(t, repo.RevokeAPIKey(db.DefaultTenantID, key.ID), ErrAPIKeyNotFound, "keys are revoked by their tenant")
+		require.NoError(t, repo.RevokeAPIKey(tenant.ID, key.ID))
+		_, err = repo.FindAPIKey(key.KeyHash)
+		assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+
+		keys, err := repo.ListAPIKeys(tenant.ID)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		assert.NotNil(t, keys[0].RevokedAt)
+	})
+}
@@ -0,0 +1,64 @@
+package campaign
+
+import (
+	"context"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/features/campaigns"
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+// CampaignScheduler expands running campaigns into messages in the background. It runs on every
+// instance, each chunk locks its campaign so instances expand different campaigns in parallel.
+// It is independent of the message schedulers, stopping them does not stop the expansion.
+type CampaignScheduler struct {
+	expander campaigns.CampaignExpanderInterface
+	interval time.Duration
+	enabled  bool
+}
+
+func NewCampaignScheduler(expander campaigns.CampaignExpanderInterface, cfg config.Config) *CampaignScheduler {
+	return &CampaignScheduler{
+		expander: expander,
+		interval: cfg.Campaigns.Interval,
+		enabled:  cfg.Campaigns.Enabled,
+	}
+}
+
+// Run blocks until ctx is cancelled
+func (s *CampaignScheduler) Run(ctx context.Context) {
+	if !s.enabled {
+		logger.Log.Info("Campaign expansion is disabled by config")
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.tick(ctx)
+		case <-ctx.Done():
+			logger.Log.Info("Campaign scheduler stopped")
+			return
+		}
+	}
+}
+
+// tick expands chunks until no campaign has recipients left to expand
+func (s *CampaignScheduler) tick(ctx context.Context) {
+	total := 0
+	for ctx.Err() == nil {
+		expanded := s.expander.ExpandNextChunk(ctx)
+		if expanded == 0 {
+			break
+		}
+		total += expanded
+	}
+	if total > 0 {
+		logger.Log.Info("Expanded campaign recipients", zap.Int("count", total))
+	}
+}
@@ -0,0 +1,77 @@
+package campaign
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func getTestConfig() config.Config {
+	cfg := config.Config{}
+	cfg.Campaigns.Enabled = true
+	cfg.Campaigns.Interval = 10 * time.Millisecond
+	return cfg
+}
+
+func TestCampaignScheduler_Run(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockExpander := mocks.NewMockCampaignExpanderInterface(ctrl)
+	done := make(chan struct{})
+	mockExpander.EXPECT().ExpandNextChunk(gomock.Any()).DoAndReturn(func(ctx context.Context) int {
+		close(done)
+		return 0
+	})
+	mockExpander.EXPECT().ExpandNextChunk(gomock.Any()).Return(0).AnyTimes()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		NewCampaignScheduler(mockExpander, getTestConfig()).Run(ctx)
+		close(stopped)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for campaign expansion")
+	}
+
+	cancel()
+	<-stopped
+}
+
+func TestCampaignScheduler_TickExpandsUntilDone(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockExpander := mocks.NewMockCampaignExpanderInterface(ctrl)
+	gomock.InOrder(
+		mockExpander.EXPECT().ExpandNextChunk(gomock.Any()).Return(500),
+		mockExpander.EXPECT().ExpandNextChunk(gomock.Any()).Return(120),
+		mockExpander.EXPECT().ExpandNextChunk(gomock.Any()).Return(0),
+	)
+
+	NewCampaignScheduler(mockExpander, getTestConfig()).tick(context.Background())
+}
+
+func TestCampaignScheduler_Disabled(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := getTestConfig()
+	cfg.Campaigns.Enabled = false
+
+	// returns immediately without ticking
+	NewCampaignScheduler(mocks.NewMockCampaignExpanderInterface(ctrl), cfg).Run(context.Background())
+}
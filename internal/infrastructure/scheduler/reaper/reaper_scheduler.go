@@ -0,0 +1,77 @@
+package reaper
+
+import (
+	"context"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/features/reaper"
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"go.uber.org/zap"
+)
+
+// LeaderChecker limits reaper runs to the leader when leader election is enabled
+type LeaderChecker interface {
+	IsLeader() bool
+}
+
+type Option func(*ReaperScheduler)
+
+func WithLeaderElection(leader LeaderChecker) Option {
+	return func(s *ReaperScheduler) {
+		s.leader = leader
+	}
+}
+
+// ReaperScheduler periodically recovers messages stuck in processing. Without leader
+// election every instance runs it, row locks keep the runs from overlapping.
+type ReaperScheduler struct {
+	service  reaper.ReaperServiceInterface
+	interval time.Duration
+	enabled  bool
+	leader   LeaderChecker
+}
+
+func NewReaperScheduler(service reaper.ReaperServiceInterface, cfg config.Config, opts ...Option) *ReaperScheduler {
+	s := &ReaperScheduler{
+		service:  service,
+		interval: cfg.Reaper.Interval,
+		enabled:  cfg.Reaper.Enabled,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Run blocks until ctx is cancelled
+func (s *ReaperScheduler) Run(ctx context.Context) {
+	if !s.enabled {
+		logger.Log.Info("Reaper is disabled by config")
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.tick(ctx)
+		case <-ctx.Done():
+			logger.Log.Info("Reaper stopped")
+			return
+		}
+	}
+}
+
+func (s *ReaperScheduler) tick(ctx context.Context) {
+	if s.leader != nil && !s.leader.IsLeader() {
+		logger.Log.Debug("Reaper tick skipped, this instance is not the leader")
+		return
+	}
+
+	if recovered := s.service.ReapStuckMessages(ctx); recovered > 0 {
+		logger.Log.Info("Reaper recovered stuck messages", zap.Int("count", recovered))
+	}
+}
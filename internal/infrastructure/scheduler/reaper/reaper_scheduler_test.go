@@ -0,0 +1,78 @@
+package reaper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"github.com/atakurt/messagingApp/internal/mocks"
+	"github.com/golang/mock/gomock"
+	"go.uber.org/zap"
+)
+
+type fakeLeader bool
+
+func (l fakeLeader) IsLeader() bool {
+	return bool(l)
+}
+
+func getTestConfig() config.Config {
+	cfg := config.Config{}
+	cfg.Reaper.Enabled = true
+	cfg.Reaper.Interval = 10 * time.Millisecond
+	return cfg
+}
+
+func TestReaperScheduler_Run(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockReaperServiceInterface(ctrl)
+	done := make(chan struct{})
+	mockService.EXPECT().ReapStuckMessages(gomock.Any()).DoAndReturn(func(ctx context.Context) int {
+		close(done)
+		return 2
+	})
+	mockService.EXPECT().ReapStuckMessages(gomock.Any()).Return(0).AnyTimes()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		NewReaperScheduler(mockService, getTestConfig()).Run(ctx)
+		close(stopped)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for reaper run")
+	}
+
+	cancel()
+	<-stopped
+}
+
+func TestReaperScheduler_SkipsWhenNotLeader(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// no calls expected on the service
+	scheduler := NewReaperScheduler(mocks.NewMockReaperServiceInterface(ctrl), getTestConfig(), WithLeaderElection(fakeLeader(false)))
+	scheduler.tick(context.Background())
+}
+
+func TestReaperScheduler_Disabled(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := getTestConfig()
+	cfg.Reaper.Enabled = false
+
+	// returns immediately without ticking
+	NewReaperScheduler(mocks.NewMockReaperServiceInterface(ctrl), cfg).Run(context.Background())
+}
@@ -2,13 +2,15 @@ package retry
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/atakurt/messagingApp/internal/features/messageretry"
 	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/instance"
 	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
 	"github.com/atakurt/messagingApp/internal/infrastructure/redis"
-	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
+	"go.uber.org/zap"
 )
 
 type RetrySchedulerInterface interface {
@@ -16,24 +18,52 @@ type RetrySchedulerInterface interface {
 	Stop(ctx context.Context)
 }
 
+// Heartbeater is refreshed after every tick so the instance registry reflects the latest batch
+type Heartbeater interface {
+	Heartbeat(ctx context.Context) error
+}
+
+type Option func(*RetryScheduler)
+
+func WithHeartbeat(heartbeat Heartbeater) Option {
+	return func(s *RetryScheduler) {
+		s.heartbeat = heartbeat
+	}
+}
+
 type RetryScheduler struct {
+	// runMu serializes Start, Stop and Drain, the command listener, /drain and shutdown call them concurrently
+	runMu       sync.Mutex
 	service     messageretry.MessageRetryServiceInterface
 	redisClient redis.Client
 	ticker      *time.Ticker
+	stopChan    chan struct{}
+	cancelTick  context.CancelFunc
+	wg          sync.WaitGroup
 	running     bool
 	cfg         config.Config
+	heartbeat   Heartbeater
+
+	statusMu   sync.RWMutex
+	lastStatus instance.ComponentStatus
 }
 
-func NewRetryScheduler(service messageretry.MessageRetryServiceInterface, redisClient redis.Client, cfg config.Config) *RetryScheduler {
-	return &RetryScheduler{
+func NewRetryScheduler(service messageretry.MessageRetryServiceInterface, redisClient redis.Client, cfg config.Config, opts ...Option) *RetryScheduler {
+	s := &RetryScheduler{
 		service:     service,
 		redisClient: redisClient,
 		running:     false,
 		cfg:         cfg,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *RetryScheduler) Start(ctx context.Context) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
 	if s.running {
 		logger.Log.Warn("Retry scheduler already running")
 		return
@@ -48,23 +78,31 @@ func (s *RetryScheduler) Start(ctx context.Context) {
 }
 
 func (s *RetryScheduler) startProcessing(ctx context.Context) {
-	s.running = true
+	s.setRunning(true)
 	s.ticker = time.NewTicker(s.cfg.Scheduler.Interval)
+	s.stopChan = make(chan struct{})
+	// tickCtx is cancelled by Drain so the batch in progress stops starting new retries
+	tickCtx, cancelTick := context.WithCancel(ctx)
+	s.cancelTick = cancelTick
 
+	ticker, stopChan := s.ticker, s.stopChan
+	s.wg.Add(1)
 	// Start the processing goroutine
 	go func() {
-		defer func() {
-			if s.ticker != nil {
-				s.ticker.Stop()
-			}
-		}()
+		defer s.wg.Done()
+		defer ticker.Stop()
 
 		for {
 			select {
-			case <-s.ticker.C:
-				if s.running {
-					s.service.ProcessMessageRetries(ctx)
+			case <-ticker.C:
+				select {
+				case <-stopChan:
+					return
+				default:
 				}
+				s.tick(tickCtx)
+			case <-stopChan:
+				return
 			case <-ctx.Done():
 				logger.Log.Info("Retry scheduler stopped due to context cancellation")
 				return
@@ -75,20 +113,105 @@ func (s *RetryScheduler) startProcessing(ctx context.Context) {
 	logger.Log.Info("Retry scheduler started with processing enabled")
 }
 
+// Stop stops scheduling new ticks and waits for the tick in progress to finish
 func (s *RetryScheduler) Stop(ctx context.Context) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
 	if !s.running {
 		logger.Log.Warn("Retry scheduler is not running")
 		return
 	}
 
-	s.running = false
+	if s.stopChan != nil {
+		close(s.stopChan)
+		s.wg.Wait()
+		s.stopChan = nil
+	}
+	s.release()
+	logger.Log.Info("Retry scheduler stopped")
+}
+
+// Drain stops scheduling new ticks and cancels the batch in progress so it starts no new
+// retries, then waits for retries already being sent until ctx expires
+func (s *RetryScheduler) Drain(ctx context.Context) error {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	if !s.running {
+		return nil
+	}
+
+	if s.stopChan != nil {
+		close(s.stopChan)
+		s.stopChan = nil
+	}
+	if s.cancelTick != nil {
+		s.cancelTick()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.release()
+		logger.Log.Info("Retry scheduler drained")
+		return nil
+	case <-ctx.Done():
+		// the batch in progress keeps running in the background, release anyway so a later Start works
+		s.release()
+		logger.Log.Warn("Retry scheduler drain timed out", zap.Int("inFlight", s.InFlight()))
+		return ctx.Err()
+	}
+}
+
+// InFlight returns the number of retries currently being processed
+func (s *RetryScheduler) InFlight() int {
+	return s.service.InFlight()
+}
+
+func (s *RetryScheduler) release() {
+	if s.cancelTick != nil {
+		s.cancelTick()
+		s.cancelTick = nil
+	}
 	if s.ticker != nil {
 		s.ticker.Stop()
 		s.ticker = nil
 	}
-	logger.Log.Info("Retry scheduler stopped")
+	s.setRunning(false)
+}
+
+// Status reports whether the retry scheduler is running and the outcome of its last tick
+func (s *RetryScheduler) Status() instance.ComponentStatus {
+	s.statusMu.RLock()
+	defer s.statusMu.RUnlock()
+	status := s.lastStatus
+	status.Running = s.running
+	return status
+}
+
+func (s *RetryScheduler) tick(ctx context.Context) {
+	startedAt := time.Now()
+	batchSize := s.service.ProcessMessageRetries(ctx)
+
+	s.statusMu.Lock()
+	s.lastStatus.LastTickAt = startedAt
+	s.lastStatus.LastBatchSize = batchSize
+	s.lastStatus.LastBatchDurationMs = time.Since(startedAt).Milliseconds()
+	s.statusMu.Unlock()
+
+	if s.heartbeat != nil {
+		if err := s.heartbeat.Heartbeat(ctx); err != nil {
+			logger.Log.Warn("Failed to refresh instance heartbeat", zap.Error(err))
+		}
+	}
 }
 
-func publishCommand(ctx context.Context, redisClient redisClient.Client, command string) error {
-	return redisClient.Publish(ctx, "scheduler:commands", command)
+func (s *RetryScheduler) setRunning(running bool) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	s.running = running
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	redisClient "github.com/atakurt/messagingApp/internal/infrastructure/redis"
 	goRedis "github.com/go-redis/redis/v8"
+	"sync"
 	"testing"
 	"time"
 
@@ -174,43 +175,67 @@ func TestRetryScheduler_Stop_WhenNotRunning(t *testing.T) {
 	assert.Nil(t, scheduler.ticker)
 }
 
-func TestRetryScheduler_SubscribeToCommands(t *testing.T) {
+func getTestConfig() config.Config {
+	return config.Config{
+		Scheduler: struct {
+			Enabled            bool
+			Interval           time.Duration
+			BatchSize          int
+			MaxConcurrent      int
+			MaxRetryConcurrent int
+			LeaseDuration      time.Duration
+			Mode               string
+			IdleBackoff        time.Duration
+			MaxIdleBackoff     time.Duration
+		}{
+			Enabled:  true,
+			Interval: time.Second,
+		},
+	}
+}
+
+type countingHeartbeat struct {
+	calls chan struct{}
+}
+
+func (h *countingHeartbeat) Heartbeat(ctx context.Context) error {
+	h.calls <- struct{}{}
+	return nil
+}
+
+func TestRetryScheduler_TickRecordsStatusAndHeartbeat(t *testing.T) {
 	logger.Log = zap.NewNop()
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockService := mocks.NewMockMessageRetryServiceInterface(ctrl)
 	mockRedis := mocks.NewMockRedisClient(ctrl)
+	heartbeat := &countingHeartbeat{calls: make(chan struct{}, 10)}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
-	defer cancel()
-
-	mockPubSub := NewMockPubSub()
-	mockRedis.EXPECT().
-		Subscribe(gomock.Any(), "scheduler:commands").
-		Return(mockPubSub).
-		AnyTimes()
+	mockService.EXPECT().ProcessMessageRetries(gomock.Any()).Return(4).MinTimes(1)
 
-	mockRedis.EXPECT().
-		Publish(gomock.Any(), "scheduler:commands", "start").
-		Return(nil).
-		Times(1)
+	cfg := getTestConfig()
+	cfg.Scheduler.Interval = 20 * time.Millisecond
+	scheduler := NewRetryScheduler(mockService, mockRedis, cfg, WithHeartbeat(heartbeat))
 
-	scheduler := NewRetryScheduler(mockService, mockRedis, getTestConfig())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	scheduler.Start(ctx)
+	defer scheduler.Stop(ctx)
 
-	// Test publishCommand
-	err := publishCommand(ctx, mockRedis, "start")
-	assert.NoError(t, err)
-
-	// Wait for context to timeout
-	<-ctx.Done()
+	select {
+	case <-heartbeat.calls:
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for heartbeat")
+	}
 
-	// Clean up
-	scheduler.Stop(ctx)
+	status := scheduler.Status()
+	assert.True(t, status.Running)
+	assert.Equal(t, 4, status.LastBatchSize)
+	assert.False(t, status.LastTickAt.IsZero())
 }
 
-func TestRetryScheduler_SubscribeToCommands_UnknownCommand(t *testing.T) {
+func TestRetryScheduler_StopWaitsForTickInProgress(t *testing.T) {
 	logger.Log = zap.NewNop()
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -218,44 +243,109 @@ func TestRetryScheduler_SubscribeToCommands_UnknownCommand(t *testing.T) {
 	mockService := mocks.NewMockMessageRetryServiceInterface(ctrl)
 	mockRedis := mocks.NewMockRedisClient(ctrl)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
-	defer cancel()
-
-	mockRedis.EXPECT().
-		Subscribe(gomock.Any(), "scheduler:commands").
-		Return(createMockPubSub()).
-		AnyTimes()
-
-	mockRedis.EXPECT().
-		Publish(gomock.Any(), "scheduler:commands", "unknown").
-		Return(nil).
-		Times(1)
-
-	scheduler := NewRetryScheduler(mockService, mockRedis, getTestConfig())
-	scheduler.Start(ctx)
-
-	// Test publishCommand with unknown command
-	err := publishCommand(ctx, mockRedis, "unknown")
-	assert.NoError(t, err)
-
-	// Wait for context to timeout
-	<-ctx.Done()
-
-	// Clean up
-	scheduler.Stop(ctx)
+	started := make(chan struct{})
+	finished := false
+	mockService.EXPECT().ProcessMessageRetries(gomock.Any()).DoAndReturn(func(ctx context.Context) int {
+		close(started)
+		time.Sleep(30 * time.Millisecond)
+		finished = true
+		return 1
+	})
+
+	cfg := getTestConfig()
+	cfg.Scheduler.Interval = 10 * time.Millisecond
+	scheduler := NewRetryScheduler(mockService, mockRedis, cfg)
+	scheduler.Start(context.Background())
+	<-started
+
+	scheduler.Stop(context.Background())
+	assert.True(t, finished)
+	assert.False(t, scheduler.Status().Running)
 }
 
-func getTestConfig() config.Config {
-	return config.Config{
-		Scheduler: struct {
-			Enabled            bool
-			Interval           time.Duration
-			BatchSize          int
-			MaxConcurrent      int
-			MaxRetryConcurrent int
-		}{
-			Enabled:  true,
-			Interval: time.Second,
-		},
-	}
+func TestRetryScheduler_Drain(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	cfg := getTestConfig()
+	cfg.Scheduler.Interval = 10 * time.Millisecond
+
+	t.Run("Cancels the batch and waits for it to finish", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockService := mocks.NewMockMessageRetryServiceInterface(ctrl)
+		started := make(chan struct{})
+		mockService.EXPECT().ProcessMessageRetries(gomock.Any()).DoAndReturn(func(ctx context.Context) int {
+			close(started)
+			<-ctx.Done()
+			return 1
+		})
+
+		scheduler := NewRetryScheduler(mockService, mocks.NewMockRedisClient(ctrl), cfg)
+		scheduler.Start(context.Background())
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		assert.NoError(t, scheduler.Drain(ctx))
+		assert.False(t, scheduler.Status().Running)
+		assert.Nil(t, scheduler.ticker)
+	})
+
+	t.Run("Times out while a retry is still in flight", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockService := mocks.NewMockMessageRetryServiceInterface(ctrl)
+		started := make(chan struct{})
+		release := make(chan struct{})
+		mockService.EXPECT().ProcessMessageRetries(gomock.Any()).DoAndReturn(func(ctx context.Context) int {
+			close(started)
+			<-release
+			return 1
+		})
+		mockService.EXPECT().InFlight().Return(2).AnyTimes()
+
+		scheduler := NewRetryScheduler(mockService, mocks.NewMockRedisClient(ctrl), cfg)
+		scheduler.Start(context.Background())
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, scheduler.Drain(ctx), context.DeadlineExceeded)
+		assert.Equal(t, 2, scheduler.InFlight())
+		assert.False(t, scheduler.Status().Running)
+		assert.Nil(t, scheduler.ticker, "a timed out drain does not block a later Start")
+
+		close(release)
+		scheduler.wg.Wait()
+	})
+
+	t.Run("Concurrent with Stop", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockService := mocks.NewMockMessageRetryServiceInterface(ctrl)
+		mockService.EXPECT().ProcessMessageRetries(gomock.Any()).Return(0).AnyTimes()
+		mockService.EXPECT().InFlight().Return(0).AnyTimes()
+
+		scheduler := NewRetryScheduler(mockService, mocks.NewMockRedisClient(ctrl), cfg)
+		for i := 0; i < 20; i++ {
+			scheduler.Start(context.Background())
+
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				scheduler.Stop(context.Background())
+			}()
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, scheduler.Drain(context.Background()))
+			}()
+			wg.Wait()
+
+			assert.False(t, scheduler.Status().Running)
+		}
+	})
 }
@@ -9,7 +9,9 @@ import (
 
 	"github.com/atakurt/messagingApp/internal/features/sendmessages"
 	"github.com/atakurt/messagingApp/internal/infrastructure/config"
+	"github.com/atakurt/messagingApp/internal/infrastructure/instance"
 	"github.com/atakurt/messagingApp/internal/infrastructure/logger"
+	"go.uber.org/zap"
 )
 
 type SchedulerInterface interface {
@@ -17,17 +19,89 @@ type SchedulerInterface interface {
 	Stop(ctx context.Context)
 }
 
+// Heartbeater is refreshed after every tick so the instance registry reflects the latest batch
+type Heartbeater interface {
+	Heartbeat(ctx context.Context) error
+}
+
+// LeaderChecker gates ticks when leader election is enabled so only the leader processes messages
+type LeaderChecker interface {
+	IsLeader() bool
+}
+
+// Limits are the adaptive batch size and concurrency, reported in the status and used to size the stream worker pool
+type Limits interface {
+	BatchSize() int
+	Concurrency() int
+	MaxConcurrency() int
+}
+
+// WakeSource signals that new messages were inserted so idle workers don't wait out their backoff
+type WakeSource interface {
+	Wakeups() <-chan struct{}
+}
+
+const (
+	// ModeTicker processes one batch every scheduler.interval
+	ModeTicker = "ticker"
+	// ModeStream keeps a pool of scheduler.maxConcurrent workers claiming messages as capacity frees up
+	ModeStream = "stream"
+)
+
+type Option func(*Scheduler)
+
+func WithHeartbeat(heartbeat Heartbeater) Option {
+	return func(s *Scheduler) {
+		s.heartbeat = heartbeat
+	}
+}
+
+func WithLeaderElection(leader LeaderChecker) Option {
+	return func(s *Scheduler) {
+		s.leader = leader
+	}
+}
+
+func WithLimits(limits Limits) Option {
+	return func(s *Scheduler) {
+		s.limits = limits
+	}
+}
+
+func WithWakeSource(source WakeSource) Option {
+	return func(s *Scheduler) {
+		s.wakeSource = source
+	}
+}
+
 type Scheduler struct {
+	// runMu serializes Start, Stop and Drain, the command listener, /drain and shutdown call them concurrently
+	runMu          sync.Mutex
 	ticker         *time.Ticker
 	stopChan       chan struct{}
+	cancelTick     context.CancelFunc
 	wg             sync.WaitGroup
 	running        bool
 	messageService sendmessages.MessageServiceInterface
 	redisClient    redisClient.Client
+	heartbeat      Heartbeater
+	leader         LeaderChecker
+	wakeSource     WakeSource
+	limits         Limits
+
+	// wake is closed and replaced to wake every idle worker at once
+	wakeMu sync.Mutex
+	wake   chan struct{}
+
+	statusMu   sync.RWMutex
+	lastStatus instance.ComponentStatus
 }
 
-func NewScheduler(service sendmessages.MessageServiceInterface, redisClient redisClient.Client) *Scheduler {
+func NewScheduler(service sendmessages.MessageServiceInterface, redisClient redisClient.Client, opts ...Option) *Scheduler {
 	s := &Scheduler{messageService: service, redisClient: redisClient}
+	for _, opt := range opts {
+		opt(s)
+	}
 	return s
 }
 
@@ -37,6 +111,8 @@ func (s *Scheduler) Start(ctx context.Context) {
 		return
 	}
 
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
 	if s.running {
 		logger.Log.Warn("Scheduler already running")
 		return
@@ -47,23 +123,41 @@ func (s *Scheduler) Start(ctx context.Context) {
 		return
 	}
 	s.stopChan = make(chan struct{})
+	// tickCtx is cancelled by Drain so the batch in progress stops starting new messages
+	tickCtx, cancelTick := context.WithCancel(ctx)
+	s.cancelTick = cancelTick
+	s.setRunning(true)
+
+	if config.Cfg.Scheduler.Mode == ModeStream {
+		s.startWorkers(tickCtx, s.stopChan)
+		logger.Log.Info("Scheduler started in stream mode", zap.Int("workers", s.workerCount()))
+		return
+	}
+
 	s.ticker = time.NewTicker(config.Cfg.Scheduler.Interval)
-	s.running = true
 	logger.Log.Info("Scheduler started")
 
+	ticker, stopChan := s.ticker, s.stopChan
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
 		for {
 			select {
-			case <-s.ticker.C:
-				if config.Cfg.Scheduler.Enabled {
-					logger.Log.Info("Scheduler tick - checking for unsent messages")
-					s.messageService.ProcessUnsentMessages(ctx)
+			case <-ticker.C:
+				// a tick may be pending when stop was requested during a long batch
+				select {
+				case <-stopChan:
+					ticker.Stop()
+					return
+				default:
 				}
-			case <-s.stopChan:
-				s.ticker.Stop()
-				s.ticker = nil
+				if !s.canProcess() {
+					continue
+				}
+				logger.Log.Info("Scheduler tick - checking for unsent messages")
+				s.tick(tickCtx)
+			case <-stopChan:
+				ticker.Stop()
 				return
 			}
 		}
@@ -71,6 +165,8 @@ func (s *Scheduler) Start(ctx context.Context) {
 }
 
 func (s *Scheduler) Stop(ctx context.Context) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
 	if !s.running {
 		logger.Log.Warn("Scheduler is not running")
 		return
@@ -81,10 +177,195 @@ func (s *Scheduler) Stop(ctx context.Context) {
 		s.wg.Wait()
 		s.stopChan = nil
 	}
-	s.running = false
+	s.release()
 	logger.Log.Info("Scheduler stopped")
 }
 
-func PublishCommand(ctx context.Context, redisClient redisClient.Client, command string) error {
-	return redisClient.Publish(ctx, "scheduler:commands", command)
+// Drain stops scheduling new ticks and cancels the batch in progress so it starts no new
+// messages, then waits for messages already being sent until ctx expires
+func (s *Scheduler) Drain(ctx context.Context) error {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	if !s.running {
+		return nil
+	}
+
+	if s.stopChan != nil {
+		close(s.stopChan)
+		s.stopChan = nil
+	}
+	if s.cancelTick != nil {
+		s.cancelTick()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.release()
+		logger.Log.Info("Scheduler drained")
+		return nil
+	case <-ctx.Done():
+		// the batch in progress keeps running in the background, release anyway so a later Start works
+		s.release()
+		logger.Log.Warn("Scheduler drain timed out", zap.Int("inFlight", s.InFlight()))
+		return ctx.Err()
+	}
+}
+
+// InFlight returns the number of messages currently being sent
+func (s *Scheduler) InFlight() int {
+	return s.messageService.InFlight()
+}
+
+func (s *Scheduler) release() {
+	if s.cancelTick != nil {
+		s.cancelTick()
+		s.cancelTick = nil
+	}
+	if s.ticker != nil {
+		s.ticker.Stop()
+		s.ticker = nil
+	}
+	s.setRunning(false)
+}
+
+// Status reports whether the scheduler is running and the outcome of its last tick
+func (s *Scheduler) Status() instance.ComponentStatus {
+	s.statusMu.RLock()
+	defer s.statusMu.RUnlock()
+	status := s.lastStatus
+	status.Running = s.running
+	if s.limits != nil {
+		status.BatchSize = s.limits.BatchSize()
+		status.Concurrency = s.limits.Concurrency()
+	}
+	return status
+}
+
+// startWorkers runs the stream mode pool; each worker claims the next message as soon as it is
+// free and backs off exponentially only while the queue is empty
+func (s *Scheduler) startWorkers(ctx context.Context, stopChan chan struct{}) {
+	s.wakeMu.Lock()
+	s.wake = make(chan struct{})
+	s.wakeMu.Unlock()
+
+	if s.wakeSource != nil {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			wakeups := s.wakeSource.Wakeups()
+			for {
+				select {
+				case <-wakeups:
+					s.wakeWorkers()
+				case <-stopChan:
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < s.workerCount(); i++ {
+		s.wg.Add(1)
+		go func(worker int) {
+			defer s.wg.Done()
+			s.runWorker(ctx, stopChan, worker)
+		}(i)
+	}
+}
+
+// workerCount is the size of the stream pool; with adaptive limits workers above the current concurrency stay idle
+func (s *Scheduler) workerCount() int {
+	if s.limits != nil {
+		return s.limits.MaxConcurrency()
+	}
+	return config.Cfg.Scheduler.MaxConcurrent
+}
+
+func (s *Scheduler) runWorker(ctx context.Context, stopChan chan struct{}, worker int) {
+	backoff := config.Cfg.Scheduler.IdleBackoff
+	for {
+		select {
+		case <-stopChan:
+			return
+		default:
+		}
+
+		if s.canProcess() && (s.limits == nil || worker < s.limits.Concurrency()) {
+			startedAt := time.Now()
+			if s.messageService.ProcessNext(ctx) {
+				s.recordStatus(startedAt, 1)
+				backoff = config.Cfg.Scheduler.IdleBackoff
+				continue
+			}
+		}
+
+		// idle until the backoff passes, new messages are signalled or the scheduler stops
+		timer := time.NewTimer(backoff)
+		select {
+		case <-stopChan:
+			timer.Stop()
+			return
+		case <-s.wakeChan():
+			timer.Stop()
+			backoff = config.Cfg.Scheduler.IdleBackoff
+		case <-timer.C:
+			backoff = min(backoff*2, config.Cfg.Scheduler.MaxIdleBackoff)
+		}
+	}
+}
+
+func (s *Scheduler) wakeChan() <-chan struct{} {
+	s.wakeMu.Lock()
+	defer s.wakeMu.Unlock()
+	return s.wake
+}
+
+func (s *Scheduler) wakeWorkers() {
+	s.wakeMu.Lock()
+	defer s.wakeMu.Unlock()
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
+
+func (s *Scheduler) canProcess() bool {
+	if !config.Cfg.Scheduler.Enabled {
+		return false
+	}
+	if s.leader != nil && !s.leader.IsLeader() {
+		logger.Log.Debug("Scheduler skipped, this instance is not the leader")
+		return false
+	}
+	return true
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	startedAt := time.Now()
+	batchSize := s.messageService.ProcessUnsentMessages(ctx)
+	s.recordStatus(startedAt, batchSize)
+
+	if s.heartbeat != nil {
+		if err := s.heartbeat.Heartbeat(ctx); err != nil {
+			logger.Log.Warn("Failed to refresh instance heartbeat", zap.Error(err))
+		}
+	}
+}
+
+func (s *Scheduler) recordStatus(startedAt time.Time, batchSize int) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	s.lastStatus.LastTickAt = startedAt
+	s.lastStatus.LastBatchSize = batchSize
+	s.lastStatus.LastBatchDurationMs = time.Since(startedAt).Milliseconds()
+}
+
+func (s *Scheduler) setRunning(running bool) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	s.running = running
 }
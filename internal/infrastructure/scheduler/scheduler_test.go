@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
@@ -131,42 +132,6 @@ func TestScheduler_Stop(t *testing.T) {
 	assert.Nil(t, scheduler.stopChan)
 }
 
-func TestScheduler_SubscribeToCommands(t *testing.T) {
-	logger.Log = zap.NewNop()
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	mockService := mocks.NewMockMessageServiceInterface(ctrl)
-	mockRedis := mocks.NewMockRedisClient(ctrl)
-
-	// Create a context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
-	defer cancel()
-
-	mockPubSub := NewMockPubSub()
-	mockRedis.EXPECT().
-		Subscribe(gomock.Any(), "scheduler:commands").
-		Return(mockPubSub).
-		AnyTimes()
-
-	mockRedis.EXPECT().
-		Publish(gomock.Any(), "scheduler:commands", "start").
-		Return(nil).
-		Times(1)
-
-	scheduler := NewScheduler(mockService, mockRedis)
-
-	// Test PublishCommand
-	err := PublishCommand(ctx, mockRedis, "start")
-	assert.NoError(t, err)
-
-	// Wait for context to timeout
-	<-ctx.Done()
-
-	// Clean up
-	scheduler.Stop(ctx)
-}
-
 func TestScheduler_Start_WhenDisabled(t *testing.T) {
 	logger.Log = zap.NewNop()
 	ctrl := gomock.NewController(t)
@@ -180,6 +145,10 @@ func TestScheduler_Start_WhenDisabled(t *testing.T) {
 			BatchSize          int
 			MaxConcurrent      int
 			MaxRetryConcurrent int
+			LeaseDuration      time.Duration
+			Mode               string
+			IdleBackoff        time.Duration
+			MaxIdleBackoff     time.Duration
 		}{
 			Enabled: false,
 		},
@@ -268,40 +237,6 @@ func TestScheduler_Stop_WhenNotRunning(t *testing.T) {
 	assert.Nil(t, scheduler.ticker)
 }
 
-func TestScheduler_SubscribeToCommands_UnknownCommand(t *testing.T) {
-	logger.Log = zap.NewNop()
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	mockService := mocks.NewMockMessageServiceInterface(ctrl)
-	mockRedis := mocks.NewMockRedisClient(ctrl)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
-	defer cancel()
-
-	mockRedis.EXPECT().
-		Subscribe(gomock.Any(), "scheduler:commands").
-		Return(createMockPubSub()).
-		AnyTimes()
-
-	mockRedis.EXPECT().
-		Publish(gomock.Any(), "scheduler:commands", "unknown").
-		Return(nil).
-		Times(1)
-
-	scheduler := NewScheduler(mockService, mockRedis)
-
-	// Test PublishCommand with unknown command
-	err := PublishCommand(ctx, mockRedis, "unknown")
-	assert.NoError(t, err)
-
-	// Wait for context to timeout
-	<-ctx.Done()
-
-	// Clean up
-	scheduler.Stop(ctx)
-}
-
 func TestScheduler_SubscribeToCommands_ErrorHandling(t *testing.T) {
 	logger.Log = zap.NewNop()
 	ctrl := gomock.NewController(t)
@@ -391,3 +326,296 @@ func TestScheduler_Start_WithTickerNotNil(t *testing.T) {
 	// Clean up
 	scheduler.Stop(ctx)
 }
+
+type countingHeartbeat struct {
+	calls chan struct{}
+}
+
+func (h *countingHeartbeat) Heartbeat(ctx context.Context) error {
+	h.calls <- struct{}{}
+	return nil
+}
+
+func TestScheduler_TickRecordsStatusAndHeartbeat(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockMessageServiceInterface(ctrl)
+	mockRedis := mocks.NewMockRedisClient(ctrl)
+	heartbeat := &countingHeartbeat{calls: make(chan struct{}, 10)}
+
+	mockService.EXPECT().ProcessUnsentMessages(gomock.Any()).Return(3).MinTimes(1)
+
+	scheduler := NewScheduler(mockService, mockRedis, WithHeartbeat(heartbeat))
+	ctx := context.Background()
+
+	config.Cfg.Scheduler.Enabled = true
+	config.Cfg.Scheduler.Interval = 20 * time.Millisecond
+
+	scheduler.Start(ctx)
+
+	select {
+	case <-heartbeat.calls:
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for heartbeat")
+	}
+
+	status := scheduler.Status()
+	assert.True(t, status.Running)
+	assert.Equal(t, 3, status.LastBatchSize)
+	assert.False(t, status.LastTickAt.IsZero())
+
+	scheduler.Stop(ctx)
+	assert.False(t, scheduler.Status().Running)
+}
+
+func TestScheduler_Drain(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	config.Cfg.Scheduler.Enabled = true
+	config.Cfg.Scheduler.Interval = 10 * time.Millisecond
+
+	t.Run("Cancels the batch and waits for it to finish", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockService := mocks.NewMockMessageServiceInterface(ctrl)
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+
+		started := make(chan struct{})
+		mockService.EXPECT().ProcessUnsentMessages(gomock.Any()).DoAndReturn(func(ctx context.Context) int {
+			close(started)
+			<-ctx.Done()
+			return 1
+		})
+
+		scheduler := NewScheduler(mockService, mockRedis)
+		scheduler.Start(context.Background())
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		assert.NoError(t, scheduler.Drain(ctx))
+		assert.False(t, scheduler.Status().Running)
+		assert.Nil(t, scheduler.ticker)
+		assert.Nil(t, scheduler.stopChan)
+	})
+
+	t.Run("Times out while a send is still in flight", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockService := mocks.NewMockMessageServiceInterface(ctrl)
+		mockRedis := mocks.NewMockRedisClient(ctrl)
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		mockService.EXPECT().ProcessUnsentMessages(gomock.Any()).DoAndReturn(func(ctx context.Context) int {
+			close(started)
+			<-release
+			return 1
+		})
+		mockService.EXPECT().InFlight().Return(1).AnyTimes()
+
+		scheduler := NewScheduler(mockService, mockRedis)
+		scheduler.Start(context.Background())
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, scheduler.Drain(ctx), context.DeadlineExceeded)
+		assert.False(t, scheduler.Status().Running)
+		assert.Equal(t, 1, scheduler.InFlight())
+		assert.Nil(t, scheduler.ticker, "a timed out drain does not block a later Start")
+		assert.Nil(t, scheduler.cancelTick)
+
+		close(release)
+		scheduler.wg.Wait()
+	})
+
+	t.Run("No-op when not running", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		scheduler := NewScheduler(mocks.NewMockMessageServiceInterface(ctrl), mocks.NewMockRedisClient(ctrl))
+		assert.NoError(t, scheduler.Drain(context.Background()))
+	})
+
+	t.Run("Concurrent with Stop", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockService := mocks.NewMockMessageServiceInterface(ctrl)
+		mockService.EXPECT().ProcessUnsentMessages(gomock.Any()).Return(0).AnyTimes()
+		mockService.EXPECT().InFlight().Return(0).AnyTimes()
+
+		scheduler := NewScheduler(mockService, mocks.NewMockRedisClient(ctrl))
+		for i := 0; i < 20; i++ {
+			scheduler.Start(context.Background())
+
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				scheduler.Stop(context.Background())
+			}()
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, scheduler.Drain(context.Background()))
+			}()
+			wg.Wait()
+
+			assert.False(t, scheduler.Status().Running)
+		}
+	})
+}
+
+type fakeWakeSource struct {
+	wakeups chan struct{}
+}
+
+func (w *fakeWakeSource) Wakeups() <-chan struct{} {
+	return w.wakeups
+}
+
+func setStreamConfig(workers int, idleBackoff time.Duration) {
+	config.Cfg.Scheduler.Enabled = true
+	config.Cfg.Scheduler.Mode = ModeStream
+	config.Cfg.Scheduler.MaxConcurrent = workers
+	config.Cfg.Scheduler.IdleBackoff = idleBackoff
+	config.Cfg.Scheduler.MaxIdleBackoff = idleBackoff
+}
+
+func TestScheduler_StreamMode(t *testing.T) {
+	logger.Log = zap.NewNop()
+	defer func() { config.Cfg.Scheduler.Mode = ModeTicker }()
+
+	t.Run("Workers drain the queue without waiting for a tick", func(t *testing.T) {
+		setStreamConfig(2, time.Hour)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockService := mocks.NewMockMessageServiceInterface(ctrl)
+		queue := make(chan struct{}, 5)
+		for i := 0; i < 5; i++ {
+			queue <- struct{}{}
+		}
+		processed := make(chan struct{}, 5)
+		mockService.EXPECT().ProcessNext(gomock.Any()).DoAndReturn(func(ctx context.Context) bool {
+			select {
+			case <-queue:
+				processed <- struct{}{}
+				return true
+			default:
+				return false
+			}
+		}).AnyTimes()
+
+		scheduler := NewScheduler(mockService, mocks.NewMockRedisClient(ctrl))
+		scheduler.Start(context.Background())
+		assert.Nil(t, scheduler.ticker)
+
+		for i := 0; i < 5; i++ {
+			select {
+			case <-processed:
+			case <-time.After(time.Second):
+				t.Fatal("Timeout waiting for workers")
+			}
+		}
+		assert.Equal(t, 1, scheduler.Status().LastBatchSize)
+
+		scheduler.Stop(context.Background())
+		assert.False(t, scheduler.Status().Running)
+	})
+
+	t.Run("Wake-up interrupts the idle backoff", func(t *testing.T) {
+		setStreamConfig(1, time.Hour)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockService := mocks.NewMockMessageServiceInterface(ctrl)
+		calls := make(chan struct{}, 10)
+		mockService.EXPECT().ProcessNext(gomock.Any()).DoAndReturn(func(ctx context.Context) bool {
+			calls <- struct{}{}
+			return false
+		}).AnyTimes()
+
+		wakeSource := &fakeWakeSource{wakeups: make(chan struct{}, 1)}
+		scheduler := NewScheduler(mockService, mocks.NewMockRedisClient(ctrl), WithWakeSource(wakeSource))
+		scheduler.Start(context.Background())
+		defer scheduler.Stop(context.Background())
+
+		<-calls
+		wakeSource.wakeups <- struct{}{}
+
+		select {
+		case <-calls:
+		case <-time.After(time.Second):
+			t.Fatal("Worker was not woken up")
+		}
+	})
+
+	t.Run("Drain stops idle workers", func(t *testing.T) {
+		setStreamConfig(3, 10*time.Millisecond)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockService := mocks.NewMockMessageServiceInterface(ctrl)
+		mockService.EXPECT().ProcessNext(gomock.Any()).Return(false).AnyTimes()
+
+		scheduler := NewScheduler(mockService, mocks.NewMockRedisClient(ctrl))
+		scheduler.Start(context.Background())
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		assert.NoError(t, scheduler.Drain(ctx))
+		assert.False(t, scheduler.Status().Running)
+	})
+}
+
+type fixedLimits struct {
+	batchSize, concurrency, maxConcurrency int
+}
+
+func (l fixedLimits) BatchSize() int      { return l.batchSize }
+func (l fixedLimits) Concurrency() int    { return l.concurrency }
+func (l fixedLimits) MaxConcurrency() int { return l.maxConcurrency }
+
+func TestScheduler_StreamModeRespectsLimits(t *testing.T) {
+	logger.Log = zap.NewNop()
+	defer func() { config.Cfg.Scheduler.Mode = ModeTicker }()
+	setStreamConfig(1, 10*time.Millisecond)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockMessageServiceInterface(ctrl)
+	active := make(chan struct{}, 10)
+	release := make(chan struct{})
+	mockService.EXPECT().ProcessNext(gomock.Any()).DoAndReturn(func(ctx context.Context) bool {
+		active <- struct{}{}
+		<-release
+		return true
+	}).AnyTimes()
+
+	limits := fixedLimits{batchSize: 8, concurrency: 2, maxConcurrency: 4}
+	scheduler := NewScheduler(mockService, mocks.NewMockRedisClient(ctrl), WithLimits(limits))
+	scheduler.Start(context.Background())
+
+	// only the first two of the four workers may send
+	<-active
+	<-active
+	select {
+	case <-active:
+		t.Fatal("More workers active than the concurrency limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	status := scheduler.Status()
+	assert.Equal(t, 8, status.BatchSize)
+	assert.Equal(t, 2, status.Concurrency)
+
+	close(release)
+	scheduler.Stop(context.Background())
+}
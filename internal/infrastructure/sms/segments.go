@@ -0,0 +1,112 @@
+package sms
+
+import (
+	"fmt"
+	"unicode/utf16"
+)
+
+type Encoding string
+
+const (
+	EncodingGSM7 Encoding = "GSM-7"
+	EncodingUCS2 Encoding = "UCS-2"
+)
+
+// Segment sizes in encoding units. A concatenated message loses room in every segment to the
+// user data header that links the parts.
+const (
+	gsm7SingleSegment    = 160
+	gsm7MultipartSegment = 153
+	ucs2SingleSegment    = 70
+	ucs2MultipartSegment = 67
+)
+
+// gsm7Basic is the GSM 03.38 default alphabet, every character takes one septet
+var gsm7Basic = runeSet("@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà")
+
+// gsm7Extension holds the characters sent as an escape plus a character, they take two septets
+var gsm7Extension = runeSet("\f^{}\\[~]|€")
+
+// Segmentation describes how content is sent: its encoding, its length in units of that
+// encoding (septets for GSM-7, UTF-16 code units for UCS-2) and the billable segments
+type Segmentation struct {
+	Encoding Encoding `json:"encoding"`
+	Units    int      `json:"units"`
+	Segments int      `json:"segments"`
+}
+
+// Analyze picks GSM-7 when every character of content is in the GSM alphabet and UCS-2 otherwise,
+// e.g. a single ş or ı turns a 160 character message into three UCS-2 segments
+func Analyze(content string) Segmentation {
+	encoding := EncodingGSM7
+	for _, r := range content {
+		if !gsm7Basic[r] && !gsm7Extension[r] {
+			encoding = EncodingUCS2
+			break
+		}
+	}
+
+	// the units of every character, a character is never split across two segments
+	var units []int
+	for _, r := range content {
+		units = append(units, unitsOf(encoding, r))
+	}
+
+	total := 0
+	for _, u := range units {
+		total += u
+	}
+
+	single, multipart := gsm7SingleSegment, gsm7MultipartSegment
+	if encoding == EncodingUCS2 {
+		single, multipart = ucs2SingleSegment, ucs2MultipartSegment
+	}
+
+	segmentation := Segmentation{Encoding: encoding, Units: total}
+	switch {
+	case total == 0:
+	case total <= single:
+		segmentation.Segments = 1
+	default:
+		used := 0
+		segmentation.Segments = 1
+		for _, u := range units {
+			if used+u > multipart {
+				segmentation.Segments++
+				used = 0
+			}
+			used += u
+		}
+	}
+	return segmentation
+}
+
+// Check analyzes content and fails if it needs more than maxSegments segments
+func Check(content string, maxSegments int) (Segmentation, error) {
+	segmentation := Analyze(content)
+	if segmentation.Segments > maxSegments {
+		return segmentation, fmt.Errorf("content needs %d %s segments, at most %d are allowed",
+			segmentation.Segments, segmentation.Encoding, maxSegments)
+	}
+	return segmentation, nil
+}
+
+func unitsOf(encoding Encoding, r rune) int {
+	if encoding == EncodingUCS2 {
+		// characters outside the basic multilingual plane are sent as a surrogate pair
+		return utf16.RuneLen(r)
+	}
+	if gsm7Extension[r] {
+		return 2
+	}
+	return 1
+}
+
+func runeSet(chars string) map[rune]bool {
+	set := make(map[rune]bool)
+	for _, r := range chars {
+		set[r] = true
+	}
+	return set
+}
@@ -0,0 +1,48 @@
+package sms
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected Segmentation
+	}{
+		{"Empty", "", Segmentation{Encoding: EncodingGSM7}},
+		{"Single GSM-7 segment", strings.Repeat("a", 160), Segmentation{EncodingGSM7, 160, 1}},
+		{"Two GSM-7 segments", strings.Repeat("a", 161), Segmentation{EncodingGSM7, 161, 2}},
+		{"Extension characters take two septets", strings.Repeat("€", 80), Segmentation{EncodingGSM7, 160, 1}},
+		{"A single ş forces UCS-2", "Ödeme ücreti: 5€, Çarşamba", Segmentation{EncodingUCS2, 26, 1}},
+		{"Ö and ü are in the GSM alphabet", "Ödeme ücreti: 5€", Segmentation{EncodingGSM7, 17, 1}},
+		{"Single UCS-2 segment", strings.Repeat("ş", 70), Segmentation{EncodingUCS2, 70, 1}},
+		{"Two UCS-2 segments", strings.Repeat("ş", 71), Segmentation{EncodingUCS2, 71, 2}},
+		{"Emoji take a surrogate pair", strings.Repeat("😀", 35), Segmentation{EncodingUCS2, 70, 1}},
+		{"Three UCS-2 segments", strings.Repeat("ı", 135), Segmentation{EncodingUCS2, 135, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Analyze(tt.content))
+		})
+	}
+}
+
+func TestAnalyze_DoesNotSplitCharacters(t *testing.T) {
+	// 152 septets then an escaped character: the escape pair moves to the second segment
+	content := strings.Repeat("a", 152) + "€" + strings.Repeat("a", 152)
+	assert.Equal(t, Segmentation{EncodingGSM7, 306, 3}, Analyze(content))
+}
+
+func TestCheck(t *testing.T) {
+	_, err := Check(strings.Repeat("a", 306), 2)
+	assert.NoError(t, err)
+
+	segmentation, err := Check(strings.Repeat("a", 307), 2)
+	assert.EqualError(t, err, "content needs 3 GSM-7 segments, at most 2 are allowed")
+	assert.Equal(t, 3, segmentation.Segments)
+}
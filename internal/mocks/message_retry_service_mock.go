@@ -34,10 +34,26 @@ func (m *MockMessageRetryServiceInterface) EXPECT() *MockMessageRetryServiceInte
 	return m.recorder
 }
 
+// InFlight mocks base method.
+func (m *MockMessageRetryServiceInterface) InFlight() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InFlight")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// InFlight indicates an expected call of InFlight.
+func (mr *MockMessageRetryServiceInterfaceMockRecorder) InFlight() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InFlight", reflect.TypeOf((*MockMessageRetryServiceInterface)(nil).InFlight))
+}
+
 // ProcessMessageRetries mocks base method.
-func (m *MockMessageRetryServiceInterface) ProcessMessageRetries(ctx context.Context) {
+func (m *MockMessageRetryServiceInterface) ProcessMessageRetries(ctx context.Context) int {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "ProcessMessageRetries", ctx)
+	ret := m.ctrl.Call(m, "ProcessMessageRetries", ctx)
+	ret0, _ := ret[0].(int)
+	return ret0
 }
 
 // ProcessMessageRetries indicates an expected call of ProcessMessageRetries.
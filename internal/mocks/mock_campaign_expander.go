@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/atakurt/messagingApp/internal/features/campaigns (interfaces: CampaignExpanderInterface)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCampaignExpanderInterface is a mock of CampaignExpanderInterface interface.
+type MockCampaignExpanderInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockCampaignExpanderInterfaceMockRecorder
+}
+
+// MockCampaignExpanderInterfaceMockRecorder is the mock recorder for MockCampaignExpanderInterface.
+type MockCampaignExpanderInterfaceMockRecorder struct {
+	mock *MockCampaignExpanderInterface
+}
+
+// NewMockCampaignExpanderInterface creates a new mock instance.
+func NewMockCampaignExpanderInterface(ctrl *gomock.Controller) *MockCampaignExpanderInterface {
+	mock := &MockCampaignExpanderInterface{ctrl: ctrl}
+	mock.recorder = &MockCampaignExpanderInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCampaignExpanderInterface) EXPECT() *MockCampaignExpanderInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ExpandNextChunk mocks base method.
+func (m *MockCampaignExpanderInterface) ExpandNextChunk(arg0 context.Context) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpandNextChunk", arg0)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// ExpandNextChunk indicates an expected call of ExpandNextChunk.
+func (mr *MockCampaignExpanderInterfaceMockRecorder) ExpandNextChunk(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpandNextChunk", reflect.TypeOf((*MockCampaignExpanderInterface)(nil).ExpandNextChunk), arg0)
+}
@@ -0,0 +1,209 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/atakurt/messagingApp/internal/infrastructure/repository (interfaces: CampaignRepositoryInterface)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	db "github.com/atakurt/messagingApp/internal/infrastructure/db"
+	gomock "github.com/golang/mock/gomock"
+	gorm "gorm.io/gorm"
+)
+
+// MockCampaignRepositoryInterface is a mock of CampaignRepositoryInterface interface.
+type MockCampaignRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockCampaignRepositoryInterfaceMockRecorder
+}
+
+// MockCampaignRepositoryInterfaceMockRecorder is the mock recorder for MockCampaignRepositoryInterface.
+type MockCampaignRepositoryInterfaceMockRecorder struct {
+	mock *MockCampaignRepositoryInterface
+}
+
+// NewMockCampaignRepositoryInterface creates a new mock instance.
+func NewMockCampaignRepositoryInterface(ctrl *gomock.Controller) *MockCampaignRepositoryInterface {
+	mock := &MockCampaignRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockCampaignRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCampaignRepositoryInterface) EXPECT() *MockCampaignRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CancelCampaign mocks base method.
+func (m *MockCampaignRepositoryInterface) CancelCampaign(arg0 uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelCampaign", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelCampaign indicates an expected call of CancelCampaign.
+func (mr *MockCampaignRepositoryInterfaceMockRecorder) CancelCampaign(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelCampaign", reflect.TypeOf((*MockCampaignRepositoryInterface)(nil).CancelCampaign), arg0)
+}
+
+// CountCampaignMessages mocks base method.
+func (m *MockCampaignRepositoryInterface) CountCampaignMessages(arg0 uint) (map[db.MessageStatus]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountCampaignMessages", arg0)
+	ret0, _ := ret[0].(map[db.MessageStatus]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountCampaignMessages indicates an expected call of CountCampaignMessages.
+func (mr *MockCampaignRepositoryInterfaceMockRecorder) CountCampaignMessages(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountCampaignMessages", reflect.TypeOf((*MockCampaignRepositoryInterface)(nil).CountCampaignMessages), arg0)
+}
+
+// CreateCampaign mocks base method.
+func (m *MockCampaignRepositoryInterface) CreateCampaign(arg0 *db.Campaign, arg1 []db.CampaignRecipient) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCampaign", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCampaign indicates an expected call of CreateCampaign.
+func (mr *MockCampaignRepositoryInterfaceMockRecorder) CreateCampaign(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCampaign", reflect.TypeOf((*MockCampaignRepositoryInterface)(nil).CreateCampaign), arg0, arg1)
+}
+
+// FailCampaign mocks base method.
+func (m *MockCampaignRepositoryInterface) FailCampaign(arg0 *gorm.DB, arg1 uint, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailCampaign", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailCampaign indicates an expected call of FailCampaign.
+func (mr *MockCampaignRepositoryInterfaceMockRecorder) FailCampaign(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailCampaign", reflect.TypeOf((*MockCampaignRepositoryInterface)(nil).FailCampaign), arg0, arg1, arg2)
+}
+
+// GetCampaign mocks base method.
+func (m *MockCampaignRepositoryInterface) GetCampaign(arg0 uint) (db.Campaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCampaign", arg0)
+	ret0, _ := ret[0].(db.Campaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCampaign indicates an expected call of GetCampaign.
+func (mr *MockCampaignRepositoryInterfaceMockRecorder) GetCampaign(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCampaign", reflect.TypeOf((*MockCampaignRepositoryInterface)(nil).GetCampaign), arg0)
+}
+
+// GetDB mocks base method.
+func (m *MockCampaignRepositoryInterface) GetDB() *gorm.DB {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDB")
+	ret0, _ := ret[0].(*gorm.DB)
+	return ret0
+}
+
+// GetDB indicates an expected call of GetDB.
+func (mr *MockCampaignRepositoryInterfaceMockRecorder) GetDB() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDB", reflect.TypeOf((*MockCampaignRepositoryInterface)(nil).GetDB))
+}
+
+// GetRecipients mocks base method.
+func (m *MockCampaignRepositoryInterface) GetRecipients(arg0 *gorm.DB, arg1 uint, arg2, arg3 int) ([]db.CampaignRecipient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecipients", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]db.CampaignRecipient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecipients indicates an expected call of GetRecipients.
+func (mr *MockCampaignRepositoryInterfaceMockRecorder) GetRecipients(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecipients", reflect.TypeOf((*MockCampaignRepositoryInterface)(nil).GetRecipients), arg0, arg1, arg2, arg3)
+}
+
+// ListCampaigns mocks base method.
+func (m *MockCampaignRepositoryInterface) ListCampaigns(arg0 uint, arg1, arg2 int) ([]db.Campaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCampaigns", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]db.Campaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCampaigns indicates an expected call of ListCampaigns.
+func (mr *MockCampaignRepositoryInterfaceMockRecorder) ListCampaigns(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCampaigns", reflect.TypeOf((*MockCampaignRepositoryInterface)(nil).ListCampaigns), arg0, arg1, arg2)
+}
+
+// LockExpandableCampaign mocks base method.
+func (m *MockCampaignRepositoryInterface) LockExpandableCampaign(arg0 *gorm.DB) (db.Campaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockExpandableCampaign", arg0)
+	ret0, _ := ret[0].(db.Campaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockExpandableCampaign indicates an expected call of LockExpandableCampaign.
+func (mr *MockCampaignRepositoryInterfaceMockRecorder) LockExpandableCampaign(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockExpandableCampaign", reflect.TypeOf((*MockCampaignRepositoryInterface)(nil).LockExpandableCampaign), arg0)
+}
+
+// PauseCampaign mocks base method.
+func (m *MockCampaignRepositoryInterface) PauseCampaign(arg0 uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PauseCampaign", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PauseCampaign indicates an expected call of PauseCampaign.
+func (mr *MockCampaignRepositoryInterfaceMockRecorder) PauseCampaign(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseCampaign", reflect.TypeOf((*MockCampaignRepositoryInterface)(nil).PauseCampaign), arg0)
+}
+
+// RecordExpansion mocks base method.
+func (m *MockCampaignRepositoryInterface) RecordExpansion(arg0 *gorm.DB, arg1 db.Campaign, arg2 []db.Message, arg3 []db.CampaignRecipient, arg4 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordExpansion", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordExpansion indicates an expected call of RecordExpansion.
+func (mr *MockCampaignRepositoryInterfaceMockRecorder) RecordExpansion(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordExpansion", reflect.TypeOf((*MockCampaignRepositoryInterface)(nil).RecordExpansion), arg0, arg1, arg2, arg3, arg4)
+}
+
+// ResumeCampaign mocks base method.
+func (m *MockCampaignRepositoryInterface) ResumeCampaign(arg0 uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResumeCampaign", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResumeCampaign indicates an expected call of ResumeCampaign.
+func (mr *MockCampaignRepositoryInterfaceMockRecorder) ResumeCampaign(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeCampaign", reflect.TypeOf((*MockCampaignRepositoryInterface)(nil).ResumeCampaign), arg0)
+}
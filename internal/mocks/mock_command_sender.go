@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/atakurt/messagingApp/internal/features/messagecontrol (interfaces: CommandSender)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	messagecontrol "github.com/atakurt/messagingApp/internal/features/messagecontrol"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCommandSender is a mock of CommandSender interface.
+type MockCommandSender struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommandSenderMockRecorder
+}
+
+// MockCommandSenderMockRecorder is the mock recorder for MockCommandSender.
+type MockCommandSenderMockRecorder struct {
+	mock *MockCommandSender
+}
+
+// NewMockCommandSender creates a new mock instance.
+func NewMockCommandSender(ctrl *gomock.Controller) *MockCommandSender {
+	mock := &MockCommandSender{ctrl: ctrl}
+	mock.recorder = &MockCommandSenderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCommandSender) EXPECT() *MockCommandSenderMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method.
+func (m *MockCommandSender) Send(arg0 context.Context, arg1 messagecontrol.Command) ([]messagecontrol.Ack, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", arg0, arg1)
+	ret0, _ := ret[0].([]messagecontrol.Ack)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockCommandSenderMockRecorder) Send(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockCommandSender)(nil).Send), arg0, arg1)
+}
@@ -0,0 +1,82 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/atakurt/messagingApp/internal/infrastructure/repository (interfaces: ConversationRepositoryInterface)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	db "github.com/atakurt/messagingApp/internal/infrastructure/db"
+	repository "github.com/atakurt/messagingApp/internal/infrastructure/repository"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockConversationRepositoryInterface is a mock of ConversationRepositoryInterface interface.
+type MockConversationRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockConversationRepositoryInterfaceMockRecorder
+}
+
+// MockConversationRepositoryInterfaceMockRecorder is the mock recorder for MockConversationRepositoryInterface.
+type MockConversationRepositoryInterfaceMockRecorder struct {
+	mock *MockConversationRepositoryInterface
+}
+
+// NewMockConversationRepositoryInterface creates a new mock instance.
+func NewMockConversationRepositoryInterface(ctrl *gomock.Controller) *MockConversationRepositoryInterface {
+	mock := &MockConversationRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockConversationRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockConversationRepositoryInterface) EXPECT() *MockConversationRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetThread mocks base method.
+func (m *MockConversationRepositoryInterface) GetThread(arg0 string, arg1 *repository.ThreadPosition, arg2 int) ([]db.ConversationEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetThread", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]db.ConversationEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetThread indicates an expected call of GetThread.
+func (mr *MockConversationRepositoryInterfaceMockRecorder) GetThread(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetThread", reflect.TypeOf((*MockConversationRepositoryInterface)(nil).GetThread), arg0, arg1, arg2)
+}
+
+// ListConversations mocks base method.
+func (m *MockConversationRepositoryInterface) ListConversations(arg0 *repository.ConversationPosition, arg1 int) ([]db.Conversation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListConversations", arg0, arg1)
+	ret0, _ := ret[0].([]db.Conversation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListConversations indicates an expected call of ListConversations.
+func (mr *MockConversationRepositoryInterfaceMockRecorder) ListConversations(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListConversations", reflect.TypeOf((*MockConversationRepositoryInterface)(nil).ListConversations), arg0, arg1)
+}
+
+// MarkRead mocks base method.
+func (m *MockConversationRepositoryInterface) MarkRead(arg0 string, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkRead indicates an expected call of MarkRead.
+func (mr *MockConversationRepositoryInterfaceMockRecorder) MarkRead(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockConversationRepositoryInterface)(nil).MarkRead), arg0, arg1)
+}
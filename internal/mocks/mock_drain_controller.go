@@ -0,0 +1,78 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/atakurt/messagingApp/internal/features/messagecontrol/drain (interfaces: DrainController)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	drain "github.com/atakurt/messagingApp/internal/infrastructure/drain"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockDrainController is a mock of DrainController interface.
+type MockDrainController struct {
+	ctrl     *gomock.Controller
+	recorder *MockDrainControllerMockRecorder
+}
+
+// MockDrainControllerMockRecorder is the mock recorder for MockDrainController.
+type MockDrainControllerMockRecorder struct {
+	mock *MockDrainController
+}
+
+// NewMockDrainController creates a new mock instance.
+func NewMockDrainController(ctrl *gomock.Controller) *MockDrainController {
+	mock := &MockDrainController{ctrl: ctrl}
+	mock.recorder = &MockDrainControllerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDrainController) EXPECT() *MockDrainControllerMockRecorder {
+	return m.recorder
+}
+
+// Progress mocks base method.
+func (m *MockDrainController) Progress() drain.Progress {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Progress")
+	ret0, _ := ret[0].(drain.Progress)
+	return ret0
+}
+
+// Progress indicates an expected call of Progress.
+func (mr *MockDrainControllerMockRecorder) Progress() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Progress", reflect.TypeOf((*MockDrainController)(nil).Progress))
+}
+
+// Start mocks base method.
+func (m *MockDrainController) Start() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Start")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Start indicates an expected call of Start.
+func (mr *MockDrainControllerMockRecorder) Start() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockDrainController)(nil).Start))
+}
+
+// Wait mocks base method.
+func (m *MockDrainController) Wait(arg0 context.Context) drain.Progress {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Wait", arg0)
+	ret0, _ := ret[0].(drain.Progress)
+	return ret0
+}
+
+// Wait indicates an expected call of Wait.
+func (mr *MockDrainControllerMockRecorder) Wait(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Wait", reflect.TypeOf((*MockDrainController)(nil).Wait), arg0)
+}
@@ -0,0 +1,108 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/atakurt/messagingApp/internal/infrastructure/repository (interfaces: ExportRepositoryInterface)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	db "github.com/atakurt/messagingApp/internal/infrastructure/db"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockExportRepositoryInterface is a mock of ExportRepositoryInterface interface.
+type MockExportRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockExportRepositoryInterfaceMockRecorder
+}
+
+// MockExportRepositoryInterfaceMockRecorder is the mock recorder for MockExportRepositoryInterface.
+type MockExportRepositoryInterfaceMockRecorder struct {
+	mock *MockExportRepositoryInterface
+}
+
+// NewMockExportRepositoryInterface creates a new mock instance.
+func NewMockExportRepositoryInterface(ctrl *gomock.Controller) *MockExportRepositoryInterface {
+	mock := &MockExportRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockExportRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExportRepositoryInterface) EXPECT() *MockExportRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CompleteExport mocks base method.
+func (m *MockExportRepositoryInterface) CompleteExport(arg0 db.Export) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteExport", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteExport indicates an expected call of CompleteExport.
+func (mr *MockExportRepositoryInterfaceMockRecorder) CompleteExport(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteExport", reflect.TypeOf((*MockExportRepositoryInterface)(nil).CompleteExport), arg0)
+}
+
+// CreateExport mocks base method.
+func (m *MockExportRepositoryInterface) CreateExport(arg0 *db.Export) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateExport", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateExport indicates an expected call of CreateExport.
+func (mr *MockExportRepositoryInterfaceMockRecorder) CreateExport(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExport", reflect.TypeOf((*MockExportRepositoryInterface)(nil).CreateExport), arg0)
+}
+
+// ExportMessages mocks base method.
+func (m *MockExportRepositoryInterface) ExportMessages(arg0 db.MessageFilter, arg1 uint, arg2 int) ([]db.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportMessages", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]db.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportMessages indicates an expected call of ExportMessages.
+func (mr *MockExportRepositoryInterfaceMockRecorder) ExportMessages(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportMessages", reflect.TypeOf((*MockExportRepositoryInterface)(nil).ExportMessages), arg0, arg1, arg2)
+}
+
+// GetExport mocks base method.
+func (m *MockExportRepositoryInterface) GetExport(arg0 uint) (db.Export, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExport", arg0)
+	ret0, _ := ret[0].(db.Export)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExport indicates an expected call of GetExport.
+func (mr *MockExportRepositoryInterfaceMockRecorder) GetExport(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExport", reflect.TypeOf((*MockExportRepositoryInterface)(nil).GetExport), arg0)
+}
+
+// ListExports mocks base method.
+func (m *MockExportRepositoryInterface) ListExports(arg0 uint, arg1, arg2 int) ([]db.Export, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExports", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]db.Export)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExports indicates an expected call of ListExports.
+func (mr *MockExportRepositoryInterfaceMockRecorder) ListExports(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExports", reflect.TypeOf((*MockExportRepositoryInterface)(nil).ListExports), arg0, arg1, arg2)
+}
@@ -0,0 +1,123 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/atakurt/messagingApp/internal/infrastructure/repository (interfaces: ImportRepositoryInterface)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	db "github.com/atakurt/messagingApp/internal/infrastructure/db"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockImportRepositoryInterface is a mock of ImportRepositoryInterface interface.
+type MockImportRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockImportRepositoryInterfaceMockRecorder
+}
+
+// MockImportRepositoryInterfaceMockRecorder is the mock recorder for MockImportRepositoryInterface.
+type MockImportRepositoryInterfaceMockRecorder struct {
+	mock *MockImportRepositoryInterface
+}
+
+// NewMockImportRepositoryInterface creates a new mock instance.
+func NewMockImportRepositoryInterface(ctrl *gomock.Controller) *MockImportRepositoryInterface {
+	mock := &MockImportRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockImportRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImportRepositoryInterface) EXPECT() *MockImportRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CompleteImport mocks base method.
+func (m *MockImportRepositoryInterface) CompleteImport(arg0 uint, arg1 db.ImportStatus, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteImport", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteImport indicates an expected call of CompleteImport.
+func (mr *MockImportRepositoryInterfaceMockRecorder) CompleteImport(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteImport", reflect.TypeOf((*MockImportRepositoryInterface)(nil).CompleteImport), arg0, arg1, arg2)
+}
+
+// CreateImport mocks base method.
+func (m *MockImportRepositoryInterface) CreateImport(arg0 *db.Import) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateImport", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateImport indicates an expected call of CreateImport.
+func (mr *MockImportRepositoryInterfaceMockRecorder) CreateImport(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateImport", reflect.TypeOf((*MockImportRepositoryInterface)(nil).CreateImport), arg0)
+}
+
+// FailStaleImports mocks base method.
+func (m *MockImportRepositoryInterface) FailStaleImports(arg0 time.Time, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailStaleImports", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FailStaleImports indicates an expected call of FailStaleImports.
+func (mr *MockImportRepositoryInterfaceMockRecorder) FailStaleImports(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailStaleImports", reflect.TypeOf((*MockImportRepositoryInterface)(nil).FailStaleImports), arg0, arg1)
+}
+
+// GetImport mocks base method.
+func (m *MockImportRepositoryInterface) GetImport(arg0 uint) (db.Import, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImport", arg0)
+	ret0, _ := ret[0].(db.Import)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImport indicates an expected call of GetImport.
+func (mr *MockImportRepositoryInterfaceMockRecorder) GetImport(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImport", reflect.TypeOf((*MockImportRepositoryInterface)(nil).GetImport), arg0)
+}
+
+// ListImportErrors mocks base method.
+func (m *MockImportRepositoryInterface) ListImportErrors(arg0 uint, arg1, arg2 int) ([]db.ImportError, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListImportErrors", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]db.ImportError)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListImportErrors indicates an expected call of ListImportErrors.
+func (mr *MockImportRepositoryInterfaceMockRecorder) ListImportErrors(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListImportErrors", reflect.TypeOf((*MockImportRepositoryInterface)(nil).ListImportErrors), arg0, arg1, arg2)
+}
+
+// RecordImportBatch mocks base method.
+func (m *MockImportRepositoryInterface) RecordImportBatch(arg0 uint, arg1 []db.Message, arg2 []db.ImportError) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordImportBatch", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordImportBatch indicates an expected call of RecordImportBatch.
+func (mr *MockImportRepositoryInterfaceMockRecorder) RecordImportBatch(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordImportBatch", reflect.TypeOf((*MockImportRepositoryInterface)(nil).RecordImportBatch), arg0, arg1, arg2)
+}
@@ -0,0 +1,79 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/atakurt/messagingApp/internal/infrastructure/repository (interfaces: InboundRepositoryInterface)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	db "github.com/atakurt/messagingApp/internal/infrastructure/db"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockInboundRepositoryInterface is a mock of InboundRepositoryInterface interface.
+type MockInboundRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockInboundRepositoryInterfaceMockRecorder
+}
+
+// MockInboundRepositoryInterfaceMockRecorder is the mock recorder for MockInboundRepositoryInterface.
+type MockInboundRepositoryInterfaceMockRecorder struct {
+	mock *MockInboundRepositoryInterface
+}
+
+// NewMockInboundRepositoryInterface creates a new mock instance.
+func NewMockInboundRepositoryInterface(ctrl *gomock.Controller) *MockInboundRepositoryInterface {
+	mock := &MockInboundRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockInboundRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInboundRepositoryInterface) EXPECT() *MockInboundRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateInboundMessage mocks base method.
+func (m *MockInboundRepositoryInterface) CreateInboundMessage(arg0 *db.InboundMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInboundMessage", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateInboundMessage indicates an expected call of CreateInboundMessage.
+func (mr *MockInboundRepositoryInterfaceMockRecorder) CreateInboundMessage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInboundMessage", reflect.TypeOf((*MockInboundRepositoryInterface)(nil).CreateInboundMessage), arg0)
+}
+
+// LastSentMessageID mocks base method.
+func (m *MockInboundRepositoryInterface) LastSentMessageID(arg0 string) (*uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LastSentMessageID", arg0)
+	ret0, _ := ret[0].(*uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LastSentMessageID indicates an expected call of LastSentMessageID.
+func (mr *MockInboundRepositoryInterfaceMockRecorder) LastSentMessageID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastSentMessageID", reflect.TypeOf((*MockInboundRepositoryInterface)(nil).LastSentMessageID), arg0)
+}
+
+// ListInboundMessages mocks base method.
+func (m *MockInboundRepositoryInterface) ListInboundMessages(arg0 string, arg1, arg2 int) ([]db.InboundMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInboundMessages", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]db.InboundMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInboundMessages indicates an expected call of ListInboundMessages.
+func (mr *MockInboundRepositoryInterfaceMockRecorder) ListInboundMessages(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInboundMessages", reflect.TypeOf((*MockInboundRepositoryInterface)(nil).ListInboundMessages), arg0, arg1, arg2)
+}
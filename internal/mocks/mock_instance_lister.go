@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/atakurt/messagingApp/internal/features/messagecontrol/instances (interfaces: InstanceLister)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	instance "github.com/atakurt/messagingApp/internal/infrastructure/instance"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockInstanceLister is a mock of InstanceLister interface.
+type MockInstanceLister struct {
+	ctrl     *gomock.Controller
+	recorder *MockInstanceListerMockRecorder
+}
+
+// MockInstanceListerMockRecorder is the mock recorder for MockInstanceLister.
+type MockInstanceListerMockRecorder struct {
+	mock *MockInstanceLister
+}
+
+// NewMockInstanceLister creates a new mock instance.
+func NewMockInstanceLister(ctrl *gomock.Controller) *MockInstanceLister {
+	mock := &MockInstanceLister{ctrl: ctrl}
+	mock.recorder = &MockInstanceListerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInstanceLister) EXPECT() *MockInstanceListerMockRecorder {
+	return m.recorder
+}
+
+// List mocks base method.
+func (m *MockInstanceLister) List(arg0 context.Context) ([]instance.Info, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0)
+	ret0, _ := ret[0].([]instance.Info)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockInstanceListerMockRecorder) List(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockInstanceLister)(nil).List), arg0)
+}
@@ -0,0 +1,78 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/atakurt/messagingApp/internal/infrastructure/monitoring (interfaces: LeaderInfo)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockLeaderInfo is a mock of LeaderInfo interface.
+type MockLeaderInfo struct {
+	ctrl     *gomock.Controller
+	recorder *MockLeaderInfoMockRecorder
+}
+
+// MockLeaderInfoMockRecorder is the mock recorder for MockLeaderInfo.
+type MockLeaderInfoMockRecorder struct {
+	mock *MockLeaderInfo
+}
+
+// NewMockLeaderInfo creates a new mock instance.
+func NewMockLeaderInfo(ctrl *gomock.Controller) *MockLeaderInfo {
+	mock := &MockLeaderInfo{ctrl: ctrl}
+	mock.recorder = &MockLeaderInfoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLeaderInfo) EXPECT() *MockLeaderInfoMockRecorder {
+	return m.recorder
+}
+
+// Backend mocks base method.
+func (m *MockLeaderInfo) Backend() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Backend")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Backend indicates an expected call of Backend.
+func (mr *MockLeaderInfoMockRecorder) Backend() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Backend", reflect.TypeOf((*MockLeaderInfo)(nil).Backend))
+}
+
+// IsLeader mocks base method.
+func (m *MockLeaderInfo) IsLeader() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsLeader")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsLeader indicates an expected call of IsLeader.
+func (mr *MockLeaderInfoMockRecorder) IsLeader() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsLeader", reflect.TypeOf((*MockLeaderInfo)(nil).IsLeader))
+}
+
+// Leader mocks base method.
+func (m *MockLeaderInfo) Leader(arg0 context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Leader", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Leader indicates an expected call of Leader.
+func (mr *MockLeaderInfoMockRecorder) Leader(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Leader", reflect.TypeOf((*MockLeaderInfo)(nil).Leader), arg0)
+}
@@ -34,10 +34,40 @@ func (m *MockMessageServiceInterface) EXPECT() *MockMessageServiceInterfaceMockR
 	return m.recorder
 }
 
+// InFlight mocks base method.
+func (m *MockMessageServiceInterface) InFlight() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InFlight")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// InFlight indicates an expected call of InFlight.
+func (mr *MockMessageServiceInterfaceMockRecorder) InFlight() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InFlight", reflect.TypeOf((*MockMessageServiceInterface)(nil).InFlight))
+}
+
+// ProcessNext mocks base method.
+func (m *MockMessageServiceInterface) ProcessNext(arg0 context.Context) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessNext", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// ProcessNext indicates an expected call of ProcessNext.
+func (mr *MockMessageServiceInterfaceMockRecorder) ProcessNext(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessNext", reflect.TypeOf((*MockMessageServiceInterface)(nil).ProcessNext), arg0)
+}
+
 // ProcessUnsentMessages mocks base method.
-func (m *MockMessageServiceInterface) ProcessUnsentMessages(arg0 context.Context) {
+func (m *MockMessageServiceInterface) ProcessUnsentMessages(arg0 context.Context) int {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "ProcessUnsentMessages", arg0)
+	ret := m.ctrl.Call(m, "ProcessUnsentMessages", arg0)
+	ret0, _ := ret[0].(int)
+	return ret0
 }
 
 // ProcessUnsentMessages indicates an expected call of ProcessUnsentMessages.
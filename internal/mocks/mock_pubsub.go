@@ -18,6 +18,11 @@ func NewMockPubSub() *MockPubSub {
 	}
 }
 
+// Receive implements the PubSub interface
+func (m *MockPubSub) Receive(ctx context.Context) (interface{}, error) {
+	return &redis.Subscription{Kind: "subscribe"}, nil
+}
+
 // ReceiveMessage implements the PubSub interface
 func (m *MockPubSub) ReceiveMessage(ctx context.Context) (*redis.Message, error) {
 	select {
@@ -0,0 +1,49 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/atakurt/messagingApp/internal/features/reaper (interfaces: ReaperServiceInterface)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockReaperServiceInterface is a mock of ReaperServiceInterface interface.
+type MockReaperServiceInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockReaperServiceInterfaceMockRecorder
+}
+
+// MockReaperServiceInterfaceMockRecorder is the mock recorder for MockReaperServiceInterface.
+type MockReaperServiceInterfaceMockRecorder struct {
+	mock *MockReaperServiceInterface
+}
+
+// NewMockReaperServiceInterface creates a new mock instance.
+func NewMockReaperServiceInterface(ctrl *gomock.Controller) *MockReaperServiceInterface {
+	mock := &MockReaperServiceInterface{ctrl: ctrl}
+	mock.recorder = &MockReaperServiceInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReaperServiceInterface) EXPECT() *MockReaperServiceInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ReapStuckMessages mocks base method.
+func (m *MockReaperServiceInterface) ReapStuckMessages(arg0 context.Context) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReapStuckMessages", arg0)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// ReapStuckMessages indicates an expected call of ReapStuckMessages.
+func (mr *MockReaperServiceInterfaceMockRecorder) ReapStuckMessages(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReapStuckMessages", reflect.TypeOf((*MockReaperServiceInterface)(nil).ReapStuckMessages), arg0)
+}
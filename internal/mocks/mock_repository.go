@@ -36,6 +36,92 @@ func (m *MockMessageRepositoryInterface) EXPECT() *MockMessageRepositoryInterfac
 	return m.recorder
 }
 
+// ClaimMessages mocks base method.
+func (m *MockMessageRepositoryInterface) ClaimMessages(arg0 string, arg1 time.Time, arg2 int, arg3 db.Priority, arg4 time.Time) ([]db.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimMessages", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]db.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimMessages indicates an expected call of ClaimMessages.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) ClaimMessages(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimMessages", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).ClaimMessages), arg0, arg1, arg2, arg3, arg4)
+}
+
+// ClaimRetries mocks base method.
+func (m *MockMessageRepositoryInterface) ClaimRetries(arg0 string, arg1 time.Time, arg2 int, arg3 db.Priority) ([]db.MessageRetry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimRetries", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]db.MessageRetry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimRetries indicates an expected call of ClaimRetries.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) ClaimRetries(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimRetries", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).ClaimRetries), arg0, arg1, arg2, arg3)
+}
+
+// CloseRetry mocks base method.
+func (m *MockMessageRepositoryInterface) CloseRetry(arg0 *db.MessageRetry, arg1 string, arg2 db.MessageStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseRetry", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseRetry indicates an expected call of CloseRetry.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) CloseRetry(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseRetry", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).CloseRetry), arg0, arg1, arg2)
+}
+
+// CreateMessage mocks base method.
+func (m *MockMessageRepositoryInterface) CreateMessage(arg0 *db.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMessage", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateMessage indicates an expected call of CreateMessage.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) CreateMessage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessage", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).CreateMessage), arg0)
+}
+
+// DeferMessage mocks base method.
+func (m *MockMessageRepositoryInterface) DeferMessage(arg0 *db.Message, arg1 string, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeferMessage", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeferMessage indicates an expected call of DeferMessage.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) DeferMessage(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeferMessage", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).DeferMessage), arg0, arg1, arg2)
+}
+
+// DeferRetry mocks base method.
+func (m *MockMessageRepositoryInterface) DeferRetry(arg0 *db.MessageRetry, arg1 string, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeferRetry", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeferRetry indicates an expected call of DeferRetry.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) DeferRetry(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeferRetry", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).DeferRetry), arg0, arg1, arg2)
+}
+
 // GetDB mocks base method.
 func (m *MockMessageRepositoryInterface) GetDB() *gorm.DB {
 	m.ctrl.T.Helper()
@@ -50,49 +136,48 @@ func (mr *MockMessageRepositoryInterfaceMockRecorder) GetDB() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDB", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).GetDB))
 }
 
-// GetMessageRetries mocks base method.
-func (m *MockMessageRepositoryInterface) GetMessageRetries(arg0 *gorm.DB, arg1 int) ([]db.MessageRetry, error) {
+// GetSentMessages mocks base method.
+func (m *MockMessageRepositoryInterface) GetSentMessages(arg0 uint, arg1, arg2 int) ([]db.Message, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetMessageRetries", arg0, arg1)
-	ret0, _ := ret[0].([]db.MessageRetry)
+	ret := m.ctrl.Call(m, "GetSentMessages", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]db.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetMessageRetries indicates an expected call of GetMessageRetries.
-func (mr *MockMessageRepositoryInterfaceMockRecorder) GetMessageRetries(arg0, arg1 interface{}) *gomock.Call {
+// GetSentMessages indicates an expected call of GetSentMessages.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) GetSentMessages(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessageRetries", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).GetMessageRetries), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSentMessages", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).GetSentMessages), arg0, arg1, arg2)
 }
 
-// GetSentMessages mocks base method.
-func (m *MockMessageRepositoryInterface) GetSentMessages(arg0, arg1 int) ([]db.Message, error) {
+// GetStuckMessages mocks base method.
+func (m *MockMessageRepositoryInterface) GetStuckMessages(arg0 *gorm.DB, arg1 time.Time, arg2 int) ([]db.Message, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSentMessages", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetStuckMessages", arg0, arg1, arg2)
 	ret0, _ := ret[0].([]db.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetSentMessages indicates an expected call of GetSentMessages.
-func (mr *MockMessageRepositoryInterfaceMockRecorder) GetSentMessages(arg0, arg1 interface{}) *gomock.Call {
+// GetStuckMessages indicates an expected call of GetStuckMessages.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) GetStuckMessages(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSentMessages", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).GetSentMessages), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStuckMessages", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).GetStuckMessages), arg0, arg1, arg2)
 }
 
-// GetUnsentMessages mocks base method.
-func (m *MockMessageRepositoryInterface) GetUnsentMessages(arg0 *gorm.DB, arg1 int) ([]db.Message, error) {
+// InsertAudit mocks base method.
+func (m *MockMessageRepositoryInterface) InsertAudit(arg0 *gorm.DB, arg1 db.MessageAudit) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUnsentMessages", arg0, arg1)
-	ret0, _ := ret[0].([]db.Message)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "InsertAudit", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetUnsentMessages indicates an expected call of GetUnsentMessages.
-func (mr *MockMessageRepositoryInterfaceMockRecorder) GetUnsentMessages(arg0, arg1 interface{}) *gomock.Call {
+// InsertAudit indicates an expected call of InsertAudit.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) InsertAudit(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnsentMessages", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).GetUnsentMessages), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertAudit", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).InsertAudit), arg0, arg1)
 }
 
 // InsertRetry mocks base method.
@@ -109,18 +194,18 @@ func (mr *MockMessageRepositoryInterfaceMockRecorder) InsertRetry(arg0, arg1, ar
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertRetry", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).InsertRetry), arg0, arg1, arg2)
 }
 
-// MarkMessageInProcess mocks base method.
-func (m *MockMessageRepositoryInterface) MarkMessageInProcess(arg0 *gorm.DB, arg1 *db.Message, arg2 time.Time) error {
+// MarkMessageForReview mocks base method.
+func (m *MockMessageRepositoryInterface) MarkMessageForReview(arg0 *gorm.DB, arg1 *db.Message, arg2 string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "MarkMessageInProcess", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "MarkMessageForReview", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// MarkMessageInProcess indicates an expected call of MarkMessageInProcess.
-func (mr *MockMessageRepositoryInterfaceMockRecorder) MarkMessageInProcess(arg0, arg1, arg2 interface{}) *gomock.Call {
+// MarkMessageForReview indicates an expected call of MarkMessageForReview.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) MarkMessageForReview(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkMessageInProcess", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).MarkMessageInProcess), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkMessageForReview", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).MarkMessageForReview), arg0, arg1, arg2)
 }
 
 // MoveToDeadLetter mocks base method.
@@ -137,44 +222,184 @@ func (mr *MockMessageRepositoryInterfaceMockRecorder) MoveToDeadLetter(arg0, arg
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveToDeadLetter", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).MoveToDeadLetter), arg0, arg1, arg2)
 }
 
-// UpdateMessageAsError mocks base method.
-func (m *MockMessageRepositoryInterface) UpdateMessageAsError(arg0 *gorm.DB, arg1 *db.Message, arg2 string) error {
+// RecordMessageDuplicate mocks base method.
+func (m *MockMessageRepositoryInterface) RecordMessageDuplicate(arg0 *db.Message, arg1 string, arg2 uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateMessageAsError", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "RecordMessageDuplicate", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateMessageAsError indicates an expected call of UpdateMessageAsError.
-func (mr *MockMessageRepositoryInterfaceMockRecorder) UpdateMessageAsError(arg0, arg1, arg2 interface{}) *gomock.Call {
+// RecordMessageDuplicate indicates an expected call of RecordMessageDuplicate.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) RecordMessageDuplicate(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMessageAsError", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).UpdateMessageAsError), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordMessageDuplicate", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).RecordMessageDuplicate), arg0, arg1, arg2)
 }
 
-// UpdateMessageAsSent mocks base method.
-func (m *MockMessageRepositoryInterface) UpdateMessageAsSent(arg0 *gorm.DB, arg1 *db.Message, arg2 string, arg3 time.Time) error {
+// RecordMessageError mocks base method.
+func (m *MockMessageRepositoryInterface) RecordMessageError(arg0 *db.Message, arg1, arg2 string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateMessageAsSent", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "RecordMessageError", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateMessageAsSent indicates an expected call of UpdateMessageAsSent.
-func (mr *MockMessageRepositoryInterfaceMockRecorder) UpdateMessageAsSent(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+// RecordMessageError indicates an expected call of RecordMessageError.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) RecordMessageError(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMessageAsSent", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).UpdateMessageAsSent), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordMessageError", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).RecordMessageError), arg0, arg1, arg2)
 }
 
-// UpdateRetryCount mocks base method.
-func (m *MockMessageRepositoryInterface) UpdateRetryCount(arg0 *gorm.DB, arg1 uint, arg2 int, arg3 string) error {
+// RecordMessageExpired mocks base method.
+func (m *MockMessageRepositoryInterface) RecordMessageExpired(arg0 *db.Message, arg1 string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateRetryCount", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "RecordMessageExpired", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateRetryCount indicates an expected call of UpdateRetryCount.
-func (mr *MockMessageRepositoryInterfaceMockRecorder) UpdateRetryCount(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+// RecordMessageExpired indicates an expected call of RecordMessageExpired.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) RecordMessageExpired(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRetryCount", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).UpdateRetryCount), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordMessageExpired", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).RecordMessageExpired), arg0, arg1)
+}
+
+// RecordMessageRetry mocks base method.
+func (m *MockMessageRepositoryInterface) RecordMessageRetry(arg0 *db.Message, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordMessageRetry", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordMessageRetry indicates an expected call of RecordMessageRetry.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) RecordMessageRetry(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordMessageRetry", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).RecordMessageRetry), arg0, arg1, arg2)
+}
+
+// RecordMessageSent mocks base method.
+func (m *MockMessageRepositoryInterface) RecordMessageSent(arg0 *db.Message, arg1, arg2 string, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordMessageSent", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordMessageSent indicates an expected call of RecordMessageSent.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) RecordMessageSent(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordMessageSent", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).RecordMessageSent), arg0, arg1, arg2, arg3)
+}
+
+// RecordMessageSuppressed mocks base method.
+func (m *MockMessageRepositoryInterface) RecordMessageSuppressed(arg0 *db.Message, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordMessageSuppressed", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordMessageSuppressed indicates an expected call of RecordMessageSuppressed.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) RecordMessageSuppressed(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordMessageSuppressed", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).RecordMessageSuppressed), arg0, arg1)
+}
+
+// RecordRetryDeadLetter mocks base method.
+func (m *MockMessageRepositoryInterface) RecordRetryDeadLetter(arg0 *db.MessageRetry, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordRetryDeadLetter", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordRetryDeadLetter indicates an expected call of RecordRetryDeadLetter.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) RecordRetryDeadLetter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordRetryDeadLetter", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).RecordRetryDeadLetter), arg0, arg1)
+}
+
+// RecordRetryFailed mocks base method.
+func (m *MockMessageRepositoryInterface) RecordRetryFailed(arg0 *db.MessageRetry, arg1 string, arg2 int, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordRetryFailed", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordRetryFailed indicates an expected call of RecordRetryFailed.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) RecordRetryFailed(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordRetryFailed", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).RecordRetryFailed), arg0, arg1, arg2, arg3)
+}
+
+// RecordRetrySent mocks base method.
+func (m *MockMessageRepositoryInterface) RecordRetrySent(arg0 *db.MessageRetry, arg1 string, arg2 *db.Message, arg3 string, arg4 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordRetrySent", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordRetrySent indicates an expected call of RecordRetrySent.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) RecordRetrySent(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordRetrySent", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).RecordRetrySent), arg0, arg1, arg2, arg3, arg4)
+}
+
+// ReleaseMessage mocks base method.
+func (m *MockMessageRepositoryInterface) ReleaseMessage(arg0 *db.Message, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseMessage", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseMessage indicates an expected call of ReleaseMessage.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) ReleaseMessage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseMessage", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).ReleaseMessage), arg0, arg1)
+}
+
+// ReleaseRetry mocks base method.
+func (m *MockMessageRepositoryInterface) ReleaseRetry(arg0 *db.MessageRetry, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseRetry", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseRetry indicates an expected call of ReleaseRetry.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) ReleaseRetry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseRetry", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).ReleaseRetry), arg0, arg1)
+}
+
+// ResetMessageToPending mocks base method.
+func (m *MockMessageRepositoryInterface) ResetMessageToPending(arg0 *gorm.DB, arg1 *db.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetMessageToPending", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetMessageToPending indicates an expected call of ResetMessageToPending.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) ResetMessageToPending(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetMessageToPending", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).ResetMessageToPending), arg0, arg1)
+}
+
+// UpdateMessageAsSent mocks base method.
+func (m *MockMessageRepositoryInterface) UpdateMessageAsSent(arg0 *gorm.DB, arg1 *db.Message, arg2 string, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMessageAsSent", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateMessageAsSent indicates an expected call of UpdateMessageAsSent.
+func (mr *MockMessageRepositoryInterfaceMockRecorder) UpdateMessageAsSent(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMessageAsSent", reflect.TypeOf((*MockMessageRepositoryInterface)(nil).UpdateMessageAsSent), arg0, arg1, arg2, arg3)
 }
@@ -0,0 +1,67 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/atakurt/messagingApp/internal/infrastructure/repository (interfaces: SpendRepositoryInterface)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	db "github.com/atakurt/messagingApp/internal/infrastructure/db"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSpendRepositoryInterface is a mock of SpendRepositoryInterface interface.
+type MockSpendRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockSpendRepositoryInterfaceMockRecorder
+}
+
+// MockSpendRepositoryInterfaceMockRecorder is the mock recorder for MockSpendRepositoryInterface.
+type MockSpendRepositoryInterfaceMockRecorder struct {
+	mock *MockSpendRepositoryInterface
+}
+
+// NewMockSpendRepositoryInterface creates a new mock instance.
+func NewMockSpendRepositoryInterface(ctrl *gomock.Controller) *MockSpendRepositoryInterface {
+	mock := &MockSpendRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockSpendRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSpendRepositoryInterface) EXPECT() *MockSpendRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// BudgetSpend mocks base method.
+func (m *MockSpendRepositoryInterface) BudgetSpend(arg0, arg1 time.Time) (float64, float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BudgetSpend", arg0, arg1)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(float64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// BudgetSpend indicates an expected call of BudgetSpend.
+func (mr *MockSpendRepositoryInterfaceMockRecorder) BudgetSpend(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BudgetSpend", reflect.TypeOf((*MockSpendRepositoryInterface)(nil).BudgetSpend), arg0, arg1)
+}
+
+// SpendBy mocks base method.
+func (m *MockSpendRepositoryInterface) SpendBy(arg0 string, arg1, arg2 time.Time) ([]db.SpendTotal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpendBy", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]db.SpendTotal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SpendBy indicates an expected call of SpendBy.
+func (mr *MockSpendRepositoryInterfaceMockRecorder) SpendBy(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpendBy", reflect.TypeOf((*MockSpendRepositoryInterface)(nil).SpendBy), arg0, arg1, arg2)
+}
@@ -0,0 +1,127 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/atakurt/messagingApp/internal/infrastructure/repository (interfaces: StatsRepositoryInterface)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	db "github.com/atakurt/messagingApp/internal/infrastructure/db"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockStatsRepositoryInterface is a mock of StatsRepositoryInterface interface.
+type MockStatsRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockStatsRepositoryInterfaceMockRecorder
+}
+
+// MockStatsRepositoryInterfaceMockRecorder is the mock recorder for MockStatsRepositoryInterface.
+type MockStatsRepositoryInterfaceMockRecorder struct {
+	mock *MockStatsRepositoryInterface
+}
+
+// NewMockStatsRepositoryInterface creates a new mock instance.
+func NewMockStatsRepositoryInterface(ctrl *gomock.Controller) *MockStatsRepositoryInterface {
+	mock := &MockStatsRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockStatsRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStatsRepositoryInterface) EXPECT() *MockStatsRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// AverageDeliverySeconds mocks base method.
+func (m *MockStatsRepositoryInterface) AverageDeliverySeconds(arg0, arg1 time.Time) (*float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AverageDeliverySeconds", arg0, arg1)
+	ret0, _ := ret[0].(*float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AverageDeliverySeconds indicates an expected call of AverageDeliverySeconds.
+func (mr *MockStatsRepositoryInterfaceMockRecorder) AverageDeliverySeconds(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AverageDeliverySeconds", reflect.TypeOf((*MockStatsRepositoryInterface)(nil).AverageDeliverySeconds), arg0, arg1)
+}
+
+// CountByStatus mocks base method.
+func (m *MockStatsRepositoryInterface) CountByStatus(arg0, arg1 time.Time) (map[db.MessageStatus]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByStatus", arg0, arg1)
+	ret0, _ := ret[0].(map[db.MessageStatus]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByStatus indicates an expected call of CountByStatus.
+func (mr *MockStatsRepositoryInterfaceMockRecorder) CountByStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByStatus", reflect.TypeOf((*MockStatsRepositoryInterface)(nil).CountByStatus), arg0, arg1)
+}
+
+// CountDeadLettered mocks base method.
+func (m *MockStatsRepositoryInterface) CountDeadLettered(arg0, arg1 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountDeadLettered", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountDeadLettered indicates an expected call of CountDeadLettered.
+func (mr *MockStatsRepositoryInterfaceMockRecorder) CountDeadLettered(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountDeadLettered", reflect.TypeOf((*MockStatsRepositoryInterface)(nil).CountDeadLettered), arg0, arg1)
+}
+
+// RetryOutcomes mocks base method.
+func (m *MockStatsRepositoryInterface) RetryOutcomes(arg0, arg1 time.Time) (int, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryOutcomes", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RetryOutcomes indicates an expected call of RetryOutcomes.
+func (mr *MockStatsRepositoryInterfaceMockRecorder) RetryOutcomes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryOutcomes", reflect.TypeOf((*MockStatsRepositoryInterface)(nil).RetryOutcomes), arg0, arg1)
+}
+
+// SentVolume mocks base method.
+func (m *MockStatsRepositoryInterface) SentVolume(arg0, arg1 time.Time, arg2 string) ([]db.VolumeBucket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SentVolume", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]db.VolumeBucket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SentVolume indicates an expected call of SentVolume.
+func (mr *MockStatsRepositoryInterfaceMockRecorder) SentVolume(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SentVolume", reflect.TypeOf((*MockStatsRepositoryInterface)(nil).SentVolume), arg0, arg1, arg2)
+}
+
+// TopErrors mocks base method.
+func (m *MockStatsRepositoryInterface) TopErrors(arg0, arg1 time.Time, arg2 int) ([]db.ErrorCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TopErrors", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]db.ErrorCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TopErrors indicates an expected call of TopErrors.
+func (mr *MockStatsRepositoryInterfaceMockRecorder) TopErrors(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopErrors", reflect.TypeOf((*MockStatsRepositoryInterface)(nil).TopErrors), arg0, arg1, arg2)
+}
@@ -0,0 +1,93 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/atakurt/messagingApp/internal/infrastructure/repository (interfaces: SuppressionRepositoryInterface)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	db "github.com/atakurt/messagingApp/internal/infrastructure/db"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSuppressionRepositoryInterface is a mock of SuppressionRepositoryInterface interface.
+type MockSuppressionRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockSuppressionRepositoryInterfaceMockRecorder
+}
+
+// MockSuppressionRepositoryInterfaceMockRecorder is the mock recorder for MockSuppressionRepositoryInterface.
+type MockSuppressionRepositoryInterfaceMockRecorder struct {
+	mock *MockSuppressionRepositoryInterface
+}
+
+// NewMockSuppressionRepositoryInterface creates a new mock instance.
+func NewMockSuppressionRepositoryInterface(ctrl *gomock.Controller) *MockSuppressionRepositoryInterface {
+	mock := &MockSuppressionRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockSuppressionRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSuppressionRepositoryInterface) EXPECT() *MockSuppressionRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// AddSuppression mocks base method.
+func (m *MockSuppressionRepositoryInterface) AddSuppression(arg0 *db.Suppression) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddSuppression", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddSuppression indicates an expected call of AddSuppression.
+func (mr *MockSuppressionRepositoryInterfaceMockRecorder) AddSuppression(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSuppression", reflect.TypeOf((*MockSuppressionRepositoryInterface)(nil).AddSuppression), arg0)
+}
+
+// IsSuppressed mocks base method.
+func (m *MockSuppressionRepositoryInterface) IsSuppressed(arg0 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsSuppressed", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsSuppressed indicates an expected call of IsSuppressed.
+func (mr *MockSuppressionRepositoryInterfaceMockRecorder) IsSuppressed(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSuppressed", reflect.TypeOf((*MockSuppressionRepositoryInterface)(nil).IsSuppressed), arg0)
+}
+
+// ListSuppressions mocks base method.
+func (m *MockSuppressionRepositoryInterface) ListSuppressions(arg0 string, arg1 int) ([]db.Suppression, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSuppressions", arg0, arg1)
+	ret0, _ := ret[0].([]db.Suppression)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSuppressions indicates an expected call of ListSuppressions.
+func (mr *MockSuppressionRepositoryInterfaceMockRecorder) ListSuppressions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSuppressions", reflect.TypeOf((*MockSuppressionRepositoryInterface)(nil).ListSuppressions), arg0, arg1)
+}
+
+// RemoveSuppression mocks base method.
+func (m *MockSuppressionRepositoryInterface) RemoveSuppression(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveSuppression", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveSuppression indicates an expected call of RemoveSuppression.
+func (mr *MockSuppressionRepositoryInterfaceMockRecorder) RemoveSuppression(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveSuppression", reflect.TypeOf((*MockSuppressionRepositoryInterface)(nil).RemoveSuppression), arg0)
+}
@@ -0,0 +1,123 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/atakurt/messagingApp/internal/infrastructure/repository (interfaces: TemplateRepositoryInterface)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	db "github.com/atakurt/messagingApp/internal/infrastructure/db"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTemplateRepositoryInterface is a mock of TemplateRepositoryInterface interface.
+type MockTemplateRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockTemplateRepositoryInterfaceMockRecorder
+}
+
+// MockTemplateRepositoryInterfaceMockRecorder is the mock recorder for MockTemplateRepositoryInterface.
+type MockTemplateRepositoryInterfaceMockRecorder struct {
+	mock *MockTemplateRepositoryInterface
+}
+
+// NewMockTemplateRepositoryInterface creates a new mock instance.
+func NewMockTemplateRepositoryInterface(ctrl *gomock.Controller) *MockTemplateRepositoryInterface {
+	mock := &MockTemplateRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockTemplateRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTemplateRepositoryInterface) EXPECT() *MockTemplateRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateTemplate mocks base method.
+func (m *MockTemplateRepositoryInterface) CreateTemplate(arg0 *db.Template) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTemplate", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTemplate indicates an expected call of CreateTemplate.
+func (mr *MockTemplateRepositoryInterfaceMockRecorder) CreateTemplate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTemplate", reflect.TypeOf((*MockTemplateRepositoryInterface)(nil).CreateTemplate), arg0)
+}
+
+// DeleteTemplate mocks base method.
+func (m *MockTemplateRepositoryInterface) DeleteTemplate(arg0 uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTemplate", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTemplate indicates an expected call of DeleteTemplate.
+func (mr *MockTemplateRepositoryInterfaceMockRecorder) DeleteTemplate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTemplate", reflect.TypeOf((*MockTemplateRepositoryInterface)(nil).DeleteTemplate), arg0)
+}
+
+// GetTemplate mocks base method.
+func (m *MockTemplateRepositoryInterface) GetTemplate(arg0 uint) (db.Template, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTemplate", arg0)
+	ret0, _ := ret[0].(db.Template)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTemplate indicates an expected call of GetTemplate.
+func (mr *MockTemplateRepositoryInterfaceMockRecorder) GetTemplate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTemplate", reflect.TypeOf((*MockTemplateRepositoryInterface)(nil).GetTemplate), arg0)
+}
+
+// ListTemplateVersions mocks base method.
+func (m *MockTemplateRepositoryInterface) ListTemplateVersions(arg0 uint) ([]db.TemplateVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTemplateVersions", arg0)
+	ret0, _ := ret[0].([]db.TemplateVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTemplateVersions indicates an expected call of ListTemplateVersions.
+func (mr *MockTemplateRepositoryInterfaceMockRecorder) ListTemplateVersions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTemplateVersions", reflect.TypeOf((*MockTemplateRepositoryInterface)(nil).ListTemplateVersions), arg0)
+}
+
+// ListTemplates mocks base method.
+func (m *MockTemplateRepositoryInterface) ListTemplates(arg0, arg1 int) ([]db.Template, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTemplates", arg0, arg1)
+	ret0, _ := ret[0].([]db.Template)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTemplates indicates an expected call of ListTemplates.
+func (mr *MockTemplateRepositoryInterfaceMockRecorder) ListTemplates(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTemplates", reflect.TypeOf((*MockTemplateRepositoryInterface)(nil).ListTemplates), arg0, arg1)
+}
+
+// UpdateTemplate mocks base method.
+func (m *MockTemplateRepositoryInterface) UpdateTemplate(arg0 uint, arg1 string, arg2 map[string]string) (db.Template, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTemplate", arg0, arg1, arg2)
+	ret0, _ := ret[0].(db.Template)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTemplate indicates an expected call of UpdateTemplate.
+func (mr *MockTemplateRepositoryInterfaceMockRecorder) UpdateTemplate(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTemplate", reflect.TypeOf((*MockTemplateRepositoryInterface)(nil).UpdateTemplate), arg0, arg1, arg2)
+}
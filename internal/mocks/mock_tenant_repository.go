@@ -0,0 +1,151 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/atakurt/messagingApp/internal/infrastructure/repository (interfaces: TenantRepositoryInterface)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	db "github.com/atakurt/messagingApp/internal/infrastructure/db"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTenantRepositoryInterface is a mock of TenantRepositoryInterface interface.
+type MockTenantRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockTenantRepositoryInterfaceMockRecorder
+}
+
+// MockTenantRepositoryInterfaceMockRecorder is the mock recorder for MockTenantRepositoryInterface.
+type MockTenantRepositoryInterfaceMockRecorder struct {
+	mock *MockTenantRepositoryInterface
+}
+
+// NewMockTenantRepositoryInterface creates a new mock instance.
+func NewMockTenantRepositoryInterface(ctrl *gomock.Controller) *MockTenantRepositoryInterface {
+	mock := &MockTenantRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockTenantRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTenantRepositoryInterface) EXPECT() *MockTenantRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockTenantRepositoryInterface) CreateAPIKey(arg0 *db.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockTenantRepositoryInterfaceMockRecorder) CreateAPIKey(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockTenantRepositoryInterface)(nil).CreateAPIKey), arg0)
+}
+
+// CreateTenant mocks base method.
+func (m *MockTenantRepositoryInterface) CreateTenant(arg0 *db.Tenant) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTenant", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTenant indicates an expected call of CreateTenant.
+func (mr *MockTenantRepositoryInterfaceMockRecorder) CreateTenant(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTenant", reflect.TypeOf((*MockTenantRepositoryInterface)(nil).CreateTenant), arg0)
+}
+
+// FindAPIKey mocks base method.
+func (m *MockTenantRepositoryInterface) FindAPIKey(arg0 string) (db.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAPIKey", arg0)
+	ret0, _ := ret[0].(db.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAPIKey indicates an expected call of FindAPIKey.
+func (mr *MockTenantRepositoryInterfaceMockRecorder) FindAPIKey(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAPIKey", reflect.TypeOf((*MockTenantRepositoryInterface)(nil).FindAPIKey), arg0)
+}
+
+// GetTenant mocks base method.
+func (m *MockTenantRepositoryInterface) GetTenant(arg0 uint) (db.Tenant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTenant", arg0)
+	ret0, _ := ret[0].(db.Tenant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTenant indicates an expected call of GetTenant.
+func (mr *MockTenantRepositoryInterfaceMockRecorder) GetTenant(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTenant", reflect.TypeOf((*MockTenantRepositoryInterface)(nil).GetTenant), arg0)
+}
+
+// ListAPIKeys mocks base method.
+func (m *MockTenantRepositoryInterface) ListAPIKeys(arg0 uint) ([]db.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAPIKeys", arg0)
+	ret0, _ := ret[0].([]db.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAPIKeys indicates an expected call of ListAPIKeys.
+func (mr *MockTenantRepositoryInterfaceMockRecorder) ListAPIKeys(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeys", reflect.TypeOf((*MockTenantRepositoryInterface)(nil).ListAPIKeys), arg0)
+}
+
+// ListTenants mocks base method.
+func (m *MockTenantRepositoryInterface) ListTenants() ([]db.Tenant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTenants")
+	ret0, _ := ret[0].([]db.Tenant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTenants indicates an expected call of ListTenants.
+func (mr *MockTenantRepositoryInterfaceMockRecorder) ListTenants() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTenants", reflect.TypeOf((*MockTenantRepositoryInterface)(nil).ListTenants))
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockTenantRepositoryInterface) RevokeAPIKey(arg0, arg1 uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockTenantRepositoryInterfaceMockRecorder) RevokeAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockTenantRepositoryInterface)(nil).RevokeAPIKey), arg0, arg1)
+}
+
+// UpdateTenant mocks base method.
+func (m *MockTenantRepositoryInterface) UpdateTenant(arg0 *db.Tenant) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTenant", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTenant indicates an expected call of UpdateTenant.
+func (mr *MockTenantRepositoryInterfaceMockRecorder) UpdateTenant(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTenant", reflect.TypeOf((*MockTenantRepositoryInterface)(nil).UpdateTenant), arg0)
+}